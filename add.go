@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// clockOn reads HH:MM as a time on date.
+func clockOn(date time.Time, text string) (time.Time, error) {
+	t, err := time.Parse("15:04", text)
+	if err != nil {
+		return t, usageErrorf("invalid time %q; want HH:MM", text)
+	}
+	return date.Add(time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute), nil
+}
+
+// manualEntry builds the entry add records: worked from..to, or for
+// duration from from when given, or for duration at no particular time.
+func manualEntry(project, task string, date time.Time, fromText, toText string, duration time.Duration) (TaskEntry, error) {
+	e := TaskEntry{Project: project, Task: task, Duration: duration}
+	var err error
+	switch {
+	case fromText == "" && toText != "":
+		return e, usageErrorf("--to needs --from")
+	case toText != "" && duration > 0:
+		return e, usageErrorf("give --to or --duration, not both")
+	case fromText == "" && duration <= 0:
+		return e, usageErrorf("add needs --from and --to, or --duration")
+	}
+	if fromText != "" {
+		if e.Start, err = clockOn(date, fromText); err != nil {
+			return e, err
+		}
+	}
+	if toText != "" {
+		end, err := clockOn(date, toText)
+		if err != nil {
+			return e, err
+		}
+		if !end.After(e.Start) {
+			return e, usageErrorf("--to %s is not after --from %s", toText, fromText)
+		}
+		e.Duration = end.Sub(e.Start)
+	} else if duration <= 0 {
+		return e, usageErrorf("--from needs --to or --duration")
+	}
+	if e.Task, e.Billable, e.Rate, err = parseBilling(e.Task); err != nil {
+		return e, withCode(exitUsage, err)
+	}
+	billByDefault(&e)
+	e.Task, e.Tags = parseTags(e.Task)
+	e.Task, e.Estimate = parseEstimate(e.Task)
+	if e.Task == "" {
+		return e, usageErrorf("add needs --task")
+	}
+	return e, nil
+}
+
+// addCommand records work done while the tracker wasn't running, in the
+// same log and store as a tracked session.
+func addCommand(args []string) error {
+	fs := newFlagSet("add")
+	project := projectFlag(fs, "project", defaultProject, "Project the work was for")
+	task := fs.String("task", "", "What the work was; #tags, ~estimates and $ work as at the prompt")
+	day := fs.String("date", "today", "Day of the work: today, yesterday or YYYY-MM-DD")
+	from := fs.String("from", "", "Start time, HH:MM")
+	to := fs.String("to", "", "End time, HH:MM")
+	duration := fs.String("duration", "", "How long, e.g. 90m or 1h30m, instead of --to")
+	estimate := durationFlag(fs, "estimate", 0, "How long the task was expected to take")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	date, err := parseDay(*day)
+	if err != nil {
+		return err
+	}
+	var d time.Duration
+	if *duration != "" {
+		if d, err = parseDurationExpr(*duration); err != nil || d <= 0 {
+			return usageErrorf("invalid --duration %q", *duration)
+		}
+	}
+	e, err := manualEntry(*project, *task, date, *from, *to, d)
+	if err != nil {
+		return err
+	}
+	if e.Estimate == 0 {
+		e.Estimate = *estimate
+	}
+	estimateFromPlan(&e, time.Now())
+	linkGitHub(&e)
+
+	existing, err := writtenEntries(date)
+	if err != nil {
+		return err
+	}
+	for _, other := range existing {
+		if other.Project == e.Project && other.Task == e.Task && other.Duration == e.Duration {
+			fmt.Printf("👌 %s already has %s (%s)\n", date.Format(dateLayout), e.Task, formatDuration("summary", e.Duration))
+			return nil
+		}
+	}
+	if !e.Start.IsZero() {
+		for _, other := range existing {
+			if !other.Start.IsZero() && other.Start.Before(entryEnd(e)) && e.Start.Before(entryEnd(other)) {
+				fmt.Printf("⚠️  Overlaps %s (%s) at %s\n", other.Task, other.Project, clockSpan(other.Start, entryEnd(other)))
+			}
+		}
+	}
+	if err := importEntries(e.Project, date, []TaskEntry{e}, false); err != nil {
+		return err
+	}
+	recordHistory(e.Project, e.Task)
+	finishPlanned(e.Project, e.Task, time.Now())
+	autoPush(date)
+	if day := append(existing, e); overCeiling(day) {
+		for _, line := range longDayWarning(day) {
+			fmt.Println(line)
+		}
+		return flagDay(date, needsReviewKey)
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// apiError answers with err as JSON, using the status its exit code
+// suggests.
+func apiError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch exitCode(err) {
+	case exitUsage:
+		status = http.StatusBadRequest
+	case exitEmpty:
+		status = http.StatusNotFound
+	case exitLocked:
+		status = http.StatusConflict
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+func apiJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.Encode(v)
+}
+
+// apiRange reads from and to from the query, both defaulting to today.
+func apiRange(r *http.Request) (time.Time, time.Time, error) {
+	from, to, err := parseDateRange(r.URL.Query().Get("from"), r.URL.Query().Get("to"))
+	if err != nil {
+		return from, to, err
+	}
+	if to.IsZero() {
+		to = startOfDay(time.Now())
+	}
+	if from.IsZero() {
+		from = to
+	}
+	return from, to, nil
+}
+
+func addAPIRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/entries", func(w http.ResponseWriter, r *http.Request) {
+		from, to, err := apiRange(r)
+		if err != nil {
+			apiError(w, err)
+			return
+		}
+		rows, err := exportRows(from, to, r.URL.Query().Get("project"), r.URL.Query().Get("tag"))
+		if err != nil {
+			apiError(w, err)
+			return
+		}
+		out := []exportedEntry{}
+		for _, row := range rows {
+			out = append(out, row.exported())
+		}
+		apiJSON(w, out)
+	})
+	mux.HandleFunc("GET /api/report", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		from, to, err := apiRange(r)
+		if err != nil {
+			apiError(w, err)
+			return
+		}
+		title := ""
+		switch period := q.Get("period"); period {
+		case "":
+		case "week", "month":
+			title, from, to = periodRange(period, from)
+		default:
+			apiError(w, usageErrorf("unknown period %q; want week or month", period))
+			return
+		}
+		days, err := dayEntries(from, to)
+		if err != nil {
+			apiError(w, err)
+			return
+		}
+		entries, kept := reportFilter{Project: q.Get("project"), Client: q.Get("client"), Match: q.Get("match"), Tag: q.Get("tag")}.filterDays(days)
+		w.Header().Set("Content-Type", "application/json")
+		if title != "" {
+			periodReport(w, title, from, to, kept, true)
+			return
+		}
+		summaryReport(w, from, to, entries, true)
+	})
+	mux.HandleFunc("GET /api/timer", func(w http.ResponseWriter, r *http.Request) {
+		t, others, ok, err := listTimers()
+		if err != nil {
+			apiError(w, err)
+			return
+		}
+		if !ok {
+			apiJSON(w, daemonReply{})
+			return
+		}
+		apiJSON(w, daemonReply{Timer: &t, Others: others})
+	})
+	mux.HandleFunc("POST /api/timer/{cmd}", func(w http.ResponseWriter, r *http.Request) {
+		var req daemonRequest
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				apiError(w, usageErrorf("bad request body: %v", err))
+				return
+			}
+		}
+		req.Cmd = r.PathValue("cmd")
+		switch req.Cmd {
+		case "start", "toggle":
+			if req.Project == "" {
+				req.Project = defaultProject
+			}
+		case "pause", "stop":
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		reply, err := runTimer(req)
+		if err != nil {
+			apiError(w, err)
+			return
+		}
+		if e := reply.Entry; e != nil {
+			apiJSON(w, map[string]exportedEntry{"entry": datedEntry{Date: e.Start.Format(dateLayout), TaskEntry: *e}.exported()})
+			return
+		}
+		apiJSON(w, reply)
+	})
+}
+
+// guardWrites lets reads through and requires the bearer token for
+// anything else; without a token the server is read-only.
+func guardWrites(token string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			h.ServeHTTP(w, r)
+			return
+		}
+		if token == "" {
+			http.Error(w, "read-only; set serve_token to allow changes", http.StatusMethodNotAllowed)
+			return
+		}
+		given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			http.Error(w, "missing or wrong token", http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
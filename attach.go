@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const attachmentPrefix = "- 🔗 **Attachment**: "
+
+// attachmentsDir is where attach --copy puts files, inside the log
+// directory.
+const attachmentsDir = "attachments"
+
+func isURL(target string) bool {
+	scheme, _, ok := strings.Cut(target, ":")
+	return ok && (strings.HasPrefix(target, scheme+"://") || scheme == "mailto")
+}
+
+// attachmentLink renders an attachment as a Markdown link. URLs are
+// labelled with themselves, files with their base name.
+func attachmentLink(target string) string {
+	label := target
+	if !isURL(target) {
+		label = filepath.Base(target)
+	}
+	if strings.ContainsAny(target, " ()") {
+		target = "<" + target + ">"
+	}
+	return fmt.Sprintf("[%s](%s)", label, target)
+}
+
+// parseAttachmentLink returns the target of a link written by
+// attachmentLink, or the text itself if it isn't a link.
+func parseAttachmentLink(text string) string {
+	if i := strings.Index(text, "]("); strings.HasPrefix(text, "[") && i >= 0 && strings.HasSuffix(text, ")") {
+		text = text[i+2 : len(text)-1]
+		return strings.TrimSuffix(strings.TrimPrefix(text, "<"), ">")
+	}
+	return text
+}
+
+// resolveAttachment checks an attachment given by the user and returns
+// what to store: URLs as they are, files relative to the log directory
+// when they live under it, otherwise as absolute paths. With copy, the
+// file is first copied into the attachments folder of the log directory.
+func resolveAttachment(target string, copyFile bool) (string, error) {
+	if isURL(target) {
+		if copyFile {
+			return "", fmt.Errorf("cannot copy a URL")
+		}
+		return target, nil
+	}
+	path, err := expandHome(target)
+	if err != nil {
+		return "", err
+	}
+	if path, err = filepath.Abs(path); err != nil {
+		return "", err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("no such file: %s", target)
+	}
+	if info.IsDir() && copyFile {
+		return "", fmt.Errorf("cannot copy a directory: %s", target)
+	}
+	dir, err := logDir()
+	if err != nil {
+		return "", err
+	}
+	if copyFile {
+		if path, err = copyAttachment(path, filepath.Join(dir, attachmentsDir)); err != nil {
+			return "", err
+		}
+	}
+	if rel, err := filepath.Rel(dir, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel), nil
+	}
+	return path, nil
+}
+
+// copyAttachment copies src into dir, picking a new name rather than
+// overwriting a different file, and returns the copy's path.
+func copyAttachment(src, dir string) (string, error) {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return "", err
+	}
+	ext := filepath.Ext(src)
+	stem := strings.TrimSuffix(filepath.Base(src), ext)
+	for i := 1; ; i++ {
+		name := stem + ext
+		if i > 1 {
+			name = fmt.Sprintf("%s-%d%s", stem, i, ext)
+		}
+		dst := filepath.Join(dir, name)
+		existing, err := os.ReadFile(dst)
+		if errors.Is(err, os.ErrNotExist) {
+			return dst, writeFileAtomic(dst, data)
+		}
+		if err != nil {
+			return "", err
+		}
+		if bytes.Equal(existing, data) {
+			return dst, nil
+		}
+	}
+}
+
+// printAttachments lists an entry's attachments, numbered for removal.
+func printAttachments(w io.Writer, e TaskEntry) {
+	if len(e.Attachments) == 0 {
+		fmt.Fprintf(w, "🔗 %q has no attachments\n", e.Task)
+		return
+	}
+	fmt.Fprintf(w, "🔗 Attachments of %q:\n", e.Task)
+	for i, a := range e.Attachments {
+		fmt.Fprintf(w, "   %d) %s\n", i+1, a)
+	}
+}
+
+// editAttachments applies the argument of the review screen's attach
+// command to e: a URL or path (optionally after --copy) adds one,
+// -N removes the Nth.
+func editAttachments(e *TaskEntry, arg string) error {
+	if n, ok := strings.CutPrefix(arg, "-"); ok && !strings.HasPrefix(n, "-") {
+		var i int
+		if _, err := fmt.Sscanf(n, "%d", &i); err != nil || i < 1 || i > len(e.Attachments) {
+			return fmt.Errorf("no attachment %q", n)
+		}
+		e.Attachments = append(e.Attachments[:i-1:i-1], e.Attachments[i:]...)
+		return nil
+	}
+	target, copyFile := strings.CutPrefix(arg, "--copy ")
+	stored, err := resolveAttachment(strings.TrimSpace(target), copyFile)
+	if err != nil {
+		return err
+	}
+	e.Attachments = append(e.Attachments, stored)
+	return nil
+}
+
+func attachCommand(args []string) error {
+	fs := newFlagSet("attach")
+	last := fs.Bool("last", false, "Attach to the last entry logged today")
+	project := projectFlag(fs, "project", "", "Project whose log to use (default: the most recently written)")
+	copyFlag := fs.Bool("copy", false, "Copy the file into the attachments folder of the log directory")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	target := fs.Arg(0)
+	if fs.NArg() > 0 {
+		if err := parseFlags(fs, fs.Args()[1:]); err != nil {
+			return err
+		}
+		if fs.NArg() > 0 {
+			return usageErrorf("attach takes one URL or path")
+		}
+	}
+	if target == "" || !*last {
+		return usageErrorf("usage: attach --last [--project NAME] [--copy] URL-or-PATH")
+	}
+
+	lf, content, err := latestLog(time.Now(), *project)
+	if err != nil {
+		return err
+	}
+	today := time.Now().Format(dateLayout)
+	days := parseLog(content)
+	entries := days[today]
+	if len(entries) == 0 {
+		return fmt.Errorf("no entries logged today in %s", lf.Path)
+	}
+	stored, err := resolveAttachment(target, *copyFlag)
+	if err != nil {
+		return err
+	}
+	e := &entries[len(entries)-1]
+	e.Attachments = append(e.Attachments, stored)
+	_, updated, err := renderLog(lf, content, days)
+	if err != nil {
+		return err
+	}
+	if err := writeLog(lf.Path, updated); err != nil {
+		return err
+	}
+	fmt.Printf("🔗 Attached %s to %q\n", stored, e.Task)
+	return nil
+}
+
+// latestLog returns the log holding date's entries for project, or the
+// most recently written one when project is empty.
+func latestLog(date time.Time, project string) (logFile, []byte, error) {
+	logs, err := listLogs()
+	if err != nil {
+		return logFile{}, nil, err
+	}
+	var best logFile
+	var bestTime time.Time
+	for _, lf := range logs {
+		if !inRange(lf, startOfDay(date), startOfDay(date)) || (project != "" && lf.Project != safeName(project)) {
+			continue
+		}
+		info, err := os.Stat(lf.Path)
+		if err != nil {
+			return logFile{}, nil, err
+		}
+		if best.Path == "" || info.ModTime().After(bestTime) {
+			best, bestTime = lf, info.ModTime()
+		}
+	}
+	if best.Path == "" {
+		return best, nil, fmt.Errorf("no log for %s yet", date.Format(dateLayout))
+	}
+	content, err := os.ReadFile(best.Path)
+	return best, content, err
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// auditEvent is one raw event of an audited run. T is monotonic
+// nanoseconds since the run started, so wall-clock changes cannot skew
+// the recomputation.
+type auditEvent struct {
+	T        int64         `json:"t"`
+	Wall     time.Time     `json:"wall"`
+	Event    string        `json:"event"`
+	Key      string        `json:"key,omitempty"`
+	Duration time.Duration `json:"duration,omitempty"`
+}
+
+var (
+	auditFile  *os.File
+	auditEnc   *json.Encoder
+	auditStart time.Time
+)
+
+// enableAudit starts recording raw events to audit/<date>.jsonl in the
+// app directory and returns the path.
+func enableAudit() (string, error) {
+	dir, err := appDir()
+	if err != nil {
+		return "", err
+	}
+	dir = filepath.Join(dir, "audit")
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, time.Now().Format(dateLayout)+".jsonl")
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return "", err
+	}
+	auditFile, auditEnc, auditStart = f, json.NewEncoder(f), time.Now()
+	audit("run_start", "", 0)
+	return path, nil
+}
+
+// audit records an event when --audit is on.
+func audit(event, key string, d time.Duration) {
+	auditAt(time.Now(), event, key, d)
+}
+
+func auditAt(now time.Time, event, key string, d time.Duration) {
+	if auditEnc == nil {
+		return
+	}
+	auditEnc.Encode(auditEvent{T: int64(now.Sub(auditStart)), Wall: now, Event: event, Key: key, Duration: d})
+}
+
+// auditSession is a session recomputed from the events.
+type auditSession struct {
+	Start     time.Time
+	Recorded  time.Duration
+	Computed  time.Duration
+	Completed bool
+}
+
+// verifyAudit replays the events the way sessionClock counts them: time
+// between consecutive readings while running, dropping gaps longer than
+// maxTickDelta. An idle pause settles as endIdle did: the time away
+// added back when kept, the idle stretch before it taken off when not.
+// It returns every session with its recomputed length and the length
+// the tracker recorded.
+func verifyAudit(events []auditEvent) []auditSession {
+	var sessions []auditSession
+	var cur *auditSession
+	var last int64
+	running := false
+	advance := func(t int64) {
+		if d := time.Duration(t - last); running && d >= 0 && d <= maxTickDelta {
+			cur.Computed += d
+		}
+		last = t
+	}
+	for _, ev := range events {
+		if cur == nil && ev.Event != "session_start" {
+			continue
+		}
+		switch ev.Event {
+		case "session_start":
+			sessions = append(sessions, auditSession{Start: ev.Wall})
+			cur = &sessions[len(sessions)-1]
+			last, running = ev.T, true
+		case "tick":
+			advance(ev.T)
+		case "pause", "suspend", "idle":
+			advance(ev.T)
+			running = false
+		case "idle_kept":
+			cur.Computed += ev.Duration
+		case "idle_dropped":
+			cur.Computed = max(cur.Computed-ev.Duration, 0)
+		case "resume":
+			last, running = ev.T, true
+		case "session_end":
+			advance(ev.T)
+			running = false
+		case "entry":
+			cur.Recorded += ev.Duration
+			cur.Completed = true
+		}
+	}
+	return sessions
+}
+
+func readAudit(path string) ([]auditEvent, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var events []auditEvent
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		var ev auditEvent
+		if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, n, err)
+		}
+		events = append(events, ev)
+	}
+	return events, scanner.Err()
+}
+
+func auditCommand(args []string) error {
+	if len(args) == 0 || args[0] != "verify" {
+		return usageErrorf("usage: audit verify FILE [--tolerance 2s]")
+	}
+	fs := newFlagSet("audit verify")
+	tolerance := fs.Duration("tolerance", 2*time.Second, "Largest difference that is not reported")
+	var path string
+	rest := args[1:]
+	if len(rest) > 0 && rest[0] != "" && rest[0][0] != '-' {
+		path, rest = rest[0], rest[1:]
+	}
+	if err := parseFlags(fs, rest); err != nil {
+		return err
+	}
+	if path == "" && fs.NArg() == 1 {
+		path = fs.Arg(0)
+	}
+	if path == "" {
+		return usageErrorf("audit verify needs the audit file")
+	}
+
+	events, err := readAudit(path)
+	if err != nil {
+		return err
+	}
+	diverged := 0
+	for i, s := range verifyAudit(events) {
+		if !s.Completed {
+			fmt.Printf("⏳ %3d) %s  no entry recorded (run ended early)\n", i+1, s.Start.Local().Format("15:04:05"))
+			continue
+		}
+		diff := s.Recorded - s.Computed
+		mark := "✅"
+		if diff > *tolerance || diff < -*tolerance {
+			mark = "❌"
+			diverged++
+		}
+		fmt.Printf("%s %3d) %s  recorded %s, recomputed %s\n", mark, i+1, s.Start.Local().Format("15:04:05"),
+			formatDuration("summary", s.Recorded), formatDuration("summary", s.Computed))
+	}
+	if diverged > 0 {
+		return fmt.Errorf("%d sessions diverge by more than %s", diverged, *tolerance)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The fixtures in testdata/audit are runs recorded around the tricky
+// parts of the pause and suspend arithmetic.
+func TestVerifyAuditFixtures(t *testing.T) {
+	tests := []struct {
+		file     string
+		sessions int
+		computed time.Duration // of the last session
+	}{
+		{"pause", 1, 90*time.Second + 200*time.Millisecond},
+		{"suspend", 1, 50*time.Second + 500*time.Millisecond},
+		{"clock-jump", 1, 90 * time.Second},
+		{"idle-dropped", 1, 90 * time.Second},
+		{"idle-kept", 1, 433 * time.Second},
+		{"ended-early", 2, 30 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			events, err := readAudit(filepath.Join("testdata", "audit", tt.file+".jsonl"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			sessions := verifyAudit(events)
+			if len(sessions) != tt.sessions {
+				t.Fatalf("%d sessions, want %d", len(sessions), tt.sessions)
+			}
+			for i, s := range sessions {
+				if diff := s.Recorded - s.Computed; s.Completed && (diff > time.Second || diff < -time.Second) {
+					t.Errorf("session %d: recorded %s, recomputed %s", i+1, s.Recorded, s.Computed)
+				}
+			}
+			if last := sessions[len(sessions)-1]; last.Computed != tt.computed {
+				t.Errorf("recomputed %s, want %s", last.Computed, tt.computed)
+			}
+		})
+	}
+}
+
+func TestAuditVerifyCommand(t *testing.T) {
+	config, logs := useTempDirs(t)
+	writeConfig(t, config, "log_dir: "+logs+"\n")
+	home := filepath.Dir(logs)
+	fixture := func(name string) string {
+		path, err := filepath.Abs(filepath.Join("testdata", "audit", name+".jsonl"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	code, out := runMain(t, home, "", "audit", "verify", fixture("ended-early"))
+	if code != exitOK || !strings.Contains(out, "no entry recorded") {
+		t.Errorf("ended early: exit %d:\n%s", code, out)
+	}
+	code, out = runMain(t, home, "", "audit", "verify", fixture("diverged"), "--tolerance", "1m")
+	if code != exitError || !strings.Contains(out, "❌") || !strings.Contains(out, "1 sessions diverge by more than 1m0s") {
+		t.Errorf("diverged: exit %d:\n%s", code, out)
+	}
+	if code, out = runMain(t, home, "", "audit", "verify", fixture("diverged"), "--tolerance", "10m"); code != exitOK {
+		t.Errorf("diverged within tolerance: exit %d:\n%s", code, out)
+	}
+}
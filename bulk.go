@@ -0,0 +1,348 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// logFile is a daily or weekly log found in the log directory.
+type logFile struct {
+	Path    string
+	Date    time.Time // the day, or the Monday of the week
+	Project string
+	Weekly  bool
+}
+
+// parseLogName recognises daily logs named by the filename pattern,
+// 2024-06-03_League.md by default, and weekly ones like 2024-W23_League.md.
+func parseLogName(name string) (logFile, bool) {
+	if date, project, ok := parseDailyName(name); ok {
+		return logFile{Date: date, Project: project}, true
+	}
+	base, ok := strings.CutSuffix(name, ".md")
+	if !ok {
+		return logFile{}, false
+	}
+	key, project, ok := strings.Cut(base, "_")
+	if !ok || project == "" {
+		return logFile{}, false
+	}
+	year, week, ok := strings.Cut(key, "-W")
+	if !ok {
+		return logFile{}, false
+	}
+	y, err1 := strconv.Atoi(year)
+	w, err2 := strconv.Atoi(week)
+	if err1 != nil || err2 != nil || w < 1 || w > 53 {
+		return logFile{}, false
+	}
+	return logFile{Date: isoWeekStart(y, w), Project: project, Weekly: true}, true
+}
+
+// isoWeekStart returns the Monday of ISO week w of year y.
+func isoWeekStart(y, w int) time.Time {
+	jan4 := time.Date(y, time.January, 4, 0, 0, 0, 0, time.Local)
+	offset := (int(jan4.Weekday()) + 6) % 7
+	return jan4.AddDate(0, 0, -offset+(w-1)*7)
+}
+
+// listLogs returns every log in the log directory, oldest first.
+func listLogs() ([]logFile, error) {
+	dir, err := logDir()
+	if err != nil {
+		return nil, err
+	}
+	dirEntries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var logs []logFile
+	for _, d := range dirEntries {
+		if d.IsDir() {
+			continue
+		}
+		if lf, ok := parseLogName(d.Name()); ok {
+			lf.Path = filepath.Join(dir, d.Name())
+			logs = append(logs, lf)
+		}
+	}
+	sort.Slice(logs, func(i, j int) bool { return logs[i].Date.Before(logs[j].Date) })
+	return logs, nil
+}
+
+// rewrite is one pending change to a log file.
+type rewrite struct {
+	From, To string // To differs from From when the file is renamed
+	Old, New []byte
+	Merge    bool // To already exists and New is merged into it
+}
+
+// printDiff shows the change against the files as they are on disk. A
+// merge shows the target growing; the source going away is implied.
+func (r rewrite) printDiff() {
+	if !r.Merge {
+		printDiffLines(unifiedDiff(r.From, r.To, r.Old, r.New))
+		return
+	}
+	existing, err := os.ReadFile(r.To)
+	if err != nil {
+		fmt.Println("❌", err)
+		return
+	}
+	lf, _ := parseLogName(filepath.Base(r.To))
+	fmt.Printf("🔀 %s is merged into %s\n", r.From, r.To)
+	printDiffLines(unifiedDiff(r.To, r.To, existing, mergeLogs(existing, r.New, lf.Weekly)))
+}
+
+// apply writes the change, keeping a .bak of every file it replaces,
+// and brings the entry store in line. A nil Old creates the file.
+func (r rewrite) apply() error {
+	if r.Old != nil {
+		if err := os.WriteFile(r.From+".bak", r.Old, 0o644); err != nil {
+			return err
+		}
+	}
+	before, after := map[string][]byte{r.From: r.Old}, map[string][]byte{}
+	data := r.New
+	if r.Merge {
+		existing, err := os.ReadFile(r.To)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(r.To+".bak", existing, 0o644); err != nil {
+			return err
+		}
+		lf, _ := parseLogName(filepath.Base(r.To))
+		data = mergeLogs(existing, r.New, lf.Weekly)
+		before[r.To] = existing
+	}
+	if err := writeFileAtomic(r.To, data); err != nil {
+		return err
+	}
+	after[r.To] = data
+	if r.To != r.From {
+		if err := os.Remove(r.From); err != nil {
+			return err
+		}
+	}
+	storeLogs(before, after)
+	return nil
+}
+
+// applyRewrites writes changes. dryRun only shows their diffs; preview
+// shows them and asks first. It reports whether anything was written.
+func applyRewrites(changes []rewrite, dryRun, preview bool) (bool, error) {
+	if dryRun || preview {
+		for _, c := range changes {
+			c.printDiff()
+		}
+	}
+	if dryRun {
+		fmt.Printf("✏️  %d file(s) would change\n", len(changes))
+		return false, nil
+	}
+	if preview && len(changes) > 0 && !confirmWrite(len(changes)) {
+		return false, nil
+	}
+	for _, c := range changes {
+		if err := c.apply(); err != nil {
+			return false, fmt.Errorf("%s: %v", c.From, err)
+		}
+	}
+	fmt.Printf("✏️  %d file(s) changed\n", len(changes))
+	return true, nil
+}
+
+// renameProjectIn rewrites the frontmatter and title of a log from one
+// project name to another and rebuilds its tags line; every other line
+// stays as it was.
+func renameProjectIn(content []byte, from, to string) []byte {
+	lines := strings.Split(string(content), "\n")
+	for i, line := range lines {
+		switch {
+		case line == "project: "+from:
+			lines[i] = "project: " + to
+		case strings.HasPrefix(line, "# 📝 Work Log for "+from+" ("):
+			lines[i] = strings.Replace(line, " for "+from+" (", " for "+to+" (", 1)
+		}
+	}
+	return retag([]byte(strings.Join(lines, "\n")))
+}
+
+func renameCommand(args []string) error {
+	fs := newFlagSet("rename")
+	mapping := fs.String("project", "", `Project rename as "Old=New"`)
+	dryRun := fs.Bool("dry-run", false, "Show the changes without writing them")
+	preview := fs.Bool("preview", false, "Show the changes and ask before writing them")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	from, to, ok := strings.Cut(*mapping, "=")
+	from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+	if !ok || from == "" || to == "" || from == to {
+		return usageErrorf(`rename needs --project "Old=New"`)
+	}
+	_, err := renameProject(from, to, *dryRun, *preview)
+	return err
+}
+
+// renameProject renames a project across all logs and its history. It
+// reports whether anything was written.
+func renameProject(from, to string, dryRun, preview bool) (bool, error) {
+	logs, err := listLogs()
+	if err != nil {
+		return false, err
+	}
+	var changes []rewrite
+	for _, lf := range logs {
+		if lf.Project != from {
+			continue
+		}
+		old, err := os.ReadFile(lf.Path)
+		if err != nil {
+			return false, err
+		}
+		target := filepath.Join(filepath.Dir(lf.Path), dailyFilename(to, lf.Date))
+		if lf.Weekly {
+			target = filepath.Join(filepath.Dir(lf.Path), weeklyFilename(to, lf.Date))
+		}
+		_, statErr := os.Stat(target)
+		changes = append(changes, rewrite{
+			From:  lf.Path,
+			To:    target,
+			Old:   old,
+			New:   renameProjectIn(old, from, to),
+			Merge: statErr == nil,
+		})
+	}
+	written, err := applyRewrites(changes, dryRun, preview)
+	if err != nil {
+		return false, err
+	}
+	if written {
+		renameHistory(from, to)
+	}
+	return written, nil
+}
+
+func renameHistory(from, to string) {
+	oldPath, err1 := historyPath(from)
+	newPath, err2 := historyPath(to)
+	if err1 != nil || err2 != nil {
+		return
+	}
+	if _, err := os.Stat(newPath); err == nil {
+		return
+	}
+	os.Rename(oldPath, newPath)
+}
+
+func retaskCommand(args []string) error {
+	fs := newFlagSet("retask")
+	match := fs.String("match", "", "Text to look for in task names")
+	replace := fs.String("replace", "", "Replacement text")
+	fromFlag := fs.String("from", "", "Only logs on or after this date (YYYY-MM-DD)")
+	toFlag := fs.String("to", "", "Only logs on or before this date (YYYY-MM-DD)")
+	dryRun := fs.Bool("dry-run", false, "Show the changes without writing them")
+	preview := fs.Bool("preview", false, "Show the changes and ask before writing them")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *match == "" {
+		return usageErrorf("retask needs --match")
+	}
+	from, to, err := parseDateRange(*fromFlag, *toFlag)
+	if err != nil {
+		return err
+	}
+
+	logs, err := listLogs()
+	if err != nil {
+		return err
+	}
+	var changes []rewrite
+	for _, lf := range logs {
+		if !inRange(lf, from, to) {
+			continue
+		}
+		old, err := os.ReadFile(lf.Path)
+		if err != nil {
+			return err
+		}
+		// A weekly log also holds days outside the range; only the
+		// sections of days inside it are rewritten.
+		day := lf.Date
+		lines := strings.Split(string(old), "\n")
+		for i, line := range lines {
+			if date, ok := parseDayHeading(line); ok {
+				day = date
+			}
+			if task, ok := strings.CutPrefix(line, taskPrefix); ok && dayInRange(day, from, to) {
+				lines[i] = taskPrefix + strings.ReplaceAll(task, *match, *replace)
+			}
+		}
+		updated := []byte(strings.Join(lines, "\n"))
+		if !bytes.Equal(old, updated) {
+			changes = append(changes, rewrite{From: lf.Path, To: lf.Path, Old: old, New: updated})
+		}
+	}
+	_, err = applyRewrites(changes, *dryRun, *preview)
+	return err
+}
+
+// parseDateRange parses optional YYYY-MM-DD bounds; zero means open.
+func parseDateRange(fromText, toText string) (time.Time, time.Time, error) {
+	var from, to time.Time
+	var err error
+	if fromText != "" {
+		if from, err = time.ParseInLocation("2006-01-02", fromText, time.Local); err != nil {
+			return from, to, usageErrorf("invalid --from date %q", fromText)
+		}
+	}
+	if toText != "" {
+		if to, err = time.ParseInLocation("2006-01-02", toText, time.Local); err != nil {
+			return from, to, usageErrorf("invalid --to date %q", toText)
+		}
+	}
+	return from, to, nil
+}
+
+// inRange reports whether any day of the log falls within [from, to].
+func inRange(lf logFile, from, to time.Time) bool {
+	last := lf.Date
+	if lf.Weekly {
+		last = lf.Date.AddDate(0, 0, 6)
+	}
+	return (from.IsZero() || !last.Before(from)) && (to.IsZero() || !lf.Date.After(to))
+}
+
+// dayInRange reports whether day falls within [from, to].
+func dayInRange(day, from, to time.Time) bool {
+	return (from.IsZero() || !day.Before(from)) && (to.IsZero() || !day.After(to))
+}
+
+// parseDay reads "today", "yesterday" or a YYYY-MM-DD date.
+func parseDay(text string) (time.Time, error) {
+	today := startOfDay(time.Now())
+	switch text {
+	case "today":
+		return today, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	}
+	date, err := time.ParseInLocation("2006-01-02", text, time.Local)
+	if err != nil {
+		return date, usageErrorf("invalid date %q", text)
+	}
+	return date, nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetaskWeeklyRange(t *testing.T) {
+	_, logs := useTempDirs(t)
+	monday := time.Date(2024, 2, 26, 0, 0, 0, 0, time.Local)
+	var week []byte
+	for i := 0; i < 3; i++ {
+		day := monday.AddDate(0, 0, i)
+		week = renderWeek("League", day, []TaskEntry{{Task: "fix importer", Project: "League", Duration: time.Hour}}, week, false)
+	}
+	os.MkdirAll(logs, os.ModePerm)
+	path := filepath.Join(logs, weeklyFilename("League", monday))
+	if err := os.WriteFile(path, week, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	daily := filepath.Join(logs, dailyFilename("League", monday.AddDate(0, 0, 7)))
+	writeDay(t, logs, "League", monday.AddDate(0, 0, 7), []TaskEntry{{Task: "fix importer", Project: "League", Duration: time.Hour}})
+
+	err := retaskCommand([]string{"--match", "importer", "--replace", "loader", "--from", "2024-02-27", "--to", "2024-02-27"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	days := parseLog(content)
+	for date, want := range map[string]string{
+		"2024-02-26": "fix importer",
+		"2024-02-27": "fix loader",
+		"2024-02-28": "fix importer",
+	} {
+		if got := days[date]; len(got) != 1 || got[0].Task != want {
+			t.Errorf("%s: %v, want %q", date, got, want)
+		}
+	}
+	other, _ := os.ReadFile(daily)
+	if got := parseLog(other)["2024-03-04"]; len(got) != 1 || got[0].Task != "fix importer" {
+		t.Errorf("daily log outside the range was changed: %v", got)
+	}
+}
+
+func TestParseDayHeading(t *testing.T) {
+	tests := []struct {
+		line string
+		ok   bool
+	}{
+		{"## Monday 2024-02-26", true},
+		{"## monday 2024-02-26", true},
+		{"## Tuesday 2024-02-26", false},
+		{"## Notes", false},
+		{"## Summary of 2024-02-26", false},
+		{"## 2024-02-26", false},
+		{"### Monday 2024-02-26", false},
+	}
+	for _, tt := range tests {
+		if _, ok := parseDayHeading(tt.line); ok != tt.ok {
+			t.Errorf("parseDayHeading(%q) = %v, want %v", tt.line, ok, tt.ok)
+		}
+	}
+}
+
+// Renaming keeps the entry tags in the frontmatter and gives the new
+// name the same file name writing the log would.
+func TestRenameProject(t *testing.T) {
+	_, logs := useTempDirs(t)
+	date := time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local)
+	writeDay(t, logs, "League", date, []TaskEntry{{Task: "fix login", Project: "League", Duration: time.Hour, Tags: []string{"bugfix"}}})
+	if _, err := renameProject("League", "Client/Ops", false, false); err != nil {
+		t.Fatal(err)
+	}
+	if name := dailyFilename("Client/Ops", date); strings.Contains(name, "/") {
+		t.Fatalf("file name %q has a slash", name)
+	}
+	content, err := os.ReadFile(filepath.Join(logs, dailyFilename("Client/Ops", date)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := frontmatterValue(content, "tags"), "[work-log, client/ops, bugfix]"; got != want {
+		t.Errorf("tags %s, want %s", got, want)
+	}
+	if _, err := os.Stat(filepath.Join(logs, dailyFilename("League", date))); !os.IsNotExist(err) {
+		t.Errorf("the old log is still there: %v", err)
+	}
+}
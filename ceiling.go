@@ -0,0 +1,238 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// dayCeiling is the longest day that is written without asking; 0
+// turns the check off.
+var dayCeiling = 14 * time.Hour
+
+// Frontmatter keys listing the days over the ceiling that were
+// confirmed, or written unattended and still need a look.
+const (
+	acknowledgedKey = "acknowledged"
+	needsReviewKey  = "needs_review"
+)
+
+func overCeiling(entries []TaskEntry) bool {
+	return dayCeiling > 0 && totalDuration(entries) > dayCeiling
+}
+
+// longDayWarning explains the overrun and lists the largest entries,
+// which are usually the culprit.
+func longDayWarning(entries []TaskEntry) []string {
+	lines := []string{fmt.Sprintf("⚠️  The day adds up to %s, over the %s ceiling. Largest entries:",
+		formatDuration("summary", totalDuration(entries)), formatDuration("summary", dayCeiling))}
+	largest := append([]TaskEntry(nil), entries...)
+	sort.SliceStable(largest, func(i, j int) bool { return largest[i].Duration > largest[j].Duration })
+	for i, e := range largest {
+		if i == 3 {
+			break
+		}
+		lines = append(lines, fmt.Sprintf("     %s  %s (%s)", formatDuration("summary", e.Duration), e.Task, e.Project))
+	}
+	return lines
+}
+
+// confirmLongDay asks whether a day over the ceiling really is that
+// long.
+func confirmLongDay(entries []TaskEntry, af *autoFinalizer) (ok, timedOut bool) {
+	for _, line := range longDayWarning(entries) {
+		fmt.Println(line)
+	}
+	answer, timedOut := af.prompt("❓ Write it as is? (yes/no): ")
+	if timedOut {
+		return false, true
+	}
+	answer = strings.ToLower(answer)
+	return answer == "yes" || answer == "y", false
+}
+
+// flagDay records key for date in every log of that day. Acknowledging
+// a day also clears its needs_review entry.
+func flagDay(date time.Time, key string) error {
+	dir, err := logDir()
+	if err != nil {
+		return err
+	}
+	day := date.Format(dateLayout)
+	var files []string
+	for _, pattern := range []string{dailyGlob(date), isoWeek(date) + "_*.md"} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return err
+		}
+		files = append(files, matches...)
+	}
+	for _, f := range files {
+		content, err := os.ReadFile(f)
+		if err != nil {
+			return err
+		}
+		if _, ok := parseLog(content)[day]; !ok {
+			continue
+		}
+		updated := setFrontmatterDates(content, key, append(frontmatterDates(content, key), day))
+		if key == acknowledgedKey {
+			var rest []string
+			for _, d := range frontmatterDates(content, needsReviewKey) {
+				if d != day {
+					rest = append(rest, d)
+				}
+			}
+			updated = setFrontmatterDates(updated, needsReviewKey, rest)
+		}
+		if err := writeLog(f, updated); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// frontmatterDates reads a comma-separated list of dates.
+func frontmatterDates(content []byte, key string) []string {
+	var dates []string
+	for _, d := range strings.Split(frontmatterValue(content, key), ",") {
+		if d = strings.TrimSpace(d); d != "" {
+			dates = append(dates, d)
+		}
+	}
+	return dates
+}
+
+// setFrontmatterDates replaces key in the frontmatter with dates, or
+// drops it when there are none.
+func setFrontmatterDates(content []byte, key string, dates []string) []byte {
+	text := string(content)
+	if !strings.HasPrefix(text, "---\n") {
+		return content
+	}
+	end := strings.Index(text[4:], "\n---\n")
+	if end < 0 {
+		return content
+	}
+	end += 4
+
+	sort.Strings(dates)
+	var unique []string
+	for i, d := range dates {
+		if i == 0 || d != dates[i-1] {
+			unique = append(unique, d)
+		}
+	}
+	var lines []string
+	for _, line := range strings.Split(text[4:end], "\n") {
+		if !strings.HasPrefix(line, key+": ") {
+			lines = append(lines, line)
+		}
+	}
+	if len(unique) > 0 {
+		lines = append(lines, key+": "+strings.Join(unique, ", "))
+	}
+	return []byte("---\n" + strings.Join(lines, "\n") + text[end:])
+}
+
+// carryReviewFlags copies the review keys of a weekly file being
+// regenerated into its new content.
+func carryReviewFlags(content, previous []byte) []byte {
+	for _, key := range []string{acknowledgedKey, needsReviewKey} {
+		if dates := frontmatterDates(previous, key); len(dates) > 0 {
+			content = setFrontmatterDates(content, key, dates)
+		}
+	}
+	return content
+}
+
+// doctorCommand lists the days over the ceiling that nobody confirmed.
+func doctorCommand(args []string) error {
+	fs := newFlagSet("doctor")
+	ack := fs.String("ack", "", "Confirm that the day (YYYY-MM-DD) really was that long")
+	fix := fs.String("fix", "", "Repair a known problem: contained-duplicates")
+	dryRun := fs.Bool("dry-run", false, "With --fix, show the changes without writing them")
+	preview := fs.Bool("preview", false, "With --fix, show the changes and ask before writing them")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	switch *fix {
+	case "":
+	case "contained-duplicates":
+		return fixContainedDuplicates(*dryRun, *preview)
+	default:
+		return usageErrorf("unknown fix %q", *fix)
+	}
+	if *ack != "" {
+		date, err := parseDay(*ack)
+		if err != nil {
+			return err
+		}
+		if err := flagDay(date, acknowledgedKey); err != nil {
+			return err
+		}
+		fmt.Println("✅ Marked", date.Format(dateLayout), "as confirmed")
+		return nil
+	}
+	logs, err := listLogs()
+	if err != nil {
+		return err
+	}
+	days := map[string][]TaskEntry{}
+	acknowledged, needsReview := map[string]bool{}, map[string]bool{}
+	for _, lf := range logs {
+		content, err := os.ReadFile(lf.Path)
+		if err != nil {
+			return err
+		}
+		for day, entries := range parseLog(content) {
+			days[day] = append(days[day], entries...)
+		}
+		for _, d := range frontmatterDates(content, acknowledgedKey) {
+			acknowledged[d] = true
+		}
+		for _, d := range frontmatterDates(content, needsReviewKey) {
+			needsReview[d] = true
+		}
+	}
+
+	var keys []string
+	for day := range days {
+		keys = append(keys, day)
+	}
+	sort.Strings(keys)
+	problems, conflicts := 0, 0
+	for _, day := range keys {
+		for _, e := range days[day] {
+			for _, note := range e.Notes {
+				if note == conflictNote {
+					fmt.Printf("⚠️  %s: %q has a merge conflict; keep one version and delete the other\n", day, e.Task)
+					conflicts++
+				}
+			}
+		}
+		total := totalDuration(days[day])
+		switch {
+		case acknowledged[day]:
+			continue
+		case needsReview[day]:
+			fmt.Printf("⚠️  %s: %s, written unattended and needs review\n", day, formatDuration("summary", total))
+		case overCeiling(days[day]):
+			fmt.Printf("⚠️  %s: %s, over the %s ceiling\n", day, formatDuration("summary", total), formatDuration("summary", dayCeiling))
+		default:
+			continue
+		}
+		problems++
+	}
+	if problems > 0 {
+		return fmt.Errorf("%d days need review; fix the logs or confirm with doctor --ack DATE", problems)
+	}
+	if conflicts > 0 {
+		return fmt.Errorf("%d entries have merge conflicts; edit the logs to resolve them", conflicts)
+	}
+	fmt.Println("✅ No suspicious days")
+	return nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ceilingDay is a home with a two hour ceiling and three hours logged
+// today by an earlier run.
+func ceilingDay(t *testing.T) (home, log string) {
+	t.Helper()
+	config, logs := useTempDirs(t)
+	writeConfig(t, config, "log_dir: "+logs+"\nday_ceiling: 2h\n")
+	today := startOfDay(time.Now())
+	writeDay(t, logs, "League", today, []TaskEntry{
+		{Task: "migration", Project: "League", Start: today, Duration: 3 * time.Hour},
+	})
+	return filepath.Dir(logs), filepath.Join(logs, dailyFilename("League", today))
+}
+
+// The ceiling counts the whole day, not only this run's sessions.
+func TestCeilingCountsEarlierRuns(t *testing.T) {
+	tests := []struct {
+		name  string
+		stdin string
+		args  [][]string
+		key   string
+	}{
+		{"session", "q\nreview\nyes\nyes\n", [][]string{{"-no-banner", "-no-git-task", "-auto-finalize", ""}}, acknowledgedKey},
+		{"timer", "", [][]string{{"start", "--task", "review"}, {"stop"}}, needsReviewKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home, log := ceilingDay(t)
+			var out string
+			for _, args := range tt.args {
+				var code int
+				if code, out = runMain(t, home, tt.stdin, args...); code != exitOK {
+					t.Fatalf("%s: exit %d:\n%s", args, code, out)
+				}
+			}
+			if !strings.Contains(out, "over the 2h0m0s ceiling") || !strings.Contains(out, "migration") {
+				t.Errorf("no warning about the earlier run:\n%s", out)
+			}
+			content, err := os.ReadFile(log)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := frontmatterDates(content, tt.key); len(got) != 1 || got[0] != time.Now().Format(dateLayout) {
+				t.Errorf("%s is %q, want today", tt.key, got)
+			}
+		})
+	}
+}
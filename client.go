@@ -0,0 +1,67 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// projectClients is clients: from the config, project to client.
+var projectClients map[string]string
+
+// clientOf is the client the project is billed to: the one it was
+// registered with, else the config's; "" for none.
+func clientOf(project string) string {
+	if i := findProject(registry, project); i >= 0 && registry[i].Client != "" {
+		return registry[i].Client
+	}
+	return projectClients[project]
+}
+
+// clientProjects lists the projects billed to client, sorted.
+func clientProjects(client string) []string {
+	seen := map[string]bool{}
+	var projects []string
+	add := func(project string) {
+		if !seen[project] && strings.EqualFold(clientOf(project), client) {
+			seen[project] = true
+			projects = append(projects, project)
+		}
+	}
+	for _, p := range registry {
+		add(p.Name)
+	}
+	for project := range projectClients {
+		add(project)
+	}
+	sort.Strings(projects)
+	return projects
+}
+
+// clientName spells client the way a project already has it, so "acme"
+// and "Acme" are one client.
+func clientName(client string) string {
+	for _, p := range registry {
+		if strings.EqualFold(p.Client, client) {
+			return p.Client
+		}
+	}
+	for _, c := range projectClients {
+		if strings.EqualFold(c, client) {
+			return c
+		}
+	}
+	return client
+}
+
+// clientTotals sums the entries per client, leaving out projects that
+// have none.
+func clientTotals(entries []TaskEntry) []periodTotal {
+	var totals []periodTotal
+	index := map[string]int{}
+	for _, e := range entries {
+		if client := clientOf(e.Project); client != "" {
+			totals = addTotal(totals, index, clientName(client), "", e)
+		}
+	}
+	return finishTotals(totals)
+}
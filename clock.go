@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// maxTickDelta is the longest gap between two clock updates that is
+// still believed. The loop updates every second, so anything beyond
+// this means the process was frozen or the clock misbehaved.
+const maxTickDelta = 2 * time.Minute
+
+// sessionClock accumulates a session's elapsed time from tick to tick
+// instead of subtracting a start time, so that one bad reading cannot
+// produce a negative or hours-long session.
+type sessionClock struct {
+	elapsed time.Duration
+	last    time.Time
+	running bool
+	jumps   []time.Duration
+}
+
+func (c *sessionClock) start(now time.Time) {
+	c.last = now
+	c.running = true
+}
+
+// tick adds the time since the last update while running. Deltas are
+// taken from the monotonic clock when both readings carry it, which
+// ignores wall-clock changes. A delta that is negative or larger than
+// maxTickDelta is dropped and remembered as a jump.
+//
+// The monotonic clock stands still while the system is suspended, so a
+// wall clock that ran more than maxTickDelta ahead of it, or a frozen
+// process, is reported as the time slept, whether running or not.
+func (c *sessionClock) tick(now time.Time) (slept time.Duration) {
+	d := now.Sub(c.last)
+	wall := now.Round(0).Sub(c.last.Round(0))
+	c.last = now
+	if diff := wall - d; diff > maxTickDelta || diff < -maxTickDelta {
+		debugf("wall clock moved %s while the monotonic clock moved %s (suspend or clock change)", wall, d)
+		if diff > 0 {
+			slept = diff
+		}
+	}
+	if d > maxTickDelta {
+		slept = max(slept, d)
+	}
+	if !c.running {
+		return slept
+	}
+	if d < 0 || d > maxTickDelta {
+		debugf("dropped implausible tick of %s", d)
+		c.jumps = append(c.jumps, d)
+		return slept
+	}
+	c.elapsed += d
+	return slept
+}
+
+func (c *sessionClock) pause(now time.Time) {
+	c.tick(now)
+	c.running = false
+}
+
+func (c *sessionClock) resume(now time.Time) {
+	c.last = now
+	c.running = true
+}
+
+// note describes the compensated jumps for the entry, if there were any.
+func (c *sessionClock) note() string {
+	if len(c.jumps) == 0 {
+		return ""
+	}
+	var total time.Duration
+	for _, j := range c.jumps {
+		if j < 0 {
+			j = -j
+		}
+		total += j
+	}
+	return fmt.Sprintf("clock jump of %s compensated", formatDuration("markdown", total))
+}
+
+var debugLog *os.File
+
+// enableDebug sends debugf output to debug.log in the app directory.
+func enableDebug() error {
+	dir, err := appDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+	debugLog, err = os.OpenFile(filepath.Join(dir, "debug.log"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	return err
+}
+
+func debugf(format string, args ...any) {
+	if debugLog == nil {
+		return
+	}
+	fmt.Fprintf(debugLog, "%s "+format+"\n", append([]any{time.Now().Format(time.RFC3339)}, args...)...)
+}
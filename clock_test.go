@@ -0,0 +1,82 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessionClock(t *testing.T) {
+	tests := []struct {
+		name    string
+		ticks   []time.Duration // offsets from 09:00, one per tick
+		elapsed time.Duration
+		jumps   int
+		note    string
+	}{
+		{"steady", []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}, 3 * time.Second, 0, ""},
+		{"backwards", []time.Duration{time.Second, -10 * time.Minute, -10*time.Minute + time.Second}, 2 * time.Second, 1,
+			"clock jump of " + formatDuration("markdown", 10*time.Minute+time.Second) + " compensated"},
+		{"three hours ahead", []time.Duration{time.Second, 3*time.Hour + time.Second, 3*time.Hour + 2*time.Second}, 2 * time.Second, 1,
+			"clock jump of " + formatDuration("markdown", 3*time.Hour) + " compensated"},
+		{"within tolerance", []time.Duration{time.Second, maxTickDelta + time.Second}, maxTickDelta + time.Second, 0, ""},
+		{"both ways", []time.Duration{-time.Hour, 2 * time.Hour, 2*time.Hour + time.Second}, time.Second, 2,
+			"clock jump of " + formatDuration("markdown", 4*time.Hour) + " compensated"},
+	}
+	for _, tt := range tests {
+		var c sessionClock
+		c.start(at("09:00"))
+		for _, off := range tt.ticks {
+			c.tick(at("09:00").Add(off))
+		}
+		if c.elapsed != tt.elapsed || len(c.jumps) != tt.jumps || c.note() != tt.note {
+			t.Errorf("%s: elapsed %s, %d jumps, note %q; want %s, %d, %q",
+				tt.name, c.elapsed, len(c.jumps), c.note(), tt.elapsed, tt.jumps, tt.note)
+		}
+	}
+}
+
+func TestSessionClockPaused(t *testing.T) {
+	var c sessionClock
+	c.start(at("09:00"))
+	c.tick(at("09:00").Add(30 * time.Second))
+	c.pause(at("09:00").Add(time.Minute))
+	// Paused time and a jump while paused are neither counted nor noted.
+	for _, off := range []time.Duration{2 * time.Minute, 3 * time.Hour, time.Minute} {
+		c.tick(at("09:00").Add(off))
+	}
+	c.resume(at("10:00"))
+	c.tick(at("10:00").Add(time.Second))
+	if c.elapsed != time.Minute+time.Second || c.note() != "" {
+		t.Errorf("elapsed %s, note %q; want 1m1s and no note", c.elapsed, c.note())
+	}
+}
+
+func TestSessionClockSlept(t *testing.T) {
+	var c sessionClock
+	c.start(at("09:00"))
+	if slept := c.tick(at("09:00").Add(time.Second)); slept != 0 {
+		t.Errorf("a normal tick reported %s asleep", slept)
+	}
+	if slept := c.tick(at("12:00")); slept != 3*time.Hour-time.Second {
+		t.Errorf("a 3h gap reported %s asleep", slept)
+	}
+	c.pause(at("12:00"))
+	if slept := c.tick(at("13:00")); slept != time.Hour {
+		t.Errorf("a 1h gap while paused reported %s asleep", slept)
+	}
+	if slept := c.tick(at("12:00")); slept != 0 {
+		t.Errorf("going backwards reported %s asleep", slept)
+	}
+}
+
+func TestSessionClockMonotonic(t *testing.T) {
+	// Readings with a monotonic clock take their deltas from it.
+	now := time.Now()
+	var c sessionClock
+	c.start(now)
+	c.tick(now.Add(time.Second))
+	c.tick(now.Add(2 * time.Second))
+	if c.elapsed != 2*time.Second || len(c.jumps) != 0 {
+		t.Errorf("elapsed %s with %d jumps, want 2s and none", c.elapsed, len(c.jumps))
+	}
+}
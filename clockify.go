@@ -0,0 +1,389 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"time"
+)
+
+// clockifyAPI is Clockify's v1 API.
+var clockifyAPI = "https://api.clockify.me/api/v1"
+
+// clockifyNote marks entries that came from Clockify, so they are not
+// pushed back to it.
+const clockifyNote = "imported from Clockify"
+
+// clockifyTime is how Clockify wants its times: UTC, to the second.
+const clockifyTime = "2006-01-02T15:04:05Z"
+
+// clockifyConfig is the clockify section of the config.
+type clockifyConfig struct {
+	Token     string // API key from the Clockify profile settings
+	Workspace string // default: the account's active workspace
+	// Projects maps local project names to Clockify's where they differ.
+	Projects map[string]string
+}
+
+func (c clockifyConfig) remoteProject(local string) string {
+	if name, ok := c.Projects[local]; ok {
+		return name
+	}
+	return local
+}
+
+func (c clockifyConfig) localProject(remote string) string {
+	for local, name := range c.Projects {
+		if name == remote {
+			return local
+		}
+	}
+	return remote
+}
+
+// clockifyEntry is a time entry as the API has it; End is nil while
+// the entry is running.
+type clockifyEntry struct {
+	ID           string `json:"id,omitempty"`
+	Description  string `json:"description"`
+	ProjectID    string `json:"projectId,omitempty"`
+	Billable     bool   `json:"billable"`
+	TimeInterval struct {
+		Start time.Time  `json:"start"`
+		End   *time.Time `json:"end"`
+	} `json:"timeInterval"`
+}
+
+// clockifyTarget pushes entries to a Clockify workspace and fetches
+// them back; the user, workspace and projects are looked up on first
+// use.
+type clockifyTarget struct {
+	cfg       clockifyConfig
+	client    *http.Client
+	user      string
+	workspace string
+	projects  map[string]string // ID by Clockify name
+	names     map[string]string
+}
+
+func newClockifyTarget(cfg Config) (syncTarget, error) {
+	return newClockifyClient(cfg)
+}
+
+func newClockifyClient(cfg Config) (*clockifyTarget, error) {
+	if cfg.Clockify.Token == "" {
+		return nil, errors.New("clockify needs clockify.token in the config")
+	}
+	return &clockifyTarget{cfg: cfg.Clockify, client: &http.Client{Timeout: syncHTTPTimeout}}, nil
+}
+
+func (c *clockifyTarget) call(method, path string, body, reply any) error {
+	auth := func(req *http.Request) { req.Header.Set("X-Api-Key", c.cfg.Token) }
+	return sendJSONAuth(c.client, method, clockifyAPI+path, auth, body, reply)
+}
+
+// loadProjects finds the user and workspace and learns the workspace's
+// project names.
+func (c *clockifyTarget) loadProjects() error {
+	if c.projects != nil {
+		return nil
+	}
+	var me struct {
+		ID              string `json:"id"`
+		ActiveWorkspace string `json:"activeWorkspace"`
+	}
+	if err := c.call(http.MethodGet, "/user", nil, &me); err != nil {
+		return err
+	}
+	c.user, c.workspace = me.ID, c.cfg.Workspace
+	if c.workspace == "" {
+		c.workspace = me.ActiveWorkspace
+	}
+	var projects []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+	if err := c.call(http.MethodGet, "/workspaces/"+c.workspace+"/projects?page-size=5000", nil, &projects); err != nil {
+		return err
+	}
+	c.projects, c.names = map[string]string{}, map[string]string{}
+	for _, p := range projects {
+		c.projects[p.Name], c.names[p.ID] = p.ID, p.Name
+	}
+	return nil
+}
+
+func (*clockifyTarget) name() string { return "clockify" }
+
+func (*clockifyTarget) accepts(e TaskEntry) bool { return !slices.Contains(e.Notes, clockifyNote) }
+
+// push creates a time entry, or updates the one made before. Entries
+// logged without a start time start at the beginning of their day. A
+// project Clockify does not know is left off.
+func (c *clockifyTarget) push(date string, e TaskEntry, remoteID string) (string, error) {
+	if err := c.loadProjects(); err != nil {
+		return "", err
+	}
+	start := e.Start
+	if start.IsZero() {
+		start, _ = time.ParseInLocation(dateLayout, date, time.Local)
+	}
+	body := map[string]any{
+		"start":       start.UTC().Format(clockifyTime),
+		"end":         start.Add(e.Duration).UTC().Format(clockifyTime),
+		"description": e.Task,
+		"billable":    e.Billable,
+	}
+	if id, ok := c.projects[c.cfg.remoteProject(e.Project)]; ok {
+		body["projectId"] = id
+	}
+	method, path := http.MethodPost, "/workspaces/"+c.workspace+"/time-entries"
+	if remoteID != "" {
+		method, path = http.MethodPut, path+"/"+remoteID
+	}
+	var reply clockifyEntry
+	if err := c.call(method, path, body, &reply); err != nil {
+		return "", err
+	}
+	return reply.ID, nil
+}
+
+// remove deletes a time entry.
+func (c *clockifyTarget) remove(remoteID string) error {
+	if err := c.loadProjects(); err != nil {
+		return err
+	}
+	return c.call(http.MethodDelete, "/workspaces/"+c.workspace+"/time-entries/"+remoteID, nil, nil)
+}
+
+// fetch returns the user's finished time entries between from and to,
+// both days included.
+func (c *clockifyTarget) fetch(from, to time.Time) ([]clockifyEntry, error) {
+	if err := c.loadProjects(); err != nil {
+		return nil, err
+	}
+	const pageSize = 200
+	var all []clockifyEntry
+	for page := 1; ; page++ {
+		path := fmt.Sprintf("/workspaces/%s/user/%s/time-entries?start=%s&end=%s&page=%d&page-size=%d", c.workspace, c.user,
+			from.UTC().Format(clockifyTime), to.AddDate(0, 0, 1).UTC().Format(clockifyTime), page, pageSize)
+		var entries []clockifyEntry
+		if err := c.call(http.MethodGet, path, nil, &entries); err != nil {
+			return nil, err
+		}
+		all = append(all, entries...)
+		if len(entries) < pageSize {
+			break
+		}
+	}
+	return slices.DeleteFunc(all, func(ce clockifyEntry) bool { return ce.TimeInterval.End == nil }), nil
+}
+
+// entry turns a Clockify entry into a local one, mapping its project
+// back and filing entries without one under fallback.
+func (c *clockifyTarget) entry(ce clockifyEntry, fallback string) TaskEntry {
+	task, tags := parseTags(ce.Description)
+	if task == "" {
+		task = "(no description)"
+	}
+	start := ce.TimeInterval.Start.Local()
+	e := TaskEntry{Task: task, Project: fallback, Start: start, Duration: ce.TimeInterval.End.Sub(start).Round(time.Second),
+		Billable: ce.Billable, Tags: tags, Notes: []string{clockifyNote}}
+	if name, ok := c.names[ce.ProjectID]; ok {
+		e.Project = c.cfg.localProject(name)
+	}
+	return e
+}
+
+func clockifyCommand(args []string) error {
+	if len(args) > 0 && args[0] == "pull" {
+		return clockifyPull(args[1:])
+	}
+	return usageErrorf("usage: clockify pull [--from DAY] [--to DAY] [--project P] [--prefer local|remote] [--preview]")
+}
+
+// clockifyPull brings the logs up to date with Clockify: entries made
+// there, on another device, are added, and entries pushed from here
+// that were edited there are edited here too. When an entry changed on
+// both sides since the last sync, prefer says which side wins; local
+// changes reach Clockify with the next sync clockify.
+func clockifyPull(args []string) error {
+	fs := newFlagSet("clockify pull")
+	fromFlag := fs.String("from", "", "First day to fetch (default: 30 days ago)")
+	toFlag := fs.String("to", "", "Last day to fetch (default: today)")
+	project := projectFlag(fs, "project", defaultProject, "Project for entries that have none in Clockify")
+	prefer := fs.String("prefer", "local", "Which side wins when an entry changed on both: local or remote")
+	preview := fs.Bool("preview", false, "Show the changes and ask before writing them")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *prefer != "local" && *prefer != "remote" {
+		return usageErrorf("--prefer wants local or remote, got %q", *prefer)
+	}
+	from, to, err := parseDateRange(*fromFlag, *toFlag)
+	if err != nil {
+		return err
+	}
+	if to.IsZero() {
+		to = startOfDay(time.Now())
+	}
+	if from.IsZero() {
+		from = to.AddDate(0, 0, -30)
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return withCode(exitUsage, err)
+	}
+	c, err := newClockifyClient(cfg)
+	if err != nil {
+		return withCode(exitUsage, err)
+	}
+	fetched, err := c.fetch(from, to)
+	if err != nil {
+		return err
+	}
+	ledger, err := loadLedger()
+	if err != nil {
+		return err
+	}
+	known := map[string]syncRecord{} // by remote ID
+	for _, records := range ledger {
+		if rec, ok := records[c.name()]; ok && !rec.Deleted && rec.Synced.After(known[rec.RemoteID].Synced) {
+			known[rec.RemoteID] = rec
+		}
+	}
+
+	today := startOfDay(time.Now())
+	var fresh []pulledEntry
+	edited, conflicts := 0, 0
+	for _, ce := range fetched {
+		remote := c.entry(ce, *project)
+		if startOfDay(remote.Start).Equal(today) && instanceRunning() {
+			continue
+		}
+		rec, ok := known[ce.ID]
+		if !ok {
+			fresh = append(fresh, pulledEntry{ce.ID, remote})
+			continue
+		}
+		changed, conflict, err := c.pullEdit(rec, remote, *prefer == "remote", *preview)
+		if err != nil {
+			return err
+		}
+		if changed {
+			edited++
+		}
+		if conflict {
+			conflicts++
+		}
+	}
+	if len(fresh) == 0 && edited == 0 && conflicts == 0 {
+		fmt.Printf("👌 The logs are up to date with Clockify from %s to %s\n", from.Format(dateLayout), to.Format(dateLayout))
+		return nil
+	}
+
+	type group struct {
+		date    time.Time
+		project string
+	}
+	var order []group
+	byGroup := map[group][]pulledEntry{}
+	for _, p := range fresh {
+		g := group{startOfDay(p.entry.Start), p.entry.Project}
+		if _, ok := byGroup[g]; !ok {
+			order = append(order, g)
+		}
+		byGroup[g] = append(byGroup[g], p)
+	}
+	sort.SliceStable(order, func(i, j int) bool { return order[i].date.Before(order[j].date) })
+	for _, g := range order {
+		pulled := byGroup[g]
+		sort.SliceStable(pulled, func(i, j int) bool { return pulled[i].entry.Start.Before(pulled[j].entry.Start) })
+		day := make([]TaskEntry, len(pulled))
+		for i, p := range pulled {
+			day[i] = p.entry
+		}
+		if err := importEntries(g.project, g.date, day, *preview); err != nil {
+			return err
+		}
+		written, err := writtenEntries(g.date)
+		if err != nil {
+			return err
+		}
+		written = slices.DeleteFunc(written, func(e TaskEntry) bool { return e.Project != g.project })
+		recordPulled(c.name(), g.date, written, pulled)
+	}
+	return nil
+}
+
+// pullEdit compares a Clockify entry that is in the ledger with its
+// local entry. An edit made only in Clockify is applied here; one made
+// on both sides is a conflict, applied here only with preferRemote.
+func (c *clockifyTarget) pullEdit(rec syncRecord, remote TaskEntry, preferRemote, preview bool) (changed, conflict bool, err error) {
+	d, err := pickEntry(rec.Entry, "")
+	if err != nil {
+		fmt.Printf("⚠️  %s is in Clockify but no longer in the logs; left alone\n", rec.Entry)
+		return false, false, nil
+	}
+	local := d.entry()
+	asPushed := *local
+	asPushed.Project, asPushed.Task, asPushed.Duration, asPushed.Billable = remote.Project, remote.Task, remote.Duration, remote.Billable
+	if !local.Start.IsZero() {
+		asPushed.Start = remote.Start
+	}
+	if entryHash(asPushed) == entryHash(*local) {
+		return false, false, nil
+	}
+	remoteEdited := !rec.matches(asPushed)
+	localEdited := !rec.matches(*local)
+	switch {
+	case !remoteEdited:
+		return false, false, nil // only changed here; sync clockify pushes it
+	case localEdited && !preferRemote:
+		fmt.Printf("⚠️  %s changed here and in Clockify (%s, %s there); kept this one, sync clockify pushes it\n",
+			rec.Entry, remote.Task, formatDuration("summary", remote.Duration))
+		return false, true, nil
+	}
+	projects := []string{local.Project}
+	if remote.Project != local.Project {
+		projects = append(projects, remote.Project)
+	}
+	*local = asPushed
+	written, err := d.write(projects, false, preview)
+	if !written || err != nil {
+		return false, localEdited, err
+	}
+	if err := recordSync(syncRecord{Entry: d.id, Target: c.name(), RemoteID: rec.RemoteID, Hash: entryHash(*local), Synced: time.Now()}); err != nil {
+		return true, localEdited, err
+	}
+	fmt.Printf("✏️  %s: took Clockify's %s, %s\n", rec.Entry, local.Task, formatDuration("summary", local.Duration))
+	return true, localEdited, nil
+}
+
+// pulledEntry is an entry made in Clockify and its remote ID.
+type pulledEntry struct {
+	remoteID string
+	entry    TaskEntry
+}
+
+// recordPulled notes the entries just pulled in the ledger under their
+// IDs in the day's log, found by task and start, so later edits in
+// Clockify are recognized. Entries the preview declined are not there.
+func recordPulled(target string, date time.Time, written []TaskEntry, pulled []pulledEntry) {
+	ids := entryIDs(date.Format(dateLayout), written)
+	for _, p := range pulled {
+		for i, e := range written {
+			if e.Task != p.entry.Task || !slices.Contains(e.Notes, clockifyNote) ||
+				!e.Start.Truncate(time.Minute).Equal(p.entry.Start.Truncate(time.Minute)) {
+				continue
+			}
+			if err := recordSync(syncRecord{Entry: ids[i], Target: target, RemoteID: p.remoteID, Hash: entryHash(e), Synced: time.Now()}); err != nil {
+				fmt.Println("⚠️  Could not update the sync ledger:", err)
+				return
+			}
+			break
+		}
+	}
+}
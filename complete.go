@@ -0,0 +1,285 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const taskCandidateMax = 200
+
+// taskCandidates are the task names the task prompt completes from: the
+// plan queue's open tasks, the project's tracked tasks, most often tracked first and the latest first
+// among equals, then its history and other projects' recent tasks.
+func taskCandidates(project string) []string {
+	type seen struct {
+		count int
+		last  string
+	}
+	counts := map[string]*seen{}
+	stored, err := loadStore()
+	if err != nil {
+		stored = nil
+	}
+	for _, s := range stored {
+		if s.Project != project || s.Task == "" || s.Task == autoClosedTask {
+			continue
+		}
+		c := counts[s.Task]
+		if c == nil {
+			c = &seen{}
+			counts[s.Task] = c
+		}
+		c.count++
+		c.last = max(c.last, s.Date)
+	}
+	var tasks []string
+	for task := range counts {
+		tasks = append(tasks, task)
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		a, b := counts[tasks[i]], counts[tasks[j]]
+		if a.count != b.count {
+			return a.count > b.count
+		}
+		if a.last != b.last {
+			return a.last > b.last
+		}
+		return tasks[i] < tasks[j]
+	})
+	tasks = dedupe(tasks, plannedTasks(project), taskCandidateMax)
+	tasks = dedupe(loadHistory(project), tasks, taskCandidateMax)
+	return dedupe(recentTasks(project, recentTaskMax), tasks, taskCandidateMax)
+}
+
+// matchTasks returns the candidates matching what was typed, ignoring
+// case: those starting with it first, then those containing it, then
+// those containing its letters in order.
+func matchTasks(typed string, candidates []string) []string {
+	typed = strings.ToLower(strings.TrimSpace(typed))
+	if typed == "" {
+		return nil
+	}
+	var prefix, contains, fuzzy []string
+	for _, c := range candidates {
+		lower := strings.ToLower(c)
+		switch {
+		case strings.HasPrefix(lower, typed):
+			prefix = append(prefix, c)
+		case strings.Contains(lower, typed):
+			contains = append(contains, c)
+		case subsequence(typed, lower):
+			fuzzy = append(fuzzy, c)
+		}
+	}
+	return append(append(prefix, contains...), fuzzy...)
+}
+
+// subsequence reports whether the runes of sub appear in s in order.
+func subsequence(sub, s string) bool {
+	for _, r := range s {
+		if sub == "" {
+			break
+		}
+		if first, size := utf8.DecodeRuneInString(sub); r == first {
+			sub = sub[size:]
+		}
+	}
+	return sub == ""
+}
+
+// lineEditor is the task prompt while the terminal is raw: it echoes
+// what is typed and shows the best matching task after it. Tab or the
+// right arrow takes the suggestion; the up and down arrows step through
+// the other matches, or through every task when nothing is typed yet.
+type lineEditor struct {
+	prompt     string
+	text       []rune
+	candidates []string
+	matches    []string
+	pick       int    // the match suggested, -1 for none
+	esc        string // escape sequence read so far
+	partial    []byte // start of a multi-byte character
+}
+
+func newLineEditor(prompt string, candidates []string) *lineEditor {
+	return &lineEditor{prompt: prompt, candidates: candidates, pick: -1}
+}
+
+func (l *lineEditor) update() {
+	l.matches = matchTasks(string(l.text), l.candidates)
+	l.pick = -1
+	if len(l.matches) > 0 {
+		l.pick = 0
+	}
+}
+
+// step moves the suggestion by delta, wrapping around.
+func (l *lineEditor) step(delta int) {
+	if len(l.text) == 0 && l.matches == nil {
+		l.matches = l.candidates
+	}
+	if len(l.matches) == 0 {
+		return
+	}
+	switch {
+	case l.pick >= 0:
+		l.pick = (l.pick + delta + len(l.matches)) % len(l.matches)
+	case delta > 0:
+		l.pick = 0
+	default:
+		l.pick = len(l.matches) - 1
+	}
+}
+
+func (l *lineEditor) accept() {
+	if l.pick >= 0 {
+		l.text = []rune(l.matches[l.pick])
+		l.update()
+	}
+}
+
+// key handles one byte of input and reports whether Enter ended the
+// line.
+func (l *lineEditor) key(b byte) bool {
+	if l.esc != "" {
+		l.esc += string(b)
+		switch {
+		case len(l.esc) == 2 && (b == '[' || b == 'O'):
+		case len(l.esc) == 2:
+			l.esc = ""
+		case b >= 0x40 && b <= 0x7e: // the end of the sequence
+			switch b {
+			case 'A':
+				l.step(-1)
+			case 'B':
+				l.step(1)
+			case 'C':
+				l.accept()
+			}
+			l.esc = ""
+		}
+		return false
+	}
+	switch {
+	case b == '\r' || b == '\n':
+		return true
+	case b == 0x1b:
+		l.esc = "\x1b"
+	case b == '\t':
+		l.accept()
+	case b == 0x7f || b == 0x08:
+		if len(l.text) > 0 {
+			l.text = l.text[:len(l.text)-1]
+			l.update()
+		}
+	case b == 0x15: // Ctrl+U
+		l.text = nil
+		l.update()
+	case b >= 0x80:
+		l.partial = append(l.partial, b)
+		if utf8.FullRune(l.partial) {
+			r, _ := utf8.DecodeRune(l.partial)
+			l.partial = nil
+			l.text = append(l.text, r)
+			l.update()
+		}
+	case b >= 0x20:
+		l.text = append(l.text, rune(b))
+		l.update()
+	}
+	return false
+}
+
+// render redraws the prompt line. A suggestion that continues what was
+// typed is shown dimmed after the cursor, any other one after an arrow.
+func (l *lineEditor) render() {
+	line := "\r\033[K" + l.prompt + string(l.text)
+	if l.pick < 0 || l.pick >= len(l.matches) {
+		fmt.Print(line)
+		return
+	}
+	hint := l.matches[l.pick]
+	typed := string(l.text)
+	if rest, ok := strings.CutPrefix(strings.ToLower(hint), strings.ToLower(typed)); ok && typed != "" && len(rest) == len(hint)-len(typed) {
+		hint = hint[len(typed):]
+	} else {
+		hint = "  → " + hint
+	}
+	if len(l.matches) > 1 {
+		hint += fmt.Sprintf(" (%d/%d)", l.pick+1, len(l.matches))
+	}
+	if hint == "" {
+		fmt.Print(line)
+		return
+	}
+	fmt.Printf("%s\033[2m%s\033[0m\033[%dD", line, hint, utf8.RuneCountInString(hint))
+}
+
+// promptTask asks for a task like prompt but offers completion from
+// candidates while stdin is a terminal. Without a terminal it is prompt.
+func (a *autoFinalizer) promptTask(prompt string, candidates []string) (string, bool) {
+	enterRaw()
+	if !rawInput.Load() {
+		return a.prompt(prompt)
+	}
+	defer leaveRaw()
+	audit("prompt_open", "", 0)
+	defer trackOverhead(time.Now())
+	ed := newLineEditor(prompt, candidates)
+	ed.render()
+	for {
+		var warn, deadline <-chan time.Time
+		if a.enabled() {
+			left := time.Until(a.at)
+			if left > autoFinalizeWarning {
+				warn = time.After(left - autoFinalizeWarning)
+			} else {
+				deadline = time.After(left)
+			}
+		}
+
+		select {
+		case key, ok := <-inputLines:
+			if !ok {
+				fmt.Print("\n")
+				return strings.TrimSpace(string(ed.text)), false
+			}
+			done := false
+			for i := 0; i < len(key) && !done; i++ {
+				done = ed.key(key[i])
+			}
+			if !done {
+				ed.render()
+				continue
+			}
+			text := strings.TrimSpace(string(ed.text))
+			ed.pick = -1
+			ed.render()
+			fmt.Print("\n")
+			if _, ok := a.warning(); ok && strings.EqualFold(text, "c") {
+				a.cancel()
+				ed = newLineEditor(prompt, candidates)
+				ed.render()
+				continue
+			}
+			return text, false
+		case <-warn:
+			fmt.Printf("\r\033[K⚠️  Auto-finalizing the day at %s - type 'c' to cancel\n", a.at.Format("15:04"))
+			ed.render()
+		case <-deadline:
+			fmt.Print("\n")
+			return "", true
+		}
+	}
+}
+
+// taskPrompt is inputPrompt with task completion for the project, for
+// the questions asked when no day is waiting to be finalized.
+func taskPrompt(prompt, project string) string {
+	var none autoFinalizer
+	text, _ := none.promptTask(prompt, taskCandidates(project))
+	return text
+}
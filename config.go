@@ -0,0 +1,456 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config holds the settings read from config.yaml. Flags given on the
+// command line take precedence over it.
+type Config struct {
+	AutoFinalize       string
+	AutoFinalizeAction string
+	Mute               bool
+	ASCII              bool
+	Layout             string
+	Target             time.Duration
+	Location           *time.Location
+	WeekdayProjects    map[time.Weekday]string
+	WeekdayTargets     map[time.Weekday]time.Duration
+	WriteMode          string
+	LogDir             string
+	DayCeiling         time.Duration
+	SessionCeiling     time.Duration
+	StartMenu          bool
+	AskTask            bool
+	GitTask            bool
+	ServeAddr          string
+	ServeToken         string
+	Duplicates         duplicateRule
+	GraceWindow        time.Duration
+	GraceGap           string // drop or pause
+	Currency           string
+	Rates              map[string]rate
+	Billable           map[string]bool // projects whose entries are billable by default
+	// Clients maps projects to the client they are billed to;
+	// ClientDetails holds each client's address for invoices.
+	Clients           map[string]string
+	ClientDetails     map[string]string
+	Invoice           invoiceConfig
+	ProjectCheck      bool
+	DND               bool
+	DNDPauseThreshold time.Duration
+	// DNDTag limits Do Not Disturb to sessions whose task carries it,
+	// e.g. deep-work; empty for every session.
+	DNDTag string
+	// PauseReasonThreshold is how long a pause lasts before resuming
+	// asks for its reason.
+	PauseReasonThreshold time.Duration
+	Sounds               map[string]string
+	Pomodoro             pomodoroConfig
+	// Durations overrides durationStyles per target.
+	Durations map[string]durationFormat
+	// Profile is the profile to use when none is given; only read from
+	// the default profile's config.
+	Profile string
+	// Project is the project used when neither -project nor a weekday
+	// rule names one.
+	Project  string
+	Filename string // pattern for daily log names
+	// MarkdownTemplate is a text/template file for daily logs.
+	MarkdownTemplate string
+	// IdleAfter is how long nobody may touch the computer before the
+	// session pauses, 0 to never; IdleCommand prints the idle time in
+	// milliseconds when the built-in detectors don't fit.
+	IdleAfter   time.Duration
+	IdleCommand string
+	Jira        jiraConfig
+	Toggl       togglConfig
+	Notify      notifyConfig
+	Remind      remindConfig
+	Slack       slackConfig
+	Gcal        gcalConfig
+	Clockify    clockifyConfig
+	Harvest     harvestConfig
+	GitHub      githubConfig
+	Email       emailConfig
+	Standup     standupConfig
+	Telegram    telegramConfig
+	// Webhooks are told about session events, by name.
+	Webhooks map[string]webhook
+}
+
+func defaultConfig() Config {
+	return Config{
+		AutoFinalize:       "23:55",
+		Project:            "League",
+		Filename:           defaultFilenamePattern,
+		AutoFinalizeAction: "exit",
+		Layout:             "daily",
+		WriteMode:          "final",
+		ProjectCheck:       true,
+		GitTask:            true,
+		DayCeiling:         14 * time.Hour,
+		SessionCeiling:     6 * time.Hour,
+		ServeAddr:          ":8787",
+		Duplicates:         duplicateRule{Similarity: 0.8, Tolerance: 2 * time.Minute},
+		GraceGap:           "drop",
+		Currency:           "EUR",
+		Rates:              map[string]rate{},
+		Billable:           map[string]bool{},
+		Clients:            map[string]string{},
+		ClientDetails:      map[string]string{},
+		Invoice:            invoiceConfig{Number: defaultInvoiceNumber, DueDays: 30},
+		DNDPauseThreshold:  5 * time.Minute,
+		Sounds:             map[string]string{},
+		Durations:          map[string]durationFormat{},
+		WeekdayProjects:    map[time.Weekday]string{},
+		WeekdayTargets:     map[time.Weekday]time.Duration{},
+		Toggl:              togglConfig{Projects: map[string]string{}},
+		Gcal:               gcalConfig{Calendar: "Work Log"},
+		Clockify:           clockifyConfig{Projects: map[string]string{}},
+		Harvest:            harvestConfig{Projects: map[string]string{}, Tasks: map[string]string{}},
+		GitHub:             githubConfig{Link: true},
+		Email:              emailConfig{Port: 587},
+		Notify:             notifyConfig{PausedAfter: 15 * time.Minute, LongSession: 3 * time.Hour, Pomodoro: true},
+		Remind:             remindConfig{Snooze: 10 * time.Minute, Bell: true},
+		Slack:              slackConfig{Status: "Focusing on {project}", Emoji: ":dart:", DND: 4 * time.Hour},
+		Webhooks:           map[string]webhook{},
+
+		PauseReasonThreshold: 5 * time.Minute,
+		Pomodoro: pomodoroConfig{
+			Work:      25 * time.Minute,
+			Break:     5 * time.Minute,
+			LongBreak: 15 * time.Minute,
+			LongEvery: 4,
+		},
+	}
+}
+
+func configPath() (string, error) {
+	dir, err := appDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.yaml"), nil
+}
+
+// loadConfig reads config.yaml on top of the defaults. A missing file is
+// not an error.
+func loadConfig() (Config, error) {
+	cfg := defaultConfig()
+	path, err := configPath()
+	if err != nil {
+		return cfg, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	values, err := parseConfig(data)
+	if err != nil {
+		return cfg, fmt.Errorf("%s: %v", path, err)
+	}
+	if err := cfg.apply(values); err != nil {
+		return cfg, fmt.Errorf("%s: %v", path, err)
+	}
+	return cfg, nil
+}
+
+// parseConfig understands the small YAML subset the config needs:
+// "key: value" pairs, comments, and one level of nesting where the
+// nested keys are joined to their parent with a dot.
+func parseConfig(data []byte) (map[string]string, error) {
+	values := map[string]string{}
+	section := ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = line[:i]
+		}
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(trimmed, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key: value", n)
+		}
+		key = strings.TrimSpace(key)
+		value = unquote(strings.TrimSpace(value))
+
+		indented := line[0] == ' ' || line[0] == '\t'
+		switch {
+		case indented && section != "":
+			values[section+"."+key] = value
+		case value == "":
+			section = key
+		default:
+			section = ""
+			values[key] = value
+		}
+	}
+	return values, scanner.Err()
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' && s[len(s)-1] == '"' || s[0] == '\'' && s[len(s)-1] == '\'') {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+func (c *Config) apply(values map[string]string) error {
+	if value, ok := values["currency"]; ok {
+		c.Currency = strings.ToUpper(value)
+	}
+	for key, value := range values {
+		var err error
+		switch {
+		case key == "auto_finalize":
+			c.AutoFinalize = value
+		case key == "auto_finalize_action":
+			c.AutoFinalizeAction = value
+		case key == "mute":
+			c.Mute, err = strconv.ParseBool(value)
+		case key == "layout":
+			if value != "daily" && value != "weekly" {
+				err = fmt.Errorf("want daily or weekly, got %q", value)
+			}
+			c.Layout = value
+		case key == "dnd":
+			c.DND, err = strconv.ParseBool(value)
+		case key == "dnd_pause_threshold":
+			c.DNDPauseThreshold, err = time.ParseDuration(value)
+		case key == "dnd_tag":
+			c.DNDTag = value
+		case key == "pause_reason_threshold":
+			c.PauseReasonThreshold, err = time.ParseDuration(value)
+		case key == "duplicates.similarity":
+			c.Duplicates.Similarity, err = strconv.ParseFloat(value, 64)
+		case key == "duplicates.tolerance":
+			c.Duplicates.Tolerance, err = time.ParseDuration(value)
+		case key == "currency":
+		case strings.HasPrefix(key, "rates."):
+			c.Rates[strings.TrimPrefix(key, "rates.")], err = parseRate(value, c.Currency)
+		case strings.HasPrefix(key, "billable."):
+			c.Billable[strings.TrimPrefix(key, "billable.")], err = strconv.ParseBool(value)
+		case strings.HasPrefix(key, "clients."):
+			c.Clients[strings.TrimPrefix(key, "clients.")] = value
+		case strings.HasPrefix(key, "client_details."):
+			c.ClientDetails[strings.TrimPrefix(key, "client_details.")] = value
+		case key == "invoice.from":
+			c.Invoice.From = value
+		case key == "invoice.number":
+			c.Invoice.Number = value
+		case key == "invoice.template":
+			c.Invoice.Template = value
+		case key == "invoice.due_days":
+			c.Invoice.DueDays, err = strconv.Atoi(value)
+		case key == "grace_window":
+			c.GraceWindow, err = time.ParseDuration(value)
+		case key == "grace_gap":
+			if value != "drop" && value != "pause" {
+				err = fmt.Errorf("want drop or pause, got %q", value)
+			}
+			c.GraceGap = value
+		case key == "serve_addr":
+			c.ServeAddr = value
+		case key == "serve_token":
+			c.ServeToken = value
+		case key == "ask_task":
+			c.AskTask, err = strconv.ParseBool(value)
+		case key == "git_task":
+			c.GitTask, err = strconv.ParseBool(value)
+		case key == "start_menu":
+			c.StartMenu, err = strconv.ParseBool(value)
+		case key == "day_ceiling":
+			c.DayCeiling, err = parseDurationExpr(value)
+		case key == "session_ceiling":
+			c.SessionCeiling, err = parseDurationExpr(value)
+		case key == "log_dir":
+			c.LogDir = value
+		case key == "project":
+			c.Project = value
+		case key == "markdown_template":
+			c.MarkdownTemplate = value
+		case key == "filename":
+			err = checkFilenamePattern(value)
+			c.Filename = value
+		case key == "profile":
+			err = checkProfile(value)
+			c.Profile = value
+		case key == "write_mode":
+			if value != "final" && value != "incremental" {
+				err = fmt.Errorf("want final or incremental, got %q", value)
+			}
+			c.WriteMode = value
+		case key == "project_check":
+			c.ProjectCheck, err = strconv.ParseBool(value)
+		case key == "ascii":
+			c.ASCII, err = strconv.ParseBool(value)
+		case key == "pomodoro.work":
+			c.Pomodoro.Work, err = time.ParseDuration(value)
+		case key == "pomodoro.break":
+			c.Pomodoro.Break, err = time.ParseDuration(value)
+		case key == "pomodoro.long_break":
+			c.Pomodoro.LongBreak, err = time.ParseDuration(value)
+		case key == "pomodoro.long_every":
+			c.Pomodoro.LongEvery, err = strconv.Atoi(value)
+		case key == "idle.after":
+			c.IdleAfter, err = time.ParseDuration(value)
+		case key == "idle.command":
+			c.IdleCommand = value
+		case key == "jira.url":
+			c.Jira.URL = value
+		case key == "jira.email":
+			c.Jira.Email = value
+		case key == "jira.token":
+			c.Jira.Token = value
+		case key == "jira.auto":
+			c.Jira.Auto, err = strconv.ParseBool(value)
+		case key == "clockify.token":
+			c.Clockify.Token = value
+		case key == "clockify.workspace":
+			c.Clockify.Workspace = value
+		case strings.HasPrefix(key, "clockify_projects."):
+			c.Clockify.Projects[strings.TrimPrefix(key, "clockify_projects.")] = value
+		case key == "standup.slack":
+			c.Standup.Slack = value
+		case key == "standup.discord":
+			c.Standup.Discord = value
+		case key == "telegram.token":
+			c.Telegram.Token = value
+		case key == "telegram.chat":
+			c.Telegram.Chat, err = strconv.ParseInt(value, 10, 64)
+		case key == "email.host":
+			c.Email.Host = value
+		case key == "email.port":
+			c.Email.Port, err = strconv.Atoi(value)
+		case key == "email.username":
+			c.Email.Username = value
+		case key == "email.password":
+			c.Email.Password = value
+		case key == "email.from":
+			c.Email.From = value
+		case key == "email.to":
+			c.Email.To = nil
+			for _, to := range strings.Split(value, ",") {
+				if to = strings.TrimSpace(to); to != "" {
+					c.Email.To = append(c.Email.To, to)
+				}
+			}
+		case key == "email.auto":
+			c.Email.Auto, err = strconv.ParseBool(value)
+		case key == "github.token":
+			c.GitHub.Token = value
+		case key == "github.repo":
+			c.GitHub.Repo = value
+		case key == "github.link":
+			c.GitHub.Link, err = strconv.ParseBool(value)
+		case key == "github.comment":
+			c.GitHub.Comment, err = strconv.ParseBool(value)
+		case key == "harvest.token":
+			c.Harvest.Token = value
+		case key == "harvest.account":
+			c.Harvest.Account = value
+		case key == "harvest.task":
+			c.Harvest.Task = value
+		case strings.HasPrefix(key, "harvest_projects."):
+			c.Harvest.Projects[strings.TrimPrefix(key, "harvest_projects.")] = value
+		case strings.HasPrefix(key, "harvest_tasks."):
+			c.Harvest.Tasks[strings.TrimPrefix(key, "harvest_tasks.")] = value
+		case key == "gcal.client_id":
+			c.Gcal.ClientID = value
+		case key == "gcal.client_secret":
+			c.Gcal.ClientSecret = value
+		case key == "gcal.calendar":
+			c.Gcal.Calendar = value
+		case key == "toggl.token":
+			c.Toggl.Token = value
+		case key == "toggl.workspace":
+			c.Toggl.Workspace, err = strconv.ParseInt(value, 10, 64)
+		case strings.HasPrefix(key, "toggl_projects."):
+			c.Toggl.Projects[strings.TrimPrefix(key, "toggl_projects.")] = value
+		case key == "notify.enabled":
+			c.Notify.Enabled, err = strconv.ParseBool(value)
+		case key == "notify.paused_after":
+			c.Notify.PausedAfter, err = time.ParseDuration(value)
+		case key == "notify.long_session":
+			c.Notify.LongSession, err = time.ParseDuration(value)
+		case key == "notify.pomodoro":
+			c.Notify.Pomodoro, err = strconv.ParseBool(value)
+		case key == "remind.after":
+			c.Remind.After, err = time.ParseDuration(value)
+		case key == "remind.snooze":
+			c.Remind.Snooze, err = time.ParseDuration(value)
+		case key == "remind.bell":
+			c.Remind.Bell, err = strconv.ParseBool(value)
+		case key == "remind.notify":
+			c.Remind.Notify, err = strconv.ParseBool(value)
+		case key == "slack.token":
+			c.Slack.Token = value
+		case key == "slack.status":
+			c.Slack.Status = value
+		case key == "slack.emoji":
+			c.Slack.Emoji = value
+		case key == "slack.dnd":
+			c.Slack.DND, err = time.ParseDuration(value)
+		case key == "target":
+			c.Target, err = parseDurationExpr(value)
+		case key == "timezone":
+			c.Location, err = time.LoadLocation(value)
+		case strings.HasPrefix(key, "weekday_projects."):
+			var day time.Weekday
+			if day, err = parseWeekday(strings.TrimPrefix(key, "weekday_projects.")); err == nil {
+				c.WeekdayProjects[day] = value
+			}
+		case strings.HasPrefix(key, "weekday_targets."):
+			var day time.Weekday
+			if day, err = parseWeekday(strings.TrimPrefix(key, "weekday_targets.")); err == nil {
+				c.WeekdayTargets[day], err = parseDurationExpr(value)
+			}
+		case strings.HasPrefix(key, "durations."):
+			c.Durations[strings.TrimPrefix(key, "durations.")], err = parseDurationFormat(value)
+		case strings.HasPrefix(key, "sounds."):
+			c.Sounds[strings.TrimPrefix(key, "sounds.")] = value
+		case strings.HasPrefix(key, "webhooks."):
+			name := strings.TrimPrefix(key, "webhooks.")
+			h := c.Webhooks[name]
+			h.URL = value
+			c.Webhooks[name] = h
+		case strings.HasPrefix(key, "webhook_events."):
+			name := strings.TrimPrefix(key, "webhook_events.")
+			h := c.Webhooks[name]
+			h.Events, err = parseWebhookEvents(value)
+			c.Webhooks[name] = h
+		case strings.HasPrefix(key, "webhook_templates."):
+			name := strings.TrimPrefix(key, "webhook_templates.")
+			h := c.Webhooks[name]
+			h.Template, err = parseWebhookTemplate(name, value)
+			c.Webhooks[name] = h
+		default:
+			err = errors.New("unknown setting")
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %v", key, err)
+		}
+	}
+	for name, h := range c.Webhooks {
+		if h.URL == "" {
+			return fmt.Errorf("webhooks.%s: missing, but its events or template are set", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,385 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const daemonTimeout = 5 * time.Second
+
+// errNoDaemon means no daemon answered; the caller does the work itself.
+var errNoDaemon = errors.New("no daemon running")
+
+// daemonRequest is one line a client sends over the control socket.
+type daemonRequest struct {
+	Cmd     string     `json:"cmd"`            // start, stop, pause, toggle, status, list, focus or shutdown
+	Name    string     `json:"name,omitempty"` // timer; empty for the only or focused one
+	Project string     `json:"project,omitempty"`
+	Task    string     `json:"task,omitempty"`
+	Issue   string     `json:"issue,omitempty"`  // Jira issue for stop
+	Reason  string     `json:"reason,omitempty"` // why pause pauses
+	Until   *time.Time `json:"until,omitempty"`  // where stop cuts a timer left running
+}
+
+// daemonReply is the daemon's one-line answer. Code is the exit code
+// the client should end with when Error is set.
+type daemonReply struct {
+	Error string         `json:"error,omitempty"`
+	Code  int            `json:"code,omitempty"`
+	Timer *detachedTimer `json:"timer,omitempty"`
+	// Others are the timers running besides Timer, for GET /api/timer.
+	Others []detachedTimer `json:"others,omitempty"`
+	Entry  *TaskEntry      `json:"entry,omitempty"`
+}
+
+func socketPath() (string, error) {
+	dir, err := appDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "daemon.sock"), nil
+}
+
+// callDaemon sends req to the running daemon. It returns errNoDaemon
+// when there is none, and the daemon's error with its exit code when
+// the request failed there.
+func callDaemon(req daemonRequest) (daemonReply, error) {
+	var reply daemonReply
+	path, err := socketPath()
+	if err != nil {
+		return reply, err
+	}
+	conn, err := net.DialTimeout("unix", path, daemonTimeout)
+	if err != nil {
+		return reply, errNoDaemon
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(daemonTimeout))
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return reply, err
+	}
+	if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&reply); err != nil {
+		return reply, fmt.Errorf("bad reply from the daemon: %v", err)
+	}
+	if reply.Error != "" {
+		return reply, withCode(reply.Code, errors.New(reply.Error))
+	}
+	return reply, nil
+}
+
+// runTimer runs a timer command through the daemon when one is running
+// and right here otherwise; either way the timer file is the state.
+func runTimer(req daemonRequest) (daemonReply, error) {
+	reply, err := callDaemon(req)
+	if errors.Is(err, errNoDaemon) {
+		reply, err = handleTimer(req, time.Now())
+	}
+	return reply, err
+}
+
+// timerRequest is runTimer for commands that leave a timer behind.
+func timerRequest(req daemonRequest) (detachedTimer, error) {
+	reply, err := runTimer(req)
+	if err != nil {
+		return detachedTimer{}, err
+	}
+	if reply.Timer == nil {
+		return detachedTimer{}, withCode(exitEmpty, errors.New("nothing is being tracked"))
+	}
+	return *reply.Timer, nil
+}
+
+// listTimers returns the shown timer and the others, from the daemon
+// when one is running.
+func listTimers() (detachedTimer, []detachedTimer, bool, error) {
+	reply, err := callDaemon(daemonRequest{Cmd: "list"})
+	if errors.Is(err, errNoDaemon) {
+		return shownTimer()
+	}
+	if err != nil || reply.Timer == nil {
+		return detachedTimer{}, nil, false, err
+	}
+	return *reply.Timer, reply.Others, true, nil
+}
+
+// handleTimer carries out one timer command. Commands other than start
+// and focus act on the only or focused timer when the request names
+// none.
+func handleTimer(req daemonRequest, now time.Time) (daemonReply, error) {
+	var t detachedTimer
+	name, err := req.Name, error(nil)
+	if req.Cmd != "start" && req.Cmd != "focus" {
+		if name, err = resolveTimer(req.Name); err != nil {
+			return daemonReply{}, err
+		}
+	}
+	switch req.Cmd {
+	case "start":
+		var project string
+		if project, err = resolveProject(req.Project); err != nil {
+			return daemonReply{}, err
+		}
+		t, err = beginTimer(name, project, req.Task, now)
+	case "pause":
+		t, err = pauseTimer(name, req.Reason, now)
+	case "toggle":
+		var ok bool
+		if _, ok, err = loadTimer(name); err != nil {
+			return daemonReply{}, err
+		} else if ok {
+			t, err = pauseTimer(name, req.Reason, now)
+		} else {
+			var project string
+			if project, err = resolveProject(req.Project); err != nil {
+				return daemonReply{}, err
+			}
+			t, err = beginTimer(name, project, req.Task, now)
+		}
+	case "stop":
+		e, err := endTimer(name, req.Task, req.Issue, now, req.Until)
+		if err != nil {
+			return daemonReply{}, err
+		}
+		return daemonReply{Entry: &e}, nil
+	case "status":
+		var ok bool
+		if t, ok, err = loadTimer(name); err != nil || !ok {
+			return daemonReply{}, err
+		}
+	case "list":
+		shown, others, ok, err := shownTimer()
+		if err != nil || !ok {
+			return daemonReply{}, err
+		}
+		return daemonReply{Timer: &shown, Others: others}, nil
+	case "focus":
+		var ok bool
+		if t, ok, err = loadTimer(name); err != nil {
+			return daemonReply{}, err
+		} else if !ok {
+			label := name
+			if label == "" {
+				label = "default"
+			}
+			return daemonReply{}, withCode(exitEmpty, fmt.Errorf("no timer %q is running", label))
+		}
+		err = focusTimer(name)
+	default:
+		return daemonReply{}, usageErrorf("unknown daemon command %q", req.Cmd)
+	}
+	if err != nil {
+		return daemonReply{}, err
+	}
+	return daemonReply{Timer: &t}, nil
+}
+
+// timerDaemon holds the running timers for the daemon. Every change
+// goes through it and on to the timer files, which keep the timers
+// across restarts and for commands run while no daemon is up.
+type timerDaemon struct {
+	mu     sync.Mutex
+	shown  *detachedTimer
+	others []detachedTimer
+	af     *autoFinalizer
+	clock  sessionClock // tells when the system slept
+}
+
+// load reads the timers from their files.
+func (d *timerDaemon) load() error {
+	shown, others, ok, err := shownTimer()
+	if err != nil {
+		return err
+	}
+	d.shown, d.others = nil, others
+	if ok {
+		d.shown = &shown
+	}
+	return nil
+}
+
+// handle carries out a request. Listing is answered from the timers
+// held; anything else goes to handleTimer, and the timers are read
+// back after it.
+func (d *timerDaemon) handle(req daemonRequest, now time.Time) (daemonReply, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if req.Cmd == "list" {
+		return daemonReply{Timer: d.shown, Others: d.others}, nil
+	}
+	reply, err := handleTimer(req, now)
+	if lerr := d.load(); lerr != nil && err == nil {
+		err = lerr
+	}
+	return reply, err
+}
+
+// tick runs the daemon's clock work at now. After the system slept it
+// pauses the running timers from when it went to sleep, as the
+// interactive tracker pauses its session; at the auto-finalize time it
+// ends the timers still running there.
+func (d *timerDaemon) tick(now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.clock.last.IsZero() {
+		d.clock.start(now)
+	}
+	if slept := d.clock.tick(now); slept > 0 {
+		d.suspended(now.Add(-slept), slept)
+	}
+	if d.af == nil || !d.af.enabled() || now.Before(d.af.at) {
+		return
+	}
+	d.finalize(d.af.at, now)
+	d.af.roll()
+	fmt.Println("🌅 Next auto-finalize at", d.af.at.Format("Mon 15:04"))
+}
+
+// suspended pauses the running timers at asleep, when the system went
+// to sleep for slept.
+func (d *timerDaemon) suspended(asleep time.Time, slept time.Duration) {
+	timers, err := loadTimers()
+	if err != nil {
+		fmt.Println("❌", err)
+		return
+	}
+	for _, t := range timers {
+		if t.PausedAt != nil || !t.Start.Before(asleep) {
+			continue
+		}
+		if _, err := pauseTimer(t.Name, "suspend", asleep); err != nil {
+			fmt.Printf("❌ Could not pause %s: %v\n", t.label(), err)
+			continue
+		}
+		fmt.Printf("💤 Paused %s: the system slept %s\n", t.label(), formatDuration("footer", slept))
+	}
+	if err := d.load(); err != nil {
+		fmt.Println("❌", err)
+	}
+}
+
+// finalize ends every timer at the day's end, as a day left unanswered
+// is: at that time, and as (auto-closed) when it has no task.
+func (d *timerDaemon) finalize(at, now time.Time) {
+	timers, err := loadTimers()
+	if err != nil {
+		fmt.Println("❌", err)
+		return
+	}
+	for _, t := range timers {
+		if !t.Start.Before(at) {
+			continue
+		}
+		var until *time.Time
+		if at.Before(now) {
+			until = &at
+		}
+		task := t.Task
+		if task == "" {
+			task = autoClosedTask
+		}
+		e, err := endTimer(t.Name, task, "", now, until)
+		if err != nil {
+			fmt.Printf("❌ Could not end %s: %v\n", t.label(), err)
+			continue
+		}
+		fmt.Printf("🌙 Auto-finalized %s · %s: %s\n", e.Project, e.Task, formatDuration("summary", e.Duration))
+	}
+	if err := d.load(); err != nil {
+		fmt.Println("❌", err)
+	}
+}
+
+func daemonCommand(args []string) error {
+	if len(args) > 0 && args[0] == "stop" {
+		if _, err := callDaemon(daemonRequest{Cmd: "shutdown"}); err != nil {
+			return err
+		}
+		fmt.Println("🛑 Daemon stopped")
+		return nil
+	}
+	fs := newFlagSet("daemon")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if _, err := callDaemon(daemonRequest{Cmd: "status"}); !errors.Is(err, errNoDaemon) {
+		return withCode(exitLocked, errors.New("a daemon is already running"))
+	}
+	path, err := socketPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	os.Remove(path) // left behind by a daemon that did not get to clean up
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(path)
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigChan
+		ln.Close()
+	}()
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return withCode(exitUsage, err)
+	}
+	// The daemon keeps running, so it always rolls into the next day.
+	af, err := newAutoFinalizer(cfg.AutoFinalize, "roll", time.Now())
+	if err != nil {
+		return withCode(exitUsage, err)
+	}
+	d := &timerDaemon{af: af}
+	if err := d.load(); err != nil {
+		return err
+	}
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	go func() {
+		for now := range ticker.C {
+			d.tick(now)
+		}
+	}()
+	fmt.Println("🛰️  Daemon listening on", path)
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go func() {
+			defer conn.Close()
+			conn.SetDeadline(time.Now().Add(daemonTimeout))
+			var req daemonRequest
+			if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&req); err != nil {
+				return
+			}
+			if req.Cmd == "shutdown" {
+				json.NewEncoder(conn).Encode(daemonReply{})
+				ln.Close()
+				return
+			}
+			reply, err := d.handle(req, time.Now())
+			if err != nil {
+				reply = daemonReply{Error: err.Error(), Code: exitCode(err)}
+			}
+			json.NewEncoder(conn).Encode(reply)
+		}()
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// runDaemon runs the daemon in the test's directories until the test
+// ends.
+func runDaemon(t *testing.T) {
+	t.Helper()
+	done := make(chan error, 1)
+	go func() { done <- daemonCommand(nil) }()
+	deadline := time.Now().Add(daemonTimeout)
+	for {
+		if _, err := callDaemon(daemonRequest{Cmd: "list"}); !errors.Is(err, errNoDaemon) {
+			break
+		}
+		select {
+		case err := <-done:
+			t.Fatal("daemon exited:", err)
+		default:
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("daemon did not come up")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Cleanup(func() {
+		callDaemon(daemonRequest{Cmd: "shutdown"})
+		<-done
+	})
+}
+
+func TestDaemonHoldsTimers(t *testing.T) {
+	config, logs := useTempDirs(t)
+	writeConfig(t, config, "log_dir: "+logs+"\n")
+	if _, err := beginTimer("", "League", "triage", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	runDaemon(t)
+
+	shown, others, ok, err := listTimers()
+	if err != nil || !ok || shown.Project != "League" || len(others) != 0 {
+		t.Fatalf("list: %+v %+v %t %v; want the timer started before the daemon", shown, others, ok, err)
+	}
+	if _, err := timerRequest(daemonRequest{Cmd: "start", Name: "deploy", Project: "Ops"}); err != nil {
+		t.Fatal(err)
+	}
+	if shown, others, _, _ = listTimers(); shown.Name != "deploy" || len(others) != 1 {
+		t.Errorf("after start: shown %q with %d others, want deploy and 1", shown.Name, len(others))
+	}
+	if _, err := timerRequest(daemonRequest{Cmd: "focus"}); err != nil {
+		t.Fatal(err)
+	}
+	if shown, _, _, _ = listTimers(); shown.Name != "" {
+		t.Errorf("after focus: shown %q, want the default timer", shown.Name)
+	}
+	if _, err := timerRequest(daemonRequest{Cmd: "focus", Name: "missing"}); exitCode(err) != exitEmpty {
+		t.Errorf("focus on a missing timer: %v", err)
+	}
+	if _, err := runTimer(daemonRequest{Cmd: "stop", Name: "deploy", Task: "release"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, others, _, _ = listTimers(); len(others) != 0 {
+		t.Errorf("%d other timers after stop, want none", len(others))
+	}
+	// The files follow, for when the daemon is gone.
+	if timers, _ := loadTimers(); len(timers) != 1 {
+		t.Errorf("%d timer files, want 1", len(timers))
+	}
+}
+
+// Toggle starts a timer under the registry's name for the project, as
+// start does.
+func TestDaemonToggleResolvesProject(t *testing.T) {
+	config, logs := useTempDirs(t)
+	writeConfig(t, config, "log_dir: "+logs+"\n")
+	defer func(known []knownProject) { registry = known }(registry)
+	registry = []knownProject{{Name: "League", Aliases: []string{"lg"}}}
+	runDaemon(t)
+
+	if _, err := timerRequest(daemonRequest{Cmd: "toggle", Name: "x", Project: "Other"}); exitCode(err) != exitUsage {
+		t.Errorf("toggle on an unknown project: %v", err)
+	}
+	if _, err := timerRequest(daemonRequest{Cmd: "toggle", Name: "x", Project: "lg"}); err != nil {
+		t.Fatal(err)
+	}
+	if timer, ok, _ := loadTimer("x"); !ok || timer.Project != "League" {
+		t.Errorf("timer %+v, want one on League", timer)
+	}
+}
+
+func TestDaemonAutoFinalize(t *testing.T) {
+	config, logs := useTempDirs(t)
+	writeConfig(t, config, "log_dir: "+logs+"\n")
+	if _, err := beginTimer("", "League", "", at("09:00")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := beginTimer("late", "Ops", "deploy", at("17:30")); err != nil {
+		t.Fatal(err)
+	}
+	af, err := newAutoFinalizer("17:00", "roll", at("08:00"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &timerDaemon{af: af}
+	if err := d.load(); err != nil {
+		t.Fatal(err)
+	}
+	d.tick(at("16:59"))
+	if timers, _ := loadTimers(); len(timers) != 2 {
+		t.Fatalf("%d timers before the deadline, want 2", len(timers))
+	}
+	d.tick(at("17:00").Add(30 * time.Second))
+	timers, _ := loadTimers()
+	if len(timers) != 1 || timers[0].Name != "late" {
+		t.Errorf("timers %+v after the deadline, want only the one started after it", timers)
+	}
+	entries, err := writtenEntries(at("00:00"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Task != autoClosedTask || entries[0].Duration != 8*time.Hour {
+		t.Errorf("logged %+v, want 8h (auto-closed)", entries)
+	}
+	if want := at("17:00").AddDate(0, 0, 1); !d.af.at.Equal(want) {
+		t.Errorf("next auto-finalize at %s, want %s", d.af.at, want)
+	}
+}
+
+func TestDaemonSuspend(t *testing.T) {
+	config, logs := useTempDirs(t)
+	writeConfig(t, config, "log_dir: "+logs+"\n")
+	if _, err := beginTimer("", "League", "triage", at("09:00")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := beginTimer("lunch", "League", "", at("09:30")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pauseTimer("lunch", "lunch", at("09:45")); err != nil {
+		t.Fatal(err)
+	}
+	d := &timerDaemon{}
+	if err := d.load(); err != nil {
+		t.Fatal(err)
+	}
+	asleep := at("10:00").Add(time.Second)
+	d.tick(at("10:00"))
+	d.tick(asleep)
+	d.tick(at("13:00"))
+	timer, _, _ := loadTimer("")
+	if timer.PausedAt == nil || !timer.PausedAt.Equal(asleep) || timer.Reason != "suspend" {
+		t.Errorf("paused at %v for %q, want at %s for suspend", timer.PausedAt, timer.Reason, asleep.Format("15:04:05"))
+	}
+	if got := timer.elapsed(at("13:00")); got != time.Hour+time.Second {
+		t.Errorf("elapsed %s after the sleep, want 1h0m1s", got)
+	}
+	// A timer paused already stays paused as it was.
+	if lunch, _, _ := loadTimer("lunch"); lunch.Reason != "lunch" || !lunch.PausedAt.Equal(at("09:45")) {
+		t.Errorf("the paused timer changed: %+v", lunch)
+	}
+}
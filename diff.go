@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// colorOutput is whether diffs are colored: on for a terminal unless
+// NO_COLOR is set.
+func colorOutput() bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// diffOp is one line of an edit script: ' ' kept, '-' removed, '+' added.
+type diffOp struct {
+	Kind byte
+	Line string
+}
+
+// diffLines computes a shortest edit script from a to b via their
+// longest common subsequence. Logs are small, so the quadratic table is
+// fine.
+func diffLines(a, b []string) []diffOp {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
+
+// unifiedDiff renders the change from old to new in unified format with
+// three lines of context. It is empty when nothing changed.
+func unifiedDiff(oldName, newName string, old, new []byte) []string {
+	a, b := splitLines(old), splitLines(new)
+	ops := diffLines(a, b)
+	const context = 3
+
+	var out []string
+	for start := 0; start < len(ops); {
+		for start < len(ops) && ops[start].Kind == ' ' {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+		// Extend the hunk while the changes are within two contexts.
+		from := max(start-context, 0)
+		end := start
+		for k := start; k < len(ops); k++ {
+			if ops[k].Kind != ' ' {
+				end = k + 1
+			} else if k-end >= 2*context {
+				break
+			}
+		}
+		to := min(end+context, len(ops))
+
+		aLine, bLine := 1, 1
+		for _, op := range ops[:from] {
+			if op.Kind != '+' {
+				aLine++
+			}
+			if op.Kind != '-' {
+				bLine++
+			}
+		}
+		aCount, bCount := 0, 0
+		for _, op := range ops[from:to] {
+			if op.Kind != '+' {
+				aCount++
+			}
+			if op.Kind != '-' {
+				bCount++
+			}
+		}
+		if len(out) == 0 {
+			out = append(out, "--- "+oldName, "+++ "+newName)
+		}
+		out = append(out, fmt.Sprintf("@@ -%d,%d +%d,%d @@", aLine, aCount, bLine, bCount))
+		for _, op := range ops[from:to] {
+			out = append(out, string(op.Kind)+op.Line)
+		}
+		start = to
+	}
+	return out
+}
+
+func splitLines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+// printDiffLines prints a unified diff, colored when colorOutput.
+func printDiffLines(lines []string) {
+	color := colorOutput()
+	for _, line := range lines {
+		if !color {
+			fmt.Println(line)
+			continue
+		}
+		code := ""
+		switch {
+		case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+			code = "1"
+		case strings.HasPrefix(line, "@@"):
+			code = "36"
+		case strings.HasPrefix(line, "-"):
+			code = "31"
+		case strings.HasPrefix(line, "+"):
+			code = "32"
+		}
+		if code == "" {
+			fmt.Println(line)
+		} else {
+			fmt.Printf("\033[%sm%s\033[0m\n", code, line)
+		}
+	}
+}
+
+// confirmWrite asks before writing previewed changes.
+func confirmWrite(files int) bool {
+	answer := strings.ToLower(inputPrompt(fmt.Sprintf("❓ Write %d file(s)? (y/N): ", files)))
+	if answer == "y" || answer == "yes" {
+		return true
+	}
+	fmt.Println("↩️  Nothing written")
+	return false
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	lines := func(s ...string) []byte { return []byte(strings.Join(s, "\n") + "\n") }
+	ten := []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10"}
+	with := func(i int, line string) []byte {
+		l := append([]string(nil), ten...)
+		l[i] = line
+		return lines(l...)
+	}
+	tests := []struct {
+		name     string
+		old, new []byte
+		want     []string
+	}{
+		{"unchanged", lines(ten...), lines(ten...), nil},
+		{"new file", nil, lines("a", "b"), []string{"--- old", "+++ new", "@@ -1,0 +1,2 @@", "+a", "+b"}},
+		{"one line", lines(ten...), with(4, "five"),
+			[]string{"--- old", "+++ new", "@@ -2,7 +2,7 @@", " 2", " 3", " 4", "-5", "+five", " 6", " 7", " 8"}},
+		{"two hunks", append(lines(ten...), "11\n"...), append(with(0, "one"), "eleven\n"...),
+			[]string{"--- old", "+++ new", "@@ -1,4 +1,4 @@", "-1", "+one", " 2", " 3", " 4",
+				"@@ -8,4 +8,4 @@", " 8", " 9", " 10", "-11", "+eleven"}},
+		{"close changes share a hunk", lines(ten...), lines("1", "2", "three", "4", "5", "6", "7", "eight", "9", "10"),
+			[]string{"--- old", "+++ new", "@@ -1,10 +1,10 @@", " 1", " 2", "-3", "+three", " 4", " 5", " 6", " 7", "-8", "+eight", " 9", " 10"}},
+	}
+	for _, tt := range tests {
+		if got := unifiedDiff("old", "new", tt.old, tt.new); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s:\n%s\nwant\n%s", tt.name, strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+		}
+	}
+}
+
+// handNote is a paragraph someone typed into a log by hand.
+const handNote = "Called the vendor about the outage; they will refund March."
+
+// handEditedLog writes today's League log with a hand-added paragraph.
+func handEditedLog(t *testing.T, logs string) string {
+	t.Helper()
+	today := startOfDay(time.Now())
+	writeDay(t, logs, "League", today, []TaskEntry{{Task: "triage", Project: "League", Start: today.Add(9 * time.Hour), Duration: time.Hour}})
+	path := filepath.Join(logs, dailyFilename("League", today))
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, append(content, "\n"+handNote+"\n"...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// Merging entries into a log keeps what was written there by hand, and
+// the preview shows it as context rather than as a change.
+func TestMergeKeepsHandEdits(t *testing.T) {
+	_, logs := useTempDirs(t)
+	path := handEditedLog(t, logs)
+	before, _ := os.ReadFile(path)
+	today := startOfDay(time.Now())
+	if err := importEntries("League", today, []TaskEntry{{Task: "deploy", Project: "League", Start: today.Add(11 * time.Hour), Duration: 30 * time.Minute}}, false); err != nil {
+		t.Fatal(err)
+	}
+	after, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(after), handNote) || !strings.Contains(string(after), "deploy") {
+		t.Errorf("merged log lost the note or the entry:\n%s", after)
+	}
+	for _, line := range unifiedDiff(path, path, before, after) {
+		if strings.HasPrefix(line, "-") && !strings.HasPrefix(line, "---") {
+			t.Errorf("the merge removes %q", line)
+		}
+	}
+}
+
+// With -preview, a write that would drop a hand-added paragraph shows
+// the removal and leaves the log be when it is declined.
+func TestPreviewDeclinedKeepsLog(t *testing.T) {
+	config, logs := useTempDirs(t)
+	writeConfig(t, config, "log_dir: "+logs+"\n")
+	path := handEditedLog(t, logs)
+	before, _ := os.ReadFile(path)
+	code, out := runMain(t, filepath.Dir(logs), "q\nreview\nyes\nn\n", "-no-banner", "-no-git-task", "-auto-finalize", "", "-preview")
+	if code != exitWriteFailed || !strings.Contains(out, "-"+handNote) || !strings.Contains(out, "Nothing written") {
+		t.Errorf("exit %d, want the removal previewed and declined:\n%s", code, out)
+	}
+	if after, _ := os.ReadFile(path); string(after) != string(before) {
+		t.Errorf("the log changed:\n%s", after)
+	}
+}
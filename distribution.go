@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+var defaultBucketEdges = []time.Duration{15 * time.Minute, 30 * time.Minute, time.Hour, 2 * time.Hour}
+
+// bucket counts the sessions with Min <= duration < Max. The last
+// bucket has no upper bound (Max 0).
+type bucket struct {
+	Min, Max time.Duration
+	Count    int
+	Total    time.Duration
+}
+
+func (b bucket) label() string {
+	switch {
+	case b.Min == 0:
+		return "<" + formatDuration("footer", b.Max)
+	case b.Max == 0:
+		return "≥" + formatDuration("footer", b.Min)
+	}
+	return formatDuration("footer", b.Min) + "–" + formatDuration("footer", b.Max)
+}
+
+// bucketDurations sorts durations into the buckets between ascending
+// edges: len(edges)+1 buckets, each including its lower edge.
+func bucketDurations(durations []time.Duration, edges []time.Duration) []bucket {
+	buckets := make([]bucket, len(edges)+1)
+	for i := range buckets {
+		if i > 0 {
+			buckets[i].Min = edges[i-1]
+		}
+		if i < len(edges) {
+			buckets[i].Max = edges[i]
+		}
+	}
+	for _, d := range durations {
+		i := sort.Search(len(edges), func(i int) bool { return d < edges[i] })
+		buckets[i].Count++
+		buckets[i].Total += d
+	}
+	return buckets
+}
+
+// percentile returns the p-th percentile (0-100) of durations by the
+// nearest-rank method.
+func percentile(durations []time.Duration, p int) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func parseBucketEdges(text string) ([]time.Duration, error) {
+	var edges []time.Duration
+	for _, part := range strings.Split(text, ",") {
+		d, err := parseDuration(part)
+		if err != nil {
+			return nil, usageErrorf("invalid bucket edge %q", part)
+		}
+		if d <= 0 || len(edges) > 0 && d <= edges[len(edges)-1] {
+			return nil, usageErrorf("bucket edges must be positive and ascending")
+		}
+		edges = append(edges, d)
+	}
+	return edges, nil
+}
+
+type projectSpread struct {
+	Project     string
+	Sessions    int
+	Median, P90 time.Duration
+}
+
+// distributionReport prints the session length histogram of entries,
+// and the median and p90 per project when more than one is included.
+func distributionReport(entries []TaskEntry, edges []time.Duration, asJSON bool) error {
+	var all []time.Duration
+	byProject := map[string][]time.Duration{}
+	for _, e := range entries {
+		all = append(all, e.Duration)
+		byProject[e.Project] = append(byProject[e.Project], e.Duration)
+	}
+	buckets := bucketDurations(all, edges)
+	var spreads []projectSpread
+	for project, ds := range byProject {
+		spreads = append(spreads, projectSpread{project, len(ds), percentile(ds, 50), percentile(ds, 90)})
+	}
+	sort.Slice(spreads, func(i, j int) bool { return spreads[i].Project < spreads[j].Project })
+
+	if asJSON {
+		type bucketJSON struct {
+			Label string `json:"label"`
+			Count int    `json:"count"`
+			Total string `json:"total"`
+		}
+		type spreadJSON struct {
+			Project  string `json:"project"`
+			Sessions int    `json:"sessions"`
+			Median   string `json:"median"`
+			P90      string `json:"p90"`
+		}
+		out := struct {
+			Buckets  []bucketJSON `json:"buckets"`
+			Projects []spreadJSON `json:"projects"`
+		}{}
+		for _, b := range buckets {
+			out.Buckets = append(out.Buckets, bucketJSON{b.label(), b.Count, formatDuration("json", b.Total)})
+		}
+		for _, s := range spreads {
+			out.Projects = append(out.Projects, spreadJSON{s.Project, s.Sessions, formatDuration("json", s.Median), formatDuration("json", s.P90)})
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+
+	most := 0
+	for _, b := range buckets {
+		most = max(most, b.Count)
+	}
+	fmt.Printf("📊 %d sessions\n", len(all))
+	for _, b := range buckets {
+		bar := ""
+		if most > 0 {
+			bar = strings.Repeat(barGlyph(), b.Count*30/most)
+		}
+		fmt.Printf("  %-9s %-30s %3d  %s\n", b.label(), bar, b.Count, formatDuration("summary", b.Total))
+	}
+	if len(spreads) > 1 {
+		fmt.Printf("\n  %-20s %8s %8s %8s\n", "project", "sessions", "median", "p90")
+		for _, s := range spreads {
+			fmt.Printf("  %-20s %8d %8s %8s\n", s.Project, s.Sessions, formatDuration("footer", s.Median), formatDuration("footer", s.P90))
+		}
+	}
+	return nil
+}
+
+func barGlyph() string {
+	if asciiMode {
+		return "#"
+	}
+	return "█"
+}
+
+// reportFilter keeps the entries a report is about.
+type reportFilter struct {
+	Project string
+	Client  string
+	Match   string // text the task contains, ignoring case
+	Tag     string
+}
+
+func (f reportFilter) keep(e TaskEntry) bool {
+	if f.Project != "" && e.Project != f.Project {
+		return false
+	}
+	if f.Client != "" && !strings.EqualFold(clientOf(e.Project), f.Client) {
+		return false
+	}
+	if f.Match != "" && !strings.Contains(strings.ToLower(e.Task), strings.ToLower(f.Match)) {
+		return false
+	}
+	return f.Tag == "" || hasTag(e, f.Tag)
+}
+
+// filterDays applies f to each day, returning the kept entries both
+// flat and per day.
+func (f reportFilter) filterDays(days map[string][]TaskEntry) ([]TaskEntry, map[string][]TaskEntry) {
+	var entries []TaskEntry
+	kept := map[string][]TaskEntry{}
+	for date, day := range days {
+		for _, e := range day {
+			if f.keep(e) {
+				entries = append(entries, e)
+				kept[date] = append(kept[date], e)
+			}
+		}
+	}
+	return entries, kept
+}
+
+// periodRange returns the title and days of the week or month around
+// date.
+func periodRange(period string, date time.Time) (string, time.Time, time.Time) {
+	if period == "week" {
+		from, to := weekOf(date)
+		return isoWeek(date), from, to
+	}
+	from, to := monthOf(date)
+	return date.Format("January 2006"), from, to
+}
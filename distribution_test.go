@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Each bucket takes its lower edge and stops short of its upper one.
+func TestBucketDurationsEdges(t *testing.T) {
+	durations := []time.Duration{
+		0, 15*time.Minute - time.Second,
+		15 * time.Minute, 30*time.Minute - time.Second,
+		30 * time.Minute,
+		time.Hour, 2*time.Hour - time.Second,
+		2 * time.Hour, 10 * time.Hour,
+	}
+	buckets := bucketDurations(durations, defaultBucketEdges)
+	var counts []int
+	var labels []string
+	for _, b := range buckets {
+		counts = append(counts, b.Count)
+		labels = append(labels, b.label())
+	}
+	if want := []int{2, 2, 1, 2, 2}; !reflect.DeepEqual(counts, want) {
+		t.Errorf("counts %v, want %v", counts, want)
+	}
+	if want := []string{"<15m", "15m–30m", "30m–1h", "1h–2h", "≥2h"}; !reflect.DeepEqual(labels, want) {
+		t.Errorf("labels %q, want %q", labels, want)
+	}
+	if want := 12 * time.Hour; buckets[4].Total != want {
+		t.Errorf("last bucket total %s, want %s", buckets[4].Total, want)
+	}
+}
+
+func TestBucketDurationsNone(t *testing.T) {
+	buckets := bucketDurations(nil, []time.Duration{time.Hour})
+	if len(buckets) != 2 || buckets[0].Count != 0 || buckets[1].Count != 0 {
+		t.Errorf("buckets %+v, want two empty ones", buckets)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	var ds []time.Duration
+	for i := 10; i >= 1; i-- {
+		ds = append(ds, time.Duration(i)*time.Minute)
+	}
+	tests := []struct {
+		durations []time.Duration
+		p         int
+		want      time.Duration
+	}{
+		{nil, 50, 0},
+		{ds[:1], 90, 10 * time.Minute},
+		{ds, 0, time.Minute},
+		{ds, 50, 5 * time.Minute},
+		{ds, 90, 9 * time.Minute},
+		{ds, 100, 10 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := percentile(tt.durations, tt.p); got != tt.want {
+			t.Errorf("percentile(%v, %d) = %s, want %s", tt.durations, tt.p, got, tt.want)
+		}
+	}
+	if ds[0] != 10*time.Minute {
+		t.Error("percentile sorted its argument")
+	}
+}
+
+func TestParseBucketEdges(t *testing.T) {
+	edges, err := parseBucketEdges("10m,45m,1.5")
+	if want := []time.Duration{10 * time.Minute, 45 * time.Minute, 90 * time.Minute}; err != nil || !reflect.DeepEqual(edges, want) {
+		t.Errorf("edges %v, %v; want %v", edges, err, want)
+	}
+	for _, text := range []string{"", "10m,fish", "30m,15m", "15m,15m", "0,15m"} {
+		if _, err := parseBucketEdges(text); exitCode(err) != exitUsage {
+			t.Errorf("parseBucketEdges(%q): %v", text, err)
+		}
+	}
+}
+
+func TestDistributionReportJSON(t *testing.T) {
+	config, logs := useTempDirs(t)
+	writeConfig(t, config, "log_dir: "+logs+"\n")
+	writeDay(t, logs, "League", at("00:00"), []TaskEntry{
+		{Task: "design", Project: "League", Start: at("09:00"), Duration: 90 * time.Minute, Tags: []string{"deep"}},
+		{Task: "refactor", Project: "League", Start: at("11:00"), Duration: 40 * time.Minute, Tags: []string{"deep"}},
+		{Task: "email", Project: "League", Start: at("12:00"), Duration: 10 * time.Minute},
+	})
+	writeDay(t, logs, "Consulting", at("00:00"), []TaskEntry{
+		{Task: "spec", Project: "Consulting", Start: at("14:00"), Duration: 3 * time.Hour, Tags: []string{"deep"}},
+	})
+	code, out := runMain(t, filepath.Dir(logs), "", "report", "--distribution", "--tag", "deep",
+		"--from", "2024-03-01", "--to", "2024-03-01", "--json")
+	if code != exitOK {
+		t.Fatalf("exit %d:\n%s", code, out)
+	}
+	var got struct {
+		Buckets []struct {
+			Label string
+			Count int
+		}
+		Projects []struct {
+			Project  string
+			Sessions int
+			Median   string
+		}
+	}
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("%v:\n%s", err, out)
+	}
+	var counts []int
+	for _, b := range got.Buckets {
+		counts = append(counts, b.Count)
+	}
+	if want := []int{0, 0, 1, 1, 1}; !reflect.DeepEqual(counts, want) {
+		t.Errorf("counts %v, want %v", counts, want)
+	}
+	if len(got.Projects) != 2 || got.Projects[0].Project != "Consulting" || got.Projects[1].Sessions != 2 {
+		t.Errorf("projects %+v", got.Projects)
+	}
+}
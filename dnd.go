@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// dndController reads and sets the desktop's Do Not Disturb state.
+type dndController interface {
+	Enabled() (bool, error)
+	Set(on bool) error
+}
+
+func runOutput(name string, args ...string) (string, error) {
+	out, err := exec.Command(name, args...).Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+type macDND struct{}
+
+func (macDND) Enabled() (bool, error) {
+	out, err := runOutput("defaults", "-currentHost", "read", "com.apple.notificationcenterui", "doNotDisturb")
+	return out == "1", err
+}
+
+func (macDND) Set(on bool) error {
+	if _, err := runOutput("defaults", "-currentHost", "write", "com.apple.notificationcenterui", "doNotDisturb", "-boolean", fmt.Sprint(on)); err != nil {
+		return err
+	}
+	_, err := runOutput("killall", "NotificationCenter")
+	return err
+}
+
+// gnomeDND treats hidden notification banners as Do Not Disturb, which
+// is what the GNOME shell toggle does.
+type gnomeDND struct{}
+
+func (gnomeDND) Enabled() (bool, error) {
+	out, err := runOutput("gsettings", "get", "org.gnome.desktop.notifications", "show-banners")
+	return out == "false", err
+}
+
+func (gnomeDND) Set(on bool) error {
+	_, err := runOutput("gsettings", "set", "org.gnome.desktop.notifications", "show-banners", fmt.Sprint(!on))
+	return err
+}
+
+// kdeDND inhibits notifications over D-Bus. Inhibit takes a hints map
+// that qdbus cannot express, so it goes through gdbus.
+type kdeDND struct {
+	cookie string
+}
+
+func (k *kdeDND) Enabled() (bool, error) {
+	out, err := runOutput("qdbus", "org.freedesktop.Notifications", "/org/freedesktop/Notifications", "org.freedesktop.Notifications.Inhibited")
+	return out == "true", err
+}
+
+func (k *kdeDND) Set(on bool) error {
+	base := []string{"call", "--session", "--dest", "org.freedesktop.Notifications",
+		"--object-path", "/org/freedesktop/Notifications"}
+	if on {
+		out, err := runOutput("gdbus", append(base, "--method", "org.freedesktop.Notifications.Inhibit", "worklog", "focus session", "{}")...)
+		k.cookie = strings.Trim(out, "(uint32 ,)")
+		return err
+	}
+	if k.cookie == "" {
+		return nil
+	}
+	_, err := runOutput("gdbus", append(base, "--method", "org.freedesktop.Notifications.UnInhibit", k.cookie)...)
+	k.cookie = ""
+	return err
+}
+
+type noopDND struct{}
+
+func (noopDND) Enabled() (bool, error) { return false, nil }
+func (noopDND) Set(bool) error         { return nil }
+
+func detectDND() dndController {
+	switch runtime.GOOS {
+	case "darwin":
+		return macDND{}
+	case "linux":
+		desktop := strings.ToUpper(os.Getenv("XDG_CURRENT_DESKTOP"))
+		switch {
+		case strings.Contains(desktop, "GNOME"):
+			return gnomeDND{}
+		case strings.Contains(desktop, "KDE"):
+			return &kdeDND{}
+		}
+	}
+	return noopDND{}
+}
+
+// focusMode turns Do Not Disturb on for the length of a session and puts
+// back whatever state it found. With a tag, only sessions whose task
+// carries it get Do Not Disturb.
+type focusMode struct {
+	ctl            dndController
+	pauseThreshold time.Duration
+	tag            string
+	prev           bool
+	active         bool
+}
+
+func newFocusMode(enabled bool, pauseThreshold time.Duration, tag string) *focusMode {
+	if !enabled {
+		return &focusMode{ctl: noopDND{}}
+	}
+	return &focusMode{ctl: detectDND(), pauseThreshold: pauseThreshold, tag: strings.TrimPrefix(tag, "#")}
+}
+
+// wants reports whether a session on task gets Do Not Disturb.
+func (f *focusMode) wants(task string) bool {
+	if f.tag == "" {
+		return true
+	}
+	_, tags := parseTags(task)
+	return hasTag(TaskEntry{Tags: tags}, f.tag)
+}
+
+// start turns Do Not Disturb on for a session on task, or restores the
+// previous state when the task no longer calls for it.
+func (f *focusMode) start(task string) {
+	if !f.wants(task) {
+		f.restore()
+		return
+	}
+	if f.active {
+		return
+	}
+	prev, err := f.ctl.Enabled()
+	if err == nil {
+		err = f.ctl.Set(true)
+	}
+	if err != nil {
+		fmt.Println("⚠️  Could not enable Do Not Disturb:", err)
+		f.ctl = noopDND{}
+		return
+	}
+	f.prev = prev
+	f.active = true
+}
+
+func (f *focusMode) restore() {
+	if !f.active {
+		return
+	}
+	f.active = false
+	if err := f.ctl.Set(f.prev); err != nil {
+		fmt.Println("⚠️  Could not restore Do Not Disturb:", err)
+	}
+}
+
+// paused restores the previous state once a pause has lasted longer
+// than the threshold.
+func (f *focusMode) paused(d time.Duration) {
+	if d > f.pauseThreshold {
+		f.restore()
+	}
+}
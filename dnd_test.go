@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeDND records what Do Not Disturb was set to.
+type fakeDND struct {
+	on    bool
+	calls []bool
+	err   error
+}
+
+func (f *fakeDND) Enabled() (bool, error) { return f.on, f.err }
+
+func (f *fakeDND) Set(on bool) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.on = on
+	f.calls = append(f.calls, on)
+	return nil
+}
+
+func TestFocusModePairing(t *testing.T) {
+	for _, before := range []bool{false, true} {
+		ctl := &fakeDND{on: before}
+		f := &focusMode{ctl: ctl, pauseThreshold: 5 * time.Minute}
+
+		f.start("write docs")
+		if !ctl.on {
+			t.Fatal("start did not enable Do Not Disturb")
+		}
+		f.start("write docs") // resuming while still active changes nothing
+
+		f.paused(time.Minute) // a short pause keeps it on
+		if !ctl.on {
+			t.Fatal("a pause under the threshold restored it")
+		}
+		f.paused(6 * time.Minute)
+		if ctl.on != before {
+			t.Fatalf("a long pause left it %v, want %v as found", ctl.on, before)
+		}
+		f.paused(7 * time.Minute) // still paused: nothing more to restore
+
+		f.start("write docs") // resume
+		f.restore()           // quit
+		f.restore()           // the deferred restore of a crash or signal
+		if ctl.on != before {
+			t.Errorf("ended with it %v, want %v as found", ctl.on, before)
+		}
+		want := []bool{true, before, true, before}
+		if len(ctl.calls) != len(want) {
+			t.Fatalf("calls %v, want %v", ctl.calls, want)
+		}
+		for i := range want {
+			if ctl.calls[i] != want[i] {
+				t.Fatalf("calls %v, want %v", ctl.calls, want)
+			}
+		}
+	}
+}
+
+func TestFocusModeTag(t *testing.T) {
+	ctl := &fakeDND{}
+	f := &focusMode{ctl: ctl, tag: "deep-work"}
+
+	f.start("answer email")
+	if ctl.on || len(ctl.calls) != 0 {
+		t.Fatalf("untagged session touched Do Not Disturb: %v", ctl.calls)
+	}
+	f.start("refactor importer #Deep-Work")
+	if !ctl.on {
+		t.Fatal("tagged session did not enable Do Not Disturb")
+	}
+	f.start("answer email") // switching to an untagged task
+	if ctl.on {
+		t.Fatal("switching to an untagged task kept Do Not Disturb on")
+	}
+	f.restore()
+	if len(ctl.calls) != 2 {
+		t.Errorf("calls %v, want one enable and one restore", ctl.calls)
+	}
+}
+
+func TestFocusModeFailure(t *testing.T) {
+	ctl := &fakeDND{err: errors.New("no D-Bus")}
+	f := &focusMode{ctl: ctl}
+	f.start("")
+	if f.active {
+		t.Fatal("active although enabling failed")
+	}
+	if _, ok := f.ctl.(noopDND); !ok {
+		t.Errorf("controller is %T after a failure, want the no-op one", f.ctl)
+	}
+	f.restore()
+}
+
+func TestFocusModeDisabled(t *testing.T) {
+	f := newFocusMode(false, time.Minute, "")
+	f.start("x")
+	f.restore()
+	if _, ok := f.ctl.(noopDND); !ok {
+		t.Errorf("controller is %T with -dnd off", f.ctl)
+	}
+}
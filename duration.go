@@ -0,0 +1,374 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// durationFormat is one way of writing a duration:
+//
+//	go       1h45m0s
+//	short    1h45m
+//	compact  1h 45m
+//	verbose  1 hour 45 minutes
+//	clock    01:45:00
+//	hm       1:45
+//	decimal  1.75 (Places digits)
+type durationFormat struct {
+	Style  string
+	Places int
+}
+
+// defaultDurationStyles picks the format per place a duration is shown.
+// Exports add their own keys; anything not listed uses the go style.
+var defaultDurationStyles = map[string]durationFormat{
+	"footer":   {Style: "short"},
+	"summary":  {Style: "go"},
+	"markdown": {Style: "go"},
+	"status":   {Style: "hm"},
+	"csv":      {Style: "decimal", Places: 2},
+}
+
+var durationStyles = defaultDurationStyles
+
+// setDurationStyles applies the durations section of the config on top
+// of the defaults.
+func setDurationStyles(overrides map[string]durationFormat) {
+	styles := make(map[string]durationFormat, len(defaultDurationStyles))
+	for target, f := range defaultDurationStyles {
+		styles[target] = f
+	}
+	for target, f := range overrides {
+		styles[target] = f
+	}
+	durationStyles = styles
+}
+
+// parseDurationFormat reads a style name, with "decimal:1" setting the
+// number of decimal places (2 by default).
+func parseDurationFormat(s string) (durationFormat, error) {
+	style, places, hasPlaces := strings.Cut(s, ":")
+	f := durationFormat{Style: style, Places: 2}
+	switch style {
+	case "go", "short", "compact", "verbose", "clock", "hm":
+		if hasPlaces {
+			return f, fmt.Errorf("%s takes no precision", style)
+		}
+	case "decimal":
+		if hasPlaces {
+			n, err := strconv.Atoi(places)
+			if err != nil || n < 0 || n > 6 {
+				return f, fmt.Errorf("want 0-6 decimal places, got %q", places)
+			}
+			f.Places = n
+		}
+	default:
+		return f, fmt.Errorf("want go, short, compact, verbose, clock, hm or decimal, got %q", s)
+	}
+	return f, nil
+}
+
+// formatDuration writes d in the style configured for target.
+func formatDuration(target string, d time.Duration) string {
+	f, ok := durationStyles[target]
+	if !ok {
+		f = durationFormat{Style: "go"}
+	}
+	return f.format(d)
+}
+
+func (f durationFormat) format(d time.Duration) string {
+	if f.Style == "go" || f.Style == "" {
+		return d.Round(time.Second).String()
+	}
+	if d < 0 {
+		return "-" + f.format(-d)
+	}
+	if f.Style == "decimal" {
+		return strconv.FormatFloat(d.Hours(), 'f', f.Places, 64)
+	}
+	if f.Style == "clock" {
+		s := int64(d.Round(time.Second) / time.Second)
+		return fmt.Sprintf("%02d:%02d:%02d", s/3600, s/60%60, s%60)
+	}
+
+	m := int64(d.Round(time.Minute) / time.Minute)
+	h, m := m/60, m%60
+	switch f.Style {
+	case "hm":
+		return fmt.Sprintf("%d:%02d", h, m)
+	case "verbose":
+		parts := []string{}
+		if h > 0 {
+			parts = append(parts, plural(h, "hour"))
+		}
+		if m > 0 || h == 0 {
+			parts = append(parts, plural(m, "minute"))
+		}
+		return strings.Join(parts, " ")
+	case "compact":
+		switch {
+		case h == 0:
+			return fmt.Sprintf("%dm", m)
+		case m == 0:
+			return fmt.Sprintf("%dh", h)
+		}
+		return fmt.Sprintf("%dh %dm", h, m)
+	}
+	switch {
+	case h == 0:
+		return fmt.Sprintf("%dm", m)
+	case m == 0:
+		return fmt.Sprintf("%dh", h)
+	}
+	return fmt.Sprintf("%dh%02dm", h, m)
+}
+
+func plural(n int64, unit string) string {
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
+
+// parseDuration reads a duration written in any of the styles, so logs
+// stay readable after the markdown style changes. A bare number is hours.
+func parseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if d, err := time.ParseDuration(strings.ReplaceAll(s, " ", "")); err == nil {
+		return d, nil
+	}
+	if hours, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Duration(hours * float64(time.Hour)).Round(time.Second), nil
+	}
+	if strings.Contains(s, ":") {
+		var total time.Duration
+		parts := strings.Split(s, ":")
+		if len(parts) > 3 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		units := []time.Duration{time.Hour, time.Minute, time.Second}
+		for i, p := range parts {
+			n, err := strconv.Atoi(p)
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+			total += time.Duration(n) * units[i]
+		}
+		return total, nil
+	}
+	fields := strings.Fields(s)
+	if len(fields) == 0 || len(fields)%2 != 0 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	var total time.Duration
+	for i := 0; i < len(fields); i += 2 {
+		n, err := strconv.Atoi(fields[i])
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		switch strings.TrimSuffix(fields[i+1], "s") {
+		case "hour":
+			total += time.Duration(n) * time.Hour
+		case "minute":
+			total += time.Duration(n) * time.Minute
+		case "second":
+			total += time.Duration(n) * time.Second
+		default:
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+	}
+	return total, nil
+}
+
+// durationExprError points at the offending column of an expression.
+type durationExprError struct {
+	Input string
+	Pos   int // 1-based
+	Msg   string
+}
+
+func (e *durationExprError) Error() string {
+	return fmt.Sprintf("%q, position %d: %s", e.Input, e.Pos, e.Msg)
+}
+
+// parseDurationExpr reads durations typed by the user, allowing sums,
+// differences and integer multiples such as "2*25m+10m" or "1:30+45m".
+// Multiplication binds tighter; parentheses group. Negative results are
+// rejected.
+func parseDurationExpr(s string) (time.Duration, error) {
+	p := &exprParser{input: s}
+	v, err := p.sum()
+	if err != nil {
+		return 0, err
+	}
+	if p.skipSpace(); p.pos < len(s) {
+		return 0, p.errorf("unexpected %q", s[p.pos])
+	}
+	if v.number {
+		if d, err := parseDuration(s); err == nil {
+			return d, nil
+		}
+		return 0, &durationExprError{s, 1, "number without a unit"}
+	}
+	if v.d < 0 {
+		return 0, &durationExprError{s, 1, "result is negative"}
+	}
+	return v.d, nil
+}
+
+// durationValue is a duration flag that takes what parseDurationExpr
+// does, so "--estimate 2*25m" works as well as "--estimate 50m".
+type durationValue struct{ d *time.Duration }
+
+func (v durationValue) String() string {
+	if v.d == nil {
+		return time.Duration(0).String()
+	}
+	return v.d.String()
+}
+
+func (v durationValue) Set(s string) error {
+	d, err := parseDurationExpr(s)
+	if err != nil {
+		return err
+	}
+	*v.d = d
+	return nil
+}
+
+// durationFlag defines a duration flag on fs.
+func durationFlag(fs *flag.FlagSet, name string, value time.Duration, usage string) *time.Duration {
+	d := &value
+	fs.Var(durationValue{d}, name, usage)
+	return d
+}
+
+type exprParser struct {
+	input string
+	pos   int
+}
+
+// exprValue is a duration, or a bare integer that can only multiply one.
+type exprValue struct {
+	d      time.Duration
+	n      int64
+	number bool
+	pos    int
+}
+
+func (p *exprParser) errorf(format string, args ...any) error {
+	return &durationExprError{p.input, p.pos + 1, fmt.Sprintf(format, args...)}
+}
+
+func (p *exprParser) skipSpace() {
+	for p.pos < len(p.input) && p.input[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func (p *exprParser) sum() (exprValue, error) {
+	left, err := p.product()
+	if err != nil {
+		return left, err
+	}
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.input) || (p.input[p.pos] != '+' && p.input[p.pos] != '-') {
+			return left, nil
+		}
+		op := p.input[p.pos]
+		p.pos++
+		right, err := p.product()
+		if err != nil {
+			return left, err
+		}
+		for _, v := range []exprValue{left, right} {
+			if v.number {
+				return left, &durationExprError{p.input, v.pos + 1, "number without a unit"}
+			}
+		}
+		if op == '+' {
+			left.d += right.d
+		} else {
+			left.d -= right.d
+		}
+	}
+}
+
+func (p *exprParser) product() (exprValue, error) {
+	left, err := p.operand()
+	if err != nil {
+		return left, err
+	}
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.input) || p.input[p.pos] != '*' {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.operand()
+		if err != nil {
+			return left, err
+		}
+		switch {
+		case left.number && right.number:
+			left.n *= right.n
+		case left.number:
+			left = exprValue{d: right.d * time.Duration(left.n), pos: left.pos}
+		case right.number:
+			left.d *= time.Duration(right.n)
+		default:
+			return left, &durationExprError{p.input, right.pos + 1, "can only multiply by a whole number"}
+		}
+	}
+}
+
+func (p *exprParser) operand() (exprValue, error) {
+	p.skipSpace()
+	start := p.pos
+	if p.pos < len(p.input) && p.input[p.pos] == '(' {
+		p.pos++
+		v, err := p.sum()
+		if err != nil {
+			return v, err
+		}
+		if p.skipSpace(); p.pos >= len(p.input) || p.input[p.pos] != ')' {
+			return v, p.errorf("missing )")
+		}
+		p.pos++
+		v.pos = start
+		return v, nil
+	}
+	for p.pos < len(p.input) && !strings.ContainsRune("+-*()", rune(p.input[p.pos])) {
+		p.pos++
+	}
+	text := strings.TrimSpace(p.input[start:p.pos])
+	if text == "" {
+		p.pos = start
+		if p.pos >= len(p.input) {
+			return exprValue{}, p.errorf("expected a duration")
+		}
+		return exprValue{}, p.errorf("expected a duration, got %q", p.input[p.pos])
+	}
+	if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return exprValue{n: n, number: true, pos: start}, nil
+	}
+	for i := start; i < p.pos; i++ {
+		c := p.input[i]
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '.' || c == ':' || c == ' ' || c >= 0x80) {
+			p.pos = i
+			return exprValue{}, p.errorf("unknown operator %q", c)
+		}
+	}
+	d, err := parseDuration(text)
+	if err != nil {
+		p.pos = start
+		p.skipSpace()
+		return exprValue{}, p.errorf("not a duration: %q", text)
+	}
+	return exprValue{d: d, pos: start}, nil
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestDurationFormat(t *testing.T) {
+	const (
+		s29half = 29*time.Second + 500*time.Millisecond
+		almostH = 59*time.Minute + 59*time.Second + 500*time.Millisecond
+		day     = 25*time.Hour + 30*time.Minute
+	)
+	tests := []struct {
+		format string
+		d      time.Duration
+		want   string
+	}{
+		{"go", 0, "0s"},
+		{"go", s29half, "30s"},
+		{"go", almostH, "1h0m0s"},
+		{"go", -s29half, "-30s"},
+
+		{"short", 0, "0m"},
+		{"short", s29half, "0m"},
+		{"short", 30 * time.Second, "1m"},
+		{"short", almostH, "1h"},
+		{"short", 105 * time.Minute, "1h45m"},
+		{"short", 65 * time.Minute, "1h05m"},
+		{"short", day, "25h30m"},
+		{"short", -105 * time.Minute, "-1h45m"},
+
+		{"compact", s29half, "0m"},
+		{"compact", almostH, "1h"},
+		{"compact", 105 * time.Minute, "1h 45m"},
+		{"compact", day, "25h 30m"},
+
+		{"verbose", 0, "0 minutes"},
+		{"verbose", time.Minute, "1 minute"},
+		{"verbose", almostH, "1 hour"},
+		{"verbose", 61 * time.Minute, "1 hour 1 minute"},
+		{"verbose", day, "25 hours 30 minutes"},
+
+		{"clock", 0, "00:00:00"},
+		{"clock", s29half, "00:00:30"},
+		{"clock", almostH, "01:00:00"},
+		{"clock", day, "25:30:00"},
+		{"clock", -time.Second, "-00:00:01"},
+
+		{"hm", 0, "0:00"},
+		{"hm", s29half, "0:00"},
+		{"hm", 30 * time.Second, "0:01"},
+		{"hm", almostH, "1:00"},
+		{"hm", 105 * time.Minute, "1:45"},
+		{"hm", day, "25:30"},
+		{"hm", -105 * time.Minute, "-1:45"},
+
+		{"decimal", 0, "0.00"},
+		{"decimal", 105 * time.Minute, "1.75"},
+		{"decimal", 59*time.Minute + 30*time.Second, "0.99"},
+		{"decimal:1", 59*time.Minute + 30*time.Second, "1.0"},
+		{"decimal:1", 57 * time.Minute, "0.9"},
+		{"decimal:0", 89 * time.Minute, "1"},
+		{"decimal:0", 91 * time.Minute, "2"},
+		{"decimal:0", 29 * time.Minute, "0"},
+		{"decimal:6", time.Second, "0.000278"},
+		{"decimal:6", day, "25.500000"},
+		{"decimal", -105 * time.Minute, "-1.75"},
+	}
+	for _, tt := range tests {
+		f, err := parseDurationFormat(tt.format)
+		if err != nil {
+			t.Fatalf("parseDurationFormat(%q): %v", tt.format, err)
+		}
+		if got := f.format(tt.d); got != tt.want {
+			t.Errorf("%s of %s = %q, want %q", tt.format, tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestParseDurationFormatErrors(t *testing.T) {
+	for _, s := range []string{"", "fancy", "decimal:7", "decimal:-1", "decimal:x", "clock:2"} {
+		if _, err := parseDurationFormat(s); err == nil {
+			t.Errorf("parseDurationFormat(%q) accepted it", s)
+		}
+	}
+}
+
+// Every style reads back as what it wrote, to the precision it keeps,
+// so logs stay readable after the style changes.
+func TestParseDurationRoundTrip(t *testing.T) {
+	d := 105 * time.Minute
+	for _, style := range []string{"go", "short", "compact", "verbose", "clock", "hm", "decimal"} {
+		text := durationFormat{Style: style, Places: 2}.format(d)
+		got, err := parseDuration(text)
+		if err != nil || got != d {
+			t.Errorf("parseDuration(%q) = %s, %v; want %s", text, got, err, d)
+		}
+	}
+}
+
+func TestParseDurationExpr(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"25m", 25 * time.Minute},
+		{"25m+40m", 65 * time.Minute},
+		{"2*25m+10m", time.Hour},
+		{"10m+2*25m", time.Hour},
+		{"3*45m", 135 * time.Minute},
+		{"45m*3", 135 * time.Minute},
+		{"2*(25m+5m)", time.Hour},
+		{"1h-15m", 45 * time.Minute},
+		{"1h-15m-15m", 30 * time.Minute},
+		{"1:30+45m", 135 * time.Minute},
+		{"1.5+30m", 2 * time.Hour},
+		{"1 hour 30 minutes + 1:00", 150 * time.Minute},
+		{"  2 * 25m  +  10m ", time.Hour},
+		{"1.75", 105 * time.Minute},
+		{"30m-30m", 0},
+	}
+	for _, tt := range tests {
+		got, err := parseDurationExpr(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("parseDurationExpr(%q) = %s, %v; want %s", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseDurationExprErrors(t *testing.T) {
+	tests := []struct {
+		in  string
+		pos int
+		msg string
+	}{
+		{"25m/2", 4, `unknown operator '/'`},
+		{"25m + ", 7, "expected a duration"},
+		{"+25m", 1, `expected a duration, got '+'`},
+		{"25m*40m", 5, "can only multiply by a whole number"},
+		{"2+25m", 1, "number without a unit"},
+		{"(25m+5m", 8, "missing )"},
+		{"25m)", 4, `unexpected ')'`},
+		{"10m-25m", 1, "result is negative"},
+		{"25m+fish", 5, `not a duration: "fish"`},
+	}
+	for _, tt := range tests {
+		_, err := parseDurationExpr(tt.in)
+		e, ok := err.(*durationExprError)
+		if !ok {
+			t.Errorf("parseDurationExpr(%q): got %v, want a position", tt.in, err)
+			continue
+		}
+		if e.Pos != tt.pos || e.Msg != tt.msg {
+			t.Errorf("parseDurationExpr(%q): position %d %q, want %d %q", tt.in, e.Pos, e.Msg, tt.pos, tt.msg)
+		}
+	}
+}
+
+// Duration flags take the same expressions as the prompts.
+func TestDurationFlag(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	estimate := durationFlag(fs, "estimate", time.Hour, "")
+	timebox := durationFlag(fs, "for", 0, "")
+	if *estimate != time.Hour || *timebox != 0 {
+		t.Fatalf("defaults %s and %s, want 1h0m0s and 0s", *estimate, *timebox)
+	}
+	if err := fs.Parse([]string{"-estimate", "2*25m+10m", "-for", "1:30"}); err != nil {
+		t.Fatal(err)
+	}
+	if *estimate != time.Hour || *timebox != 90*time.Minute {
+		t.Errorf("parsed %s and %s, want 1h0m0s and 1h30m0s", *estimate, *timebox)
+	}
+	if err := fs.Parse([]string{"-for", "10m-25m"}); err == nil {
+		t.Error("a negative -for was accepted")
+	}
+}
+
+func TestParseEstimateExpr(t *testing.T) {
+	task, estimate := parseEstimate("fix login ~2*25m ~soon")
+	if task != "fix login ~soon" || estimate != 50*time.Minute {
+		t.Errorf("parseEstimate = %q, %s; want %q, 50m0s", task, estimate, "fix login ~soon")
+	}
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dayEdit is a change to one of a day's logged entries.
+type dayEdit struct {
+	date  time.Time
+	rows  []datedEntry
+	index int
+	id    string // the entry's ID, which edits keep
+}
+
+// pickEntry finds the entry ref names: an entry ID as export and sync
+// status show it, or its number among the day's entries. Without ref
+// the day's entries are listed and one is asked for.
+func pickEntry(ref, day string) (dayEdit, error) {
+	var d dayEdit
+	if date, _, ok := strings.Cut(ref, "/"); ok {
+		day = date
+	}
+	date, err := parseDay(day)
+	if err != nil {
+		return d, err
+	}
+	if date.Equal(startOfDay(time.Now())) && instanceRunning() {
+		return d, withCode(exitLocked, errors.New("today is being tracked; use 'list' at the end of the day instead"))
+	}
+	rows, err := exportRows(date, date, "", "")
+	if err != nil {
+		return d, err
+	}
+	if len(rows) == 0 {
+		return d, withCode(exitEmpty, fmt.Errorf("no entries on %s", date.Format(dateLayout)))
+	}
+	// Number the day's entries in the order they happened, across
+	// projects, as the list shows them.
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].Start.Before(rows[j].Start) })
+	d = dayEdit{date: date, rows: rows, index: -1}
+	if ref == "" {
+		entries := make([]TaskEntry, len(rows))
+		for i, r := range rows {
+			entries[i] = r.TaskEntry
+		}
+		fmt.Printf("📋 %s\n", date.Format(dateLayout))
+		for _, line := range formatSummary(entries, 0, len(entries)) {
+			fmt.Println(line)
+		}
+		ref = inputPrompt("👉 Which entry? (number, Enter to cancel) ")
+		if ref == "" {
+			return d, withCode(exitEmpty, errors.New("nothing picked"))
+		}
+	}
+	if n, err := strconv.Atoi(ref); err == nil {
+		if n < 1 || n > len(rows) {
+			return d, usageErrorf("no entry %d on %s", n, date.Format(dateLayout))
+		}
+		d.index = n - 1
+	}
+	for i, r := range rows {
+		if r.ID == ref {
+			d.index = i
+		}
+	}
+	if d.index < 0 {
+		return d, usageErrorf("no entry %q on %s", ref, date.Format(dateLayout))
+	}
+	d.id = rows[d.index].ID
+	return d, nil
+}
+
+func (d dayEdit) entry() *TaskEntry { return &d.rows[d.index].TaskEntry }
+
+// write renders the logs of the projects touched and updates the store.
+// It reports whether the change was written.
+func (d dayEdit) write(projects []string, dryRun, preview bool) (bool, error) {
+	byProject := map[string][]TaskEntry{}
+	for _, p := range projects {
+		byProject[p] = nil
+	}
+	for _, r := range d.rows {
+		if _, ok := byProject[r.Project]; ok {
+			byProject[r.Project] = append(byProject[r.Project], r.TaskEntry)
+		}
+	}
+	changes, err := dayRewrites(d.date, byProject)
+	if err != nil {
+		return false, err
+	}
+	written, err := applyRewrites(changes, dryRun, preview)
+	if !written || err != nil {
+		return false, err
+	}
+	var entries []TaskEntry
+	for _, r := range d.rows {
+		if _, ok := byProject[r.Project]; ok {
+			entries = append(entries, r.TaskEntry)
+		}
+	}
+	if err := storeReplace(d.date.Format(dateLayout), projects, entries); err != nil {
+		fmt.Println("⚠️  Could not update the entry store:", err)
+	}
+	return true, nil
+}
+
+// editCommand changes a past entry's task, project, duration or start.
+// Without any of them it asks for the task and duration.
+func editCommand(args []string) error {
+	fs := newFlagSet("edit")
+	day := fs.String("date", "today", "Day of the entry when giving its number")
+	task := fs.String("task", "", "New task; #tags replace the entry's tags")
+	project := projectFlag(fs, "project", "", "Project to move the entry to")
+	duration := fs.String("duration", "", "New duration, e.g. 45m")
+	from := fs.String("from", "", "New start time, HH:MM")
+	dryRun := fs.Bool("dry-run", false, "Show the changes without writing them")
+	preview := fs.Bool("preview", false, "Show the changes and ask before writing them")
+	var ref string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		ref, args = args[0], args[1:]
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if ref == "" && fs.NArg() == 1 {
+		ref = fs.Arg(0)
+	}
+	d, err := pickEntry(ref, *day)
+	if err != nil {
+		return err
+	}
+	e := d.entry()
+	oldProject := e.Project
+
+	if *task == "" && *project == "" && *duration == "" && *from == "" {
+		*task = inputPrompt(fmt.Sprintf("📝 Task [Enter: %s] ", e.Task))
+		*duration = inputPrompt(fmt.Sprintf("⏱️  Duration [Enter: %s] ", formatDuration("summary", e.Duration)))
+	}
+	if *task != "" {
+		name, billable, r, err := parseBilling(*task)
+		if err != nil {
+			return withCode(exitUsage, err)
+		}
+		name, tags := parseTags(name)
+		if name == "" {
+			return usageErrorf("the task needs a name besides its tags")
+		}
+		e.Task = name
+		if tags != nil {
+			e.Tags = tags
+		}
+		if billable {
+			e.Billable, e.Rate = true, r
+		}
+	}
+	if *project != "" {
+		e.Project = *project
+	}
+	if *duration != "" {
+		v, err := parseDurationExpr(*duration)
+		if err != nil || v <= 0 {
+			return usageErrorf("invalid --duration %q", *duration)
+		}
+		e.Duration = v
+	}
+	if *from != "" {
+		start, err := clockOn(d.date, *from)
+		if err != nil {
+			return err
+		}
+		for i := range e.Pauses {
+			if !e.Pauses[i].Start.IsZero() && !e.Start.IsZero() {
+				e.Pauses[i].Start = start.Add(e.Pauses[i].Start.Sub(e.Start))
+				e.Pauses[i].End = start.Add(e.Pauses[i].End.Sub(e.Start))
+			}
+		}
+		e.Start = start
+	}
+
+	written, err := d.write([]string{oldProject, e.Project}, *dryRun, *preview)
+	if !written || err != nil {
+		return err
+	}
+	recordHistory(e.Project, e.Task)
+	return nil
+}
+
+func deleteCommand(args []string) error {
+	fs := newFlagSet("delete")
+	day := fs.String("date", "today", "Day of the entry when giving its number")
+	dryRun := fs.Bool("dry-run", false, "Show the changes without writing them")
+	preview := fs.Bool("preview", false, "Show the changes and ask before writing them")
+	remote := fs.Bool("remote", false, "Also delete what the entry was synced as")
+	yes := fs.Bool("yes", false, "Delete without asking first")
+	var ref string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		ref, args = args[0], args[1:]
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if ref == "" && fs.NArg() == 1 {
+		ref = fs.Arg(0)
+	}
+	d, err := pickEntry(ref, *day)
+	if err != nil {
+		return err
+	}
+	e := *d.entry()
+	if !*yes && !*dryRun && !*preview {
+		answer := strings.ToLower(inputPrompt(fmt.Sprintf("🗑️  Delete %s (%s) from %s? (y/N) ", e.Task, formatDuration("summary", e.Duration), d.date.Format(dateLayout))))
+		if answer != "y" && answer != "yes" {
+			fmt.Println("↩️  Nothing deleted")
+			return nil
+		}
+	}
+	d.rows = append(d.rows[:d.index:d.index], d.rows[d.index+1:]...)
+	written, err := d.write([]string{e.Project}, *dryRun, *preview)
+	if !written || err != nil {
+		return err
+	}
+	ledger, err := loadLedger()
+	if err != nil {
+		return nil
+	}
+	targets := make([]string, 0, len(ledger[d.id]))
+	for target, rec := range ledger[d.id] {
+		if !rec.Deleted {
+			targets = append(targets, target)
+		}
+	}
+	sort.Strings(targets)
+	failed := 0
+	for _, target := range targets {
+		rec := ledger[d.id][target]
+		if !*remote {
+			fmt.Printf("⚠️  Already synced to %s as %s; delete it there too, or delete with --remote\n", rec.Target, rec.RemoteID)
+			continue
+		}
+		if err := removeSynced(rec); err != nil {
+			fmt.Printf("⚠️  Could not delete %s from %s: %v\n", rec.RemoteID, rec.Target, err)
+			failed++
+			continue
+		}
+		fmt.Printf("🗑️  Deleted %s from %s\n", rec.RemoteID, rec.Target)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d synced copies are still there", failed, len(targets))
+	}
+	return nil
+}
+
+// removeSynced deletes the remote copy rec records and notes that in the
+// ledger.
+func removeSynced(rec syncRecord) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	target, err := syncTargets[rec.Target](cfg)
+	if err != nil {
+		return err
+	}
+	deleter, ok := target.(syncDeleter)
+	if !ok {
+		return fmt.Errorf("%s cannot delete entries", rec.Target)
+	}
+	if err := deleter.remove(rec.RemoteID); err != nil {
+		return err
+	}
+	rec.Deleted, rec.Synced = true, time.Now()
+	return recordSync(rec)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// editDay logs two projects on 2024-03-01 whose files list their
+// entries in another order than they happened.
+func editDay(t *testing.T) (home string) {
+	t.Helper()
+	config, logs := useTempDirs(t)
+	writeConfig(t, config, "log_dir: "+logs+"\n")
+	writeDay(t, logs, "Alpha", at("00:00"), []TaskEntry{{Task: "deploy", Project: "Alpha", Start: at("14:00"), Duration: time.Hour}})
+	writeDay(t, logs, "League", at("00:00"), []TaskEntry{{Task: "review", Project: "League", Start: at("09:00"), Duration: time.Hour}})
+	return filepath.Dir(logs)
+}
+
+func dayTasks(t *testing.T) []string {
+	t.Helper()
+	rows, err := exportRows(at("00:00"), at("00:00"), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tasks []string
+	for _, r := range rows {
+		tasks = append(tasks, r.Task)
+	}
+	return tasks
+}
+
+// Entries are numbered by when they started, not by file.
+func TestPickEntryByStart(t *testing.T) {
+	editDay(t)
+	d, err := pickEntry("1", "2024-03-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := d.entry(); e.Task != "review" {
+		t.Errorf("entry 1 is %q, want the 09:00 review", e.Task)
+	}
+}
+
+// delete asks first unless given --yes.
+func TestDeleteAsks(t *testing.T) {
+	tests := []struct {
+		stdin string
+		args  []string
+		left  int
+	}{
+		{"n\n", nil, 2},
+		{"", nil, 2},
+		{"y\n", nil, 1},
+		{"", []string{"--yes"}, 1},
+	}
+	for _, tt := range tests {
+		home := editDay(t)
+		args := append([]string{"delete", "1", "--date", "2024-03-01"}, tt.args...)
+		if code, out := runMain(t, home, tt.stdin, args...); code != exitOK {
+			t.Fatalf("%q %q: exit %d:\n%s", tt.stdin, args, code, out)
+		}
+		if tasks := dayTasks(t); len(tasks) != tt.left {
+			t.Errorf("%q %q left %q, want %d entries", tt.stdin, args, tasks, tt.left)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// emailConfig is the email section of the config: where the end-of-day
+// summary is sent and through which server.
+type emailConfig struct {
+	Host     string
+	Port     int // 587 for STARTTLS, 465 for TLS from the start
+	Username string
+	Password string
+	From     string
+	To       []string
+	Auto     bool // send the summary when the day is finished
+}
+
+// email is the email section of the config, set at startup.
+var email emailConfig
+
+// daySummaryMail is the summary of date's entries: each entry, the total
+// per project and how the day compares to target.
+func daySummaryMail(date time.Time, target time.Duration) (subject, body string, err error) {
+	entries, err := writtenEntries(date)
+	if err != nil {
+		return "", "", err
+	}
+	slices.SortStableFunc(entries, func(a, b TaskEntry) int { return a.Start.Compare(b.Start) })
+	total := totalDuration(entries)
+	subject = fmt.Sprintf("Work log %s: %s", date.Format("Mon 2006-01-02"), formatDuration("summary", total))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Work log for %s\n\n", date.Format("Monday, 2 January 2006"))
+	if len(entries) == 0 {
+		b.WriteString("Nothing tracked.\n")
+	}
+	var projects []periodTotal
+	index := map[string]int{}
+	for _, e := range entries {
+		start := "--:--"
+		if !e.Start.IsZero() {
+			start = e.Start.Format("15:04")
+		}
+		fmt.Fprintf(&b, "%s  %-12s %-40s %s\n", start, e.Project, e.Task, formatDuration("summary", e.Duration))
+		projects = addTotal(projects, index, e.Project, "", e)
+	}
+	if len(projects) > 0 {
+		b.WriteString("\nPer project\n")
+		for _, p := range finishTotals(projects) {
+			fmt.Fprintf(&b, "  %-12s %s (%d entries)%s\n", p.Name, formatDuration("summary", p.total), p.Entries, earnedColumn(p.Earned))
+		}
+	}
+	fmt.Fprintf(&b, "\nTracked: %s across %d entries\n", formatDuration("summary", total), len(entries))
+	for _, line := range earningsLines(entries) {
+		b.WriteString(line + "\n")
+	}
+	if line := goalSummary(total, target); line != "" {
+		b.WriteString(line + "\n")
+	}
+	return subject, b.String(), nil
+}
+
+// send mails body to the configured recipients. Port 465 speaks TLS from
+// the start; elsewhere the connection is upgraded with STARTTLS when the
+// server offers it.
+func (c emailConfig) send(subject, body string) error {
+	if c.Host == "" || c.From == "" || len(c.To) == 0 {
+		return errors.New("email needs email.host, email.from and email.to in the config")
+	}
+	headers := []string{
+		"From: " + c.From,
+		"To: " + strings.Join(c.To, ", "),
+		"Subject: " + mime.QEncoding.Encode("utf-8", subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=utf-8",
+		"Content-Transfer-Encoding: 8bit",
+	}
+	msg := []byte(strings.ReplaceAll(strings.Join(headers, "\n")+"\n\n"+body, "\n", "\r\n"))
+	addr := net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+	var auth smtp.Auth
+	if c.Username != "" {
+		auth = smtp.PlainAuth("", c.Username, c.Password, c.Host)
+	}
+	dialer := &net.Dialer{Timeout: syncHTTPTimeout}
+	tlsConfig := &tls.Config{ServerName: c.Host}
+	var conn net.Conn
+	var err error
+	if c.Port == 465 {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	client, err := smtp.NewClient(conn, c.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+	if ok, _ := client.Extension("STARTTLS"); ok && c.Port != 465 {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+	if auth != nil {
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(c.From); err != nil {
+		return err
+	}
+	for _, to := range c.To {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// mailDay sends date's summary when email.auto is on, after the day is
+// written. Failures only warn; email can send it again.
+func mailDay(date time.Time, target time.Duration) {
+	if !email.Auto {
+		return
+	}
+	subject, body, err := daySummaryMail(date, target)
+	if err == nil {
+		err = email.send(subject, body)
+	}
+	if err != nil {
+		fmt.Println("⚠️  Summary not emailed:", err)
+		return
+	}
+	fmt.Println("📧 Emailed the day's summary to", strings.Join(email.To, ", "))
+}
+
+// emailCommand sends a day's summary, or shows it with --dry-run.
+func emailCommand(args []string) error {
+	fs := newFlagSet("email")
+	day := fs.String("date", "today", "Day to summarize: today, yesterday or YYYY-MM-DD")
+	dryRun := fs.Bool("dry-run", false, "Print the mail instead of sending it")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	date, err := parseDay(*day)
+	if err != nil {
+		return err
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return withCode(exitUsage, err)
+	}
+	subject, body, err := daySummaryMail(date, cfg.targetFor(date))
+	if err != nil {
+		return err
+	}
+	if *dryRun {
+		fmt.Printf("Subject: %s\n\n%s", subject, body)
+		return nil
+	}
+	if err := email.send(subject, body); err != nil {
+		return err
+	}
+	fmt.Println("📧 Emailed the summary to", strings.Join(email.To, ", "))
+	return nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+)
+
+// fakeSMTP answers one SMTP session on a local port and returns the
+// port and the commands it was sent, which it closes after QUIT.
+func fakeSMTP(t *testing.T) (int, <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	commands := make(chan string, 100)
+	go func() {
+		defer close(commands)
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+		reply("220 fake ESMTP")
+		data := false
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			if data {
+				if line == "." {
+					data = false
+					reply("250 queued")
+				}
+				continue
+			}
+			commands <- line
+			switch verb := strings.ToUpper(strings.Fields(line + " ")[0]); verb {
+			case "EHLO":
+				reply("250 fake")
+			case "DATA":
+				data = true
+				reply("354 go ahead")
+			case "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+	return ln.Addr().(*net.TCPAddr).Port, commands
+}
+
+// A server on another port than 465 gets the mail over a plain
+// connection when it offers no STARTTLS.
+func TestEmailSend(t *testing.T) {
+	port, commands := fakeSMTP(t)
+	c := emailConfig{Host: "127.0.0.1", Port: port, From: "me@example.com", To: []string{"a@example.com", "b@example.com"}}
+	if err := c.send("Worklog", "Tracked: 1h"); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for line := range commands {
+		got = append(got, line)
+	}
+	want := []string{"EHLO localhost", "MAIL FROM:<me@example.com>", "RCPT TO:<a@example.com>", "RCPT TO:<b@example.com>", "DATA", "QUIT"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("commands\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
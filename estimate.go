@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+const estimatePrefix = "- 🎯 **Estimate**: "
+
+// parseEstimate takes a "~" word with a duration out of a task: "fix
+// login ~2h" is the task "fix login" estimated at two hours. A "~" word
+// that is not a duration stays part of the task.
+func parseEstimate(task string) (string, time.Duration) {
+	var kept []string
+	var estimate time.Duration
+	for _, f := range strings.Fields(task) {
+		if text, ok := strings.CutPrefix(f, "~"); ok && text != "" {
+			if d, err := parseDurationExpr(text); err == nil && d > 0 {
+				estimate = d
+				continue
+			}
+		}
+		kept = append(kept, f)
+	}
+	return strings.Join(kept, " "), estimate
+}
+
+// estimateFromPlan gives e the estimate of the open planned task it is
+// for when it has none of its own.
+func estimateFromPlan(e *TaskEntry, at time.Time) {
+	if e.Estimate > 0 {
+		return
+	}
+	queue, err := loadQueue(at)
+	if err != nil {
+		return
+	}
+	for _, p := range queue {
+		if p.open() && p.fits(e.Project) && strings.EqualFold(p.Task, e.Task) {
+			e.Estimate, _ = time.ParseDuration(p.Estimate)
+			return
+		}
+	}
+}
+
+// estimateRow compares the time estimated for a task, or a project's
+// estimated tasks together, with the time tracked on it.
+type estimateRow struct {
+	Project  string `json:"project"`
+	Task     string `json:"task,omitempty"`
+	Tasks    int    `json:"tasks,omitempty"`
+	Estimate string `json:"estimate"`
+	Actual   string `json:"actual"`
+	Variance string `json:"variance"` // actual minus estimate
+	Percent  int    `json:"variance_percent"`
+
+	estimate, actual time.Duration
+}
+
+func (r *estimateRow) finish() {
+	r.Estimate, r.Actual = formatDuration("json", r.estimate), formatDuration("json", r.actual)
+	r.Variance = formatDuration("json", r.actual-r.estimate)
+	if r.estimate > 0 {
+		r.Percent = int((r.actual - r.estimate) * 100 / r.estimate)
+	}
+}
+
+// varianceText is the variance as reports print it, e.g. "+35m (+29%)".
+func (r estimateRow) varianceText() string {
+	d, sign := r.actual-r.estimate, "+"
+	if d < 0 {
+		d, sign = -d, "-"
+	}
+	return fmt.Sprintf("%s%s (%+d%%)", sign, formatDuration("summary", d), r.Percent)
+}
+
+// estimateRows groups the entries by project and task and compares the
+// tasks with an estimate. A task's estimate is the largest any of its
+// entries carries, as with -estimate each session repeats it; its
+// actual time is what all its entries add up to.
+func estimateRows(entries []TaskEntry) (tasks, projects []estimateRow, total estimateRow) {
+	index := map[string]int{}
+	var rows []estimateRow
+	for _, e := range entries {
+		key := e.Project + "\x00" + strings.ToLower(e.Task)
+		i, ok := index[key]
+		if !ok {
+			i = len(rows)
+			index[key] = i
+			rows = append(rows, estimateRow{Project: e.Project, Task: e.Task})
+		}
+		rows[i].actual += e.Duration
+		rows[i].estimate = max(rows[i].estimate, e.Estimate)
+	}
+	perProject := map[string]*estimateRow{}
+	total = estimateRow{Project: "all"}
+	for _, r := range rows {
+		if r.estimate <= 0 {
+			continue
+		}
+		r.finish()
+		tasks = append(tasks, r)
+		p := perProject[r.Project]
+		if p == nil {
+			p = &estimateRow{Project: r.Project}
+			perProject[r.Project] = p
+		}
+		for _, sum := range []*estimateRow{p, &total} {
+			sum.Tasks++
+			sum.estimate += r.estimate
+			sum.actual += r.actual
+		}
+	}
+	sort.SliceStable(tasks, func(i, j int) bool { return tasks[i].Project < tasks[j].Project })
+	for _, p := range perProject {
+		p.finish()
+		projects = append(projects, *p)
+	}
+	sort.Slice(projects, func(i, j int) bool { return projects[i].Project < projects[j].Project })
+	total.finish()
+	return tasks, projects, total
+}
+
+// noEstimatesHint explains an estimates report with nothing in it.
+const noEstimatesHint = "no estimated tasks in the range; estimate one with ~2h in its name, plan --estimate or -estimate"
+
+// estimateReport prints estimate against actual per task and project.
+// With failOnEmpty, a range without estimated tasks is an exitEmpty
+// error.
+func estimateReport(w io.Writer, from, to time.Time, entries []TaskEntry, asJSON, failOnEmpty bool) error {
+	tasks, projects, total := estimateRows(entries)
+	if len(tasks) == 0 && failOnEmpty {
+		return withCode(exitEmpty, errors.New(noEstimatesHint))
+	}
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			From     string        `json:"from"`
+			To       string        `json:"to"`
+			Tasks    []estimateRow `json:"tasks"`
+			Projects []estimateRow `json:"projects"`
+			Total    estimateRow   `json:"total"`
+		}{from.Format(dateLayout), to.Format(dateLayout), tasks, projects, total})
+	}
+	fmt.Fprintf(w, "🎯 Estimates %s – %s\n", from.Format(dateLayout), to.Format(dateLayout))
+	if len(tasks) == 0 {
+		fmt.Fprintf(w, "\n  %s\n", noEstimatesHint)
+		return nil
+	}
+	fmt.Fprintf(w, "\n  %-32s %10s %10s  %s\n", "", "estimate", "actual", "variance")
+	for _, p := range projects {
+		fmt.Fprintf(w, "\n  %s\n", p.Project)
+		for _, r := range tasks {
+			if r.Project == p.Project {
+				fmt.Fprintf(w, "    %-30s %10s %10s  %s\n", r.Task, formatDuration("summary", r.estimate), formatDuration("summary", r.actual), r.varianceText())
+			}
+		}
+		fmt.Fprintf(w, "    %-30s %10s %10s  %s\n", fmt.Sprintf("%d tasks", p.Tasks), formatDuration("summary", p.estimate), formatDuration("summary", p.actual), p.varianceText())
+	}
+	over := 0
+	for _, r := range tasks {
+		if r.actual > r.estimate {
+			over++
+		}
+	}
+	fmt.Fprintf(w, "\n  Overall %s (%d of %d tasks took longer than estimated)\n", total.varianceText(), over, len(tasks))
+	return nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// Exit codes, so scripts can branch on the outcome.
+const (
+	exitOK          = 0
+	exitError       = 1 // anything not covered below
+	exitUsage       = 2 // invalid flags, arguments or config
+	exitWriteFailed = 3 // the log could not be written; a fallback copy was saved
+	exitEmpty       = 4 // nothing to do and -fail-on-empty was given
+	exitLocked      = 5 // another instance holds the lock
+)
+
+type codedError struct {
+	code int
+	err  error
+}
+
+func (e *codedError) Error() string { return e.err.Error() }
+func (e *codedError) Unwrap() error { return e.err }
+
+// withCode tags err with the exit code the process should end with.
+func withCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &codedError{code: code, err: err}
+}
+
+func usageErrorf(format string, args ...any) error {
+	return withCode(exitUsage, fmt.Errorf(format, args...))
+}
+
+var errEmpty = withCode(exitEmpty, errors.New("nothing to do"))
+
+func exitCode(err error) int {
+	var coded *codedError
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.As(err, &coded):
+		return coded.code
+	}
+	return exitError
+}
+
+// jsonOutput is set by a --json flag; errors are then printed as JSON
+// too, so whatever reads the output can parse it either way.
+var jsonOutput bool
+
+// exit prints err, if any, and ends the process with its exit code
+// once the webhooks still being sent are through.
+func exit(err error) {
+	leaveRaw()
+	waitWebhooks()
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+	case jsonOutput:
+		printJSON(os.Stdout, map[string]any{"error": err.Error(), "code": exitCode(err)})
+	default:
+		fmt.Println("❌", err)
+	}
+	os.Exit(exitCode(err))
+}
+
+// printJSON writes v as indented JSON.
+func printJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// jsonValue is a --json flag: setting it also sets jsonOutput.
+type jsonValue struct{ on *bool }
+
+func (v jsonValue) String() string {
+	if v.on == nil {
+		return "false"
+	}
+	return strconv.FormatBool(*v.on)
+}
+
+func (v jsonValue) Set(s string) error {
+	on, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	*v.on, jsonOutput = on, on
+	return nil
+}
+
+func (jsonValue) IsBoolFlag() bool { return true }
+
+// jsonFlag defines --json on fs.
+func jsonFlag(fs *flag.FlagSet, usage string) *bool {
+	on := new(bool)
+	fs.Var(jsonValue{on}, "json", usage)
+	return on
+}
+
+// parseFlags parses args into fs, reporting bad flags as usage errors.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return withCode(exitUsage, err)
+	}
+	return nil
+}
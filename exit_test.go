@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{nil, exitOK},
+		{flag.ErrHelp, exitOK},
+		{errors.New("boom"), exitError},
+		{usageErrorf("bad flag"), exitUsage},
+		{errEmpty, exitEmpty},
+		{fmt.Errorf("stop: %w", withCode(exitLocked, errors.New("held"))), exitLocked},
+		{withCode(exitWriteFailed, errors.New("disk full")), exitWriteFailed},
+	}
+	for _, tt := range tests {
+		if got := exitCode(tt.err); got != tt.want {
+			t.Errorf("exitCode(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+// TestExitCodes runs the tool for representative outcomes and checks
+// the code it ends with.
+func TestExitCodes(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		setup  func(t *testing.T, config, logs string)
+		stdin  string
+		args   []string
+		want   int
+	}{
+		{name: "success", args: []string{"status"}, want: exitOK},
+		{name: "help", args: []string{"report", "-h"}, want: exitOK},
+		{name: "unknown flag", args: []string{"report", "--bogus"}, want: exitUsage},
+		{name: "bad date", args: []string{"report", "--from", "someday"}, want: exitUsage},
+		{name: "bad config", config: "target: lots\n", args: []string{"status"}, want: exitUsage},
+		{name: "unknown setting", config: "no_such_setting: 1\n", args: []string{"status"}, want: exitUsage},
+		{name: "empty history", args: []string{"history", "--fail-on-empty"}, want: exitEmpty},
+		{name: "nothing to stop", args: []string{"stop", "--task", "x"}, want: exitEmpty},
+		{name: "empty export", args: []string{"export", "--format", "jsonl"}, want: exitEmpty},
+		{name: "empty report", args: []string{"report"}, want: exitOK},
+		{name: "empty report, failing", args: []string{"report", "--fail-on-empty"}, want: exitEmpty},
+		{name: "no estimates", args: []string{"report", "--estimates", "--json"}, want: exitOK},
+		{name: "no estimates, failing", args: []string{"report", "--estimates", "--fail-on-empty"}, want: exitEmpty},
+		{name: "no match", args: []string{"log", "--grep", "nothing"}, want: exitOK},
+		{name: "no match, failing", args: []string{"log", "--grep", "nothing", "--fail-on-empty"}, want: exitEmpty},
+		{name: "empty stats", args: []string{"stats", "--json"}, want: exitOK},
+		{name: "empty stats, failing", args: []string{"stats", "--fail-on-empty"}, want: exitEmpty},
+		{
+			name:  "fail-on-empty with an entry",
+			stdin: "q\n\nyes\n",
+			args:  []string{"-no-banner", "-no-git-task", "-fail-on-empty", "-auto-finalize", ""},
+			want:  exitOK,
+		},
+		{
+			name: "lock held",
+			setup: func(t *testing.T, config, logs string) {
+				os.MkdirAll(config, os.ModePerm)
+				os.WriteFile(filepath.Join(config, "worklog.lock"), []byte(fmt.Sprint(os.Getpid())), 0o644)
+			},
+			args: []string{"-no-banner"},
+			want: exitLocked,
+		},
+		{
+			name: "timer already running",
+			setup: func(t *testing.T, config, logs string) {
+				if code, out := runMain(t, filepath.Dir(logs), "", "start"); code != exitOK {
+					t.Fatalf("start: %d %s", code, out)
+				}
+			},
+			args: []string{"start"},
+			want: exitLocked,
+		},
+		{
+			name: "log directory not writable",
+			setup: func(t *testing.T, config, logs string) {
+				os.WriteFile(logs, []byte("a file, not a directory"), 0o644)
+			},
+			stdin: "q\nreview\nyes\n",
+			args:  []string{"-no-banner", "-no-git-task", "-auto-finalize", ""},
+			want:  exitWriteFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, logs := useTempDirs(t)
+			writeConfig(t, config, "log_dir: "+logs+"\n"+tt.config)
+			if tt.setup != nil {
+				tt.setup(t, config, logs)
+			}
+			code, out := runMain(t, filepath.Dir(logs), tt.stdin, tt.args...)
+			if code != tt.want {
+				t.Errorf("exit code %d, want %d; output:\n%s", code, tt.want, out)
+			}
+		})
+	}
+}
+
+func TestJSONErrors(t *testing.T) {
+	config, logs := useTempDirs(t)
+	writeConfig(t, config, "log_dir: "+logs+"\n")
+	code, out := runMain(t, filepath.Dir(logs), "", "timers", "--json", "switch", "nope")
+	var reply struct {
+		Error string `json:"error"`
+		Code  int    `json:"code"`
+	}
+	if err := json.Unmarshal([]byte(out), &reply); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out)
+	}
+	if reply.Code != code || code == exitOK || strings.TrimSpace(reply.Error) == "" {
+		t.Errorf("exit code %d with %+v", code, reply)
+	}
+}
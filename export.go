@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// exportedEntry is one line of export --format jsonl.
+type exportedEntry struct {
+	ID          string         `json:"id,omitempty"`
+	Date        string         `json:"date"`
+	Project     string         `json:"project"`
+	Client      string         `json:"client,omitempty"`
+	Task        string         `json:"task"`
+	Start       *time.Time     `json:"start,omitempty"` // unknown for entries logged before times were written
+	End         *time.Time     `json:"end,omitempty"`
+	Duration    string         `json:"duration"`
+	Seconds     int64          `json:"seconds"`
+	Pauses      []handoffPause `json:"pauses,omitempty"`
+	Notes       []string       `json:"notes,omitempty"`
+	Billable    bool           `json:"billable,omitempty"`
+	Rate        string         `json:"rate,omitempty"`
+	Amount      string         `json:"amount,omitempty"` // at its own or the project's rate
+	Attachments []string       `json:"attachments,omitempty"`
+	Tags        []string       `json:"tags,omitempty"`
+	Estimate    string         `json:"estimate,omitempty"`
+
+	Interruptions []storedInterruption `json:"interruptions,omitempty"`
+}
+
+// datedEntry is an entry together with the day it was logged on.
+type datedEntry struct {
+	Date string
+	TaskEntry
+}
+
+// exportRows returns the logged entries between from and to, oldest day
+// first and in log order within a day. The logs give times to the
+// minute; the entry store fills in the exact ones where it agrees.
+func exportRows(from, to time.Time, project, tag string) ([]datedEntry, error) {
+	days, err := dayEntries(from, to)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := loadStore()
+	if err != nil {
+		return nil, err
+	}
+	var dates []string
+	for date := range days {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+	var rows []datedEntry
+	for _, date := range dates {
+		ids, match := matchDay(stored, date, days[date])
+		for i, e := range days[date] {
+			if project != "" && e.Project != project || tag != "" && !hasTag(e, tag) {
+				continue
+			}
+			if s := match[i]; s != nil && s.Start != nil && s.Start.Truncate(time.Minute).Equal(e.Start.Truncate(time.Minute)) {
+				known := s.entry()
+				e.Start, e.Pauses, e.Interruptions = known.Start, known.Pauses, known.Interruptions
+			}
+			e.ID = ids[i]
+			rows = append(rows, datedEntry{Date: date, TaskEntry: e})
+		}
+	}
+	return rows, nil
+}
+
+func exportJSONL(rows []datedEntry) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetEscapeHTML(false)
+	for _, r := range rows {
+		if err := enc.Encode(r.exported()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r datedEntry) exported() exportedEntry {
+	out := exportedEntry{
+		ID: r.ID, Date: r.Date, Project: r.Project, Client: clientOf(r.Project), Task: r.Task,
+		Duration: formatDuration("json", r.Duration), Seconds: int64(r.Duration.Seconds()),
+		Notes: r.Notes, Billable: r.Billable, Attachments: r.Attachments, Tags: r.Tags,
+	}
+	if !r.Start.IsZero() {
+		start, end := r.Start, entryEnd(r.TaskEntry)
+		out.Start, out.End = &start, &end
+	}
+	if !r.Rate.isZero() {
+		out.Rate = r.Rate.String()
+	}
+	if r.Estimate > 0 {
+		out.Estimate = formatDuration("json", r.Estimate)
+	}
+	if cents, currency, ok := entryEarning(r.TaskEntry); ok {
+		out.Amount = formatCents(cents) + " " + currency
+	}
+	for _, p := range r.Pauses {
+		hp := handoffPause{Duration: p.Duration().Round(time.Second).String(), Reason: p.Reason}
+		if !p.Start.IsZero() {
+			start, end := p.Start, p.End
+			hp.Start, hp.End = &start, &end
+		}
+		out.Pauses = append(out.Pauses, hp)
+	}
+	for _, i := range r.Interruptions {
+		out.Interruptions = append(out.Interruptions, storedInterruption{Start: i.Start, End: i.End, By: i.By, Note: i.Note})
+	}
+	return out
+}
+
+// exportCSV writes a timesheet with one row per entry. Times are local
+// HH:MM and left empty when unknown; durations use the csv style,
+// decimal hours unless configured otherwise. Amounts are left empty for
+// entries that are not billable or have no rate.
+func exportCSV(rows []datedEntry) error {
+	w := csv.NewWriter(os.Stdout)
+	w.Write([]string{"date", "project", "task", "start", "end", "duration", "tags", "billable", "amount", "currency", "client"})
+	for _, r := range rows {
+		start, end := "", ""
+		if !r.Start.IsZero() {
+			start, end = r.Start.Local().Format("15:04"), entryEnd(r.TaskEntry).Local().Format("15:04")
+		}
+		billable, amount, currency := "", "", ""
+		if r.Billable {
+			billable = "yes"
+		}
+		if cents, c, ok := entryEarning(r.TaskEntry); ok {
+			amount, currency = formatCents(cents), c
+		}
+		w.Write([]string{r.Date, r.Project, r.Task, start, end, formatDuration("csv", r.Duration), strings.Join(r.Tags, " "), billable, amount, currency, clientOf(r.Project)})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func exportCommand(args []string) error {
+	fs := newFlagSet("export")
+	format := fs.String("format", "jsonl", "Output format: jsonl, csv, ics or org")
+	project := projectFlag(fs, "project", "", "Only include this project")
+	client := fs.String("client", "", "Only include the projects billed to this client")
+	tag := fs.String("tag", "", "Only include entries with this tag")
+	fromText := fs.String("from", "", "First day (YYYY-MM-DD, default the first log)")
+	toText := fs.String("to", "", "Last day (YYYY-MM-DD, default today)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *format != "jsonl" && *format != "csv" && *format != "ics" && *format != "org" {
+		return usageErrorf("unknown export format %q; want jsonl, csv, ics or org", *format)
+	}
+	from, to, err := parseDateRange(*fromText, *toText)
+	if err != nil {
+		return err
+	}
+	if to.IsZero() {
+		to = startOfDay(time.Now())
+	}
+	rows, err := exportRows(from, to, *project, *tag)
+	if err != nil {
+		return err
+	}
+	if *client != "" {
+		kept := rows[:0]
+		for _, r := range rows {
+			if strings.EqualFold(clientOf(r.Project), *client) {
+				kept = append(kept, r)
+			}
+		}
+		rows = kept
+	}
+	if len(rows) == 0 {
+		return withCode(exitEmpty, fmt.Errorf("no entries to export up to %s", to.Format(dateLayout)))
+	}
+	switch *format {
+	case "csv":
+		return exportCSV(rows)
+	case "ics":
+		return exportICS(rows)
+	case "org":
+		return exportOrg(rows)
+	}
+	return exportJSONL(rows)
+}
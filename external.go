@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+)
+
+// lastWrite remembers what this run last wrote to a log, to notice the
+// file changing under us (Obsidian, sync tools) before the next write.
+type lastWrite struct {
+	hash    [sha256.Size]byte
+	entries []string // today's entries as written
+}
+
+var lastWrites = map[string]lastWrite{}
+
+// conflictNote marks both versions of an entry changed in the tracker
+// and outside it; doctor reports them.
+const conflictNote = "⚠️ conflict: edited both here and outside the tracker, both versions kept"
+
+func entryText(e TaskEntry) string {
+	var b bytes.Buffer
+	writeEntries(&b, []TaskEntry{e})
+	return b.String()
+}
+
+func entryTexts(entries []TaskEntry) []string {
+	texts := make([]string, len(entries))
+	for i, e := range entries {
+		texts[i] = entryText(e)
+	}
+	return texts
+}
+
+// mergeExternal combines today's entries as last written (base), as
+// found on disk and as held in memory, position by position. A side
+// that left an entry alone takes the other side's change; entries that
+// were changed differently on both sides are kept twice with a
+// conflict note. Entries appended on disk come before those appended
+// in memory, and entries removed on disk stay removed unless they were
+// changed in memory.
+func mergeExternal(base []string, disk, mine []TaskEntry) ([]TaskEntry, int) {
+	diskText, mineText := entryTexts(disk), entryTexts(mine)
+	var merged []TaskEntry
+	conflicts := 0
+	for i, b := range base {
+		hasDisk, hasMine := i < len(disk), i < len(mine)
+		switch {
+		case !hasMine && (!hasDisk || diskText[i] == b):
+			// Removed in memory.
+		case !hasMine:
+			merged = append(merged, disk[i])
+		case !hasDisk:
+			if mineText[i] != b {
+				merged = append(merged, mine[i])
+			}
+		case diskText[i] == b || diskText[i] == mineText[i]:
+			merged = append(merged, mine[i])
+		case mineText[i] == b:
+			merged = append(merged, disk[i])
+		default:
+			ours, theirs := mine[i], disk[i]
+			ours.Notes = append(append([]string(nil), ours.Notes...), conflictNote)
+			theirs.Notes = append(append([]string(nil), theirs.Notes...), conflictNote)
+			merged = append(merged, ours, theirs)
+			conflicts++
+		}
+	}
+	if len(disk) > len(base) {
+		merged = append(merged, disk[len(base):]...)
+	}
+	if len(mine) > len(base) {
+		merged = append(merged, mine[len(base):]...)
+	}
+	return merged, conflicts
+}
+
+// reconcile returns the entries to write to path: entries as they are,
+// unless the file changed since this run last wrote it, in which case
+// they are merged with what is on disk now.
+func reconcile(path string, existing []byte, date string, entries []TaskEntry) []TaskEntry {
+	last, ok := lastWrites[path]
+	if !ok || existing == nil || sha256.Sum256(existing) == last.hash {
+		return entries
+	}
+	merged, conflicts := mergeExternal(last.entries, parseLog(existing)[date], entries)
+	fmt.Printf("🔀 %s was changed outside the tracker; merged the changes", path)
+	if conflicts > 0 {
+		fmt.Printf(" (%d conflicts kept side by side, see doctor)", conflicts)
+	}
+	fmt.Println()
+	return merged
+}
+
+func rememberWrite(path string, content []byte, entries []TaskEntry) {
+	lastWrites[path] = lastWrite{hash: sha256.Sum256(content), entries: entryTexts(entries)}
+}
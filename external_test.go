@@ -0,0 +1,118 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMergeExternal(t *testing.T) {
+	e := func(task string) TaskEntry {
+		return TaskEntry{Task: task, Project: "League", Start: at("09:00"), Duration: 20 * time.Minute}
+	}
+	conflicted := func(task string) TaskEntry {
+		c := e(task)
+		c.Notes = []string{conflictNote}
+		return c
+	}
+	base := entryTexts([]TaskEntry{e("a"), e("b")})
+	tests := []struct {
+		name       string
+		disk, mine []TaskEntry
+		want       []TaskEntry
+		conflicts  int
+	}{
+		{"unchanged", []TaskEntry{e("a"), e("b")}, []TaskEntry{e("a"), e("b")}, []TaskEntry{e("a"), e("b")}, 0},
+		{"both appended", []TaskEntry{e("a"), e("b"), e("disk")}, []TaskEntry{e("a"), e("b"), e("mine")},
+			[]TaskEntry{e("a"), e("b"), e("disk"), e("mine")}, 0},
+		{"edited on disk", []TaskEntry{e("A"), e("b")}, []TaskEntry{e("a"), e("b")}, []TaskEntry{e("A"), e("b")}, 0},
+		{"edited in memory", []TaskEntry{e("a"), e("b")}, []TaskEntry{e("a"), e("B")}, []TaskEntry{e("a"), e("B")}, 0},
+		{"edited alike", []TaskEntry{e("A"), e("b")}, []TaskEntry{e("A"), e("b")}, []TaskEntry{e("A"), e("b")}, 0},
+		{"edited both ways", []TaskEntry{e("disk"), e("b")}, []TaskEntry{e("mine"), e("b")},
+			[]TaskEntry{conflicted("mine"), conflicted("disk"), e("b")}, 1},
+		{"removed on disk", []TaskEntry{e("a")}, []TaskEntry{e("a"), e("b")}, []TaskEntry{e("a")}, 0},
+		{"removed on disk, edited in memory", []TaskEntry{e("a")}, []TaskEntry{e("a"), e("B")}, []TaskEntry{e("a"), e("B")}, 0},
+		{"removed in memory", []TaskEntry{e("a"), e("b")}, []TaskEntry{e("a")}, []TaskEntry{e("a")}, 0},
+		{"removed in memory, edited on disk", []TaskEntry{e("a"), e("B")}, []TaskEntry{e("a")}, []TaskEntry{e("a"), e("B")}, 0},
+	}
+	for _, tt := range tests {
+		got, conflicts := mergeExternal(base, tt.disk, tt.mine)
+		if !reflect.DeepEqual(got, tt.want) || conflicts != tt.conflicts {
+			t.Errorf("%s: got %+v with %d conflicts, want %+v with %d", tt.name, got, conflicts, tt.want, tt.conflicts)
+		}
+	}
+}
+
+// editLog rewrites today's log of project the way an editor would,
+// changing its entries with edit.
+func editLog(t *testing.T, logs, project string, edit func([]TaskEntry) []TaskEntry) {
+	t.Helper()
+	now := time.Now()
+	path := filepath.Join(logs, dailyFilename(project, now))
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := edit(parseLog(content)[now.Format(dateLayout)])
+	if err := os.WriteFile(path, append(renderMarkdown(project, now, entries), inProgressMarker...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Edits made to the log between two incremental writes survive the
+// second one.
+func TestWriteDailyMergesExternalEdits(t *testing.T) {
+	config, logs := useTempDirs(t)
+	writeConfig(t, config, "log_dir: "+logs+"\n")
+	t.Cleanup(func() {
+		priorEntries = map[string][]TaskEntry{}
+		lastWrites = map[string]lastWrite{}
+	})
+	today := startOfDay(time.Now())
+	session := func(task string, hour int) TaskEntry {
+		return TaskEntry{Task: task, Project: "League", Start: today.Add(time.Duration(hour) * time.Hour), Duration: 30 * time.Minute}
+	}
+	written, err := writeDaily("League", []TaskEntry{session("review", 9), session("triage", 10)}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	editLog(t, logs, "League", func(entries []TaskEntry) []TaskEntry {
+		entries[0].Task = "code review"
+		entries[1].Task = "triage from the phone"
+		return append(entries, session("standup", 11))
+	})
+	mine := append(written, session("deploy", 12))
+	mine[1].Task = "triage bugs"
+	written, err = writeDaily("League", mine, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tasks []string
+	for _, e := range written {
+		tasks = append(tasks, e.Task)
+	}
+	want := []string{"code review", "triage bugs", "triage from the phone", "standup", "deploy"}
+	if !reflect.DeepEqual(tasks, want) {
+		t.Errorf("written %q, want %q", tasks, want)
+	}
+	logged, err := writtenEntries(today)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(entryTexts(logged), entryTexts(written)) {
+		t.Errorf("the log has\n%+v\nwant\n%+v", logged, written)
+	}
+
+	// A write with nothing changed outside leaves the entries be.
+	if again, err := writeDaily("League", written, false); err != nil || !reflect.DeepEqual(entryTexts(again), entryTexts(written)) {
+		t.Errorf("rewriting changed the entries: %+v, %v", again, err)
+	}
+
+	code, out := runMain(t, filepath.Dir(logs), "", "doctor")
+	if code == exitOK || !strings.Contains(out, `"triage bugs" has a merge conflict`) {
+		t.Errorf("doctor: exit %d:\n%s", code, out)
+	}
+}
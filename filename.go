@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// defaultFilenamePattern names daily logs like 2024-06-03_League.md.
+const defaultFilenamePattern = "{date}_{project}.md"
+
+// filenamePattern is the filename setting; filenameRegexp recognises
+// the names it produces.
+var (
+	filenamePattern = defaultFilenamePattern
+	filenameRegexp  = mustFilenameRegexp(defaultFilenamePattern)
+)
+
+var placeholders = map[string]string{
+	"{date}":    `(?P<date>\d{4}-\d{2}-\d{2})`,
+	"{year}":    `(?P<year>\d{4})`,
+	"{month}":   `(?P<month>\d{2})`,
+	"{day}":     `(?P<day>\d{2})`,
+	"{project}": `(?P<project>.+?)`,
+}
+
+// checkFilenamePattern makes sure a pattern names one file per project
+// and day in the log directory itself.
+func checkFilenamePattern(pattern string) error {
+	if !strings.HasSuffix(pattern, ".md") || strings.ContainsAny(pattern, `/\`) {
+		return fmt.Errorf("want a file name ending in .md, got %q", pattern)
+	}
+	if !strings.Contains(pattern, "{project}") {
+		return fmt.Errorf("%q needs {project}", pattern)
+	}
+	hasDate := strings.Contains(pattern, "{date}")
+	hasParts := strings.Contains(pattern, "{year}") && strings.Contains(pattern, "{month}") && strings.Contains(pattern, "{day}")
+	if !hasDate && !hasParts {
+		return fmt.Errorf("%q needs {date}, or {year}, {month} and {day}", pattern)
+	}
+	_, err := filenameRegexpFor(pattern)
+	return err
+}
+
+func filenameRegexpFor(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	for rest := pattern; rest != ""; {
+		i := strings.Index(rest, "{")
+		if i < 0 {
+			b.WriteString(regexp.QuoteMeta(rest))
+			break
+		}
+		b.WriteString(regexp.QuoteMeta(rest[:i]))
+		j := strings.Index(rest[i:], "}")
+		if j < 0 {
+			return nil, fmt.Errorf("unclosed { in %q", pattern)
+		}
+		name := rest[i : i+j+1]
+		group, ok := placeholders[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown placeholder %s in %q", name, pattern)
+		}
+		if strings.Contains(b.String(), "?P<"+strings.Trim(name, "{}")+">") {
+			return nil, fmt.Errorf("%s appears twice in %q", name, pattern)
+		}
+		b.WriteString(group)
+		rest = rest[i+j+1:]
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+func mustFilenameRegexp(pattern string) *regexp.Regexp {
+	re, err := filenameRegexpFor(pattern)
+	if err != nil {
+		panic(err)
+	}
+	return re
+}
+
+// setFilenamePattern applies the filename setting, checked by the config.
+func setFilenamePattern(pattern string) {
+	if pattern == "" {
+		pattern = defaultFilenamePattern
+	}
+	filenamePattern = pattern
+	filenameRegexp = mustFilenameRegexp(pattern)
+}
+
+// dailyFilename names project's log for date, with the characters a
+// file name cannot hold replaced.
+func dailyFilename(project string, date time.Time) string {
+	return expandFilename(date, safeName(project))
+}
+
+// dailyGlob matches the daily logs of date for every project.
+func dailyGlob(date time.Time) string {
+	return expandFilename(date, "*")
+}
+
+func expandFilename(date time.Time, project string) string {
+	return strings.NewReplacer(
+		"{date}", date.Format(dateLayout),
+		"{year}", date.Format("2006"),
+		"{month}", date.Format("01"),
+		"{day}", date.Format("02"),
+		"{project}", project,
+	).Replace(filenamePattern)
+}
+
+// parseDailyName reads the date and project back from a daily log name.
+func parseDailyName(name string) (time.Time, string, bool) {
+	m := filenameRegexp.FindStringSubmatch(filepath.Base(name))
+	if m == nil {
+		return time.Time{}, "", false
+	}
+	parts := map[string]string{}
+	for i, group := range filenameRegexp.SubexpNames() {
+		if group != "" {
+			parts[group] = m[i]
+		}
+	}
+	text := parts["date"]
+	if text == "" {
+		text = parts["year"] + "-" + parts["month"] + "-" + parts["day"]
+	}
+	date, err := time.ParseInLocation(dateLayout, text, time.Local)
+	if err != nil || parts["project"] == "" {
+		return time.Time{}, "", false
+	}
+	return date, parts["project"], true
+}
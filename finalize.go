@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	autoClosedTask      = "(auto-closed)"
+	autoFinalizeWarning = 60 * time.Second
+)
+
+// autoFinalizer ends the day on its own when the "Done for the day?"
+// question is never answered, e.g. because the laptop lid was closed.
+type autoFinalizer struct {
+	clock     string
+	action    string
+	at        time.Time // zero when disabled
+	cancelled bool
+	// now reads the clock; nil means time.Now. Tests set it to cross
+	// the deadline without waiting for it.
+	now func() time.Time
+}
+
+func (a *autoFinalizer) clockNow() time.Time {
+	if a.now != nil {
+		return a.now()
+	}
+	return time.Now()
+}
+
+func newAutoFinalizer(clock, action string, from time.Time) (*autoFinalizer, error) {
+	if action != "exit" && action != "roll" {
+		return nil, fmt.Errorf("invalid -auto-finalize-action %q (want exit or roll)", action)
+	}
+	af := &autoFinalizer{clock: clock, action: action}
+	if clock == "" {
+		return af, nil
+	}
+	at, err := nextAutoFinalize(clock, from)
+	if err != nil {
+		return nil, err
+	}
+	af.at = at
+	return af, nil
+}
+
+// nextAutoFinalize returns the first occurrence of the HH:MM clock time
+// strictly after from.
+func nextAutoFinalize(clock string, from time.Time) (time.Time, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid -auto-finalize time %q (want HH:MM)", clock)
+	}
+	year, month, day := from.Date()
+	at := time.Date(year, month, day, t.Hour(), t.Minute(), 0, 0, from.Location())
+	if !at.After(from) {
+		at = at.AddDate(0, 0, 1)
+	}
+	return at, nil
+}
+
+func (a *autoFinalizer) enabled() bool {
+	return !a.at.IsZero() && !a.cancelled
+}
+
+func (a *autoFinalizer) due() bool {
+	return a.enabled() && !a.clockNow().Before(a.at)
+}
+
+// warning reports how long is left when the deadline is close enough
+// that the user should be given a chance to cancel.
+func (a *autoFinalizer) warning() (time.Duration, bool) {
+	if !a.enabled() {
+		return 0, false
+	}
+	left := a.at.Sub(a.clockNow())
+	return left, left > 0 && left <= autoFinalizeWarning
+}
+
+func (a *autoFinalizer) cancel() {
+	if _, ok := a.warning(); ok {
+		a.cancelled = true
+		fmt.Println("🛑 Auto-finalize cancelled for today")
+	}
+}
+
+// reconfigure switches to a new clock time and action, keeping the
+// current ones if either is invalid.
+func (a *autoFinalizer) reconfigure(clock, action string) error {
+	if clock == a.clock && action == a.action {
+		return nil
+	}
+	next, err := newAutoFinalizer(clock, action, a.clockNow())
+	if err != nil {
+		return err
+	}
+	next.now = a.now
+	*a = *next
+	return nil
+}
+
+// roll schedules the next auto-finalize after the current one fired.
+func (a *autoFinalizer) roll() {
+	a.cancelled = false
+	at, err := nextAutoFinalize(a.clock, a.at)
+	if err == nil {
+		a.at = at
+	}
+}
+
+// prompt behaves like inputPrompt but gives up once the auto-finalize
+// deadline passes. Answering "c" during the warning window cancels the
+// deadline and asks again.
+func (a *autoFinalizer) prompt(prompt string) (string, bool) {
+	leaveRaw()
+	audit("prompt_open", "", 0)
+	defer trackOverhead(time.Now())
+	fmt.Print(prompt)
+	for {
+		var warn, deadline <-chan time.Time
+		if a.enabled() {
+			left := a.at.Sub(a.clockNow())
+			if left > autoFinalizeWarning {
+				warn = time.After(left - autoFinalizeWarning)
+			} else {
+				deadline = time.After(left)
+			}
+		}
+
+		select {
+		case text := <-inputLines:
+			text = strings.TrimSpace(text)
+			if _, ok := a.warning(); ok && strings.EqualFold(text, "c") {
+				a.cancel()
+				fmt.Print(prompt)
+				continue
+			}
+			return text, false
+		case <-warn:
+			fmt.Printf("\n⚠️  Auto-finalizing the day at %s - type 'c' to cancel\n%s", a.at.Format("15:04"), prompt)
+		case <-deadline:
+			fmt.Print("\n")
+			return "", true
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextAutoFinalize(t *testing.T) {
+	tests := []struct {
+		from time.Time
+		want time.Time
+	}{
+		{at("09:00"), at("23:55")},
+		{at("23:54"), at("23:55")},
+		{at("23:55"), at("23:55").AddDate(0, 0, 1)},
+		{at("23:59"), at("23:55").AddDate(0, 0, 1)},
+	}
+	for _, tt := range tests {
+		got, err := nextAutoFinalize("23:55", tt.from)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("nextAutoFinalize(23:55, %s) = %s, want %s", tt.from.Format("15:04"), got, tt.want)
+		}
+	}
+	if _, err := nextAutoFinalize("25:00", at("09:00")); err == nil {
+		t.Error("nextAutoFinalize accepted 25:00")
+	}
+}
+
+func TestAutoFinalizerCrossesDeadline(t *testing.T) {
+	clock := &fakeClock{at("23:50")}
+	af, err := newAutoFinalizer("23:55", "exit", clock.now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	af.now = clock.now
+
+	if af.due() {
+		t.Fatal("due five minutes early")
+	}
+	if _, ok := af.warning(); ok {
+		t.Fatal("warning five minutes early")
+	}
+	clock.advance(4*time.Minute + 30*time.Second)
+	if left, ok := af.warning(); !ok || left != 30*time.Second {
+		t.Fatalf("warning() = %s, %v; want 30s, true", left, ok)
+	}
+	clock.advance(30 * time.Second)
+	if !af.due() {
+		t.Fatal("not due at the deadline")
+	}
+	if _, ok := af.warning(); ok {
+		t.Error("still warning once due")
+	}
+}
+
+func TestAutoFinalizerCancel(t *testing.T) {
+	clock := &fakeClock{at("23:50")}
+	af, err := newAutoFinalizer("23:55", "roll", clock.now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	af.now = clock.now
+
+	af.cancel()
+	if af.cancelled {
+		t.Fatal("cancelled outside the warning window")
+	}
+	clock.advance(4*time.Minute + 10*time.Second)
+	af.cancel()
+	clock.advance(time.Minute)
+	if af.due() {
+		t.Fatal("due after being cancelled")
+	}
+
+	af.roll()
+	if want := at("23:55").AddDate(0, 0, 1); !af.at.Equal(want) {
+		t.Errorf("rolled to %s, want %s", af.at, want)
+	}
+	if af.cancelled {
+		t.Error("cancel carried over into the next day")
+	}
+	clock.advance(24 * time.Hour)
+	if !af.due() {
+		t.Error("not due on the next day")
+	}
+}
+
+func TestAutoFinalizerDisabled(t *testing.T) {
+	af, err := newAutoFinalizer("", "exit", at("09:00"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	af.now = func() time.Time { return at("23:59") }
+	if af.due() {
+		t.Error("due while disabled")
+	}
+	if _, err := newAutoFinalizer("23:55", "sleep", at("09:00")); err == nil {
+		t.Error("accepted action sleep")
+	}
+}
+
+func TestAutoFinalizerReconfigureKeepsClock(t *testing.T) {
+	clock := &fakeClock{at("17:00")}
+	af, err := newAutoFinalizer("23:55", "exit", clock.now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	af.now = clock.now
+	if err := af.reconfigure("18:00", "exit"); err != nil {
+		t.Fatal(err)
+	}
+	if !af.at.Equal(at("18:00")) {
+		t.Fatalf("reconfigured to %s, want 18:00 today", af.at)
+	}
+	clock.advance(time.Hour)
+	if !af.due() {
+		t.Error("not due at the new time")
+	}
+	if err := af.reconfigure("18:00", "nap"); err == nil {
+		t.Error("accepted action nap")
+	}
+}
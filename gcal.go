@@ -0,0 +1,358 @@
+package main
+
+import (
+	"cmp"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Google's OAuth endpoints and the Calendar API.
+var (
+	gcalAuthURL  = "https://accounts.google.com/o/oauth2/v2/auth"
+	gcalTokenURL = "https://oauth2.googleapis.com/token"
+	gcalAPI      = "https://www.googleapis.com/calendar/v3"
+)
+
+const (
+	gcalScope        = "https://www.googleapis.com/auth/calendar"
+	gcalLoginTimeout = 5 * time.Minute
+)
+
+// gcalConfig is the gcal section of the config. The client is a
+// "Desktop app" OAuth client from the Google Cloud console.
+type gcalConfig struct {
+	ClientID     string
+	ClientSecret string
+	Calendar     string // the calendar entries go to, made when missing
+}
+
+// gcalToken is what gcal login keeps next to the config: the tokens
+// for offline access and the calendar found or made for them.
+type gcalToken struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	Expiry       time.Time `json:"expiry"`
+	Calendar     string    `json:"calendar,omitempty"` // the name CalendarID was looked up by
+	CalendarID   string    `json:"calendar_id,omitempty"`
+}
+
+func gcalTokenPath() (string, error) {
+	dir, err := appDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gcal_token.json"), nil
+}
+
+func loadGcalToken() (gcalToken, bool, error) {
+	var t gcalToken
+	path, err := gcalTokenPath()
+	if err != nil {
+		return t, false, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return t, false, nil
+	}
+	if err != nil {
+		return t, false, err
+	}
+	if err := json.Unmarshal(data, &t); err != nil {
+		return t, false, fmt.Errorf("%s: %v", path, err)
+	}
+	return t, true, nil
+}
+
+// saveGcalToken writes the tokens readable by the owner only.
+func saveGcalToken(t gcalToken) error {
+	path, err := gcalTokenPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+// gcalExchange posts form to the token endpoint and returns the tokens
+// it answers with; a refresh keeps the refresh token it was given.
+func gcalExchange(cfg gcalConfig, form url.Values) (gcalToken, error) {
+	form.Set("client_id", cfg.ClientID)
+	form.Set("client_secret", cfg.ClientSecret)
+	client := http.Client{Timeout: syncHTTPTimeout}
+	resp, err := client.PostForm(gcalTokenURL, form)
+	if err != nil {
+		return gcalToken{}, err
+	}
+	defer resp.Body.Close()
+	var reply struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int64  `json:"expires_in"`
+		Error        string `json:"error"`
+		Description  string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+		return gcalToken{}, fmt.Errorf("token endpoint: %s", resp.Status)
+	}
+	if reply.Error != "" {
+		return gcalToken{}, fmt.Errorf("token endpoint: %s %s", reply.Error, reply.Description)
+	}
+	return gcalToken{
+		AccessToken:  reply.AccessToken,
+		RefreshToken: cmp.Or(reply.RefreshToken, form.Get("refresh_token")),
+		Expiry:       time.Now().Add(time.Duration(reply.ExpiresIn) * time.Second),
+	}, nil
+}
+
+func randomURLString() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// gcalLogin lets the tool use the calendar: the browser is sent to
+// Google's consent page, which redirects back to a listener on the
+// loopback interface with the code that is traded for the tokens.
+func gcalLogin(cfg gcalConfig) error {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+	redirect := "http://" + ln.Addr().String()
+	state, verifier := randomURLString(), randomURLString()
+	challenge := sha256.Sum256([]byte(verifier))
+	consent := gcalAuthURL + "?" + url.Values{
+		"client_id":             {cfg.ClientID},
+		"redirect_uri":          {redirect},
+		"response_type":         {"code"},
+		"scope":                 {gcalScope},
+		"access_type":           {"offline"},
+		"prompt":                {"consent"},
+		"state":                 {state},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}.Encode()
+
+	type result struct {
+		code string
+		err  error
+	}
+	results := make(chan result, 1)
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("state") != state {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		var res result
+		if res.code = q.Get("code"); res.code == "" {
+			res.err = fmt.Errorf("Google said %s", cmp.Or(q.Get("error"), "no"))
+			fmt.Fprintln(w, "Not signed in:", res.err)
+		} else {
+			fmt.Fprintln(w, "Signed in; worklog can use Google Calendar now. You can close this tab.")
+		}
+		select {
+		case results <- res:
+		default:
+		}
+	})}
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	fmt.Println("🔑 Open this page to let worklog add events to your Google Calendar:")
+	fmt.Println("   " + consent)
+	var res result
+	select {
+	case res = <-results:
+	case <-time.After(gcalLoginTimeout):
+		return fmt.Errorf("no answer from Google within %s", formatDuration("footer", gcalLoginTimeout))
+	}
+	if res.err != nil {
+		return res.err
+	}
+	token, err := gcalExchange(cfg, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {res.code},
+		"code_verifier": {verifier},
+		"redirect_uri":  {redirect},
+	})
+	if err != nil {
+		return err
+	}
+	if token.RefreshToken == "" {
+		return errors.New("Google gave no refresh token; remove worklog's access in your Google account and log in again")
+	}
+	return saveGcalToken(token)
+}
+
+// gcalTarget pushes entries as events to a calendar of their own.
+type gcalTarget struct {
+	cfg    gcalConfig
+	client *http.Client
+	token  gcalToken
+}
+
+func newGcalTarget(cfg Config) (syncTarget, error) {
+	if cfg.Gcal.ClientID == "" || cfg.Gcal.ClientSecret == "" {
+		return nil, errors.New("gcal needs gcal.client_id and gcal.client_secret in the config")
+	}
+	token, ok, err := loadGcalToken()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("not signed in to Google; run gcal login first")
+	}
+	return &gcalTarget{cfg: cfg.Gcal, client: &http.Client{Timeout: syncHTTPTimeout}, token: token}, nil
+}
+
+// call makes an API request, refreshing the access token first when it
+// has run out.
+func (g *gcalTarget) call(method, path string, body, reply any) error {
+	if time.Until(g.token.Expiry) < time.Minute {
+		fresh, err := gcalExchange(g.cfg, url.Values{"grant_type": {"refresh_token"}, "refresh_token": {g.token.RefreshToken}})
+		if err != nil {
+			return fmt.Errorf("%v; run gcal login again", err)
+		}
+		g.token.AccessToken, g.token.RefreshToken, g.token.Expiry = fresh.AccessToken, fresh.RefreshToken, fresh.Expiry
+		if err := saveGcalToken(g.token); err != nil {
+			return err
+		}
+	}
+	auth := func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+g.token.AccessToken) }
+	return sendJSONAuth(g.client, method, gcalAPI+path, auth, body, reply)
+}
+
+// calendarID finds the configured calendar among the account's, or
+// makes it, and remembers its ID.
+func (g *gcalTarget) calendarID() (string, error) {
+	if g.token.CalendarID != "" && g.token.Calendar == g.cfg.Calendar {
+		return g.token.CalendarID, nil
+	}
+	type calendar struct {
+		ID      string `json:"id"`
+		Summary string `json:"summary"`
+	}
+	id := ""
+	for page := ""; ; {
+		var list struct {
+			Items []calendar `json:"items"`
+			Next  string     `json:"nextPageToken"`
+		}
+		if err := g.call(http.MethodGet, "/users/me/calendarList?pageToken="+url.QueryEscape(page), nil, &list); err != nil {
+			return "", err
+		}
+		for _, c := range list.Items {
+			if c.Summary == g.cfg.Calendar {
+				id = c.ID
+			}
+		}
+		if id != "" || list.Next == "" {
+			break
+		}
+		page = list.Next
+	}
+	if id == "" {
+		var made calendar
+		if err := g.call(http.MethodPost, "/calendars", map[string]string{"summary": g.cfg.Calendar}, &made); err != nil {
+			return "", err
+		}
+		fmt.Printf("📅 Made the %s calendar\n", g.cfg.Calendar)
+		id = made.ID
+	}
+	g.token.Calendar, g.token.CalendarID = g.cfg.Calendar, id
+	return id, saveGcalToken(g.token)
+}
+
+func (*gcalTarget) name() string { return "gcal" }
+
+// accepts leaves out entries logged without a start time, which have
+// no place on a calendar.
+func (*gcalTarget) accepts(e TaskEntry) bool { return !e.Start.IsZero() }
+
+// push creates the entry's event, from its start to its end with the
+// pauses in it, or updates the one made before.
+func (g *gcalTarget) push(date string, e TaskEntry, remoteID string) (string, error) {
+	calendar, err := g.calendarID()
+	if err != nil {
+		return "", err
+	}
+	description := e.Notes
+	if len(e.Tags) > 0 {
+		description = append([]string{"#" + strings.Join(e.Tags, " #")}, description...)
+	}
+	event := map[string]any{
+		"summary":      e.Project + " · " + e.Task,
+		"description":  strings.Join(description, "\n"),
+		"start":        map[string]string{"dateTime": e.Start.Format(time.RFC3339)},
+		"end":          map[string]string{"dateTime": entryEnd(e).Format(time.RFC3339)},
+		"transparency": "transparent",
+	}
+	method, path := http.MethodPost, "/calendars/"+url.PathEscape(calendar)+"/events"
+	if remoteID != "" {
+		method, path = http.MethodPut, path+"/"+url.PathEscape(remoteID)
+	}
+	var reply struct {
+		ID string `json:"id"`
+	}
+	if err := g.call(method, path, event, &reply); err != nil {
+		return "", err
+	}
+	return reply.ID, nil
+}
+
+// remove deletes an event.
+func (g *gcalTarget) remove(remoteID string) error {
+	calendar, err := g.calendarID()
+	if err != nil {
+		return err
+	}
+	return g.call(http.MethodDelete, "/calendars/"+url.PathEscape(calendar)+"/events/"+url.PathEscape(remoteID), nil, nil)
+}
+
+// gcalCommand signs in to Google for sync gcal, or out again.
+func gcalCommand(args []string) error {
+	if len(args) != 1 || args[0] != "login" && args[0] != "logout" {
+		return usageErrorf("usage: gcal login | gcal logout")
+	}
+	if args[0] == "logout" {
+		path, err := gcalTokenPath()
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		fmt.Println("👋 Signed out of Google; the tokens are gone from", filepath.Dir(path))
+		return nil
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return withCode(exitUsage, err)
+	}
+	if cfg.Gcal.ClientID == "" || cfg.Gcal.ClientSecret == "" {
+		return usageErrorf("gcal needs gcal.client_id and gcal.client_secret in the config")
+	}
+	if err := gcalLogin(cfg.Gcal); err != nil {
+		return err
+	}
+	fmt.Println("✅ Signed in to Google; sync gcal adds the day's entries to the", cfg.Gcal.Calendar, "calendar")
+	return nil
+}
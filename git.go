@@ -0,0 +1,26 @@
+package main
+
+import "path/filepath"
+
+// gitTask names the work by the git repository the tracker runs in, as
+// "feature/login-flow @ myrepo". It is empty outside a repository and
+// on a detached HEAD.
+func gitTask() string {
+	branch, err := runOutput("git", "symbolic-ref", "--quiet", "--short", "HEAD")
+	if err != nil || branch == "" {
+		return ""
+	}
+	top, err := runOutput("git", "rev-parse", "--show-toplevel")
+	if err != nil || top == "" {
+		return branch
+	}
+	return branch + " @ " + filepath.Base(top)
+}
+
+// branchTask is gitTask when the tracker suggests it.
+func (t *tracker) branchTask() string {
+	if !t.gitTask {
+		return ""
+	}
+	return gitTask()
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// githubAPI is GitHub's REST API.
+var githubAPI = "https://api.github.com"
+
+// githubConfig is the github section of the config.
+type githubConfig struct {
+	Token   string // a token that may read issues, and comment on them for sync github
+	Repo    string // owner/name for bare #123; default: the origin of the repository worked in
+	Link    bool   // look up the issues tasks name when their entry closes
+	Comment bool   // comment the time spent on the issue when a session ends
+}
+
+// github is the github section of the config, set at startup.
+var github githubConfig
+
+var (
+	githubIssueURL = regexp.MustCompile(`https://github\.com/([\w.-]+/[\w.-]+)/(?:issues|pull)/(\d+)\b`)
+	githubIssueRef = regexp.MustCompile(`(?:^|\s)([\w.-]+/[\w.-]+)?#(\d+)\b`)
+	githubRemote   = regexp.MustCompile(`github\.com[:/]([\w.-]+/[\w.-]+?)(?:\.git)?$`)
+)
+
+// githubIssue is an issue or pull request, which share their numbers.
+type githubIssue struct {
+	Repo   string
+	Number int
+}
+
+func (i githubIssue) String() string { return fmt.Sprintf("%s#%d", i.Repo, i.Number) }
+
+// githubRepo is the repository bare #123 references are in.
+func githubRepo() string {
+	if github.Repo != "" {
+		return github.Repo
+	}
+	origin, err := runOutput("git", "remote", "get-url", "origin")
+	if err != nil {
+		return ""
+	}
+	if m := githubRemote.FindStringSubmatch(origin); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// findGitHubIssue finds the issue text names: a link to it, owner/name#123,
+// or #123 in the repository of githubRepo.
+func findGitHubIssue(text string) (githubIssue, bool) {
+	m := githubIssueURL.FindStringSubmatch(text)
+	if m == nil {
+		if m = githubIssueRef.FindStringSubmatch(text); m == nil {
+			return githubIssue{}, false
+		}
+		if m[1] == "" {
+			if m[1] = githubRepo(); m[1] == "" {
+				return githubIssue{}, false
+			}
+		}
+	}
+	n, err := strconv.Atoi(m[2])
+	return githubIssue{Repo: m[1], Number: n}, err == nil
+}
+
+// entryGitHubIssue is the issue an entry is linked to, by the link
+// linkGitHub attached to it.
+func entryGitHubIssue(e TaskEntry) (githubIssue, bool) {
+	for _, a := range e.Attachments {
+		if m := githubIssueURL.FindStringSubmatch(a); m != nil && m[0] == a {
+			return findGitHubIssue(a)
+		}
+	}
+	return githubIssue{}, false
+}
+
+func githubCall(client *http.Client, method, path string, body, reply any) error {
+	auth := func(req *http.Request) {
+		req.Header.Set("Accept", "application/vnd.github+json")
+		req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+		if github.Token != "" {
+			req.Header.Set("Authorization", "Bearer "+github.Token)
+		}
+	}
+	return sendJSONAuth(client, method, githubAPI+path, auth, body, reply)
+}
+
+// linkGitHub ties an entry whose task names a GitHub issue to it: the
+// issue's page is attached, a link in the task shortens to owner/name#123
+// and a task that is nothing but the reference gets the issue's title.
+// Failures only warn; the task keeps the reference.
+func linkGitHub(e *TaskEntry) {
+	if !github.Link {
+		return
+	}
+	if _, ok := entryGitHubIssue(*e); ok {
+		return
+	}
+	issue, ok := findGitHubIssue(e.Task)
+	if !ok {
+		return
+	}
+	var reply struct {
+		Title string `json:"title"`
+		URL   string `json:"html_url"`
+	}
+	client := &http.Client{Timeout: syncHTTPTimeout}
+	if err := githubCall(client, http.MethodGet, fmt.Sprintf("/repos/%s/issues/%d", issue.Repo, issue.Number), nil, &reply); err != nil {
+		fmt.Printf("⚠️  Could not look up %s on GitHub: %v\n", issue, err)
+		return
+	}
+	e.Task = githubIssueURL.ReplaceAllString(e.Task, issue.String())
+	if strings.TrimSpace(githubIssueRef.ReplaceAllString(e.Task, "")) == "" {
+		e.Task = strings.TrimSpace(e.Task) + " " + reply.Title
+	}
+	e.Attachments = append(e.Attachments, reply.URL)
+	fmt.Printf("🐙 %s: %s\n", issue, reply.Title)
+}
+
+// githubTarget comments the time spent on the issues entries are linked
+// to.
+type githubTarget struct {
+	client *http.Client
+}
+
+func newGitHubTarget(Config) (syncTarget, error) {
+	if github.Token == "" {
+		return nil, errors.New("github needs github.token in the config")
+	}
+	return githubTarget{client: &http.Client{Timeout: syncHTTPTimeout}}, nil
+}
+
+func (githubTarget) name() string { return "github" }
+
+func (githubTarget) accepts(e TaskEntry) bool {
+	_, ok := entryGitHubIssue(e)
+	return ok
+}
+
+// push comments on the entry's issue, or edits the comment made before.
+// Remote IDs are owner/name#123/COMMENT.
+func (g githubTarget) push(date string, e TaskEntry, remoteID string) (string, error) {
+	issue, _ := entryGitHubIssue(e)
+	body := map[string]string{"body": fmt.Sprintf("⏱️ %s on %s: %s", formatDuration("summary", e.Duration.Round(time.Minute)), date, e.Task)}
+	method, path := http.MethodPost, fmt.Sprintf("/repos/%s/issues/%d/comments", issue.Repo, issue.Number)
+	if i := strings.LastIndex(remoteID, "/"); i >= 0 && remoteID[:i] == issue.String() {
+		method, path = http.MethodPatch, fmt.Sprintf("/repos/%s/issues/comments/%s", issue.Repo, remoteID[i+1:])
+	}
+	var reply struct {
+		ID int64 `json:"id"`
+	}
+	if err := githubCall(g.client, method, path, body, &reply); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/%d", issue, reply.ID), nil
+}
+
+// remove deletes the comment.
+func (g githubTarget) remove(remoteID string) error {
+	repo, _, _ := strings.Cut(remoteID, "#")
+	i := strings.LastIndex(remoteID, "/")
+	if repo == remoteID || i < len(repo) {
+		return fmt.Errorf("%q is not owner/name#123/COMMENT", remoteID)
+	}
+	return githubCall(g.client, http.MethodDelete, fmt.Sprintf("/repos/%s/issues/comments/%s", repo, remoteID[i+1:]), nil, nil)
+}
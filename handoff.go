@@ -0,0 +1,294 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// handoffNote marks entries that came from a teammate's export.
+const handoffNote = "paired: imported from handoff"
+
+// handoffFile is the portable form of one day's entries.
+type handoffFile struct {
+	Version int            `json:"version"`
+	Date    string         `json:"date"`
+	Entries []handoffEntry `json:"entries"`
+}
+
+type handoffEntry struct {
+	Task        string         `json:"task"`
+	Project     string         `json:"project"`
+	Start       *time.Time     `json:"start,omitempty"` // unknown for entries read back from the logs
+	End         *time.Time     `json:"end,omitempty"`
+	Duration    string         `json:"duration"`
+	Pauses      []handoffPause `json:"pauses,omitempty"`
+	Notes       []string       `json:"notes,omitempty"`
+	Attachments []string       `json:"attachments,omitempty"`
+	Tags        []string       `json:"tags,omitempty"`
+	Billable    bool           `json:"billable,omitempty"`
+	Rate        string         `json:"rate,omitempty"`
+	Estimate    string         `json:"estimate,omitempty"`
+
+	Interruptions []handoffInterruption `json:"interruptions,omitempty"`
+}
+
+type handoffInterruption struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	By    string    `json:"by"`
+	Note  string    `json:"note,omitempty"`
+}
+
+type handoffPause struct {
+	Duration string     `json:"duration"`
+	Reason   string     `json:"reason,omitempty"`
+	Start    *time.Time `json:"start,omitempty"`
+	End      *time.Time `json:"end,omitempty"`
+}
+
+func handoffCommand(args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "export":
+			return handoffExport(args[1:])
+		case "import":
+			return handoffImport(args[1:])
+		}
+	}
+	return usageErrorf("usage: handoff export [--date DAY] | handoff import FILE [--as-project P] [--split PERCENT]")
+}
+
+func handoffExport(args []string) error {
+	fs := newFlagSet("handoff export")
+	day := fs.String("date", "today", "Day to export: today, yesterday or YYYY-MM-DD")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	date, err := parseDay(*day)
+	if err != nil {
+		return err
+	}
+
+	var entries []TaskEntry
+	if startOfDay(time.Now()).Equal(date) {
+		info, err := currentStatus(time.Now())
+		if err != nil {
+			return err
+		}
+		entries = info.Entries
+	} else if entries, err = writtenEntries(date); err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return withCode(exitEmpty, fmt.Errorf("no entries on %s", date.Format(dateLayout)))
+	}
+
+	out := handoffFile{Version: 1, Date: date.Format(dateLayout)}
+	for _, e := range entries {
+		h := handoffEntry{Task: e.Task, Project: e.Project, Duration: e.Duration.String(), Notes: e.Notes, Attachments: e.Attachments, Tags: e.Tags, Billable: e.Billable}
+		if !e.Rate.isZero() {
+			h.Rate = e.Rate.String()
+		}
+		if e.Estimate > 0 {
+			h.Estimate = e.Estimate.String()
+		}
+		for _, i := range e.Interruptions {
+			h.Interruptions = append(h.Interruptions, handoffInterruption{Start: i.Start, End: i.End, By: i.By, Note: i.Note})
+		}
+		if !e.Start.IsZero() {
+			start, end := e.Start, entryEnd(e)
+			h.Start, h.End = &start, &end
+		}
+		for _, p := range e.Pauses {
+			hp := handoffPause{Duration: p.Duration().String(), Reason: p.Reason}
+			if !p.Start.IsZero() {
+				start, end := p.Start, p.End
+				hp.Start, hp.End = &start, &end
+			}
+			h.Pauses = append(h.Pauses, hp)
+		}
+		out.Entries = append(out.Entries, h)
+	}
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+func handoffImport(args []string) error {
+	fs := newFlagSet("handoff import")
+	asProject := projectFlag(fs, "as-project", "", "Project to file the entries under (default: as exported)")
+	share := fs.Int("split", 100, "Percentage of each duration to keep, e.g. 50 when pairing")
+	preview := fs.Bool("preview", false, "Show the changes and ask before writing them")
+	var path string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		path, args = args[0], args[1:]
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if path == "" && fs.NArg() == 1 {
+		path = fs.Arg(0)
+	}
+	if path == "" {
+		return usageErrorf("handoff import needs the exported file")
+	}
+	if *share < 1 || *share > 100 {
+		return usageErrorf("--split must be between 1 and 100, got %d", *share)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var in handoffFile
+	if err := json.Unmarshal(data, &in); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	if in.Version != 1 {
+		return fmt.Errorf("%s: unsupported handoff version %d", path, in.Version)
+	}
+	date, err := time.ParseInLocation(dateLayout, in.Date, time.Local)
+	if err != nil {
+		return fmt.Errorf("%s: bad date %q", path, in.Date)
+	}
+	if startOfDay(time.Now()).Equal(date) && instanceRunning() {
+		return withCode(exitLocked, errors.New("today is being tracked; import after finishing the day"))
+	}
+
+	var order []string
+	byProject := map[string][]TaskEntry{}
+	for _, h := range in.Entries {
+		e, err := h.entry()
+		if err != nil {
+			return fmt.Errorf("%s: %q: %v", path, h.Task, err)
+		}
+		if *asProject != "" {
+			e.Project = *asProject
+		}
+		if e.Project == "" {
+			return usageErrorf("%s: %q has no project; pass --as-project", path, h.Task)
+		}
+		e.Duration = (e.Duration * time.Duration(*share) / 100).Round(time.Second)
+		e.Notes = append(e.Notes, handoffNote)
+		if _, ok := byProject[e.Project]; !ok {
+			order = append(order, e.Project)
+		}
+		byProject[e.Project] = append(byProject[e.Project], e)
+	}
+	for _, project := range order {
+		if err := importEntries(project, date, byProject[project], *preview); err != nil {
+			return err
+		}
+	}
+	day, err := writtenEntries(date)
+	if err != nil || !overCeiling(day) {
+		return err
+	}
+	for _, line := range longDayWarning(day) {
+		fmt.Println(line)
+	}
+	return flagDay(date, needsReviewKey)
+}
+
+func (h handoffEntry) entry() (TaskEntry, error) {
+	e := TaskEntry{Task: h.Task, Project: h.Project, Notes: h.Notes, Attachments: h.Attachments, Tags: h.Tags, Billable: h.Billable}
+	var err error
+	if e.Duration, err = time.ParseDuration(h.Duration); err != nil {
+		return e, err
+	}
+	if h.Rate != "" {
+		if e.Rate, err = parseRate(h.Rate, defaultCurrency); err != nil {
+			return e, err
+		}
+	}
+	if h.Estimate != "" {
+		if e.Estimate, err = time.ParseDuration(h.Estimate); err != nil {
+			return e, err
+		}
+	}
+	for _, i := range h.Interruptions {
+		e.Interruptions = append(e.Interruptions, Interruption{Start: i.Start, End: i.End, By: i.By, Note: i.Note})
+	}
+	if h.Start != nil {
+		e.Start = *h.Start
+	}
+	for _, p := range h.Pauses {
+		d, err := time.ParseDuration(p.Duration)
+		if err != nil {
+			return e, err
+		}
+		start := e.Start
+		if p.Start != nil {
+			start = *p.Start
+		}
+		e.Pauses = append(e.Pauses, Pause{Start: start, End: start.Add(d), Reason: p.Reason})
+	}
+	return e, nil
+}
+
+// importEntries merges entries into project's log for date. Entries
+// imported before are left out, so importing the same file twice is
+// harmless. With preview the change is shown and confirmed first.
+func importEntries(project string, date time.Time, entries []TaskEntry, preview bool) error {
+	dir, err := logDir()
+	if err != nil {
+		return err
+	}
+	weekly := logLayout == "weekly"
+	name := dailyFilename(project, date)
+	if weekly {
+		name = weeklyFilename(project, date)
+	}
+	path := filepath.Join(dir, name)
+	existing, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	seen := map[string]bool{}
+	for _, e := range parseLog(existing)[date.Format(dateLayout)] {
+		seen[e.Task+"\x00"+e.Duration.String()] = true
+	}
+	var fresh []TaskEntry
+	for _, e := range entries {
+		if !seen[e.Task+"\x00"+e.Duration.String()] {
+			fresh = append(fresh, e)
+		}
+	}
+	if len(fresh) == 0 {
+		fmt.Printf("👌 %s already has these entries\n", path)
+		return nil
+	}
+
+	var content []byte
+	if weekly {
+		content = renderWeek(project, date, fresh, nil, false)
+	} else {
+		content = renderMarkdown(project, date, fresh)
+	}
+	if existing != nil {
+		content = mergeLogs(existing, content, weekly)
+	}
+	if preview {
+		printDiffLines(unifiedDiff(path, path, existing, content))
+		if !confirmWrite(1) {
+			return nil
+		}
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+	if err := writeLog(path, content, fresh...); err != nil {
+		return err
+	}
+	fmt.Printf("✅ Added %d entries to %s\n", len(fresh), path)
+	return nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// pairedDay is a day with every field a handoff carries.
+func pairedDay() []TaskEntry {
+	return []TaskEntry{
+		{Task: "pairing on importer", Project: "League", Start: at("09:00"), Duration: 80 * time.Minute,
+			Pauses:        []Pause{{Start: at("09:30"), End: at("09:40"), Reason: "coffee"}},
+			Notes:         []string{"found the off-by-one"},
+			Attachments:   []string{"https://example.com/pr/12"},
+			Tags:          []string{"pairing", "deep-work"},
+			Billable:      true,
+			Rate:          rate{Cents: 9500, Currency: "EUR"},
+			Estimate:      time.Hour,
+			Interruptions: []Interruption{{Start: at("10:00"), End: at("10:05"), By: "Sam", Note: "deploy question"}}},
+		{Task: "review", Project: "League", Start: at("11:00"), Duration: 25 * time.Minute},
+	}
+}
+
+// exportDay logs entries in a fresh home and exports the day.
+func exportDay(t *testing.T, entries []TaskEntry) []byte {
+	t.Helper()
+	config, logs := useTempDirs(t)
+	writeConfig(t, config, "log_dir: "+logs+"\n")
+	writeDay(t, logs, "League", at("00:00"), entries)
+	code, out := runMain(t, filepath.Dir(logs), "", "handoff", "export", "--date", "2024-03-01")
+	if code != exitOK {
+		t.Fatalf("export: exit %d:\n%s", code, out)
+	}
+	return []byte(out)
+}
+
+// importDay imports an export into a fresh home and returns the
+// entries logged there.
+func importDay(t *testing.T, export []byte, args ...string) []TaskEntry {
+	t.Helper()
+	config, logs := useTempDirs(t)
+	writeConfig(t, config, "log_dir: "+logs+"\n")
+	home := filepath.Dir(logs)
+	file := filepath.Join(home, "handoff.json")
+	if err := os.WriteFile(file, export, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	code, out := runMain(t, home, "", append([]string{"handoff", "import", file}, args...)...)
+	if code != exitOK {
+		t.Fatalf("import: exit %d:\n%s", code, out)
+	}
+	entries, err := writtenEntries(at("00:00"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return entries
+}
+
+func TestHandoffRoundTrip(t *testing.T) {
+	got := importDay(t, exportDay(t, pairedDay()))
+	want := pairedDay()
+	for i := range want {
+		want[i].Notes = append(want[i].Notes, handoffNote)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("imported\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestHandoffImportOptions(t *testing.T) {
+	got := importDay(t, exportDay(t, pairedDay()), "--as-project", "Consulting", "--split", "50")
+	if len(got) != 2 {
+		t.Fatalf("imported %d entries, want 2", len(got))
+	}
+	for i, e := range got {
+		if e.Project != "Consulting" {
+			t.Errorf("entry %d filed under %q", i, e.Project)
+		}
+		if want := pairedDay()[i].Duration / 2; e.Duration != want {
+			t.Errorf("entry %d lasts %s, want %s", i, e.Duration, want)
+		}
+	}
+}
+
+func TestHandoffImportTwice(t *testing.T) {
+	export := exportDay(t, pairedDay())
+	config, logs := useTempDirs(t)
+	writeConfig(t, config, "log_dir: "+logs+"\n")
+	home := filepath.Dir(logs)
+	file := filepath.Join(home, "handoff.json")
+	if err := os.WriteFile(file, export, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runMain(t, home, "", "handoff", "import", file)
+	code, out := runMain(t, home, "", "handoff", "import", file)
+	if code != exitOK || !strings.Contains(out, "already has these entries") {
+		t.Errorf("second import: exit %d:\n%s", code, out)
+	}
+	if entries, _ := writtenEntries(at("00:00")); len(entries) != 2 {
+		t.Errorf("%d entries after importing twice, want 2", len(entries))
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// harvestAPI is Harvest's v2 API.
+var harvestAPI = "https://api.harvestapp.com/v2"
+
+// harvestConfig is the harvest section of the config.
+type harvestConfig struct {
+	Token   string // personal access token from id.getharvest.com/developers
+	Account string // the account ID shown next to the token
+	Task    string // task to log under when a project has several and none is mapped
+	// Projects maps local project names to Harvest's where they differ,
+	// and Tasks local projects to the Harvest task their time goes to.
+	Projects map[string]string
+	Tasks    map[string]string
+}
+
+func (c harvestConfig) remoteProject(local string) string {
+	if name, ok := c.Projects[local]; ok {
+		return name
+	}
+	return local
+}
+
+// harvestProject is a project the user may log time to, with its
+// tasks' IDs by name.
+type harvestProject struct {
+	ID    int64
+	Tasks map[string]int64
+}
+
+// harvestTarget pushes entries to Harvest as time entries; the user's
+// project and task assignments are looked up on first use.
+type harvestTarget struct {
+	cfg      harvestConfig
+	client   *http.Client
+	projects map[string]harvestProject // by lowercased Harvest name
+	loadErr  error
+}
+
+func newHarvestTarget(cfg Config) (syncTarget, error) {
+	if cfg.Harvest.Token == "" || cfg.Harvest.Account == "" {
+		return nil, errors.New("harvest needs harvest.token and harvest.account in the config")
+	}
+	return &harvestTarget{cfg: cfg.Harvest, client: &http.Client{Timeout: syncHTTPTimeout}}, nil
+}
+
+func (h *harvestTarget) call(method, path string, body, reply any) error {
+	auth := func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer "+h.cfg.Token)
+		req.Header.Set("Harvest-Account-Id", h.cfg.Account)
+		req.Header.Set("User-Agent", "worklog")
+	}
+	return sendJSONAuth(h.client, method, harvestAPI+path, auth, body, reply)
+}
+
+// loadProjects learns the projects and tasks the user is assigned to.
+func (h *harvestTarget) loadProjects() error {
+	if h.projects != nil || h.loadErr != nil {
+		return h.loadErr
+	}
+	projects := map[string]harvestProject{}
+	for page := 1; page > 0; {
+		var reply struct {
+			Assignments []struct {
+				Project struct {
+					ID   int64  `json:"id"`
+					Name string `json:"name"`
+				} `json:"project"`
+				Tasks []struct {
+					Task struct {
+						ID   int64  `json:"id"`
+						Name string `json:"name"`
+					} `json:"task"`
+				} `json:"task_assignments"`
+			} `json:"project_assignments"`
+			NextPage *int `json:"next_page"`
+		}
+		if h.loadErr = h.call(http.MethodGet, fmt.Sprintf("/users/me/project_assignments?page=%d", page), nil, &reply); h.loadErr != nil {
+			return h.loadErr
+		}
+		for _, a := range reply.Assignments {
+			p := harvestProject{ID: a.Project.ID, Tasks: map[string]int64{}}
+			for _, t := range a.Tasks {
+				p.Tasks[strings.ToLower(t.Task.Name)] = t.Task.ID
+			}
+			projects[strings.ToLower(a.Project.Name)] = p
+		}
+		if page = 0; reply.NextPage != nil {
+			page = *reply.NextPage
+		}
+	}
+	h.projects = projects
+	return nil
+}
+
+func (*harvestTarget) name() string { return "harvest" }
+
+// accepts takes the entries of projects Harvest has for the user. When
+// the lookup fails it takes all, so push reports why.
+func (h *harvestTarget) accepts(e TaskEntry) bool {
+	if h.loadProjects() != nil {
+		return true
+	}
+	_, ok := h.projects[strings.ToLower(h.cfg.remoteProject(e.Project))]
+	return ok
+}
+
+// task picks the Harvest task of an entry of p: the one mapped to its
+// project, else harvest.task, else the project's only one.
+func (h *harvestTarget) task(p harvestProject, project string) (int64, error) {
+	name := h.cfg.Tasks[project]
+	if name == "" {
+		name = h.cfg.Task
+	}
+	if name != "" {
+		if id, ok := p.Tasks[strings.ToLower(name)]; ok {
+			return id, nil
+		}
+		return 0, fmt.Errorf("Harvest has no task %q in %s", name, h.cfg.remoteProject(project))
+	}
+	if len(p.Tasks) == 1 {
+		for _, id := range p.Tasks {
+			return id, nil
+		}
+	}
+	return 0, fmt.Errorf("%s has %d tasks in Harvest; pick one with harvest_tasks.%s or harvest.task", h.cfg.remoteProject(project), len(p.Tasks), project)
+}
+
+// push creates a time entry on the entry's day, in hours, or updates
+// the one made before.
+func (h *harvestTarget) push(date string, e TaskEntry, remoteID string) (string, error) {
+	if err := h.loadProjects(); err != nil {
+		return "", err
+	}
+	p := h.projects[strings.ToLower(h.cfg.remoteProject(e.Project))]
+	task, err := h.task(p, e.Project)
+	if err != nil {
+		return "", err
+	}
+	notes := e.Task
+	if len(e.Tags) > 0 {
+		notes += " #" + strings.Join(e.Tags, " #")
+	}
+	body := map[string]any{
+		"project_id": p.ID,
+		"task_id":    task,
+		"spent_date": date,
+		"hours":      math.Round(e.Duration.Hours()*100) / 100,
+		"notes":      notes,
+	}
+	method, path := http.MethodPost, "/time_entries"
+	if remoteID != "" {
+		method, path = http.MethodPatch, path+"/"+remoteID
+	}
+	var reply struct {
+		ID int64 `json:"id"`
+	}
+	if err := h.call(method, path, body, &reply); err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(reply.ID, 10), nil
+}
+
+// remove deletes a time entry.
+func (h *harvestTarget) remove(remoteID string) error {
+	return h.call(http.MethodDelete, "/time_entries/"+remoteID, nil, nil)
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// heatShades go from nothing tracked to the busiest days; heatASCII is
+// the same for -ascii.
+var (
+	heatShades = []string{"·", "░", "▒", "▓", "█"}
+	heatASCII  = []string{".", ":", "+", "*", "#"}
+)
+
+// heatLevel sorts a day's total into one of four levels by the thirds of
+// the target, or of the busiest day without a target; 0 is nothing.
+func heatLevel(d, scale time.Duration) int {
+	switch {
+	case d <= 0:
+		return 0
+	case scale <= 0 || d >= scale:
+		return 4
+	}
+	return 1 + int(3*d/scale)
+}
+
+// printHeatmap draws a year of totals with a column per week, Monday at
+// the top, and the months above the weeks they start in.
+func printHeatmap(year int, totals map[string]time.Duration, scale time.Duration) {
+	shades := heatShades
+	if asciiMode {
+		shades = heatASCII
+	}
+	first := time.Date(year, time.January, 1, 0, 0, 0, 0, time.Local)
+	last := time.Date(year, time.December, 31, 0, 0, 0, 0, time.Local)
+	start, _ := weekOf(first)
+
+	var months strings.Builder
+	months.WriteString("     ")
+	rows := make([]strings.Builder, 7)
+	for i, name := range []string{"Mon", "", "Wed", "", "Fri", "", "Sun"} {
+		fmt.Fprintf(&rows[i], "%-4s ", name)
+	}
+	labelled := time.Month(0)
+	for week := start; !week.After(last); week = week.AddDate(0, 0, 7) {
+		label := " "
+		for d := 0; d < 7; d++ {
+			day := week.AddDate(0, 0, d)
+			if day.Year() != year {
+				rows[d].WriteString(" ")
+				continue
+			}
+			if day.Day() == 1 || day.Equal(first) {
+				if day.Month() != labelled {
+					labelled = day.Month()
+					label = day.Month().String()[:1]
+				}
+			}
+			rows[d].WriteString(shades[heatLevel(totals[day.Format(dateLayout)], scale)])
+		}
+		months.WriteString(label)
+	}
+	fmt.Println(months.String())
+	for i := range rows {
+		fmt.Println(rows[i].String())
+	}
+	legend := "     less "
+	for _, s := range shades {
+		legend += s
+	}
+	fmt.Println(legend + " more")
+}
+
+func heatmapCommand(args []string) error {
+	fs := newFlagSet("heatmap")
+	yearFlag := fs.Int("year", time.Now().Year(), "Year to draw")
+	project := projectFlag(fs, "project", "", "Only include this project")
+	asJSON := jsonFlag(fs, "Print the total of each day worked as JSON")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return withCode(exitUsage, err)
+	}
+	from := time.Date(*yearFlag, time.January, 1, 0, 0, 0, 0, time.Local)
+	to := time.Date(*yearFlag, time.December, 31, 0, 0, 0, 0, time.Local)
+	days, err := dayEntries(from, to)
+	if err != nil {
+		return err
+	}
+	totals := map[string]time.Duration{}
+	var sum, busiest time.Duration
+	worked := 0
+	for date, entries := range days {
+		for _, e := range entries {
+			if *project == "" || e.Project == *project {
+				totals[date] += e.Duration
+			}
+		}
+		if d := totals[date]; d > 0 {
+			sum += d
+			worked++
+			busiest = max(busiest, d)
+		}
+	}
+	if worked == 0 {
+		return withCode(exitEmpty, errors.New("nothing tracked in "+fmt.Sprint(*yearFlag)))
+	}
+	if *asJSON {
+		perDay := map[string]string{}
+		for date, d := range totals {
+			if d > 0 {
+				perDay[date] = formatDuration("json", d)
+			}
+		}
+		return printJSON(os.Stdout, struct {
+			Year       int               `json:"year"`
+			Total      string            `json:"total"`
+			DaysWorked int               `json:"days_worked"`
+			Busiest    string            `json:"busiest"`
+			Days       map[string]string `json:"days"`
+		}{*yearFlag, formatDuration("json", sum), worked, formatDuration("json", busiest), perDay})
+	}
+	scale := cfg.Target
+	if scale <= 0 || *project != "" {
+		scale = busiest
+	}
+	fmt.Printf("🗓️  %d · %s over %d days\n\n", *yearFlag, formatDuration("summary", sum), worked)
+	printHeatmap(*yearFlag, totals, scale)
+	return nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// useTempDirs points the config directory and the logs at fresh
+// temporary directories for the length of the test.
+func useTempDirs(t testing.TB) (config, logs string) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	logs = filepath.Join(home, "logs")
+	saved := outputDir
+	outputDir = logs
+	t.Cleanup(func() { outputDir = saved })
+	config, err := appDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return config, logs
+}
+
+// fakeClock is a clock tests move by hand.
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+// at is 2024-03-01 (a Friday) at the given local clock time.
+func at(clock string) time.Time {
+	t, err := time.ParseInLocation("2006-01-02 15:04", "2024-03-01 "+clock, time.Local)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// TestMain lets tests run the tool itself: with WORKLOG_TEST_MAIN set,
+// the test binary is the worklog command.
+func TestMain(m *testing.M) {
+	if os.Getenv("WORKLOG_TEST_MAIN") != "" {
+		os.Args = append([]string{"worklog"}, os.Args[1:]...)
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runMain runs the tool with args and stdin in home, a directory from
+// useTempDirs, and returns its exit code and output.
+func runMain(t *testing.T, home, stdin string, args ...string) (int, string) {
+	t.Helper()
+	return runMainInput(t, home, strings.NewReader(stdin), args...)
+}
+
+// runMainInput is runMain reading stdin from r.
+func runMainInput(t *testing.T, home string, r io.Reader, args ...string) (int, string) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(),
+		"WORKLOG_TEST_MAIN=1",
+		"HOME="+home,
+		"XDG_CONFIG_HOME="+filepath.Join(home, ".config"),
+		"TMPDIR="+home,
+		"NO_COLOR=1",
+	)
+	cmd.Dir = home
+	cmd.Stdin = r
+	out, err := cmd.CombinedOutput()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		t.Fatal(err)
+	}
+	return cmd.ProcessState.ExitCode(), string(out)
+}
+
+// writeConfig writes config.yaml into the config directory.
+func writeConfig(t *testing.T, dir, content string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// typed is one line of input typed after a delay.
+type typed struct {
+	after time.Duration
+	line  string
+}
+
+// typist feeds its lines to a process one at a time, each after its
+// delay, like someone slowly answering prompts.
+type typist struct {
+	lines []typed
+	rest  []byte
+}
+
+func (t *typist) Read(p []byte) (int, error) {
+	if len(t.rest) == 0 {
+		if len(t.lines) == 0 {
+			return 0, io.EOF
+		}
+		time.Sleep(t.lines[0].after)
+		t.rest = []byte(t.lines[0].line + "\n")
+		t.lines = t.lines[1:]
+	}
+	n := copy(p, t.rest)
+	t.rest = t.rest[n:]
+	return n, nil
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	historyLimit  = 100
+	recentTaskMax = 5
+)
+
+func historyDir() (string, error) {
+	dir, err := appDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "history"), nil
+}
+
+func historyPath(project string) (string, error) {
+	dir, err := historyDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, safeName(project)+".txt"), nil
+}
+
+func readLines(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// loadHistory returns the project's task history, most recent first.
+func loadHistory(project string) []string {
+	path, err := historyPath(project)
+	if err != nil {
+		return nil
+	}
+	lines, _ := readLines(path)
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+	return lines
+}
+
+func saveHistory(project string, recent []string) error {
+	path, err := historyPath(project)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	if len(recent) > historyLimit {
+		recent = recent[:historyLimit]
+	}
+	var b strings.Builder
+	for i := len(recent) - 1; i >= 0; i-- {
+		b.WriteString(recent[i] + "\n")
+	}
+	return os.WriteFile(path, []byte(b.String()), 0o644)
+}
+
+// recordHistory moves task to the front of the project's history.
+func recordHistory(project, task string) {
+	if task == "" || task == autoClosedTask {
+		return
+	}
+	recent := []string{task}
+	for _, t := range loadHistory(project) {
+		if t != task {
+			recent = append(recent, t)
+		}
+	}
+	if err := saveHistory(project, recent); err != nil {
+		fmt.Println("❌ Could not save task history:", err)
+	}
+}
+
+// recentTasks returns up to n recent tasks of the project, topped up
+// from other projects' histories when the project has fewer than n.
+func recentTasks(project string, n int) []string {
+	recent := dedupe(loadHistory(project), nil, n)
+	if len(recent) >= n {
+		return recent
+	}
+	dir, err := historyDir()
+	if err != nil {
+		return recent
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.txt"))
+	own, _ := historyPath(project)
+	for _, f := range files {
+		if f == own {
+			continue
+		}
+		name := strings.TrimSuffix(filepath.Base(f), ".txt")
+		recent = dedupe(loadHistory(name), recent, n)
+	}
+	return recent
+}
+
+func dedupe(from, into []string, n int) []string {
+	seen := make(map[string]bool, len(into))
+	for _, t := range into {
+		seen[t] = true
+	}
+	for _, t := range from {
+		if len(into) >= n {
+			break
+		}
+		if !seen[t] {
+			seen[t] = true
+			into = append(into, t)
+		}
+	}
+	return into
+}
+
+// migrateHistory moves the old single history file into the active
+// project's history the first time the per-project layout is used.
+func migrateHistory(project string) {
+	base, err := appDir()
+	if err != nil {
+		return
+	}
+	legacy := filepath.Join(base, "history.txt")
+	old, err := readLines(legacy)
+	if err != nil {
+		return
+	}
+	dir, _ := historyDir()
+	if _, err := os.Stat(dir); err == nil {
+		return
+	}
+	for i, j := 0, len(old)-1; i < j; i, j = i+1, j-1 {
+		old[i], old[j] = old[j], old[i]
+	}
+	if err := saveHistory(project, dedupe(old, nil, historyLimit)); err != nil {
+		fmt.Println("❌ Could not migrate task history:", err)
+		return
+	}
+	os.Rename(legacy, legacy+".bak")
+	fmt.Printf("📦 Moved task history into project %s\n", project)
+}
+
+// pickTask resolves the answer to the task prompt: a number picks from
+// the listed tasks and a trailing Tab completes from the project history.
+func pickTask(answer string, recent []string, project string) string {
+	if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(recent) {
+		return recent[n-1]
+	}
+	if prefix, ok := strings.CutSuffix(answer, "\t"); ok {
+		prefix = strings.TrimSpace(prefix)
+		for _, t := range loadHistory(project) {
+			if strings.HasPrefix(strings.ToLower(t), strings.ToLower(prefix)) {
+				return t
+			}
+		}
+		return prefix
+	}
+	return strings.TrimSpace(answer)
+}
+
+// printChoices lists tasks under heading, numbered from first, for the
+// task prompt to pick from.
+func printChoices(heading string, tasks []string, first int) {
+	if len(tasks) == 0 {
+		return
+	}
+	fmt.Println(heading)
+	for i, t := range tasks {
+		fmt.Printf("  %d) %s\n", first+i, t)
+	}
+}
+
+// removeTask drops task from tasks.
+func removeTask(tasks []string, task string) []string {
+	kept := tasks[:0]
+	for _, t := range tasks {
+		if t != task {
+			kept = append(kept, t)
+		}
+	}
+	return kept
+}
+
+func historyCommand(args []string) error {
+	fs := newFlagSet("history")
+	project := projectFlag(fs, "project", defaultProject, "Name of the project")
+	failOnEmpty := fs.Bool("fail-on-empty", false, "Exit with code 4 when the history is empty")
+	asJSON := jsonFlag(fs, "Print the history as a JSON array")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	action := fs.Arg(0)
+	if action != "" {
+		if err := parseFlags(fs, fs.Args()[1:]); err != nil {
+			return err
+		}
+	}
+
+	switch action {
+	case "":
+		tasks := loadHistory(*project)
+		if len(tasks) == 0 && *failOnEmpty {
+			if !*asJSON {
+				fmt.Println("📭 No task history for", *project)
+			}
+			return errEmpty
+		}
+		if *asJSON {
+			return printJSON(os.Stdout, append([]string{}, tasks...))
+		}
+		if len(tasks) == 0 {
+			fmt.Println("📭 No task history for", *project)
+			return nil
+		}
+		for _, t := range tasks {
+			fmt.Println(t)
+		}
+		return nil
+	case "clear":
+		answer := strings.ToLower(inputPrompt(fmt.Sprintf("🗑️  Clear task history for %s? (yes/no): ", *project)))
+		if answer != "yes" && answer != "y" {
+			return nil
+		}
+		path, err := historyPath(*project)
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		fmt.Println("✅ Task history cleared for", *project)
+		return nil
+	default:
+		return usageErrorf("unknown history action %q", action)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+const icsTime = "20060102T150405Z"
+
+// icsText escapes text for an iCalendar property value.
+func icsText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
+
+// icsLine writes one content line, folded at 75 octets without
+// splitting a character.
+func icsLine(w *bufio.Writer, line string) {
+	for len(line) > 75 {
+		cut := 75
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		w.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+	}
+	w.WriteString(line + "\r\n")
+}
+
+// workedStretches splits an entry at its timed pauses, so the gaps show
+// up in the calendar.
+func workedStretches(e TaskEntry) [][2]time.Time {
+	var stretches [][2]time.Time
+	start := e.Start
+	for _, p := range e.Pauses {
+		if p.Start.IsZero() || p.Start.Before(start) {
+			continue
+		}
+		stretches = append(stretches, [2]time.Time{start, p.Start})
+		start = p.End
+	}
+	return append(stretches, [2]time.Time{start, entryEnd(e)})
+}
+
+// exportICS writes the entries as a calendar, one event per stretch
+// worked. Entries logged without a time cannot be placed and are left
+// out.
+func exportICS(rows []datedEntry) error {
+	w := bufio.NewWriter(os.Stdout)
+	stamp := time.Now().UTC().Format(icsTime)
+	icsLine(w, "BEGIN:VCALENDAR")
+	icsLine(w, "VERSION:2.0")
+	icsLine(w, "PRODID:-//track-worklogs//worklog//EN")
+	icsLine(w, "CALSCALE:GREGORIAN")
+	skipped := 0
+	for _, r := range rows {
+		if r.Start.IsZero() {
+			skipped++
+			continue
+		}
+		sum := sha256.Sum256([]byte(r.ID))
+		description := fmt.Sprintf("%s · %s", r.Project, formatDuration("summary", r.Duration))
+		if len(r.Tags) > 0 {
+			description += "\n#" + strings.Join(r.Tags, " #")
+		}
+		for _, note := range r.Notes {
+			description += "\n" + note
+		}
+		for i, s := range workedStretches(r.TaskEntry) {
+			if !s[1].After(s[0]) {
+				continue
+			}
+			icsLine(w, "BEGIN:VEVENT")
+			icsLine(w, fmt.Sprintf("UID:%x-%d@track-worklogs", sum[:8], i))
+			icsLine(w, "DTSTAMP:"+stamp)
+			icsLine(w, "DTSTART:"+s[0].UTC().Format(icsTime))
+			icsLine(w, "DTEND:"+s[1].UTC().Format(icsTime))
+			icsLine(w, "SUMMARY:"+icsText(r.Task))
+			icsLine(w, "DESCRIPTION:"+icsText(description))
+			icsLine(w, "CATEGORIES:"+icsText(r.Project))
+			icsLine(w, "TRANSP:OPAQUE")
+			icsLine(w, "END:VEVENT")
+		}
+	}
+	icsLine(w, "END:VCALENDAR")
+	if skipped > 0 {
+		fmt.Fprintf(os.Stderr, "⚠️  Left out %d entries logged without a time\n", skipped)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// idleReason marks a pause started by idle detection.
+const idleReason = "idle"
+
+// idlePollInterval is how often the detector is asked; the desktop
+// tools it runs are too slow to call on every tick.
+const idlePollInterval = 5 * time.Second
+
+// idleDetector reports how long the user has not touched keyboard or
+// mouse.
+type idleDetector interface {
+	Idle() (time.Duration, error)
+}
+
+// commandIdle runs a configured command that prints the idle time in
+// milliseconds, like xprintidle does.
+type commandIdle struct {
+	command string
+}
+
+func (c commandIdle) Idle() (time.Duration, error) {
+	out, err := exec.Command("sh", "-c", c.command).Output()
+	if err != nil {
+		return 0, err
+	}
+	ms, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s: want milliseconds, got %q", c.command, strings.TrimSpace(string(out)))
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+var hidIdleTime = regexp.MustCompile(`"HIDIdleTime" = (\d+)`)
+
+type macIdle struct{}
+
+func (macIdle) Idle() (time.Duration, error) {
+	out, err := runOutput("ioreg", "-c", "IOHIDSystem", "-d", "4")
+	if err != nil {
+		return 0, err
+	}
+	m := hidIdleTime.FindStringSubmatch(out)
+	if m == nil {
+		return 0, fmt.Errorf("ioreg printed no HIDIdleTime")
+	}
+	ns, err := strconv.ParseInt(m[1], 10, 64)
+	return time.Duration(ns), err
+}
+
+// gnomeIdle asks Mutter, which also works on Wayland where X11 tools
+// see nothing.
+type gnomeIdle struct{}
+
+func (gnomeIdle) Idle() (time.Duration, error) {
+	out, err := runOutput("gdbus", "call", "--session", "--dest", "org.gnome.Mutter.IdleMonitor",
+		"--object-path", "/org/gnome/Mutter/IdleMonitor/Core", "--method", "org.gnome.Mutter.IdleMonitor.GetIdletime")
+	if err != nil {
+		return 0, err
+	}
+	ms, err := strconv.ParseInt(strings.Trim(out, "(uint64 ,)"), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("unexpected idle time %q", out)
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// detectIdle picks the detector for the desktop, or none when there is
+// no way to tell. A configured command always wins.
+func detectIdle(command string) idleDetector {
+	if command != "" {
+		return commandIdle{command: command}
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		return macIdle{}
+	case "linux":
+		if strings.Contains(strings.ToUpper(os.Getenv("XDG_CURRENT_DESKTOP")), "GNOME") {
+			return gnomeIdle{}
+		}
+		if _, err := exec.LookPath("xprintidle"); err == nil && os.Getenv("DISPLAY") != "" {
+			return commandIdle{command: "xprintidle"}
+		}
+	}
+	return nil
+}
+
+// idleMonitor pauses the running session after a stretch of inactivity
+// and notices when the user is back. A nil monitor never fires.
+type idleMonitor struct {
+	det   idleDetector
+	after time.Duration
+	last  time.Time
+}
+
+func newIdleMonitor(after time.Duration, command string) *idleMonitor {
+	if after <= 0 {
+		return nil
+	}
+	det := detectIdle(command)
+	if det == nil {
+		fmt.Println("⚠️  No idle detector for this desktop; set idle.command to enable idle detection")
+		return nil
+	}
+	return &idleMonitor{det: det, after: after}
+}
+
+// read returns the current idle time, at most every idlePollInterval.
+// A failing detector turns the monitor off.
+func (m *idleMonitor) read(now time.Time) (time.Duration, bool) {
+	if m == nil || m.det == nil || now.Sub(m.last) < idlePollInterval {
+		return 0, false
+	}
+	m.last = now
+	d, err := m.det.Idle()
+	if err != nil {
+		debugf("idle detection failed, turning it off: %v", err)
+		m.det = nil
+		return 0, false
+	}
+	return d, true
+}
+
+// away reports how long the user has been idle once it reaches the
+// threshold.
+func (m *idleMonitor) away(now time.Time) (time.Duration, bool) {
+	d, ok := m.read(now)
+	return d, ok && d >= m.after
+}
+
+// back reports whether there was input since the idle pause began.
+func (m *idleMonitor) back(now time.Time) bool {
+	d, ok := m.read(now)
+	return ok && d < m.after
+}
+
+// endIdle settles an idle pause when the user returns. Kept, the whole
+// time away counts as work; otherwise the idle stretch before the pause
+// comes off the clock and the absence from since to now is a pause.
+func endIdle(clock *sessionClock, since, pausedAt, now time.Time) (Pause, bool) {
+	answer := inputPrompt(fmt.Sprintf("\n👋 Welcome back! You were idle for %s (since %s). Keep it as work? (y/N) ",
+		formatDuration("footer", now.Sub(since).Round(time.Second)), since.Format("15:04")))
+	if strings.HasPrefix(strings.ToLower(answer), "y") {
+		clock.elapsed += now.Sub(pausedAt)
+		auditAt(now, "idle_kept", "", now.Sub(pausedAt))
+		return Pause{}, false
+	}
+	clock.elapsed = max(clock.elapsed-pausedAt.Sub(since), 0)
+	auditAt(now, "idle_dropped", "", pausedAt.Sub(since))
+	return Pause{Start: since, End: now, Reason: idleReason}, true
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const interruptPrefix = "- ⚡ **Interrupted**: "
+
+// Interruption is something that broke into a session without stopping
+// its clock: a colleague's question, a call. By says who or what it was.
+type Interruption struct {
+	Start time.Time
+	End   time.Time
+	By    string
+	Note  string
+}
+
+func (i Interruption) Duration() time.Duration {
+	return i.End.Sub(i.Start)
+}
+
+// parseInterruptionAnswer splits "Bob: deploy question" into who and
+// the note.
+func parseInterruptionAnswer(answer string) (by, note string) {
+	by, note, _ = strings.Cut(answer, ":")
+	return strings.TrimSpace(by), strings.TrimSpace(note)
+}
+
+// interruptionLine is an interruption as the log writes it, e.g. "5m0s
+// at 10:02–10:07 by Bob (deploy question)".
+func interruptionLine(i Interruption) string {
+	line := formatDuration("markdown", i.Duration())
+	if !i.Start.IsZero() {
+		line += " at " + clockSpan(i.Start, i.End)
+	}
+	line += " by " + i.By
+	if i.Note != "" {
+		line += " (" + i.Note + ")"
+	}
+	return line
+}
+
+// parseInterruptionLine reads an interruptionLine of date back.
+func parseInterruptionLine(date, text string) (Interruption, bool) {
+	head, by, ok := strings.Cut(text, " by ")
+	if !ok {
+		return Interruption{}, false
+	}
+	length, span, _ := strings.Cut(head, " at ")
+	d, err := parseDuration(length)
+	if err != nil {
+		return Interruption{}, false
+	}
+	var i Interruption
+	if from, _, ok := parseClockSpan(date, span); ok {
+		i.Start = from
+	}
+	i.End = i.Start.Add(d)
+	if j := strings.Index(by, " ("); j >= 0 && strings.HasSuffix(by, ")") {
+		by, i.Note = by[:j], by[j+2:len(by)-1]
+	}
+	i.By = by
+	return i, true
+}
+
+// interruptionTotals counts the entries' interruptions and the time
+// they took.
+func interruptionTotals(entries []TaskEntry) (count int, total time.Duration) {
+	for _, e := range entries {
+		for _, i := range e.Interruptions {
+			count++
+			total += i.Duration()
+		}
+	}
+	return count, total
+}
+
+// formatInterruptions sums the day's interruptions, naming who or what
+// interrupted most often first, e.g. "3, 25m0s (Bob 2, standup 1)".
+func formatInterruptions(entries []TaskEntry) string {
+	count, total := interruptionTotals(entries)
+	if count == 0 {
+		return ""
+	}
+	var names []string
+	byName := map[string]int{}
+	for _, e := range entries {
+		for _, i := range e.Interruptions {
+			key := strings.ToLower(i.By)
+			if _, ok := byName[key]; !ok {
+				names = append(names, i.By)
+			}
+			byName[key]++
+		}
+	}
+	sort.SliceStable(names, func(a, b int) bool { return byName[strings.ToLower(names[a])] > byName[strings.ToLower(names[b])] })
+	parts := make([]string, len(names))
+	for n, name := range names {
+		parts[n] = fmt.Sprintf("%s %d", name, byName[strings.ToLower(name)])
+	}
+	return fmt.Sprintf("%d, %s (%s)", count, formatDuration("summary", total), strings.Join(parts, ", "))
+}
@@ -0,0 +1,364 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// invoiceConfig is the invoice section of the config.
+type invoiceConfig struct {
+	From     string // your name and address, lines separated by |
+	Number   string // pattern for invoice numbers: {year}, {month}, {n}
+	Template string // text/template file, Markdown or .html
+	DueDays  int
+}
+
+const defaultInvoiceNumber = "{year}-{n}"
+
+// invoiceLine is the billable time on one task at one rate.
+type invoiceLine struct {
+	Project  string
+	Task     string
+	Hours    string // decimal, two places
+	Rate     string
+	Amount   string
+	Currency string
+	duration time.Duration
+	cents    int64
+}
+
+// invoiceData is what an invoice template is executed with.
+type invoiceData struct {
+	Number        string
+	Issued        string
+	Due           string
+	Client        string
+	ClientDetails []string
+	From          []string
+	Period        string
+	Start, End    string
+	Lines         []invoiceLine
+	Totals        []string // one per currency, e.g. "1200.00 EUR"
+	Hours         string
+}
+
+const markdownInvoice = `# Invoice {{.Number}}
+
+{{range .From}}{{.}}
+{{end}}
+**Bill to:** {{.Client}}
+{{range .ClientDetails}}{{.}}
+{{end}}
+| | |
+|---|---|
+| Issued | {{.Issued}} |
+| Due | {{.Due}} |
+| Period | {{.Period}} ({{.Start}} – {{.End}}) |
+
+| Project | Task | Hours | Rate | Amount |
+|---|---|---:|---:|---:|
+{{range .Lines}}| {{.Project}} | {{.Task}} | {{.Hours}} | {{.Rate}} | {{.Amount}} {{.Currency}} |
+{{end}}
+**Hours:** {{.Hours}}
+{{range .Totals}}**Total:** {{.}}
+{{end}}`
+
+const htmlInvoice = `<!doctype html>
+<html><head><meta charset="utf-8"><title>Invoice {{.Number}}</title>
+<style>body{font-family:sans-serif;max-width:50em;margin:2em auto}table{border-collapse:collapse;width:100%}td,th{padding:.3em .5em;border-bottom:1px solid #ddd;text-align:left}.n{text-align:right}</style>
+</head><body>
+<h1>Invoice {{.Number}}</h1>
+<p>{{range .From}}{{.}}<br>{{end}}</p>
+<p><strong>Bill to:</strong> {{.Client}}<br>{{range .ClientDetails}}{{.}}<br>{{end}}</p>
+<p>Issued {{.Issued}} · due {{.Due}} · {{.Period}} ({{.Start}} – {{.End}})</p>
+<table>
+<tr><th>Project</th><th>Task</th><th class="n">Hours</th><th class="n">Rate</th><th class="n">Amount</th></tr>
+{{range .Lines}}<tr><td>{{.Project}}</td><td>{{.Task}}</td><td class="n">{{.Hours}}</td><td class="n">{{.Rate}}</td><td class="n">{{.Amount}} {{.Currency}}</td></tr>
+{{end}}</table>
+<p><strong>Hours:</strong> {{.Hours}}</p>
+{{range .Totals}}<p><strong>Total:</strong> {{.}}</p>
+{{end}}</body></html>
+`
+
+// detailLines splits a config value written as lines separated by |.
+func detailLines(s string) []string {
+	var lines []string
+	for _, line := range strings.Split(s, "|") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func hoursText(d time.Duration) string {
+	return strconv.FormatFloat(d.Hours(), 'f', 2, 64)
+}
+
+// invoiceLines groups the client's billable entries by project, task
+// and rate. Billable entries without a rate are returned apart.
+func invoiceLines(entries []TaskEntry) ([]invoiceLine, []TaskEntry) {
+	var lines []invoiceLine
+	var missing []TaskEntry
+	index := map[string]int{}
+	for _, e := range entries {
+		if !e.Billable {
+			continue
+		}
+		r, ok := resolveRate(e)
+		if !ok {
+			missing = append(missing, e)
+			continue
+		}
+		key := e.Project + "\x00" + e.Task + "\x00" + r.String()
+		i, ok := index[key]
+		if !ok {
+			i = len(lines)
+			index[key] = i
+			lines = append(lines, invoiceLine{Project: e.Project, Task: e.Task, Rate: r.String(), Currency: r.Currency})
+		}
+		lines[i].duration += e.Duration
+		lines[i].cents += r.earn(e.Duration)
+	}
+	for i := range lines {
+		lines[i].Hours, lines[i].Amount = hoursText(lines[i].duration), formatCents(lines[i].cents)
+	}
+	return lines, missing
+}
+
+// issuedInvoice is one line of invoices.json, so numbers count up and an
+// invoice made again for the same client and month keeps its number.
+type issuedInvoice struct {
+	Number string `json:"number"`
+	Client string `json:"client"`
+	Month  string `json:"month"`
+	Issued string `json:"issued"`
+	Total  string `json:"total"`
+}
+
+func invoicesPath() (string, error) {
+	dir, err := appDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "invoices.json"), nil
+}
+
+func loadInvoices() ([]issuedInvoice, error) {
+	path, err := invoicesPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var issued []issuedInvoice
+	if err := json.Unmarshal(data, &issued); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return issued, nil
+}
+
+func saveInvoices(issued []issuedInvoice) error {
+	path, err := invoicesPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(issued, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, append(data, '\n'))
+}
+
+// invoiceNumber fills in the pattern; {n} counts the invoices issued in
+// the same year, from 001.
+func invoiceNumber(pattern string, issued []issuedInvoice, now time.Time) string {
+	year := now.Format("2006")
+	n := 1
+	for _, inv := range issued {
+		if strings.HasPrefix(inv.Issued, year) {
+			n++
+		}
+	}
+	return strings.NewReplacer("{year}", year, "{month}", now.Format("01"), "{n}", fmt.Sprintf("%03d", n)).Replace(pattern)
+}
+
+// renderInvoice executes the template, HTML-escaping when it is HTML.
+func renderInvoice(w io.Writer, text string, asHTML bool, data invoiceData) error {
+	if asHTML {
+		t, err := htmltemplate.New("invoice").Parse(text)
+		if err != nil {
+			return err
+		}
+		return t.Execute(w, data)
+	}
+	t, err := template.New("invoice").Parse(text)
+	if err != nil {
+		return err
+	}
+	return t.Execute(w, data)
+}
+
+// invoiceCommand renders an invoice for the billable time on a client's
+// projects in one month and records its number.
+func invoiceCommand(args []string) error {
+	fs := newFlagSet("invoice")
+	client := fs.String("client", "", "Client to bill, as named under clients: in the config or with project add --client")
+	monthText := fs.String("month", "", "Month to bill, YYYY-MM (default last month)")
+	format := fs.String("format", "", "md or html (default from the template, else md)")
+	templatePath := fs.String("template", "", "Template file (default invoice.template or the built-in one)")
+	number := fs.String("number", "", "Invoice number instead of the next one")
+	out := fs.String("out", "", "File to write (default standard output)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return withCode(exitUsage, err)
+	}
+	if *client == "" {
+		return usageErrorf("invoice needs --client")
+	}
+	projects := clientProjects(*client)
+	if len(projects) == 0 {
+		return usageErrorf("no projects for client %q; list them under clients: in the config or register them with project add --client", *client)
+	}
+
+	now := time.Now()
+	month, _ := monthOf(now)
+	month = month.AddDate(0, -1, 0)
+	if *monthText != "" {
+		if month, err = time.ParseInLocation("2006-01", *monthText, time.Local); err != nil {
+			return usageErrorf("invalid --month %q; want YYYY-MM", *monthText)
+		}
+	}
+	period, from, to := periodRange("month", month)
+	days, err := dayEntries(from, to)
+	if err != nil {
+		return err
+	}
+	var dates []string
+	for date := range days {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+	var entries []TaskEntry
+	for _, date := range dates {
+		for _, e := range days[date] {
+			for _, p := range projects {
+				if e.Project == p {
+					entries = append(entries, e)
+				}
+			}
+		}
+	}
+	lines, missing := invoiceLines(entries)
+	for _, e := range missing {
+		fmt.Fprintf(os.Stderr, "⚠️  Left out billable %q (%s): it has no rate; set rates.%s in the config\n", e.Task, e.Project, e.Project)
+	}
+	if len(lines) == 0 {
+		return withCode(exitEmpty, fmt.Errorf("no billable time for %s in %s", *client, period))
+	}
+
+	text, asHTML := markdownInvoice, *format == "html"
+	if *templatePath == "" {
+		*templatePath = cfg.Invoice.Template
+	}
+	if *templatePath != "" {
+		path, err := expandHome(*templatePath)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		text = string(data)
+		if *format == "" {
+			asHTML = strings.HasSuffix(*templatePath, ".html") || strings.HasSuffix(*templatePath, ".htm")
+		}
+	} else if asHTML {
+		text = htmlInvoice
+	}
+	if *format != "" && *format != "md" && *format != "html" {
+		return usageErrorf("unknown invoice format %q; want md or html", *format)
+	}
+
+	issued, err := loadInvoices()
+	if err != nil {
+		return err
+	}
+	key := month.Format("2006-01")
+	again := -1
+	for i, inv := range issued {
+		if inv.Client == *client && inv.Month == key {
+			again = i
+		}
+	}
+	switch {
+	case *number != "":
+	case again >= 0:
+		*number = issued[again].Number
+	default:
+		pattern := cfg.Invoice.Number
+		if pattern == "" {
+			pattern = defaultInvoiceNumber
+		}
+		*number = invoiceNumber(pattern, issued, now)
+	}
+
+	totals := map[string]int64{}
+	var hours time.Duration
+	for _, l := range lines {
+		totals[l.Currency] += l.cents
+		hours += l.duration
+	}
+	data := invoiceData{
+		Number: *number, Issued: now.Format(dateLayout), Due: now.AddDate(0, 0, cfg.Invoice.DueDays).Format(dateLayout),
+		Client: *client, ClientDetails: detailLines(cfg.ClientDetails[*client]), From: detailLines(cfg.Invoice.From),
+		Period: period, Start: from.Format(dateLayout), End: to.Format(dateLayout),
+		Lines: lines, Totals: strings.Split(moneyText(totals), " · "), Hours: hoursText(hours),
+	}
+
+	w := io.Writer(os.Stdout)
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := renderInvoice(w, text, asHTML, data); err != nil {
+		return fmt.Errorf("invoice template: %v", err)
+	}
+
+	record := issuedInvoice{Number: *number, Client: *client, Month: key, Issued: data.Issued, Total: moneyText(totals)}
+	if again >= 0 {
+		issued[again] = record
+	} else {
+		issued = append(issued, record)
+	}
+	if err := saveInvoices(issued); err != nil {
+		return err
+	}
+	if *out != "" {
+		fmt.Printf("🧾 Invoice %s for %s: %s, written to %s\n", *number, *client, record.Total, *out)
+	}
+	return nil
+}
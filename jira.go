@@ -0,0 +1,157 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// jiraConfig is the jira section of the config.
+type jiraConfig struct {
+	URL   string // e.g. https://example.atlassian.net
+	Email string
+	Token string // API token from id.atlassian.com
+	Auto  bool   // push when a session ends
+}
+
+var issueKey = regexp.MustCompile(`\b[A-Z][A-Z0-9]+-\d+\b`)
+
+// entryIssue finds the Jira issue of an entry: a tag that is an issue
+// key, or else the first key in the task.
+func entryIssue(e TaskEntry) string {
+	for _, t := range e.Tags {
+		if key := issueKey.FindString(t); key == t {
+			return key
+		}
+	}
+	return issueKey.FindString(e.Task)
+}
+
+// jiraTarget pushes entries with an issue key as worklogs.
+type jiraTarget struct {
+	cfg    jiraConfig
+	client *http.Client
+}
+
+func newJiraTarget(cfg Config) (syncTarget, error) {
+	j := cfg.Jira
+	if j.URL == "" || j.Email == "" || j.Token == "" {
+		return nil, errors.New("jira needs jira.url, jira.email and jira.token in the config")
+	}
+	j.URL = strings.TrimRight(j.URL, "/")
+	return jiraTarget{cfg: j, client: &http.Client{Timeout: syncHTTPTimeout}}, nil
+}
+
+func (jiraTarget) name() string { return "jira" }
+
+func (jiraTarget) accepts(e TaskEntry) bool { return entryIssue(e) != "" }
+
+// push adds a worklog to the entry's issue, or updates the one made
+// before. Remote IDs are ISSUE/WORKLOG. Jira wants at least a minute.
+func (j jiraTarget) push(date string, e TaskEntry, remoteID string) (string, error) {
+	issue := entryIssue(e)
+	started := e.Start
+	if started.IsZero() {
+		started, _ = time.ParseInLocation(dateLayout, date, time.Local)
+	}
+	body := map[string]any{
+		"timeSpentSeconds": max(int64(e.Duration.Round(time.Minute).Seconds()), 60),
+		"started":          started.Format("2006-01-02T15:04:05.000-0700"),
+		"comment": map[string]any{
+			"type": "doc", "version": 1,
+			"content": []any{map[string]any{
+				"type":    "paragraph",
+				"content": []any{map[string]any{"type": "text", "text": e.Task}},
+			}},
+		},
+	}
+	method, url := http.MethodPost, fmt.Sprintf("%s/rest/api/3/issue/%s/worklog", j.cfg.URL, issue)
+	if oldIssue, worklog, ok := strings.Cut(remoteID, "/"); ok && oldIssue == issue {
+		method, url = http.MethodPut, url+"/"+worklog
+	}
+	var reply struct {
+		ID string `json:"id"`
+	}
+	if err := sendJSON(j.client, method, url, j.cfg.Email, j.cfg.Token, body, &reply); err != nil {
+		return "", err
+	}
+	return issue + "/" + reply.ID, nil
+}
+
+// remove deletes a worklog.
+func (j jiraTarget) remove(remoteID string) error {
+	issue, worklog, ok := strings.Cut(remoteID, "/")
+	if !ok {
+		return fmt.Errorf("%q is not ISSUE/WORKLOG", remoteID)
+	}
+	url := fmt.Sprintf("%s/rest/api/3/issue/%s/worklog/%s", j.cfg.URL, issue, worklog)
+	return sendJSON(j.client, http.MethodDelete, url, j.cfg.Email, j.cfg.Token, nil, nil)
+}
+
+// tagIssue tags entries with the -issue key unless they name an issue
+// already.
+func (t *tracker) tagIssue(entries []TaskEntry) {
+	for i, e := range entries {
+		if t.issue != "" && entryIssue(e) == "" {
+			entries[i].Tags = append(e.Tags, t.issue)
+		}
+	}
+}
+
+// autoPushTargets are the targets pushed to when a session ends: Jira
+// with jira.auto on, GitHub with github.comment.
+func autoPushTargets(cfg Config) []syncTarget {
+	var targets []syncTarget
+	for _, auto := range []struct {
+		on        bool
+		newTarget func(Config) (syncTarget, error)
+	}{{cfg.Jira.Auto, newJiraTarget}, {cfg.GitHub.Comment, newGitHubTarget}} {
+		if !auto.on {
+			continue
+		}
+		target, err := auto.newTarget(cfg)
+		if err != nil {
+			fmt.Println("⚠️ ", err)
+			continue
+		}
+		targets = append(targets, target)
+	}
+	return targets
+}
+
+// pushAuto pushes today's new entries to the auto-push targets after a
+// session. Failures only warn; what was not pushed is left for sync.
+func (t *tracker) pushAuto() {
+	entries := t.today()
+	for _, target := range t.autoPush {
+		if _, err := pushPending(target, time.Now().Format(dateLayout), entries, false); err != nil {
+			fmt.Printf("⚠️  Not pushed to %s: %v\n", target.name(), err)
+		}
+	}
+}
+
+// autoPush pushes the day's new entries to the auto-push targets, for
+// sessions tracked without the interactive clock.
+func autoPush(date time.Time) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return
+	}
+	targets := autoPushTargets(cfg)
+	if len(targets) == 0 {
+		return
+	}
+	entries, err := writtenEntries(date)
+	if err != nil {
+		fmt.Println("⚠️  Not pushed:", err)
+		return
+	}
+	for _, target := range targets {
+		if _, err := pushPending(target, date.Format(dateLayout), entries, false); err != nil {
+			fmt.Printf("⚠️  Not pushed to %s: %v\n", target.name(), err)
+		}
+	}
+}
@@ -0,0 +1,378 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// syncRecord notes that an entry was pushed to an external system. Hash
+// is the entry's content at the time, so later edits can be detected.
+type syncRecord struct {
+	Entry    string    `json:"entry"`
+	Target   string    `json:"target"`
+	RemoteID string    `json:"remote_id"`
+	Hash     string    `json:"hash"`
+	Synced   time.Time `json:"synced"`
+	Deleted  bool      `json:"deleted,omitempty"`
+}
+
+// entryHash fingerprints the fields a sync target receives: project,
+// task, duration, start to the minute, tags, billable and notes.
+func entryHash(e TaskEntry) string {
+	start := ""
+	if !e.Start.IsZero() {
+		start = e.Start.UTC().Format("2006-01-02T15:04")
+	}
+	fields := []string{e.Project, e.Task, e.Duration.String(), start, strings.Join(e.Tags, " "), strconv.FormatBool(e.Billable)}
+	sum := sha256.Sum256([]byte(strings.Join(append(fields, e.Notes...), "\x00")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// matches reports whether rec was made for e as it is now. A record
+// made before the hash covered the start, tags and billable matches on
+// the fields its hash covered, so upgrading does not push everything
+// again.
+func (rec syncRecord) matches(e TaskEntry) bool {
+	if rec.Hash == entryHash(e) {
+		return true
+	}
+	sum := sha256.Sum256([]byte(strings.Join(append([]string{e.Project, e.Task, e.Duration.String()}, e.Notes...), "\x00")))
+	return rec.Hash == hex.EncodeToString(sum[:8])
+}
+
+func ledgerPath() (string, error) {
+	dir, err := appDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "sync.jsonl"), nil
+}
+
+// loadLedger returns the latest record per entry and target.
+func loadLedger() (map[string]map[string]syncRecord, error) {
+	ledger := map[string]map[string]syncRecord{}
+	path, err := ledgerPath()
+	if err != nil {
+		return ledger, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return ledger, nil
+	}
+	if err != nil {
+		return ledger, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		var rec syncRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return ledger, fmt.Errorf("%s:%d: %v", path, n, err)
+		}
+		if ledger[rec.Entry] == nil {
+			ledger[rec.Entry] = map[string]syncRecord{}
+		}
+		ledger[rec.Entry][rec.Target] = rec
+	}
+	return ledger, scanner.Err()
+}
+
+// recordSync appends rec to the ledger. Sync commands call it after
+// every successful push or remote delete.
+func recordSync(rec syncRecord) error {
+	path, err := ledgerPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// pendingSync lists the entries of a day that a target has not seen in
+// their current form: never pushed, or edited since.
+func pendingSync(date string, entries []TaskEntry, target string) ([]TaskEntry, error) {
+	ledger, err := loadLedger()
+	if err != nil {
+		return nil, err
+	}
+	var pending []TaskEntry
+	for i, id := range entryIDs(date, entries) {
+		rec, ok := ledger[id][target]
+		if !ok || rec.Deleted || !rec.matches(entries[i]) {
+			pending = append(pending, entries[i])
+		}
+	}
+	return pending, nil
+}
+
+// syncedTargets lists where the i-th of a day's entries has been pushed.
+func syncedTargets(date string, entries []TaskEntry, i int) []syncRecord {
+	ledger, err := loadLedger()
+	if err != nil {
+		return nil
+	}
+	var records []syncRecord
+	for _, rec := range ledger[entryIDs(date, entries)[i]] {
+		if !rec.Deleted {
+			records = append(records, rec)
+		}
+	}
+	sort.Slice(records, func(a, b int) bool { return records[a].Target < records[b].Target })
+	return records
+}
+
+// syncTarget is an external system entries are pushed to.
+type syncTarget interface {
+	name() string
+	// accepts reports whether e belongs in the target at all.
+	accepts(e TaskEntry) bool
+	// push creates e remotely, or updates it when remoteID is set, and
+	// returns its remote ID.
+	push(date string, e TaskEntry, remoteID string) (string, error)
+}
+
+// syncDeleter is a target that can delete what it was pushed, for
+// delete --remote.
+type syncDeleter interface {
+	// remove deletes the remote copy with remoteID, as push returned it.
+	remove(remoteID string) error
+}
+
+// pushPending pushes the day's entries the target has not seen in their
+// current form and records each push in the ledger. It returns how many
+// entries were pushed, or would be with dryRun.
+func pushPending(target syncTarget, date string, entries []TaskEntry, dryRun bool) (int, error) {
+	ledger, err := loadLedger()
+	if err != nil {
+		return 0, err
+	}
+	pushed := 0
+	for i, id := range entryIDs(date, entries) {
+		e := entries[i]
+		rec, ok := ledger[id][target.name()]
+		if !target.accepts(e) || ok && !rec.Deleted && rec.matches(e) {
+			continue
+		}
+		remoteID := ""
+		if ok && !rec.Deleted {
+			remoteID = rec.RemoteID
+		}
+		if dryRun {
+			fmt.Printf("🔄 Would push %s (%s) to %s\n", e.Task, formatDuration("summary", e.Duration), target.name())
+			pushed++
+			continue
+		}
+		remoteID, err := target.push(date, e, remoteID)
+		if err != nil {
+			return pushed, fmt.Errorf("%s: %s: %v", target.name(), e.Task, err)
+		}
+		if err := recordSync(syncRecord{Entry: id, Target: target.name(), RemoteID: remoteID, Hash: entryHash(e), Synced: time.Now()}); err != nil {
+			return pushed, err
+		}
+		fmt.Printf("🔄 Pushed %s (%s) to %s as %s\n", e.Task, formatDuration("summary", e.Duration), target.name(), remoteID)
+		pushed++
+	}
+	return pushed, nil
+}
+
+const syncHTTPTimeout = 15 * time.Second
+
+// sendJSON makes a request to a target's API with basic auth, sending
+// body as JSON unless it is nil and decoding the answer into reply
+// unless that is nil.
+func sendJSON(client *http.Client, method, url, user, password string, body, reply any) error {
+	return sendJSONAuth(client, method, url, func(req *http.Request) { req.SetBasicAuth(user, password) }, body, reply)
+}
+
+// sendJSONAuth is sendJSON for APIs that authenticate some other way:
+// auth sets the credentials on the request.
+func sendJSONAuth(client *http.Client, method, url string, auth func(*http.Request), body, reply any) error {
+	var payload io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		payload = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, url, payload)
+	if err != nil {
+		return err
+	}
+	auth(req)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	text, _ := io.ReadAll(io.LimitReader(resp.Body, 32<<20))
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s: %s", method, url, resp.Status)
+	}
+	if reply == nil {
+		return nil
+	}
+	return json.Unmarshal(text, reply)
+}
+
+// syncTargets are the configured targets by name.
+var syncTargets = map[string]func(cfg Config) (syncTarget, error){
+	"clockify": newClockifyTarget,
+	"gcal":     newGcalTarget,
+	"github":   newGitHubTarget,
+	"harvest":  newHarvestTarget,
+	"jira":     newJiraTarget,
+	"toggl":    newTogglTarget,
+}
+
+func syncCommand(args []string) error {
+	if len(args) > 0 && syncTargets[args[0]] != nil {
+		return syncPush(args[0], args[1:])
+	}
+	if len(args) == 0 || args[0] != "status" {
+		return usageErrorf("usage: sync status [--date DAY] | sync jira|toggl|gcal|clockify|harvest|github [--date DAY] [--dry-run]")
+	}
+	fs := newFlagSet("sync status")
+	day := fs.String("date", "today", "Day to show: today, yesterday or YYYY-MM-DD")
+	asJSON := jsonFlag(fs, "Print each entry's sync state as JSON")
+	if err := parseFlags(fs, args[1:]); err != nil {
+		return err
+	}
+	date, err := parseDay(*day)
+	if err != nil {
+		return err
+	}
+	entries, err := writtenEntries(date)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return withCode(exitEmpty, fmt.Errorf("no entries on %s", date.Format(dateLayout)))
+	}
+	ledger, err := loadLedger()
+	if err != nil {
+		return err
+	}
+
+	type targetState struct {
+		Target   string    `json:"target"`
+		RemoteID string    `json:"remote_id"`
+		State    string    `json:"state"` // synced, changed or deleted
+		Synced   time.Time `json:"synced"`
+	}
+	type entryState struct {
+		ID       string        `json:"id"`
+		Task     string        `json:"task"`
+		Duration string        `json:"duration"`
+		Targets  []targetState `json:"targets"`
+	}
+	var states []entryState
+	for i, id := range entryIDs(date.Format(dateLayout), entries) {
+		e := entries[i]
+		s := entryState{ID: id, Task: e.Task, Duration: formatDuration("json", e.Duration), Targets: []targetState{}}
+		records := ledger[id]
+		targets := make([]string, 0, len(records))
+		for target := range records {
+			targets = append(targets, target)
+		}
+		sort.Strings(targets)
+		for _, target := range targets {
+			rec := records[target]
+			state := "synced"
+			switch {
+			case rec.Deleted:
+				state = "deleted"
+			case !rec.matches(e):
+				state = "changed"
+			}
+			s.Targets = append(s.Targets, targetState{target, rec.RemoteID, state, rec.Synced})
+		}
+		states = append(states, s)
+	}
+	if *asJSON {
+		return printJSON(os.Stdout, states)
+	}
+
+	fmt.Printf("🔄 Sync state for %s\n", date.Format(dateLayout))
+	for i, s := range states {
+		fmt.Printf("%3d) %-40s ⏱️ %s\n", i+1, s.Task, formatDuration("summary", entries[i].Duration))
+		if len(s.Targets) == 0 {
+			fmt.Println("       · not synced")
+			continue
+		}
+		for _, t := range s.Targets {
+			switch t.State {
+			case "deleted":
+				fmt.Printf("       🗑️  %s: deleted remotely\n", t.Target)
+			case "changed":
+				fmt.Printf("       ✏️  %s %s: changed since %s, will be re-pushed\n", t.Target, t.RemoteID, t.Synced.Local().Format("2006-01-02 15:04"))
+			default:
+				fmt.Printf("       ✅ %s %s\n", t.Target, t.RemoteID)
+			}
+		}
+	}
+	return nil
+}
+
+// syncPush pushes a day's pending entries to the named target.
+func syncPush(name string, args []string) error {
+	fs := newFlagSet("sync " + name)
+	day := fs.String("date", "today", "Day to push: today, yesterday or YYYY-MM-DD")
+	dryRun := fs.Bool("dry-run", false, "List what would be pushed without pushing it")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	date, err := parseDay(*day)
+	if err != nil {
+		return err
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return withCode(exitUsage, err)
+	}
+	target, err := syncTargets[name](cfg)
+	if err != nil {
+		return withCode(exitUsage, err)
+	}
+	entries, err := writtenEntries(date)
+	if err != nil {
+		return err
+	}
+	n, err := pushPending(target, date.Format(dateLayout), entries, *dryRun)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		fmt.Printf("👌 Nothing new for %s on %s\n", name, date.Format(dateLayout))
+	}
+	return nil
+}
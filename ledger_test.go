@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Any change a sync target would receive makes the entry pending again.
+func TestEntryHashCoversPushedFields(t *testing.T) {
+	base := TaskEntry{Task: "review", Project: "League", Start: at("09:00"), Duration: 20 * time.Minute,
+		Tags: []string{"pr"}, Notes: []string{"looked at the importer"}}
+	changes := map[string]func(*TaskEntry){
+		"project":  func(e *TaskEntry) { e.Project = "Consulting" },
+		"task":     func(e *TaskEntry) { e.Task = "code review" },
+		"duration": func(e *TaskEntry) { e.Duration = 25 * time.Minute },
+		"start":    func(e *TaskEntry) { e.Start = at("09:05") },
+		"no start": func(e *TaskEntry) { e.Start = time.Time{} },
+		"tags":     func(e *TaskEntry) { e.Tags = []string{"pr", "urgent"} },
+		"billable": func(e *TaskEntry) { e.Billable = true },
+		"notes":    func(e *TaskEntry) { e.Notes = nil },
+	}
+	for name, change := range changes {
+		e := base
+		change(&e)
+		if entryHash(e) == entryHash(base) {
+			t.Errorf("changing the %s keeps the hash", name)
+		}
+	}
+	// Seconds are not kept in the logs, so they must not count.
+	e := base
+	e.Start = e.Start.Add(30 * time.Second)
+	if entryHash(e) != entryHash(base) {
+		t.Error("a start 30s later changes the hash")
+	}
+}
+
+// Records made before the hash covered start, tags and billable still
+// match, on the fields they covered.
+func TestSyncRecordMatchesOldHash(t *testing.T) {
+	e := TaskEntry{Task: "review", Project: "League", Start: at("09:00"), Duration: 20 * time.Minute, Billable: true}
+	rec := syncRecord{Hash: "3628631174ae34a9"} // League, review, 20m0s
+	if !rec.matches(e) {
+		t.Error("an old record does not match its entry")
+	}
+	e.Duration = time.Hour
+	if rec.matches(e) {
+		t.Error("an old record matches an entry edited since")
+	}
+}
+
+// fakeJira records the requests made to it and answers worklog pushes.
+func fakeJira(t *testing.T) (*httptest.Server, *[]string) {
+	var mu sync.Mutex
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		fmt.Fprint(w, `{"id": "100"}`)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func TestDeleteRemote(t *testing.T) {
+	for _, remote := range []bool{false, true} {
+		t.Run(fmt.Sprintf("remote=%t", remote), func(t *testing.T) {
+			srv, requests := fakeJira(t)
+			config, logs := useTempDirs(t)
+			writeConfig(t, config, "log_dir: "+logs+"\njira:\n  url: "+srv.URL+"\n  email: me@example.com\n  token: secret\n")
+			writeDay(t, logs, "League", at("00:00"), []TaskEntry{
+				{Task: "fix PROJ-12 crash", Project: "League", Start: at("09:00"), Duration: 30 * time.Minute},
+			})
+			home := filepath.Dir(logs)
+			if code, out := runMain(t, home, "", "sync", "jira", "--date", "2024-03-01"); code != exitOK {
+				t.Fatalf("sync: exit %d:\n%s", code, out)
+			}
+			args := []string{"delete", "1", "--date", "2024-03-01", "--yes"}
+			if remote {
+				args = append(args, "--remote")
+			}
+			code, out := runMain(t, home, "", args...)
+			if code != exitOK {
+				t.Fatalf("delete: exit %d:\n%s", code, out)
+			}
+			deleted := slices.Contains(*requests, "DELETE /rest/api/3/issue/PROJ-12/worklog/100")
+			if deleted != remote {
+				t.Errorf("deleted remotely: %t, want %t; requests %q", deleted, remote, *requests)
+			}
+			if want := "--remote"; !remote && !strings.Contains(out, want) {
+				t.Errorf("no hint at %s:\n%s", want, out)
+			}
+			ledger, err := loadLedger()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(ledger) == 0 {
+				t.Fatal("sync left no records")
+			}
+			for id, records := range ledger {
+				if records["jira"].Deleted != remote {
+					t.Errorf("%s: ledger has deleted=%t, want %t", id, records["jira"].Deleted, remote)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// acquireLock makes sure only one tracking instance runs at a time. A
+// lock left behind by a process that no longer exists is taken over.
+func lockPath() (string, error) {
+	dir, err := appDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "worklog.lock"), nil
+}
+
+func acquireLock() (release func(), err error) {
+	path, err := lockPath()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			fmt.Fprintf(file, "%d\n", os.Getpid())
+			file.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		if pid, ok := lockOwner(path); ok && processAlive(pid) {
+			return nil, withCode(exitLocked, fmt.Errorf("another instance (pid %d) is already tracking", pid))
+		}
+		os.Remove(path)
+	}
+	return nil, withCode(exitLocked, fmt.Errorf("could not take the lock %s", path))
+}
+
+// instanceRunning reports whether the process holding the lock is alive.
+func instanceRunning() bool {
+	path, err := lockPath()
+	if err != nil {
+		return false
+	}
+	pid, ok := lockOwner(path)
+	return ok && processAlive(pid)
+}
+
+func lockOwner(path string) (int, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	return pid, err == nil
+}
+
+func processAlive(pid int) bool {
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return proc.Signal(syscall.Signal(0)) == nil
+}
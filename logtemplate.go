@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// logTemplate is the markdown_template setting, parsed; nil keeps the
+// built-in layout of daily logs.
+var logTemplate *template.Template
+
+// logView is what markdown_template is executed with. Entries and
+// Earnings are the built-in blocks, which reports and merging read
+// back; Items lays the same entries out for templates of their own.
+type logView struct {
+	Project  string
+	Date     string
+	Weekday  string
+	Tags     []string
+	Entries  string
+	Earnings string
+	Items    []logItem
+	Total    string
+	Start    string // first start, HH:MM, when known
+	End      string // last end
+}
+
+// logItem is one entry with its values written out.
+type logItem struct {
+	Task        string
+	Duration    string
+	Start       string
+	End         string
+	Tags        []string
+	Billable    bool
+	Rate        string
+	Estimate    string // empty without one
+	Pauses      []string
+	Notes       []string
+	Attachments []string
+}
+
+// loadLogTemplate parses the template file at path.
+func loadLogTemplate(path string) (*template.Template, error) {
+	full, err := expandHome(path)
+	if err != nil {
+		return nil, err
+	}
+	text, err := os.ReadFile(full)
+	if err != nil {
+		return nil, err
+	}
+	t, err := template.New("log").Funcs(template.FuncMap{"join": strings.Join}).Parse(string(text))
+	if err != nil {
+		return nil, fmt.Errorf("markdown_template: %v", err)
+	}
+	return t, nil
+}
+
+func newLogView(project string, date time.Time, entries []TaskEntry) logView {
+	var entryBlock, earnings bytes.Buffer
+	writeEntries(&entryBlock, entries)
+	writeEarnings(&earnings, entries)
+	v := logView{
+		Project:  project,
+		Date:     date.Format(dateLayout),
+		Weekday:  date.Weekday().String(),
+		Tags:     strings.Split(frontmatterTags(project, entries), ", "),
+		Entries:  entryBlock.String(),
+		Earnings: earnings.String(),
+		Total:    formatDuration("markdown", totalDuration(entries)),
+	}
+	for _, e := range entries {
+		item := logItem{Task: e.Task, Duration: formatDuration("markdown", e.Duration), Tags: e.Tags,
+			Billable: e.Billable, Notes: e.Notes, Attachments: e.Attachments}
+		if e.Estimate > 0 {
+			item.Estimate = formatDuration("markdown", e.Estimate)
+		}
+		if !e.Rate.isZero() {
+			item.Rate = e.Rate.String()
+		} else if r, ok := resolveRate(e); ok && e.Billable {
+			item.Rate = r.String()
+		}
+		if !e.Start.IsZero() {
+			item.Start, item.End = e.Start.Format("15:04"), entryEnd(e).Format("15:04")
+			if v.Start == "" {
+				v.Start = item.Start
+			}
+			v.End = item.End
+		}
+		for _, p := range e.Pauses {
+			item.Pauses = append(item.Pauses, formatDuration("markdown", p.Duration()))
+		}
+		v.Items = append(v.Items, item)
+	}
+	return v
+}
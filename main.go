@@ -5,181 +5,488 @@ import (
 	"flag"
 	"fmt"
 	"os"
-	"os/signal"
-	"path/filepath"
 	"strings"
-	"syscall"
 	"time"
 )
 
-var digits = map[rune][]string{
-	'0': {" ███ ", "█   █", "█   █", "█   █", " ███ "},
-	'1': {"  █  ", " ██  ", "  █  ", "  █  ", " ███ "},
-	'2': {" ███ ", "    █", " ███ ", "█    ", "█████"},
-	'3': {"████ ", "    █", " ███ ", "    █", "████ "},
-	'4': {"█  █ ", "█  █ ", "█████", "   █ ", "   █ "},
-	'5': {"█████", "█    ", "████ ", "    █", "████ "},
-	'6': {" ███ ", "█    ", "████ ", "█   █", " ███ "},
-	'7': {"█████", "   █ ", "  █  ", " █   ", " █   "},
-	'8': {" ███ ", "█   █", " ███ ", "█   █", " ███ "},
-	'9': {" ███ ", "█   █", " ████", "    █", " ███ "},
-	':': {"     ", "  █  ", "     ", "  █  ", "     "},
-}
+// defaultProject is the project setting, used when nothing else names
+// one.
+var defaultProject = "League"
 
 type TaskEntry struct {
 	Task     string
+	Project  string
+	Start    time.Time
 	Duration time.Duration
+	Pauses   []Pause
+	Notes    []string
+	Billable bool
+	Rate     rate // overrides the project's rate when set
+	// Attachments are URLs or file paths, relative to the log directory
+	// when the file lives under it.
+	Attachments []string
+	Tags        []string
+	Estimate    time.Duration // how long the task was expected to take
+	// Interruptions broke into the session without pausing it.
+	Interruptions []Interruption
+	// ID names the entry for edits and sync once it is stored. The entry
+	// store keeps it; the logs do not.
+	ID string
+}
+
+// Pause is one interval during which the session's clock was stopped.
+type Pause struct {
+	Start  time.Time
+	End    time.Time
+	Reason string
 }
 
-func clearScreen() {
-	fmt.Print("\033[2J\033[H")
+func (p Pause) Duration() time.Duration {
+	return p.End.Sub(p.Start)
 }
 
-func renderTime(d time.Duration, paused bool) {
-	clearScreen()
-	h := int(d.Hours())
-	m := int(d.Minutes()) % 60
-	s := int(d.Seconds()) % 60
-	timeStr := fmt.Sprintf("%02d:%02d:%02d", h, m, s)
+var inputLines = make(chan string)
 
-	rows := make([]string, 5)
-	for _, ch := range timeStr {
-		for i := 0; i < 5; i++ {
-			rows[i] += digits[ch][i] + "  "
+// readInput feeds stdin to inputLines one line at a time so that the
+// session loop and the prompts share a single reader. While the
+// terminal is raw, each key is passed on by itself.
+func readInput() {
+	reader := bufio.NewReader(os.Stdin)
+	var line []byte
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			close(inputLines)
+			return
+		}
+		if rawInput.Load() && len(line) == 0 {
+			inputLines <- string(b)
+			continue
+		}
+		line = append(line, b)
+		if b == '\n' {
+			inputLines <- string(line)
+			line = nil
 		}
 	}
-	for _, row := range rows {
-		fmt.Println(row)
-	}
+}
 
-	if paused {
-		fmt.Println("\n⏸️  Paused - Press 'p' to resume | 'q' to end task")
-	} else {
-		fmt.Println("\n▶️  Tracking - Press 'p' to pause | 'q' to end task")
-	}
+// promptOverhead is the time spent answering the tool's own prompts. It
+// is never part of any entry's Duration.
+var promptOverhead time.Duration
+
+func trackOverhead(since time.Time) {
+	promptOverhead += time.Since(since)
+	audit("prompt_close", "", 0)
 }
 
 func inputPrompt(prompt string) string {
+	leaveRaw()
+	audit("prompt_open", "", 0)
+	defer trackOverhead(time.Now())
 	fmt.Print(prompt)
-	reader := bufio.NewReader(os.Stdin)
-	text, _ := reader.ReadString('\n')
+	text := <-inputLines
 	return strings.TrimSpace(text)
 }
 
-func writeMarkdown(project string, entries []TaskEntry) {
-	year, month, day := time.Now().Date()
-	filename := fmt.Sprintf("%04d-%02d-%02d_%s.md", year, month, day, project)
+var commands = map[string]func(args []string) error{
+	"add":      addCommand,
+	"attach":   attachCommand,
+	"audit":    auditCommand,
+	"clockify": clockifyCommand,
+	"daemon":   daemonCommand,
+	"delete":   deleteCommand,
+	"doctor":   doctorCommand,
+	"edit":     editCommand,
+	"email":    emailCommand,
+	"export":   exportCommand,
+	"gcal":     gcalCommand,
+	"handoff":  handoffCommand,
+	"heatmap":  heatmapCommand,
+	"history":  historyCommand,
+	"invoice":  invoiceCommand,
+	"log":      logCommand,
+	"migrate":  migrateCommand,
+	"pause":    pauseCommand,
+	"plan":     planCommand,
+	"profiles": profilesCommand,
+	"project":  projectCommand,
+	"rename":   renameCommand,
+	"report":   reportCommand,
+	"resume":   resumeCommand,
+	"retask":   retaskCommand,
+	"serve":    serveCommand,
+	"sound":    soundCommand,
+	"start":    startCommand,
+	"standup":  standupCommand,
+	"stats":    statsCommand,
+	"status":   statusCommand,
+	"stop":     stopCommand,
+	"store":    storeCommand,
+	"sync":     syncCommand,
+	"telegram": telegramCommand,
+	"timers":   timersCommand,
+	"toggl":    togglCommand,
+	"today":    todayCommand,
+	"toggle":   toggleCommand,
+	"webhook":  webhookCommand,
+}
 
-	// Build full path: ~/Desktop/rohan/league-rohan
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		fmt.Println("❌ Could not determine home directory:", err)
-		return
+func newFlagSet(name string) *flag.FlagSet {
+	return flag.NewFlagSet(name, flag.ContinueOnError)
+}
+
+// printDaySummary lists entries; done is the whole day's total for the
+// goal line.
+func printDaySummary(entries []TaskEntry, done time.Duration) {
+	fmt.Println("\n📊 Today")
+	for _, line := range formatSummary(entries, 0, len(entries)) {
+		fmt.Println(line)
+	}
+	if breaks := formatBreaks(entries); breaks != "" {
+		fmt.Printf("     Breaks: %s · work/break %s\n", breaks, breakRatio(totalDuration(entries), breakTotal(entries)))
 	}
+	if interruptions := formatInterruptions(entries); interruptions != "" {
+		fmt.Println("     Interruptions:", interruptions)
+	}
+	for _, line := range earningsLines(entries) {
+		fmt.Println("     " + line)
+	}
+	fmt.Printf("     Logging overhead: %s\n", formatDuration("summary", promptOverhead))
+	if line := goalSummary(done, dayTarget); line != "" {
+		fmt.Println(line)
+	}
+}
 
-	saveDir := filepath.Join(homeDir, "Desktop", "rohan", "league-rohan")
-	err = os.MkdirAll(saveDir, os.ModePerm)
+// finishDay writes the day's logs. The returned error carries the exit
+// code describing how that went.
+func finishDay(project string, entries []TaskEntry, failOnEmpty bool, review string) error {
+	if len(entries) == 0 && failOnEmpty {
+		return errEmpty
+	}
+	entries, err := writeDaily(project, entries, true)
 	if err != nil {
-		fmt.Println("❌ Could not create directory:", err)
-		return
+		return err
 	}
+	if review != "" {
+		if err := flagDay(time.Now(), review); err != nil {
+			fmt.Println("❌ Could not flag the day:", err)
+		}
+	}
+	clearState()
+	done := totalDuration(entries)
+	if day, err := writtenEntries(time.Now()); err == nil {
+		done = totalDuration(day)
+	}
+	printDaySummary(entries, done)
+	mailDay(time.Now(), dayTarget)
+	fmt.Println("👋 Session complete. See you next time!")
+	return nil
+}
+
+func main() {
+	go readInput()
 
-	fullPath := filepath.Join(saveDir, filename)
-	file, err := os.Create(fullPath)
+	if err := chooseProfile(); err != nil {
+		exit(err)
+	}
+	cfg, err := loadConfig()
 	if err != nil {
-		fmt.Println("❌ Error writing Markdown:", err)
-		return
+		exit(withCode(exitUsage, fmt.Errorf("could not load config: %v", err)))
+	}
+	soundConfig = cfg.Sounds
+	muted = cfg.Mute
+	asciiMode = cfg.ASCII
+	logLayout = cfg.Layout
+	writeMode = cfg.WriteMode
+	outputDir = cfg.LogDir
+	dayCeiling = cfg.DayCeiling
+	sessionCeiling = cfg.SessionCeiling
+	defaultCurrency = cfg.Currency
+	projectRates = cfg.Rates
+	billableProjects = cfg.Billable
+	projectClients = cfg.Clients
+	webhooks = cfg.Webhooks
+	slack = cfg.Slack
+	github = cfg.GitHub
+	email = cfg.Email
+	setDurationStyles(cfg.Durations)
+	setFilenamePattern(cfg.Filename)
+	if cfg.MarkdownTemplate != "" {
+		if logTemplate, err = loadLogTemplate(cfg.MarkdownTemplate); err != nil {
+			exit(withCode(exitUsage, fmt.Errorf("could not load config: %v", err)))
+		}
+	}
+	if registry, err = loadProjects(); err != nil {
+		exit(err)
+	}
+	defaultProject = cfg.Project
+	if p, err := resolveProject(defaultProject); err == nil {
+		defaultProject = p
 	}
-	defer file.Close()
 
-	// Write Markdown content
-	fmt.Fprintf(file, "---\ntags: [work-log, %s]\ndate: %04d-%02d-%02d\nproject: %s\n---\n\n",
-		strings.ToLower(project), year, month, day, project)
-	fmt.Fprintf(file, "# 📝 Work Log for %s (%04d-%02d-%02d)\n\n", project, year, month, day)
+	if len(os.Args) > 1 {
+		if cmd, ok := commands[os.Args[1]]; ok {
+			exit(cmd(os.Args[2:]))
+		}
+	}
 
-	for _, entry := range entries {
-		fmt.Fprintf(file, "- **Task**: %s\n  - ⏱️ **Duration**: %s\n", entry.Task, entry.Duration.Round(time.Second))
+	rule, hasRule := cfg.ruleFor(cfg.localNow())
+	project := defaultProject
+	if rule.Project != "" {
+		project = rule.Project
 	}
 
-	fmt.Println("✅ Markdown log saved to", fullPath)
-}
+	projectFlag := projectFlag(flag.CommandLine, "project", project, "Name of the project, or one of its aliases")
+	autoFinalizeFlag := flag.String("auto-finalize", cfg.AutoFinalize, "Local time (HH:MM) at which an unanswered day is finalized; empty to disable")
+	autoFinalizeActionFlag := flag.String("auto-finalize-action", cfg.AutoFinalizeAction, "What to do after auto-finalizing: exit or roll into a fresh day")
+	muteFlag := flag.Bool("mute", cfg.Mute, "Silence all sounds and bells")
+	asciiFlag := flag.Bool("ascii", cfg.ASCII, "Draw the clock with '#' instead of block characters")
+	failOnEmptyFlag := flag.Bool("fail-on-empty", false, "Exit with code 4 instead of writing an empty log")
+	noProjectCheckFlag := flag.Bool("no-project-check", !cfg.ProjectCheck, "Don't warn when today already has a log for another project")
+	noBannerFlag := flag.Bool("no-banner", false, "Skip the last-7-days summary at startup")
+	debugFlag := flag.Bool("debug", false, "Log diagnostics to debug.log in the config directory")
+	dndFlag := flag.Bool("dnd", cfg.DND, "Turn on Do Not Disturb while a session is tracking")
+	auditFlag := flag.Bool("audit", false, "Record every raw timing event for audit verify")
+	menuFlag := flag.Bool("menu", cfg.StartMenu, "Show a menu before tracking starts")
+	taskFlag := flag.String("task", "", "Task of the first session, shown under the clock; Enter at the end keeps it")
+	askTaskFlag := flag.Bool("ask-task", cfg.AskTask, "Ask what each session is for before it starts")
+	noGitTaskFlag := flag.Bool("no-git-task", !cfg.GitTask, "Don't suggest the git branch and repository as the task")
+	sameFlag := flag.Bool("same", false, "Start with the task and project of the last working day's first entry")
+	idleFlag := flag.Duration("idle-after", cfg.IdleAfter, "Pause after this long without keyboard or mouse input (0 to never)")
+	breakAfterFlag := flag.Duration("break-after", cfg.Remind.After, "Remind you to take a break after tracking this long without a pause (0 to never)")
+	pomodoroFlag := flag.Bool("pomodoro", false, "Count each session down from pomodoro.work and take the breaks in between")
+	forFlag := durationFlag(flag.CommandLine, "for", 0, "Timebox each session: count down from this long, then track the overtime")
+	estimateFlag := durationFlag(flag.CommandLine, "estimate", 0, "Estimate for the task of each session that names none with ~")
+	issueFlag := flag.String("issue", "", "Jira issue to log every session against, when the task names none")
+	previewFlag := flag.Bool("preview", false, "Show how each write changes a log already on disk and ask before writing it")
+	flag.String("profile", profile, "Profile whose config, history, state and logs to use (also WORKLOG_PROFILE)")
+	flag.Parse()
+	project = *projectFlag
+	if project, err = resolveProject(project); err != nil {
+		exit(err)
+	}
+	if *debugFlag {
+		if err := enableDebug(); err != nil {
+			fmt.Println("⚠️  Could not open debug log:", err)
+		}
+	}
 
-func runSession() (TaskEntry, bool, bool) {
-	start := time.Now()
-	elapsed := time.Duration(0)
-	paused := false
-	endTask := false
-	quitApp := false
-
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT)
-
-	go func() {
-		reader := bufio.NewReader(os.Stdin)
-		for {
-			b, err := reader.ReadByte()
-			if err != nil {
-				continue
-			}
-			switch b {
-			case 'p', 'P':
-				paused = !paused
-				if !paused {
-					start = time.Now().Add(-elapsed)
-				}
-			case 'q', 'Q':
-				quitApp = true
-				endTask = true
-				return
-			}
+	if *auditFlag {
+		if path, err := enableAudit(); err != nil {
+			fmt.Println("⚠️  Could not open audit log:", err)
+		} else {
+			defer auditFile.Close()
+			fmt.Println("🔍 Recording timing events to", path)
 		}
-	}()
+	}
 
-loop:
-	for {
-		select {
-		case <-sigChan:
-			endTask = true
-			break loop
-		default:
-			if !paused {
-				elapsed = time.Since(start)
-			}
-			renderTime(elapsed, paused)
-			time.Sleep(1 * time.Second)
+	explicit := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
 
-			if endTask || quitApp {
-				break loop
-			}
+	var notices []string
+	if hasRule {
+		notices = append(notices, "📅 "+rule.String())
+		if explicit["project"] && rule.Project != "" && rule.Project != project {
+			notices = append(notices, fmt.Sprintf("⚠️  -project %s overrides the %s rule (%s)", project, rule.Day, rule.Project))
 		}
 	}
+	if !*noProjectCheckFlag {
+		project = checkProject(project, time.Now())
+	}
+	asciiMode = *asciiFlag
+	muted = *muteFlag
+	previewWrites = *previewFlag
 
-	fmt.Print("\n")
-	task := inputPrompt("📝 What task did you just finish? ")
-	return TaskEntry{Task: task, Duration: elapsed}, quitApp, true
-}
+	if *forFlag > 0 && *pomodoroFlag {
+		exit(usageErrorf("-for and -pomodoro both set the length of a session; pick one"))
+	}
 
-func main() {
-	projectFlag := flag.String("project", "League", "Name of the project")
-	flag.Parse()
-	project := *projectFlag
+	af, err := newAutoFinalizer(*autoFinalizeFlag, *autoFinalizeActionFlag, time.Now())
+	if err != nil {
+		exit(withCode(exitUsage, err))
+	}
+
+	t := &tracker{
+		project: project,
+		af:      af,
+		config:  newConfigWatcher(explicit, af),
+		focus:   newFocusMode(*dndFlag, cfg.DNDPauseThreshold, cfg.DNDTag),
+		idle:    newIdleMonitor(*idleFlag, cfg.IdleCommand),
+		notify:  newNotifier(cfg.Notify),
+		remind:  newBreakReminder(remindConfig{After: *breakAfterFlag, Snooze: cfg.Remind.Snooze, Bell: cfg.Remind.Bell, Notify: cfg.Remind.Notify}),
+
+		pauseReasonAfter: cfg.PauseReasonThreshold,
+		target:           cfg.targetFor(cfg.localNow()),
+		askTask:          *askTaskFlag,
+		gitTask:          !*noGitTaskFlag,
+		pomodoro:         cfg.Pomodoro,
+		pomodoroMode:     *pomodoroFlag && cfg.Pomodoro.Work > 0,
+		timebox:          *forFlag,
+		estimate:         *estimateFlag,
+		graceWindow:      cfg.GraceWindow,
+		gracePause:       cfg.GraceGap == "pause",
+		issue:            *issueFlag,
+	}
+	dayTarget = t.target
+	t.autoPush = autoPushTargets(cfg)
+	release, err := acquireLock()
+	if err != nil {
+		exit(err)
+	}
+	defer release()
+	if timers, _ := loadTimers(); len(timers) > 0 {
+		for _, t := range timers {
+			fmt.Printf("⚠️  A timer started with start is still running for %s since %s; end it with stop\n", t.label(), t.Start.Format("15:04"))
+		}
+	}
+
+	migrateHistory(project)
+	t.recoverSession(time.Now())
+	if written, err := writtenEntries(time.Now()); err == nil {
+		t.written = written
+		t.earlier = totalDuration(written)
+	}
 
-	var entries []TaskEntry
+	if !*noBannerFlag {
+		if banner, err := recentBanner(time.Now()); err == nil && banner != "" {
+			notices = append(notices, "📈 "+banner)
+		}
+	}
+	t.banner = strings.Join(notices, "\n")
+
+	last, hasLast := lastFirstEntry(cfg, time.Now())
+	if *sameFlag {
+		if !hasLast {
+			fmt.Println("⚠️  No earlier entry to repeat")
+		} else {
+			t.repeat(last)
+		}
+	}
+	if *taskFlag != "" {
+		if t.preset == nil {
+			t.preset = &TaskEntry{Project: project}
+		}
+		t.preset.Task = *taskFlag
+	}
+	if *menuFlag && !*sameFlag && !t.startMenu(last, hasLast) {
+		fmt.Println("👋 See you next time!")
+		return
+	}
 
+day:
 	for {
-		entry, quit, valid := runSession()
+		done, quit, valid := t.runSession()
 		if valid {
-			entries = append(entries, entry)
+			for _, e := range done {
+				announce(newWebhookEvent(webhookStop, e.Project, e.Task, entryEnd(e), e.Duration))
+			}
+			if len(done) == 1 && t.absorb(done[0]) {
+				done = nil
+			}
+			t.tagIssue(done)
+			for i := range done {
+				linkGitHub(&done[i])
+			}
+			t.entries = append(t.entries, done...)
+			for _, entry := range done {
+				recordHistory(entry.Project, entry.Task)
+				finishPlanned(entry.Project, entry.Task, time.Now())
+			}
+			if writeMode == "incremental" {
+				written, err := writeDaily(t.project, t.entries, false)
+				if err != nil {
+					fmt.Println("❌", err)
+				}
+				t.entries = written
+			}
+			t.pushAuto()
 		}
+		t.publish(time.Time{}, 0, false)
 		if quit {
 			fmt.Println("👋 Quit early with 'q'. See you next time!")
 		}
+		if t.breakDue {
+			t.breakDue = false
+			t.takeBreak()
+		}
+		if t.switching {
+			t.switching = false
+			continue
+		}
 
-		answer := strings.ToLower(inputPrompt("✅ Done for the day? (yes/no): "))
-		if answer == "yes" || answer == "y" {
-			writeMarkdown(project, entries)
-			fmt.Println("👋 Session complete. See you next time!")
-			return
+		for !af.due() {
+			answer, timedOut := af.prompt("✅ Done for the day? (yes/no/list/project NAME): ")
+			if timedOut {
+				break
+			}
+			if name, ok := strings.CutPrefix(answer, "project "); ok {
+				next, err := resolveProject(strings.TrimSpace(name))
+				if err != nil {
+					fmt.Println("❌", err)
+					continue
+				}
+				t.project = next
+				fmt.Println("📁 Switched to", next)
+				continue day
+			}
+			switch strings.ToLower(answer) {
+			case "yes", "y":
+				review := ""
+				if overCeiling(t.today()) {
+					ok, timedOut := confirmLongDay(t.today(), af)
+					if timedOut {
+						break
+					}
+					if !ok {
+						fmt.Println("↩️  Not written; use 'list' to fix the entries")
+						continue
+					}
+					review = acknowledgedKey
+				}
+				err := finishDay(t.project, t.entries, *failOnEmptyFlag, review)
+				release()
+				exit(err)
+			case "list", "l":
+				t.entries = browseEntries(t.entries)
+				t.publish(time.Time{}, 0, false)
+			default:
+				continue day
+			}
+		}
+
+		fmt.Println("🌙 Auto-finalizing the day at", af.at.Format("15:04"))
+		previewWrites = false // nobody is there to confirm
+		review := ""
+		if overCeiling(t.today()) {
+			for _, line := range longDayWarning(t.today()) {
+				fmt.Println(line)
+			}
+			review = needsReviewKey
+		}
+		if af.action != "roll" {
+			err := finishDay(t.project, t.entries, *failOnEmptyFlag, review)
+			release()
+			exit(err)
+		}
+		if _, err := writeDaily(t.project, t.entries, true); err != nil {
+			fmt.Println("❌", err)
+		} else {
+			if review != "" {
+				if err := flagDay(af.at, review); err != nil {
+					fmt.Println("❌ Could not flag the day:", err)
+				}
+			}
+			mailDay(af.at, t.target)
 		}
+		t.entries = nil
+		t.written = nil
+		t.earlier = 0
+		t.publish(time.Time{}, 0, false)
+		previewWrites = *previewFlag
+		af.roll()
+		t.target = cfg.targetFor(af.at)
+		dayTarget = t.target
+		fmt.Println("🌅 Rolled into a fresh day. Next auto-finalize at", af.at.Format("Mon 15:04"))
 	}
 }
@@ -0,0 +1,410 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// renderMarkdown returns the daily log for project on date, from the
+// markdown_template when there is one.
+func renderMarkdown(project string, date time.Time, entries []TaskEntry) []byte {
+	if logTemplate != nil {
+		var b bytes.Buffer
+		err := logTemplate.Execute(&b, newLogView(project, date, entries))
+		if err == nil {
+			return b.Bytes()
+		}
+		fmt.Println("⚠️  markdown_template failed, writing the default layout:", err)
+	}
+	year, month, day := date.Date()
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "---\ntags: [%s]\ndate: %04d-%02d-%02d\nproject: %s\n---\n\n",
+		frontmatterTags(project, entries), year, month, day, project)
+	fmt.Fprintf(&b, "# 📝 Work Log for %s (%04d-%02d-%02d)\n\n", project, year, month, day)
+
+	writeEntries(&b, entries)
+	writeEarnings(&b, entries)
+	return b.Bytes()
+}
+
+func writeEntries(b *bytes.Buffer, entries []TaskEntry) {
+	for _, entry := range entries {
+		fmt.Fprintf(b, "- **Task**: %s\n  - ⏱️ **Duration**: %s\n", entry.Task, formatDuration("markdown", entry.Duration))
+		if !entry.Start.IsZero() {
+			fmt.Fprintf(b, "  %s%s\n", timePrefix, clockSpan(entry.Start, entryEnd(entry)))
+		}
+		if entry.Billable {
+			value := "project rate"
+			if !entry.Rate.isZero() {
+				value = entry.Rate.String()
+			}
+			fmt.Fprintf(b, "  %s%s\n", billablePrefix, value)
+		}
+		if len(entry.Tags) > 0 {
+			fmt.Fprintf(b, "  %s%s\n", tagPrefix, strings.Join(entry.Tags, ", "))
+		}
+		if entry.Estimate > 0 {
+			fmt.Fprintf(b, "  %s%s\n", estimatePrefix, formatDuration("markdown", entry.Estimate))
+		}
+		for _, p := range entry.Pauses {
+			line := formatDuration("markdown", p.Duration())
+			if !p.Start.IsZero() && !entry.Start.IsZero() {
+				line += " at " + clockSpan(p.Start, p.End)
+			} else if p.Reason == "" {
+				continue
+			}
+			if p.Reason != "" {
+				line += " (" + p.Reason + ")"
+			}
+			fmt.Fprintf(b, "  %s%s\n", pausePrefix, line)
+		}
+		for _, i := range entry.Interruptions {
+			fmt.Fprintf(b, "  %s%s\n", interruptPrefix, interruptionLine(i))
+		}
+		for _, note := range entry.Notes {
+			fmt.Fprintf(b, "  %s%s\n", notePrefix, note)
+		}
+		for _, a := range entry.Attachments {
+			fmt.Fprintf(b, "  %s%s\n", attachmentPrefix, attachmentLink(a))
+		}
+	}
+}
+
+// outputDir is the log_dir setting; empty keeps the legacy directory.
+var outputDir string
+
+func logDir() (string, error) {
+	if outputDir != "" {
+		return expandHome(outputDir)
+	}
+	return legacyLogDir()
+}
+
+// legacyLogDir is where logs were kept before log_dir existed.
+func legacyLogDir() (string, error) {
+	// Build full path: ~/Desktop/rohan/league-rohan
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not determine home directory: %v", err)
+	}
+	dir := filepath.Join(homeDir, "Desktop", "rohan", "league-rohan")
+	if profile != "" {
+		dir = filepath.Join(dir, profile)
+	}
+	return dir, nil
+}
+
+// logLayout is "daily" for one file per day or "weekly" for one file
+// per ISO week.
+var logLayout = "daily"
+
+// inProgressMarker ends logs written before the day is finalized.
+const inProgressMarker = "<!-- worklog: day in progress -->\n"
+
+// priorEntries holds, per log, what earlier runs had already logged
+// today when this run first wrote to it; they stay ahead of this run's
+// entries.
+var priorEntries = map[string][]TaskEntry{}
+
+// previewWrites makes writeMarkdown show how it would change a log
+// already on disk and ask before writing it. The session turns it off
+// for writes nobody is there to confirm.
+var previewWrites bool
+
+// writeMarkdown saves today's log. Unless final, the log is marked as
+// still in progress. Entries from earlier runs today are kept, and if
+// the file changed on disk since this run last wrote it, the outside
+// changes are merged in first; this run's entries as written are
+// returned. When the log directory cannot be written, a copy goes to
+// the temp directory and the returned error carries exitWriteFailed.
+// With previewWrites, a declined final write is kept the same way and
+// a declined one before it is left for the next write.
+func writeMarkdown(project string, entries []TaskEntry, final bool) ([]TaskEntry, error) {
+	now := time.Now()
+	today := now.Format(dateLayout)
+	saveDir, err := logDir()
+
+	filename := dailyFilename(project, now)
+	if logLayout == "weekly" {
+		filename = weeklyFilename(project, now)
+	}
+	fullPath := filepath.Join(saveDir, filename)
+	var existing []byte
+	if err == nil {
+		existing, _ = os.ReadFile(fullPath)
+	}
+	prior, seen := priorEntries[fullPath]
+	if !seen {
+		prior = parseLog(existing)[today]
+		priorEntries[fullPath] = prior
+		if len(prior) > 0 {
+			fmt.Printf("📎 %s already has %d entries from today; adding to them\n", fullPath, len(prior))
+		}
+	}
+	all := append(append([]TaskEntry(nil), prior...), entries...)
+	if err == nil {
+		all = reconcile(fullPath, existing, today, all)
+	}
+	n := min(len(prior), len(all))
+	priorEntries[fullPath], entries = all[:n:n], all[n:]
+
+	var content []byte
+	if logLayout == "weekly" {
+		content = renderWeek(project, now, all, existing, !final)
+	} else {
+		content = carryReviewFlags(renderMarkdown(project, now, all), existing)
+		if !final {
+			content = append(content, inProgressMarker...)
+		}
+	}
+
+	if err == nil && previewWrites && existing != nil && !bytes.Equal(existing, content) {
+		printDiffLines(unifiedDiff(fullPath, fullPath, existing, content))
+		if !confirmWrite(1) {
+			if !final {
+				return entries, nil
+			}
+			err = errors.New("declined in the preview")
+		}
+	}
+	if err == nil {
+		err = os.MkdirAll(saveDir, os.ModePerm)
+	}
+	if err == nil {
+		if err = writeFileAtomic(fullPath, content); err == nil {
+			rememberWrite(fullPath, content, all)
+			if err := storeDay(today, project, all); err != nil {
+				fmt.Println("⚠️  Could not update the entry store:", err)
+			}
+			if final {
+				fmt.Println("✅ Markdown log saved to", fullPath)
+			}
+			return entries, nil
+		}
+	}
+
+	fallback := filepath.Join(os.TempDir(), profilePrefix()+filename)
+	if ferr := os.WriteFile(fallback, content, 0o644); ferr != nil {
+		return entries, fmt.Errorf("error writing Markdown: %v; fallback failed too: %v", err, ferr)
+	}
+	return entries, withCode(exitWriteFailed, fmt.Errorf("error writing Markdown: %v; saved a copy to %s", err, fallback))
+}
+
+// writeMode is "final" to write the logs once at the end of the day or
+// "incremental" to also rewrite them after every session.
+var writeMode = "final"
+
+// writeDaily writes one daily file per project that has entries, or an
+// empty log for project when there are none. It returns the entries as
+// written, grouped by project, including any merged outside changes.
+func writeDaily(project string, entries []TaskEntry, final bool) ([]TaskEntry, error) {
+	if len(entries) == 0 {
+		return writeMarkdown(project, nil, final)
+	}
+	var order []string
+	byProject := map[string][]TaskEntry{}
+	for _, entry := range entries {
+		if _, ok := byProject[entry.Project]; !ok {
+			order = append(order, entry.Project)
+		}
+		byProject[entry.Project] = append(byProject[entry.Project], entry)
+	}
+	var written []TaskEntry
+	var firstErr error
+	for _, p := range order {
+		got, err := writeMarkdown(p, byProject[p], final)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		written = append(written, got...)
+	}
+	return written, firstErr
+}
+
+const (
+	taskPrefix     = "- **Task**: "
+	durationPrefix = "- ⏱️ **Duration**: "
+	pausePrefix    = "- ⏸️ **Paused**: "
+	notePrefix     = "- 📎 **Note**: "
+	timePrefix     = "- 🕒 **Time**: "
+)
+
+// parseLog reads the entries of a daily or weekly log, keyed by date
+// (YYYY-MM-DD). Daily logs take the date from their frontmatter, weekly
+// logs from each day heading.
+func parseLog(content []byte) map[string][]TaskEntry {
+	days := map[string][]TaskEntry{}
+	project, date := "", ""
+	for _, line := range strings.Split(string(content), "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "project: "):
+			project = strings.TrimSpace(strings.TrimPrefix(line, "project: "))
+		case strings.HasPrefix(line, "date: "):
+			date = strings.TrimSpace(strings.TrimPrefix(line, "date: "))
+		case strings.HasPrefix(line, "## "):
+			// Only a weekly file's day headings start a day; a template
+			// may add headings of its own.
+			if day, ok := parseDayHeading(line); ok {
+				date = day.Format(dateLayout)
+			}
+		case strings.HasPrefix(line, taskPrefix):
+			days[date] = append(days[date], TaskEntry{Task: strings.TrimPrefix(line, taskPrefix), Project: project})
+		case strings.HasPrefix(trimmed, durationPrefix):
+			entries := days[date]
+			if len(entries) == 0 {
+				continue
+			}
+			d, err := parseDuration(strings.TrimPrefix(trimmed, durationPrefix))
+			if err == nil {
+				entries[len(entries)-1].Duration = d
+			}
+		case strings.HasPrefix(trimmed, billablePrefix):
+			if entries := days[date]; len(entries) > 0 {
+				last := &entries[len(entries)-1]
+				last.Billable = true
+				if value := strings.TrimPrefix(trimmed, billablePrefix); value != "project rate" {
+					last.Rate, _ = parseRate(value, defaultCurrency)
+				}
+			}
+		case strings.HasPrefix(trimmed, tagPrefix):
+			if entries := days[date]; len(entries) > 0 {
+				last := &entries[len(entries)-1]
+				last.Tags = append(last.Tags, parseTagList(strings.TrimPrefix(trimmed, tagPrefix))...)
+			}
+		case strings.HasPrefix(trimmed, estimatePrefix):
+			if entries := days[date]; len(entries) > 0 {
+				entries[len(entries)-1].Estimate, _ = parseDuration(strings.TrimPrefix(trimmed, estimatePrefix))
+			}
+		case strings.HasPrefix(trimmed, notePrefix):
+			if entries := days[date]; len(entries) > 0 {
+				last := &entries[len(entries)-1]
+				last.Notes = append(last.Notes, strings.TrimPrefix(trimmed, notePrefix))
+			}
+		case strings.HasPrefix(trimmed, attachmentPrefix):
+			if entries := days[date]; len(entries) > 0 {
+				last := &entries[len(entries)-1]
+				last.Attachments = append(last.Attachments, parseAttachmentLink(strings.TrimPrefix(trimmed, attachmentPrefix)))
+			}
+		case strings.HasPrefix(trimmed, pausePrefix):
+			entries := days[date]
+			if len(entries) == 0 {
+				continue
+			}
+			rest := strings.TrimPrefix(trimmed, pausePrefix)
+			length, reason := rest, ""
+			if i := strings.Index(rest, " ("); i >= 0 {
+				length, reason = rest[:i], rest[i+1:]
+			}
+			length, span, _ := strings.Cut(length, " at ")
+			d, err := parseDuration(length)
+			if err != nil {
+				continue
+			}
+			// The wall clock survives to the minute, the length exactly.
+			var start time.Time
+			if from, _, ok := parseClockSpan(date, span); ok {
+				start = from
+			}
+			last := &entries[len(entries)-1]
+			last.Pauses = append(last.Pauses, Pause{Start: start, End: start.Add(d), Reason: strings.Trim(reason, "()")})
+		case strings.HasPrefix(trimmed, interruptPrefix):
+			if entries := days[date]; len(entries) > 0 {
+				if i, ok := parseInterruptionLine(date, strings.TrimPrefix(trimmed, interruptPrefix)); ok {
+					last := &entries[len(entries)-1]
+					last.Interruptions = append(last.Interruptions, i)
+				}
+			}
+		case strings.HasPrefix(trimmed, timePrefix):
+			if entries := days[date]; len(entries) > 0 {
+				if from, _, ok := parseClockSpan(date, strings.TrimPrefix(trimmed, timePrefix)); ok {
+					entries[len(entries)-1].Start = from
+				}
+			}
+		}
+	}
+	return days
+}
+
+// clockSpan writes a time range such as "09:15–10:40".
+func clockSpan(from, to time.Time) string {
+	return from.Format("15:04") + "–" + to.Format("15:04")
+}
+
+// parseClockSpan reads a clockSpan on date. An end before the start is
+// taken to be on the next day.
+func parseClockSpan(date, span string) (time.Time, time.Time, bool) {
+	fromText, toText, ok := strings.Cut(strings.TrimSpace(span), "–")
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+	from, err1 := time.ParseInLocation(dateLayout+" 15:04", date+" "+fromText, time.Local)
+	to, err2 := time.ParseInLocation(dateLayout+" 15:04", date+" "+toText, time.Local)
+	if err1 != nil || err2 != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	if to.Before(from) {
+		to = to.AddDate(0, 0, 1)
+	}
+	return from, to, true
+}
+
+// writtenEntries returns the entries already saved to disk for date,
+// from both daily and weekly files.
+func writtenEntries(date time.Time) ([]TaskEntry, error) {
+	dir, err := logDir()
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, pattern := range []string{dailyGlob(date), isoWeek(date) + "_*.md"} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, matches...)
+	}
+
+	var entries []TaskEntry
+	for _, f := range files {
+		content, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, parseLog(content)[date.Format("2006-01-02")]...)
+	}
+	return entries, nil
+}
+
+// frontmatterValue returns the value of key in the log's frontmatter.
+func frontmatterValue(content []byte, key string) string {
+	for _, line := range strings.Split(string(content), "\n") {
+		if value, ok := strings.CutPrefix(line, key+": "); ok {
+			return strings.TrimSpace(value)
+		}
+		if strings.HasPrefix(line, "# ") {
+			break
+		}
+	}
+	return ""
+}
+
+// mergeLogs adds the entries of src to dst, two logs of the same project
+// and period. dst keeps its text apart from the frontmatter tags;
+// src's entries are added after it.
+func mergeLogs(dst, src []byte, weekly bool) []byte {
+	if weekly {
+		return mergeWeeks(dst, src)
+	}
+	i := bytes.Index(src, []byte("\n"+taskPrefix))
+	if i < 0 {
+		return dst
+	}
+	out := append(bytes.TrimRight(dst, "\n"), '\n')
+	return retag(append(out, src[i+1:]...))
+}
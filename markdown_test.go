@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// sampleDay is a day's sessions across two projects, with a pause,
+// a note and tags so every part of an entry gets rendered.
+func sampleDay() []TaskEntry {
+	y, m, d := time.Now().Date()
+	nine := time.Date(y, m, d, 9, 0, 0, 0, time.Local)
+	return []TaskEntry{
+		{Task: "triage", Project: "League", Start: nine, Duration: 45 * time.Minute,
+			Pauses: []Pause{{Start: nine.Add(20 * time.Minute), End: nine.Add(30 * time.Minute), Reason: "call"}}},
+		{Task: "importer", Project: "Consulting", Start: nine.Add(time.Hour), Duration: 90 * time.Minute,
+			Notes: []string{"needs review"}, Billable: true},
+		{Task: "docs", Project: "League", Start: nine.Add(3 * time.Hour), Duration: 30 * time.Minute,
+			Tags: []string{"writing"}, Estimate: time.Hour},
+	}
+}
+
+// writeLogs writes entries once as a final write, or one session at a
+// time the way incremental mode does and then the final write, and
+// returns each log's content by file name.
+func writeLogs(t *testing.T, entries []TaskEntry, incremental bool) map[string][]byte {
+	t.Helper()
+	_, logs := useTempDirs(t)
+	if incremental {
+		var written []TaskEntry
+		for _, e := range entries {
+			var err error
+			if written, err = writeDaily("League", append(written, e), false); err != nil {
+				t.Fatal(err)
+			}
+		}
+		entries = written
+	}
+	if _, err := writeDaily("League", entries, true); err != nil {
+		t.Fatal(err)
+	}
+	files, err := os.ReadDir(logs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := map[string][]byte{}
+	for _, f := range files {
+		if content[f.Name()], err = os.ReadFile(filepath.Join(logs, f.Name())); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return content
+}
+
+func TestIncrementalMatchesFinal(t *testing.T) {
+	for _, layout := range []string{"daily", "weekly"} {
+		t.Run(layout, func(t *testing.T) {
+			saved := logLayout
+			logLayout = layout
+			t.Cleanup(func() { logLayout = saved })
+
+			final := writeLogs(t, sampleDay(), false)
+			incremental := writeLogs(t, sampleDay(), true)
+			if len(final) != 2 || len(incremental) != len(final) {
+				t.Fatalf("wrote %d logs in final mode and %d incrementally, want 2", len(final), len(incremental))
+			}
+			for name, want := range final {
+				if got := incremental[name]; !bytes.Equal(got, want) {
+					t.Errorf("%s differs:\nincremental:\n%s\nfinal:\n%s", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+// Headings a template adds are not days.
+func TestParseLogOtherHeadings(t *testing.T) {
+	entries := []TaskEntry{
+		{Task: "triage", Project: "League", Start: at("09:00"), Duration: 45 * time.Minute},
+		{Task: "docs", Project: "League", Start: at("10:00"), Duration: 30 * time.Minute},
+	}
+	daily := bytes.Replace(renderMarkdown("League", at("00:00"), entries), []byte(taskPrefix), []byte("## Tasks 2\n\n"+taskPrefix), 1)
+	if got := parseLog(daily); len(got) != 1 || len(got["2024-03-01"]) != 2 {
+		t.Errorf("daily log with a heading read as %v", got)
+	}
+
+	week := renderWeek("League", at("00:00"), entries, nil, false)
+	week = bytes.Replace(week, []byte(taskPrefix+"docs"), []byte("## Afternoon\n\n"+taskPrefix+"docs"), 1)
+	if got := parseLog(week); len(got) != 1 || len(got["2024-03-01"]) != 2 {
+		t.Errorf("weekly log with a heading read as %v", got)
+	}
+	sections := parseWeek(week)
+	if len(sections) != 1 || sections[0].date != "2024-03-01" || !bytes.Contains([]byte(sections[0].raw), []byte("## Afternoon")) {
+		t.Errorf("weekly log with a heading split into %+v", sections)
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// sameAsLastTimeLookback is how far back the last working day is
+// looked for.
+const sameAsLastTimeLookback = 14
+
+// lastFirstEntry returns the first entry of the most recent earlier day
+// with any, skipping days whose weekday target is set to zero.
+func lastFirstEntry(cfg Config, now time.Time) (TaskEntry, bool) {
+	today := startOfDay(now)
+	days, err := dayEntries(today.AddDate(0, 0, -sameAsLastTimeLookback), today.AddDate(0, 0, -1))
+	if err != nil {
+		return TaskEntry{}, false
+	}
+	for day := today.AddDate(0, 0, -1); day.After(today.AddDate(0, 0, -sameAsLastTimeLookback-1)); day = day.AddDate(0, 0, -1) {
+		if target, ok := cfg.WeekdayTargets[day.Weekday()]; ok && target == 0 {
+			continue
+		}
+		if entries := days[day.Format(dateLayout)]; len(entries) > 0 {
+			return entries[0], true
+		}
+	}
+	return TaskEntry{}, false
+}
+
+// repeat makes the next session reuse e's task and project, and its
+// tags, estimate and billing unless the task typed at the end sets them.
+func (t *tracker) repeat(e TaskEntry) {
+	t.preset = &TaskEntry{Task: e.Task, Project: e.Project, Tags: slices.Clone(e.Tags),
+		Estimate: e.Estimate, Billable: e.Billable, Rate: e.Rate}
+	line := "🔁 Same as last time: " + describeRepeat(e)
+	if t.banner != "" {
+		line = t.banner + "\n" + line
+	}
+	t.banner = line
+}
+
+// describeRepeat is what repeating e reuses, written as at the prompt:
+// "triage (League) #pr ~1h $".
+func describeRepeat(e TaskEntry) string {
+	parts := []string{fmt.Sprintf("%s (%s)", e.Task, e.Project)}
+	for _, tag := range e.Tags {
+		parts = append(parts, "#"+tag)
+	}
+	if e.Estimate > 0 {
+		parts = append(parts, "~"+formatDuration("summary", e.Estimate))
+	}
+	switch {
+	case !e.Rate.isZero():
+		parts = append(parts, e.Rate.String())
+	case e.Billable:
+		parts = append(parts, "$")
+	}
+	return strings.Join(parts, " ")
+}
+
+// reusePreset gives e what it left out of the preset it repeats, as long
+// as its task is still the preset's.
+func reusePreset(e *TaskEntry, preset *TaskEntry) {
+	if preset == nil || e.Task != preset.Task {
+		return
+	}
+	if len(e.Tags) == 0 {
+		e.Tags = slices.Clone(preset.Tags)
+	}
+	if e.Estimate == 0 {
+		e.Estimate = preset.Estimate
+	}
+	if e.Rate.isZero() {
+		e.Rate = preset.Rate
+	}
+	e.Billable = e.Billable || preset.Billable
+}
+
+// startMenu is shown before the first session when start_menu or -menu
+// is set. Enter starts tracking right away; it returns false to quit.
+func (t *tracker) startMenu(last TaskEntry, hasLast bool) bool {
+	clearScreen()
+	for _, row := range RenderString(time.Now().Format("15:04")) {
+		fmt.Println(row)
+	}
+	items := []menuItem{{"1", "Start tracking (Enter)"}}
+	if hasLast {
+		items = append(items, menuItem{"s", "Same as last time: " + describeRepeat(last)})
+	}
+	items = append(items, menuItem{"2", "Today's summary"}, menuItem{"3", "This week"}, menuItem{"4q", "Quit"})
+	for {
+		fmt.Printf("\n📋 %s\n", t.project)
+		switch pickMenu(items) {
+		case '1':
+			return true
+		case 's':
+			t.repeat(last)
+			return true
+		case '2':
+			info, err := currentStatus(time.Now())
+			if err != nil {
+				fmt.Println("❌", err)
+				continue
+			}
+			all := append(info.Entries, t.entries...)
+			printDaySummary(all, totalDuration(all))
+		case '3':
+			if err := printWeek(time.Now()); err != nil {
+				fmt.Println("❌", err)
+			}
+		case '4':
+			return false
+		}
+	}
+}
+
+// menuItem is one choice of a menu. The first of its keys is shown;
+// any of them picks it.
+type menuItem struct {
+	keys  string
+	label string
+}
+
+// menuPicker is a menu while the terminal is raw: the up and down
+// arrows move the selection, Enter picks it and an item's key picks
+// that item at once.
+type menuPicker struct {
+	items []menuItem
+	sel   int
+	esc   string // escape sequence read so far
+}
+
+// key handles one byte of input and returns the first key of the item
+// picked, or 0 while nothing is.
+func (m *menuPicker) key(b byte) byte {
+	if m.esc != "" {
+		m.esc += string(b)
+		switch {
+		case len(m.esc) == 2 && (b == '[' || b == 'O'):
+		case len(m.esc) == 2:
+			m.esc = ""
+		case b >= 0x40 && b <= 0x7e: // the end of the sequence
+			switch b {
+			case 'A':
+				m.sel = (m.sel + len(m.items) - 1) % len(m.items)
+			case 'B':
+				m.sel = (m.sel + 1) % len(m.items)
+			}
+			m.esc = ""
+		}
+		return 0
+	}
+	switch b {
+	case '\r', '\n':
+		return m.items[m.sel].keys[0]
+	case 0x1b:
+		m.esc = "\x1b"
+		return 0
+	}
+	for _, item := range m.items {
+		if strings.IndexByte(item.keys, b) >= 0 {
+			return item.keys[0]
+		}
+	}
+	return 0
+}
+
+// render draws the items, over the previous drawing when redraw is set.
+func (m *menuPicker) render(redraw bool) {
+	if redraw {
+		fmt.Printf("\033[%dA", len(m.items))
+	}
+	mark := "›"
+	if asciiMode {
+		mark = ">"
+	}
+	for i, item := range m.items {
+		if i == m.sel {
+			fmt.Printf("\r\033[K%s %c) %s\n", mark, item.keys[0], item.label)
+		} else {
+			fmt.Printf("\r\033[K  %c) %s\n", item.keys[0], item.label)
+		}
+	}
+}
+
+// pickMenu shows items and returns the first key of the one picked;
+// Enter alone picks the first. It reads single keys while the terminal
+// can be made raw and a line otherwise, where 0 means no item matched.
+func pickMenu(items []menuItem) byte {
+	enterRaw()
+	if !rawInput.Load() {
+		for _, item := range items {
+			fmt.Printf("  %c) %s\n", item.keys[0], item.label)
+		}
+		text := inputPrompt("> ")
+		if text == "" {
+			return items[0].keys[0]
+		}
+		for _, item := range items {
+			if len(text) == 1 && strings.IndexByte(item.keys, text[0]) >= 0 {
+				return item.keys[0]
+			}
+		}
+		return 0
+	}
+	defer leaveRaw()
+	audit("prompt_open", "", 0)
+	defer trackOverhead(time.Now())
+	m := &menuPicker{items: items}
+	m.render(false)
+	for {
+		key, ok := <-inputLines
+		if !ok {
+			return items[0].keys[0]
+		}
+		for i := 0; i < len(key); i++ {
+			if picked := m.key(key[i]); picked != 0 {
+				return picked
+			}
+		}
+		if m.esc == "" {
+			m.render(true)
+		}
+	}
+}
+
+// printWeek lists the totals of the current ISO week so far.
+func printWeek(now time.Time) error {
+	today := startOfDay(now)
+	monday, _ := weekOf(now)
+	totals, err := dayTotals(monday, today)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\n📅 %s\n", isoWeek(now))
+	var sum time.Duration
+	for day := monday; !day.After(today); day = day.AddDate(0, 0, 1) {
+		d := totals[day.Format(dateLayout)]
+		sum += d
+		fmt.Printf("     %s %s  %s\n", day.Weekday().String()[:3], day.Format(dateLayout), formatDuration("summary", d))
+	}
+	fmt.Printf("     Total: %s\n", formatDuration("summary", sum))
+	return nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMenuPicker(t *testing.T) {
+	items := []menuItem{{"1", "Start"}, {"2", "Summary"}, {"4q", "Quit"}}
+	tests := []struct {
+		keys string
+		want byte
+	}{
+		{"\r", '1'},
+		{"\n", '1'},
+		{"2", '2'},
+		{"q", '4'},
+		{"4", '4'},
+		{"\x1b[B\r", '2'},
+		{"\x1bOB\x1bOB\r", '4'},
+		{"\x1b[A\r", '4'},
+		{"\x1b[B\x1b[B\x1b[B\r", '1'},
+		{"\x1b[C\x1b[D\r", '1'},
+		{"x\x1bx\r", '1'},
+	}
+	for _, tt := range tests {
+		m := &menuPicker{items: items}
+		var got byte
+		for i := 0; i < len(tt.keys) && got == 0; i++ {
+			got = m.key(tt.keys[i])
+		}
+		if got != tt.want {
+			t.Errorf("%q picked %q, want %q", tt.keys, got, tt.want)
+		}
+	}
+}
+
+// Without a terminal the menu reads a line.
+func TestMenuLineInput(t *testing.T) {
+	for _, tt := range []struct{ stdin, want string }{
+		{"4\n", "See you next time"},
+		{"x\n2\nq\n", "📊 Today"},
+	} {
+		config, logs := useTempDirs(t)
+		writeConfig(t, config, "log_dir: "+logs+"\n")
+		code, out := runMain(t, filepath.Dir(logs), tt.stdin, "-menu", "-no-banner", "-no-git-task")
+		if code != exitOK || !strings.Contains(out, tt.want) || !strings.Contains(out, "See you next time") {
+			t.Errorf("%q: exit code %d, want %q in:\n%s", tt.stdin, code, tt.want, out)
+		}
+	}
+}
+
+func TestReusePreset(t *testing.T) {
+	preset := &TaskEntry{Task: "triage", Project: "League", Tags: []string{"support"}, Estimate: time.Hour,
+		Billable: true, Rate: rate{Cents: 9500, Currency: "EUR"}}
+	tests := []struct {
+		name  string
+		entry TaskEntry
+		want  TaskEntry
+	}{
+		{"accepted", TaskEntry{Task: "triage"},
+			TaskEntry{Task: "triage", Tags: []string{"support"}, Estimate: time.Hour, Billable: true, Rate: preset.Rate}},
+		{"own tags and estimate", TaskEntry{Task: "triage", Tags: []string{"oncall"}, Estimate: time.Minute},
+			TaskEntry{Task: "triage", Tags: []string{"oncall"}, Estimate: time.Minute, Billable: true, Rate: preset.Rate}},
+		{"own rate", TaskEntry{Task: "triage", Billable: true, Rate: rate{Cents: 100, Currency: "USD"}},
+			TaskEntry{Task: "triage", Tags: []string{"support"}, Estimate: time.Hour, Billable: true, Rate: rate{Cents: 100, Currency: "USD"}}},
+		{"another task", TaskEntry{Task: "write docs"}, TaskEntry{Task: "write docs"}},
+	}
+	for _, tt := range tests {
+		e := tt.entry
+		reusePreset(&e, preset)
+		if !reflect.DeepEqual(e, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, e, tt.want)
+		}
+	}
+}
+
+func TestLastFirstEntry(t *testing.T) {
+	_, logs := useTempDirs(t)
+	friday := startOfDay(at("09:00"))
+	monday := friday.AddDate(0, 0, 3)
+	writeDay(t, logs, "League", friday, []TaskEntry{
+		{Task: "triage", Project: "League", Start: friday.Add(9 * time.Hour), Duration: time.Hour},
+		{Task: "review", Project: "League", Start: friday.Add(11 * time.Hour), Duration: time.Hour},
+	})
+	sunday := monday.AddDate(0, 0, -1)
+	writeDay(t, logs, "Side", sunday, []TaskEntry{{Task: "weekend hack", Project: "Side", Start: sunday.Add(20 * time.Hour), Duration: time.Hour}})
+
+	weekdays := Config{WeekdayTargets: map[time.Weekday]time.Duration{time.Saturday: 0, time.Sunday: 0}}
+	if e, ok := lastFirstEntry(weekdays, monday.Add(8*time.Hour)); !ok || e.Task != "triage" {
+		t.Errorf("with weekends off: %+v, %v; want Friday's triage", e, ok)
+	}
+	if e, ok := lastFirstEntry(Config{}, monday.Add(8*time.Hour)); !ok || e.Task != "weekend hack" {
+		t.Errorf("without weekday targets: %+v, %v; want Sunday's entry", e, ok)
+	}
+	if e, ok := lastFirstEntry(weekdays, friday.Add(8*time.Hour)); ok {
+		t.Errorf("nothing before Friday, got %+v", e)
+	}
+}
+
+// Without any history there is nothing to repeat, and the menu leaves
+// the item out.
+func TestSameWithoutHistory(t *testing.T) {
+	config, logs := useTempDirs(t)
+	writeConfig(t, config, "log_dir: "+logs+"\n")
+	code, out := runMain(t, filepath.Dir(logs), "4\n", "-menu", "-no-banner", "-no-git-task")
+	if code != exitOK || strings.Contains(out, "Same as last time") {
+		t.Errorf("menu: exit code %d:\n%s", code, out)
+	}
+	code, out = runMain(t, filepath.Dir(logs), "q\n\nyes\n", "-same", "-no-banner", "-no-git-task", "-auto-finalize", "")
+	if code != exitOK || !strings.Contains(out, "No earlier entry to repeat") {
+		t.Errorf("-same: exit code %d:\n%s", code, out)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// labelEscaper escapes a Prometheus label value.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// writeMetrics writes info as gauges in the Prometheus text format.
+func writeMetrics(w io.Writer, info statusInfo) {
+	gauge := func(name, help string) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+	}
+	flag := func(on bool) int {
+		if on {
+			return 1
+		}
+		return 0
+	}
+
+	gauge("worklog_session_running", "1 while a session is tracking or paused.")
+	fmt.Fprintf(w, "worklog_session_running %d\n", flag(info.Class != "idle"))
+	gauge("worklog_session_paused", "1 while the session is paused.")
+	fmt.Fprintf(w, "worklog_session_paused %d\n", flag(info.Class == "paused"))
+	gauge("worklog_session_elapsed_seconds", "Time tracked in the session in progress.")
+	fmt.Fprintf(w, "worklog_session_elapsed_seconds %g\n", info.Elapsed.Seconds())
+	gauge("worklog_timers_running", "Detached timers running, the shown one included.")
+	timers := len(info.Others)
+	if _, _, ok, err := listTimers(); err == nil && ok {
+		timers++
+	}
+	fmt.Fprintf(w, "worklog_timers_running %d\n", timers)
+
+	byProject := map[string]time.Duration{}
+	for _, e := range info.Entries {
+		byProject[e.Project] += e.Duration
+	}
+	if info.Elapsed > 0 {
+		byProject[info.Project] += info.Elapsed
+	}
+	projects := make([]string, 0, len(byProject))
+	for p := range byProject {
+		projects = append(projects, p)
+	}
+	sort.Strings(projects)
+	gauge("worklog_today_seconds", "Time tracked today per project, the session in progress included.")
+	for _, p := range projects {
+		fmt.Fprintf(w, "worklog_today_seconds{project=\"%s\"} %g\n", labelEscaper.Replace(p), byProject[p].Seconds())
+	}
+	gauge("worklog_today_total_seconds", "Time tracked today.")
+	fmt.Fprintf(w, "worklog_today_total_seconds %g\n", info.Total.Seconds())
+}
+
+func metricsHandler(w http.ResponseWriter, r *http.Request) {
+	info, err := currentStatus(time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	writeMetrics(w, info)
+}
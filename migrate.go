@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// migrateCommand copies the logs from the legacy directory into log_dir,
+// rewritten in the current format. Files already migrated are skipped,
+// so it can be run again after new logs land in the old place.
+func migrateCommand(args []string) error {
+	fs := newFlagSet("migrate")
+	from := fs.String("from", "", "Directory to migrate from (default: the legacy log directory)")
+	to := fs.String("to", "", "Directory to migrate to (default: log_dir from the config)")
+	move := fs.Bool("move", false, "Delete each original once it has been migrated")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	src, err := legacyLogDir()
+	if *from != "" {
+		src, err = expandHome(*from)
+	}
+	if err != nil {
+		return err
+	}
+	dst, err := logDir()
+	if *to != "" {
+		dst, err = expandHome(*to)
+	}
+	if err != nil {
+		return err
+	}
+	if filepath.Clean(src) == filepath.Clean(dst) {
+		return usageErrorf("nothing to migrate: set log_dir in config.yaml or pass --to")
+	}
+
+	dirEntries, err := os.ReadDir(src)
+	if errors.Is(err, os.ErrNotExist) {
+		fmt.Printf("📂 No legacy logs in %s\n", src)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dst, 0o755); err != nil {
+		return err
+	}
+	// Logs migrated into log_dir are in use from now on, so they go into
+	// the entry store too.
+	write := writeFileAtomic
+	if logs, err := logDir(); err == nil && filepath.Clean(logs) == filepath.Clean(dst) {
+		write = func(path string, data []byte) error { return writeLog(path, data) }
+	}
+
+	var migrated, current, conflicts, failed int
+	for _, d := range dirEntries {
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ".md") {
+			continue
+		}
+		path := filepath.Join(src, d.Name())
+		name, data, err := migrateLog(path)
+		if err != nil {
+			fmt.Printf("⚠️  %s: %v\n", d.Name(), err)
+			failed++
+			continue
+		}
+		target := filepath.Join(dst, name)
+		existing, err := os.ReadFile(target)
+		switch {
+		case err == nil && bytes.Equal(existing, data):
+			current++
+		case err == nil:
+			fmt.Printf("⚠️  %s: %s already exists with other content, left alone\n", d.Name(), name)
+			conflicts++
+			continue
+		case errors.Is(err, os.ErrNotExist):
+			if err := write(target, data); err != nil {
+				return err
+			}
+			fmt.Printf("✅ %s → %s\n", d.Name(), name)
+			migrated++
+		default:
+			return err
+		}
+		if *move {
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+		}
+	}
+	fmt.Printf("📦 %d migrated, %d already up to date, %d conflicts, %d unreadable\n", migrated, current, conflicts, failed)
+	if conflicts > 0 {
+		return fmt.Errorf("%d files clash with existing logs in %s", conflicts, dst)
+	}
+	return nil
+}
+
+// migrateLog parses a legacy file and renders it in the current format,
+// returning the canonical file name with it.
+func migrateLog(path string) (string, []byte, error) {
+	lf, ok := parseLogName(filepath.Base(path))
+	if !ok {
+		return "", nil, errors.New("not named like a log (DATE_Project.md)")
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil, err
+	}
+	days := parseLog(content)
+	if len(days) == 0 {
+		return "", nil, errors.New("no entries found")
+	}
+	return renderLog(lf, content, days)
+}
+
+// renderLog renders days, parsed from the log lf with content, in the
+// current format. It returns the canonical file name and the content.
+func renderLog(lf logFile, content []byte, days map[string][]TaskEntry) (string, []byte, error) {
+	project := frontmatterValue(content, "project")
+	if project == "" {
+		project = lf.Project
+	}
+
+	if lf.Weekly {
+		week := frontmatterValue(content, "week")
+		if week == "" {
+			week = isoWeek(lf.Date)
+		}
+		var sections []weekSection
+		for key, entries := range days {
+			date, err := time.ParseInLocation("2006-01-02", key, time.Local)
+			if err != nil {
+				return "", nil, fmt.Errorf("bad day heading %q", key)
+			}
+			sections = append(sections, renderDaySection(date, entries))
+		}
+		sort.Slice(sections, func(i, j int) bool { return sections[i].date < sections[j].date })
+		return weekFilename(week, project), carryReviewFlags(assembleWeek(project, week, sections), content), nil
+	}
+
+	if len(days) > 1 {
+		return "", nil, errors.New("daily log with more than one date")
+	}
+	date := lf.Date
+	for key := range days {
+		if key == "" {
+			continue
+		}
+		var err error
+		if date, err = time.ParseInLocation("2006-01-02", key, time.Local); err != nil {
+			return "", nil, fmt.Errorf("bad date %q", key)
+		}
+	}
+	var entries []TaskEntry
+	for _, e := range days {
+		entries = e
+	}
+	return dailyFilename(project, date), carryReviewFlags(renderMarkdown(project, date, entries), content), nil
+}
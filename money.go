@@ -0,0 +1,277 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// rate is an hourly rate in integer cents, so money never goes through
+// floating point.
+type rate struct {
+	Cents    int64
+	Currency string
+}
+
+func (r rate) isZero() bool { return r.Currency == "" }
+
+func (r rate) String() string {
+	return formatCents(r.Cents) + " " + r.Currency + "/h"
+}
+
+// earn is what d is worth at r, rounded half up to the cent.
+func (r rate) earn(d time.Duration) int64 {
+	secs := int64(d.Round(time.Second) / time.Second)
+	return (r.Cents*secs + 1800) / 3600
+}
+
+var currencySymbols = map[string]string{"$": "USD", "€": "EUR", "£": "GBP"}
+
+// defaultCurrency applies to rates written without one; projectRates
+// holds the rates section of the config and billableProjects the
+// billable one.
+var (
+	defaultCurrency  = "EUR"
+	projectRates     = map[string]rate{}
+	billableProjects = map[string]bool{}
+)
+
+// parseRate reads "$120/h", "120 EUR/h", "€95.50" or "120", taking
+// fallback as the currency when none is given.
+func parseRate(s, fallback string) (rate, error) {
+	text := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "/h"))
+	currency := ""
+	for symbol, code := range currencySymbols {
+		if rest, ok := strings.CutPrefix(text, symbol); ok {
+			text, currency = rest, code
+		}
+	}
+	if i := strings.IndexFunc(text, unicode.IsLetter); i >= 0 {
+		if currency != "" {
+			return rate{}, fmt.Errorf("invalid rate %q", s)
+		}
+		text, currency = strings.TrimSpace(text[:i]), strings.ToUpper(strings.TrimSpace(text[i:]))
+		if len(currency) != 3 {
+			return rate{}, fmt.Errorf("invalid currency in %q", s)
+		}
+	}
+	cents, err := parseCents(text)
+	if err != nil {
+		return rate{}, fmt.Errorf("invalid rate %q", s)
+	}
+	if currency == "" {
+		currency = fallback
+	}
+	return rate{Cents: cents, Currency: currency}, nil
+}
+
+// parseCents reads a non-negative amount with at most two decimals.
+func parseCents(s string) (int64, error) {
+	whole, frac, _ := strings.Cut(s, ".")
+	if len(frac) > 2 || whole == "" && frac == "" {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	for len(frac) < 2 {
+		frac += "0"
+	}
+	if whole == "" {
+		whole = "0"
+	}
+	w, err := strconv.ParseUint(whole, 10, 62)
+	if err != nil {
+		return 0, err
+	}
+	f, err := strconv.ParseUint(frac, 10, 8)
+	if err != nil {
+		return 0, err
+	}
+	return int64(w)*100 + int64(f), nil
+}
+
+func formatCents(c int64) string {
+	sign := ""
+	if c < 0 {
+		sign, c = "-", -c
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, c/100, c%100)
+}
+
+// parseBilling takes the billing tokens out of a task: "$" marks it
+// billable at the project's rate, a rate such as "$120/h" or
+// "95 EUR/h" sets its own.
+func parseBilling(task string) (string, bool, rate, error) {
+	fields := strings.Fields(task)
+	var kept []string
+	billable := false
+	var r rate
+	for i := 0; i < len(fields); i++ {
+		f := fields[i]
+		switch {
+		case f == "$":
+			billable = true
+		case strings.HasSuffix(f, "/h") && strings.ContainsAny(f, "0123456789"):
+			parsed, err := parseRate(f, defaultCurrency)
+			if err != nil {
+				return task, false, r, err
+			}
+			billable, r = true, parsed
+		case i+1 < len(fields) && strings.HasSuffix(fields[i+1], "/h") && strings.ContainsAny(f, "0123456789"):
+			parsed, err := parseRate(f+" "+fields[i+1], defaultCurrency)
+			if err != nil {
+				return task, false, r, err
+			}
+			billable, r = true, parsed
+			i++
+		default:
+			kept = append(kept, f)
+		}
+	}
+	return strings.Join(kept, " "), billable, r, nil
+}
+
+// billByDefault marks a new entry billable when its project is; the
+// mark can still be taken off in the list at the end of the day.
+func billByDefault(e *TaskEntry) {
+	if billableProjects[e.Project] {
+		e.Billable = true
+	}
+}
+
+// resolveRate is the entry's own rate or else its project's.
+func resolveRate(e TaskEntry) (rate, bool) {
+	if !e.Rate.isZero() {
+		return e.Rate, true
+	}
+	r, ok := projectRates[e.Project]
+	return r, ok
+}
+
+// entryEarning is what a billable entry is worth, when it has a rate.
+func entryEarning(e TaskEntry) (int64, string, bool) {
+	if !e.Billable {
+		return 0, "", false
+	}
+	r, ok := resolveRate(e)
+	if !ok {
+		return 0, "", false
+	}
+	return r.earn(e.Duration), r.Currency, true
+}
+
+// addEarned adds the entry's earnings to totals per currency.
+func addEarned(totals map[string]int64, e TaskEntry) map[string]int64 {
+	if cents, currency, ok := entryEarning(e); ok {
+		if totals == nil {
+			totals = map[string]int64{}
+		}
+		totals[currency] += cents
+	}
+	return totals
+}
+
+// earnedTotal sums the entries' earnings per currency.
+func earnedTotal(entries []TaskEntry) map[string]int64 {
+	var totals map[string]int64
+	for _, e := range entries {
+		totals = addEarned(totals, e)
+	}
+	return totals
+}
+
+// moneyText writes amounts per currency as "150.00 EUR · 20.00 USD",
+// or "" when there are none.
+func moneyText(totals map[string]int64) string {
+	var currencies []string
+	for c := range totals {
+		currencies = append(currencies, c)
+	}
+	sort.Strings(currencies)
+	parts := make([]string, len(currencies))
+	for i, c := range currencies {
+		parts[i] = formatCents(totals[c]) + " " + c
+	}
+	return strings.Join(parts, " · ")
+}
+
+// earning is the billable time at one rate.
+type earning struct {
+	Rate     rate
+	Duration time.Duration
+	Cents    int64
+}
+
+// earnings groups the billable entries by rate, most valuable first,
+// and returns the billable ones without a rate separately.
+func earnings(entries []TaskEntry) ([]earning, []TaskEntry) {
+	byRate := map[rate]*earning{}
+	var missing []TaskEntry
+	for _, e := range entries {
+		if !e.Billable {
+			continue
+		}
+		r, ok := resolveRate(e)
+		if !ok {
+			missing = append(missing, e)
+			continue
+		}
+		if byRate[r] == nil {
+			byRate[r] = &earning{Rate: r}
+		}
+		byRate[r].Duration += e.Duration
+		byRate[r].Cents += r.earn(e.Duration)
+	}
+	var out []earning
+	for _, e := range byRate {
+		out = append(out, *e)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Rate.Currency != out[j].Rate.Currency {
+			return out[i].Rate.Currency < out[j].Rate.Currency
+		}
+		return out[i].Rate.Cents > out[j].Rate.Cents
+	})
+	return out, missing
+}
+
+// earningsLines describes the earnings per rate and the total per
+// currency; amounts in different currencies are never added up.
+func earningsLines(entries []TaskEntry) []string {
+	groups, missing := earnings(entries)
+	if len(groups) == 0 && len(missing) == 0 {
+		return nil
+	}
+	var lines []string
+	totals := map[string]int64{}
+	for _, g := range groups {
+		lines = append(lines, fmt.Sprintf("%s × %s = %s %s", g.Rate, formatDuration("summary", g.Duration), formatCents(g.Cents), g.Rate.Currency))
+		totals[g.Rate.Currency] += g.Cents
+	}
+	if len(groups) > 0 {
+		lines = append(lines, "Total: "+moneyText(totals))
+	}
+	for _, e := range missing {
+		lines = append(lines, fmt.Sprintf("⚠️  NO RATE for billable %q (%s): set rates.%s in the config or give the entry a rate", e.Task, e.Project, e.Project))
+	}
+	return lines
+}
+
+const (
+	billablePrefix = "- 💰 **Billable**: "
+	earningsHeader = "**💰 Earnings**"
+)
+
+// writeEarnings adds the earnings block after a day's entries.
+func writeEarnings(b *bytes.Buffer, entries []TaskEntry) {
+	lines := earningsLines(entries)
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n%s\n\n", earningsHeader)
+	for _, line := range lines {
+		fmt.Fprintf(b, "- %s\n", line)
+	}
+}
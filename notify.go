@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// notifyConfig is the notify section of the config. A zero duration
+// turns its notification off.
+type notifyConfig struct {
+	Enabled     bool
+	PausedAfter time.Duration // still paused this long
+	LongSession time.Duration // tracked this long without a pause
+	Pomodoro    bool          // a pomodoro is over
+}
+
+// notifyCommand builds the command that shows a desktop notification.
+func notifyCommand(ctx context.Context, title, body string) (*exec.Cmd, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		quote := func(s string) string { return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"` }
+		script := fmt.Sprintf("display notification %s with title %s", quote(body), quote(title))
+		return exec.CommandContext(ctx, "osascript", "-e", script), nil
+	case "windows":
+		quote := func(s string) string { return "'" + strings.ReplaceAll(s, "'", "''") + "'" }
+		script := `[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$x = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$t = $x.GetElementsByTagName('text')
+$t[0].AppendChild($x.CreateTextNode(` + quote(title) + `)) > $null
+$t[1].AppendChild($x.CreateTextNode(` + quote(body) + `)) > $null
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('worklog').Show([Windows.UI.Notifications.ToastNotification]::new($x))`
+		return exec.CommandContext(ctx, "powershell", "-NoProfile", "-Command", script), nil
+	}
+	if _, err := exec.LookPath("notify-send"); err != nil {
+		return nil, fmt.Errorf("notify-send not found")
+	}
+	return exec.CommandContext(ctx, "notify-send", "--app-name=worklog", title, body), nil
+}
+
+// sendNotification shows a notification in the background; it is only
+// a nicety, so failures are ignored.
+func sendNotification(title, body string) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), soundTimeout)
+		defer cancel()
+		if cmd, err := notifyCommand(ctx, title, body); err == nil {
+			cmd.Run()
+		}
+	}()
+}
+
+// notifier sends each notification once per pause or stretch of work.
+// A nil notifier sends nothing.
+type notifier struct {
+	cfg       notifyConfig
+	project   string
+	since     time.Duration // elapsed when the current stretch began
+	wasPaused bool
+	pauseSent bool
+	longSent  bool
+}
+
+func newNotifier(cfg notifyConfig) *notifier {
+	if !cfg.Enabled {
+		return nil
+	}
+	return &notifier{cfg: cfg}
+}
+
+// start begins a session of project.
+func (n *notifier) start(project string, elapsed time.Duration) {
+	if n == nil {
+		return
+	}
+	*n = notifier{cfg: n.cfg, project: project, since: elapsed}
+}
+
+// tick looks at the session every second: elapsed is the time tracked
+// and pausedFor how long the running pause has lasted.
+func (n *notifier) tick(elapsed time.Duration, paused bool, pausedFor time.Duration) {
+	if n == nil {
+		return
+	}
+	if paused {
+		n.wasPaused = true
+		if after := n.cfg.PausedAfter; after > 0 && pausedFor >= after && !n.pauseSent {
+			n.pauseSent = true
+			sendNotification("⏸️ Timer paused "+formatDuration("footer", after.Round(time.Minute)), "Still paused on "+n.project+"; press p to resume")
+		}
+		return
+	}
+	if n.wasPaused {
+		n.wasPaused, n.pauseSent, n.longSent = false, false, false
+		n.since = elapsed
+	}
+	if long := n.cfg.LongSession; long > 0 && elapsed-n.since >= long && !n.longSent {
+		n.longSent = true
+		sendNotification("🧘 "+formatDuration("footer", long)+" without a break", "You've been tracking "+n.project+" since the last pause; time to stretch")
+	}
+}
+
+// timebox announces that the session's timebox ran out.
+func (n *notifier) timebox(title, body string) {
+	if n == nil {
+		return
+	}
+	sendNotification(title, body)
+}
+
+// pomodoro announces the end of a pomodoro or a break.
+func (n *notifier) pomodoro(title, body string) {
+	if n == nil || !n.cfg.Pomodoro {
+		return
+	}
+	sendNotification(title, body)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+const orgTime = "2006-01-02 Mon 15:04"
+
+// orgTag makes a tag usable in an org heading, where tags are letters,
+// digits, _, @, # and %.
+func orgTag(tag string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r == '@' || r == '#' || r == '%' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+			return r
+		}
+		return '_'
+	}, tag)
+}
+
+// orgClock writes one CLOCK line, to the minute as org keeps them.
+func orgClock(from, to time.Time) string {
+	from, to = from.Local().Truncate(time.Minute), to.Local().Truncate(time.Minute)
+	d := to.Sub(from)
+	return fmt.Sprintf("CLOCK: [%s]--[%s] => %2d:%02d", from.Format(orgTime), to.Format(orgTime), int(d.Hours()), int(d.Minutes())%60)
+}
+
+// exportOrg writes an org file with a heading per project and one per
+// task under it, carrying a CLOCK line for each stretch worked, newest
+// first, so org's clock table can report on it. Entries logged without
+// a time have nothing to clock and are left out.
+func exportOrg(rows []datedEntry) error {
+	type task struct {
+		name   string
+		tags   []string
+		clocks [][2]time.Time
+	}
+	var projects []string
+	tasks := map[string][]*task{}
+	index := map[string]*task{}
+	skipped := 0
+	for _, r := range rows {
+		if r.Start.IsZero() {
+			skipped++
+			continue
+		}
+		if _, ok := tasks[r.Project]; !ok {
+			projects = append(projects, r.Project)
+			tasks[r.Project] = nil
+		}
+		key := r.Project + "\x00" + r.Task
+		t := index[key]
+		if t == nil {
+			t = &task{name: r.Task}
+			index[key] = t
+			tasks[r.Project] = append(tasks[r.Project], t)
+		}
+		for _, tag := range r.Tags {
+			name := orgTag(strings.ToLower(tag))
+			if !slices.Contains(t.tags, name) {
+				t.tags = append(t.tags, name)
+			}
+		}
+		for _, s := range workedStretches(r.TaskEntry) {
+			if s[1].After(s[0]) {
+				t.clocks = append(t.clocks, s)
+			}
+		}
+	}
+	sort.Strings(projects)
+
+	w := bufio.NewWriter(os.Stdout)
+	fmt.Fprintln(w, "#+TITLE: Work log")
+	fmt.Fprintln(w, "#+BEGIN: clocktable :scope file :maxlevel 2")
+	fmt.Fprintln(w, "#+END:")
+	for _, project := range projects {
+		fmt.Fprintf(w, "\n* %s\n", project)
+		for _, t := range tasks[project] {
+			heading := "** " + t.name
+			if len(t.tags) > 0 {
+				heading += " :" + strings.Join(t.tags, ":") + ":"
+			}
+			fmt.Fprintln(w, heading)
+			fmt.Fprintln(w, "   :LOGBOOK:")
+			sort.Slice(t.clocks, func(i, j int) bool { return t.clocks[i][0].After(t.clocks[j][0]) })
+			for _, c := range t.clocks {
+				fmt.Fprintln(w, "   "+orgClock(c[0], c[1]))
+			}
+			fmt.Fprintln(w, "   :END:")
+		}
+	}
+	if skipped > 0 {
+		fmt.Fprintf(os.Stderr, "⚠️  Left out %d entries logged without a time\n", skipped)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+)
+
+// TestPromptTimeNotCounted answers the pre-task prompt, the end of
+// session prompt and the review screen slowly and checks that none of
+// that time ends up in the entry, only in the logging overhead.
+func TestPromptTimeNotCounted(t *testing.T) {
+	config, logs := useTempDirs(t)
+	writeConfig(t, config, "log_dir: "+logs+"\n")
+	slow := 400 * time.Millisecond
+	in := &typist{lines: []typed{
+		{slow, "write docs"}, // pre-task prompt
+		{0, "q"},
+		{slow, ""},     // end of session: keep the task
+		{slow, "list"}, // review screen
+		{slow, ""},
+		{0, "yes"},
+	}}
+	code, out := runMainInput(t, filepath.Dir(logs), in, "-no-banner", "-no-git-task", "-ask-task", "-auto-finalize", "")
+	if code != exitOK {
+		t.Fatalf("exit code %d; output:\n%s", code, out)
+	}
+	m := regexp.MustCompile(`Logging overhead: (\S+)`).FindStringSubmatch(out)
+	if m == nil {
+		t.Fatalf("no logging overhead in the summary:\n%s", out)
+	}
+	if d, err := time.ParseDuration(m[1]); err != nil || d < time.Second {
+		t.Errorf("logging overhead %s, want the ~1.2s spent at the prompts", m[1])
+	}
+
+	files, _ := filepath.Glob(filepath.Join(logs, "*.md"))
+	if len(files) != 1 {
+		t.Fatalf("found logs %v, want one", files)
+	}
+	content, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries []TaskEntry
+	for _, day := range parseLog(content) {
+		entries = append(entries, day...)
+	}
+	if len(entries) != 1 || entries[0].Task != "write docs" {
+		t.Fatalf("logged %+v, want one write docs entry", entries)
+	}
+	if d := entries[0].Duration; d >= slow {
+		t.Errorf("entry lasts %s; prompt time leaked into it", d)
+	}
+}
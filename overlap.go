@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// duplicateRule tunes the "forgot to stop, started a new one" check: a
+// session that lies within another (give or take Tolerance) with a task
+// name at least Similarity alike is the same work tracked twice.
+type duplicateRule struct {
+	Similarity float64
+	Tolerance  time.Duration
+}
+
+// containedDuplicate is an entry Drop lying within entry Keep.
+type containedDuplicate struct {
+	Keep, Drop int
+}
+
+func entryEnd(e TaskEntry) time.Time {
+	end := e.Start.Add(e.Duration)
+	for _, p := range e.Pauses {
+		end = end.Add(p.Duration())
+	}
+	return end
+}
+
+// containedDuplicates finds entries that another entry of the same
+// project fully contains. Entries without a start time are never
+// matched, and each entry is dropped at most once.
+func containedDuplicates(entries []TaskEntry, rule duplicateRule) []containedDuplicate {
+	var found []containedDuplicate
+	dropped := map[int]bool{}
+	for i, outer := range entries {
+		if outer.Start.IsZero() || dropped[i] {
+			continue
+		}
+		for j, inner := range entries {
+			if i == j || inner.Start.IsZero() || dropped[j] || inner.Project != outer.Project {
+				continue
+			}
+			if inner.Duration > outer.Duration || inner.Duration == outer.Duration && j < i {
+				continue
+			}
+			if inner.Start.Before(outer.Start.Add(-rule.Tolerance)) || entryEnd(inner).After(entryEnd(outer).Add(rule.Tolerance)) {
+				continue
+			}
+			if taskSimilarity(outer.Task, inner.Task) < rule.Similarity {
+				continue
+			}
+			found = append(found, containedDuplicate{Keep: i, Drop: j})
+			dropped[j] = true
+		}
+	}
+	return found
+}
+
+// taskSimilarity is 1 minus the edit distance between the normalized
+// names relative to the longer one: 1 for equal names, 0 for unrelated.
+func taskSimilarity(a, b string) float64 {
+	x := []rune(strings.Join(strings.Fields(strings.ToLower(a)), " "))
+	y := []rune(strings.Join(strings.Fields(strings.ToLower(b)), " "))
+	longest := max(len(x), len(y))
+	if longest == 0 {
+		return 1
+	}
+	prev := make([]int, len(y)+1)
+	cur := make([]int, len(y)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(x); i++ {
+		cur[0] = i
+		for j := 1; j <= len(y); j++ {
+			cost := 1
+			if x[i-1] == y[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return 1 - float64(prev[len(y)])/float64(longest)
+}
+
+// fixContainedDuplicates drops, in every log, the entries that another
+// entry contains under the configured duplicate rule.
+func fixContainedDuplicates(dryRun, preview bool) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return withCode(exitUsage, err)
+	}
+	logs, err := listLogs()
+	if err != nil {
+		return err
+	}
+	var changes []rewrite
+	var trash []trashRecord
+	for _, lf := range logs {
+		content, err := os.ReadFile(lf.Path)
+		if err != nil {
+			return err
+		}
+		days := parseLog(content)
+		changed := false
+		for date, entries := range days {
+			dups := containedDuplicates(entries, cfg.Duplicates)
+			if len(dups) == 0 {
+				continue
+			}
+			drop := map[int]bool{}
+			for _, d := range dups {
+				fmt.Printf("🧹 %s: %q (%s) lies within %q (%s)\n", date, entries[d.Drop].Task, formatDuration("summary", entries[d.Drop].Duration),
+					entries[d.Keep].Task, formatDuration("summary", entries[d.Keep].Duration))
+				drop[d.Drop] = true
+				trash = append(trash, trashRecord{Removed: time.Now(), Reason: "contained-duplicates", File: lf.Path, Date: date, Entry: entries[d.Drop]})
+			}
+			var kept []TaskEntry
+			for i, e := range entries {
+				if !drop[i] {
+					kept = append(kept, e)
+				}
+			}
+			days[date] = kept
+			changed = true
+		}
+		if !changed {
+			continue
+		}
+		_, updated, err := renderLog(lf, content, days)
+		if err != nil {
+			return fmt.Errorf("%s: %v", lf.Path, err)
+		}
+		changes = append(changes, rewrite{From: lf.Path, To: lf.Path, Old: content, New: updated})
+	}
+	if len(changes) == 0 {
+		fmt.Println("✅ No contained duplicates")
+		return nil
+	}
+	written, err := applyRewrites(changes, dryRun, preview)
+	if err != nil || !written {
+		return err
+	}
+	return appendTrash(trash)
+}
+
+// trashRecord keeps an entry removed by a fix so it can be restored by
+// hand.
+type trashRecord struct {
+	Removed time.Time `json:"removed"`
+	Reason  string    `json:"reason"`
+	File    string    `json:"file"`
+	Date    string    `json:"date"`
+	Entry   TaskEntry `json:"entry"`
+}
+
+func trashPath() (string, error) {
+	dir, err := appDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "trash.jsonl"), nil
+}
+
+func appendTrash(records []trashRecord) error {
+	path, err := trashPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	for _, rec := range records {
+		if err := enc.Encode(rec); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
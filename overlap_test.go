@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestContainedDuplicates(t *testing.T) {
+	rule := duplicateRule{Similarity: 0.8, Tolerance: 2 * time.Minute}
+	entry := func(task, start string, d time.Duration) TaskEntry {
+		return TaskEntry{Task: task, Project: "League", Start: at(start), Duration: d}
+	}
+	outer := entry("fix login bug", "09:00", time.Hour)
+	paused := entry("fix login bug", "09:00", 30*time.Minute)
+	paused.Pauses = []Pause{{Start: at("09:10"), End: at("09:40")}}
+	other := entry("fix login bug", "09:10", 20*time.Minute)
+	other.Project = "Consulting"
+	tests := []struct {
+		name    string
+		entries []TaskEntry
+		want    []containedDuplicate
+	}{
+		{"within", []TaskEntry{outer, entry("fix login bug", "09:10", 20*time.Minute)}, []containedDuplicate{{0, 1}}},
+		{"listed first", []TaskEntry{entry("fix login bug", "09:10", 20*time.Minute), outer}, []containedDuplicate{{1, 0}}},
+		{"same times", []TaskEntry{outer, outer}, []containedDuplicate{{0, 1}}},
+		{"near-identical name", []TaskEntry{outer, entry("Fix  login bugs", "09:10", 20*time.Minute)}, []containedDuplicate{{0, 1}}},
+		{"starts within tolerance", []TaskEntry{outer, entry("fix login bug", "08:58", 20*time.Minute)}, []containedDuplicate{{0, 1}}},
+		{"ends within tolerance", []TaskEntry{outer, entry("fix login bug", "09:45", 17*time.Minute)}, []containedDuplicate{{0, 1}}},
+		{"within a pause's stretch", []TaskEntry{paused, entry("fix login bug", "09:40", 15*time.Minute)}, []containedDuplicate{{0, 1}}},
+		{"two within one", []TaskEntry{outer, entry("fix login bug", "09:10", 20*time.Minute), entry("fix login bug", "09:15", 10*time.Minute)},
+			[]containedDuplicate{{0, 1}, {0, 2}}},
+
+		// Near misses that must be left alone.
+		{"starts before tolerance", []TaskEntry{outer, entry("fix login bug", "08:57", 20*time.Minute)}, nil},
+		{"ends after tolerance", []TaskEntry{outer, entry("fix login bug", "09:45", 18*time.Minute)}, nil},
+		{"only overlaps", []TaskEntry{outer, entry("fix login bug", "09:30", time.Hour)}, nil},
+		{"back to back", []TaskEntry{outer, entry("fix login bug", "10:00", 20*time.Minute)}, nil},
+		{"similar name", []TaskEntry{outer, entry("fix logout bug", "09:10", 20*time.Minute)}, nil},
+		{"other task", []TaskEntry{outer, entry("review", "09:10", 20*time.Minute)}, nil},
+		{"other project", []TaskEntry{outer, other}, nil},
+		{"no start", []TaskEntry{outer, {Task: "fix login bug", Project: "League", Duration: 20 * time.Minute}}, nil},
+		{"after the pause", []TaskEntry{paused, entry("fix login bug", "09:55", 10*time.Minute)}, nil},
+	}
+	for _, tt := range tests {
+		if got := containedDuplicates(tt.entries, rule); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestContainedDuplicatesRule(t *testing.T) {
+	entries := []TaskEntry{
+		{Task: "fix login", Project: "League", Start: at("09:00"), Duration: time.Hour},
+		{Task: "fix logout", Project: "League", Start: at("08:55"), Duration: 20 * time.Minute},
+	}
+	if got := containedDuplicates(entries, duplicateRule{Similarity: 0.8, Tolerance: 2 * time.Minute}); got != nil {
+		t.Errorf("default rule: got %v", got)
+	}
+	if got := containedDuplicates(entries, duplicateRule{Similarity: 0.6, Tolerance: 5 * time.Minute}); len(got) != 1 {
+		t.Errorf("looser rule: got %v, want one", got)
+	}
+}
+
+func TestTaskSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"", "", 1},
+		{"review", "review", 1},
+		{"Review  PR", "review pr", 1},
+		{"review", "", 0},
+		{"abcd", "wxyz", 0},
+		{"fix login bug", "fix login bugs", 1 - 1.0/14},
+		{"fix login", "fix logout", 0.7},
+	}
+	for _, tt := range tests {
+		if got := taskSimilarity(tt.a, tt.b); got < tt.want-1e-9 || got > tt.want+1e-9 {
+			t.Errorf("taskSimilarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// The fix keeps the containing entry and puts the other in the trash.
+func TestFixContainedDuplicates(t *testing.T) {
+	config, logs := useTempDirs(t)
+	writeConfig(t, config, "log_dir: "+logs+"\n")
+	writeDay(t, logs, "League", at("00:00"), []TaskEntry{
+		{Task: "fix login bug", Project: "League", Start: at("09:00"), Duration: time.Hour},
+		{Task: "fix login bug", Project: "League", Start: at("09:10"), Duration: 20 * time.Minute},
+		{Task: "review", Project: "League", Start: at("11:00"), Duration: 15 * time.Minute},
+	})
+	home := filepath.Dir(logs)
+	if code, out := runMain(t, home, "", "doctor", "--fix", "contained-duplicates", "--dry-run"); code != exitOK {
+		t.Fatalf("dry run: exit %d:\n%s", code, out)
+	}
+	if entries, _ := writtenEntries(at("00:00")); len(entries) != 3 {
+		t.Fatalf("%d entries after a dry run, want 3", len(entries))
+	}
+	if code, out := runMain(t, home, "", "doctor", "--fix", "contained-duplicates"); code != exitOK {
+		t.Fatalf("fix: exit %d:\n%s", code, out)
+	}
+	entries, err := writtenEntries(at("00:00"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Duration != time.Hour || entries[1].Task != "review" {
+		t.Errorf("kept %+v", entries)
+	}
+	f, err := os.Open(filepath.Join(config, "trash.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var trash []trashRecord
+	for sc := bufio.NewScanner(f); sc.Scan(); {
+		var rec trashRecord
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			t.Fatal(err)
+		}
+		trash = append(trash, rec)
+	}
+	if len(trash) != 1 || trash[0].Reason != "contained-duplicates" || trash[0].Entry.Duration != 20*time.Minute {
+		t.Errorf("trash %+v", trash)
+	}
+}
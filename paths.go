@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// appDir is where the active profile keeps its own files (config,
+// history, state, ...).
+func appDir() (string, error) {
+	dir, err := baseDir()
+	if err != nil || profile == "" {
+		return dir, err
+	}
+	return filepath.Join(dir, "profiles", profile), nil
+}
+
+// baseDir is the tool's config directory, which is also the default
+// profile's.
+func baseDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "worklog"), nil
+}
+
+// expandHome replaces a leading "~/" with the home directory.
+func expandHome(path string) (string, error) {
+	rest, ok := strings.CutPrefix(path, "~/")
+	if !ok {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, rest), nil
+}
+
+// safeName turns a project name into something usable as a file name.
+func safeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|':
+			return '_'
+		}
+		return r
+	}, name)
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// periodTaskMax is how many tasks the text report lists.
+const periodTaskMax = 10
+
+// weekOf returns Monday to Sunday of date's ISO week.
+func weekOf(date time.Time) (time.Time, time.Time) {
+	monday := startOfDay(date).AddDate(0, 0, -((int(date.Weekday()) + 6) % 7))
+	return monday, monday.AddDate(0, 0, 6)
+}
+
+// monthOf returns the first and last day of date's month.
+func monthOf(date time.Time) (time.Time, time.Time) {
+	first := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, date.Location())
+	return first, first.AddDate(0, 1, -1)
+}
+
+type periodTotal struct {
+	Name    string `json:"name"`
+	Project string `json:"project,omitempty"`
+	Entries int    `json:"entries"`
+	Total   string `json:"total"`
+	Earned  string `json:"earned,omitempty"`
+	Breaks  string `json:"breaks,omitempty"` // days only
+	// Interruptions and the time they took, days only.
+	Interruptions int    `json:"interruptions,omitempty"`
+	Interrupted   string `json:"interrupted,omitempty"`
+	total         time.Duration
+	interrupted   time.Duration
+	breaks        time.Duration
+	earned        map[string]int64
+}
+
+// addTotal adds e to the total named name, keeping totals in first-seen
+// order.
+func addTotal(totals []periodTotal, index map[string]int, name, project string, e TaskEntry) []periodTotal {
+	key := project + "\x00" + name
+	i, ok := index[key]
+	if !ok {
+		i = len(totals)
+		index[key] = i
+		totals = append(totals, periodTotal{Name: name, Project: project})
+	}
+	totals[i].Entries++
+	totals[i].total += e.Duration
+	totals[i].earned = addEarned(totals[i].earned, e)
+	return totals
+}
+
+func finishTotals(totals []periodTotal) []periodTotal {
+	sort.SliceStable(totals, func(i, j int) bool { return totals[i].total > totals[j].total })
+	for i := range totals {
+		totals[i].Total = formatDuration("json", totals[i].total)
+		totals[i].Earned = moneyText(totals[i].earned)
+	}
+	return totals
+}
+
+// earnedColumn is the earnings shown after a report line, if any.
+func earnedColumn(text string) string {
+	if text == "" {
+		return ""
+	}
+	return "  💰 " + text
+}
+
+// periodReport prints the totals of a week or month: per project, per
+// day and per task, with the average over the days that have entries
+// and the busiest day.
+func periodReport(w io.Writer, title string, from, to time.Time, days map[string][]TaskEntry, asJSON bool) error {
+	var projects, tasks, perDay []periodTotal
+	var all []TaskEntry
+	projectIndex, taskIndex := map[string]int{}, map[string]int{}
+	var total, busiestTotal time.Duration
+	busiest, worked := "", 0
+	last := to
+	if today := startOfDay(time.Now()); today.Before(last) {
+		last = today
+	}
+	for day := from; !day.After(last); day = day.AddDate(0, 0, 1) {
+		date := day.Format(dateLayout)
+		entries := days[date]
+		d := totalDuration(entries)
+		count, interrupted := interruptionTotals(entries)
+		perDay = append(perDay, periodTotal{Name: date, Entries: len(entries), total: d, breaks: breakTotal(entries), earned: earnedTotal(entries),
+			Interruptions: count, interrupted: interrupted})
+		if len(entries) == 0 {
+			continue
+		}
+		worked++
+		all = append(all, entries...)
+		for _, e := range entries {
+			projects = addTotal(projects, projectIndex, e.Project, "", e)
+			tasks = addTotal(tasks, taskIndex, e.Task, e.Project, e)
+		}
+		total += d
+		if d > busiestTotal {
+			busiest, busiestTotal = date, d
+		}
+	}
+	average := time.Duration(0)
+	if worked > 0 {
+		average = total / time.Duration(worked)
+	}
+	for i := range perDay {
+		perDay[i].Total = formatDuration("json", perDay[i].total)
+		perDay[i].Earned = moneyText(perDay[i].earned)
+		perDay[i].Breaks = durationJSON(perDay[i].breaks)
+		perDay[i].Interrupted = durationJSON(perDay[i].interrupted)
+	}
+	earned := moneyText(earnedTotal(all))
+	breaks := breakTotal(all)
+	interruptions, interrupted := interruptionTotals(all)
+
+	if asJSON {
+		out := struct {
+			Period   string        `json:"period"`
+			From     string        `json:"from"`
+			To       string        `json:"to"`
+			Total    string        `json:"total"`
+			Average  string        `json:"average_per_day_worked"`
+			Breaks   string        `json:"breaks,omitempty"`
+			Ratio    string        `json:"work_break_ratio,omitempty"`
+			Count    int           `json:"interruptions,omitempty"`
+			Lost     string        `json:"interrupted,omitempty"`
+			Earned   string        `json:"earned,omitempty"`
+			Busiest  string        `json:"busiest_day,omitempty"`
+			Projects []periodTotal `json:"projects"`
+			Days     []periodTotal `json:"days"`
+			Tasks    []periodTotal `json:"tasks"`
+			Clients  []periodTotal `json:"clients,omitempty"`
+			Tags     []periodTotal `json:"tags,omitempty"`
+		}{title, from.Format(dateLayout), to.Format(dateLayout), formatDuration("json", total), formatDuration("json", average),
+			durationJSON(breaks), breakRatio(total, breaks), interruptions, durationJSON(interrupted), earned, busiest, finishTotals(projects), perDay, finishTotals(tasks), clientTotals(all), tagTotals(all)}
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+
+	fmt.Fprintf(w, "📊 %s (%s – %s)\n", title, from.Format(dateLayout), to.Format(dateLayout))
+	fmt.Fprintln(w, "\n  Projects")
+	projects = finishTotals(projects)
+	for _, p := range projects {
+		fmt.Fprintf(w, "    %s %10s  (%d entries)%s%s\n", projectLabel(p.Name, 24), formatDuration("summary", p.total), p.Entries, earnedColumn(p.Earned), bar(p.total, projects[0].total))
+	}
+	if clients := clientTotals(all); len(clients) > 0 {
+		fmt.Fprintln(w, "\n  Clients")
+		for _, c := range clients {
+			fmt.Fprintf(w, "    %-24s %10s  (%d entries)%s\n", c.Name, formatDuration("summary", c.total), c.Entries, earnedColumn(c.Earned))
+		}
+	}
+	fmt.Fprintln(w, "\n  Days")
+	for _, d := range perDay {
+		date, _ := time.ParseInLocation(dateLayout, d.Name, time.Local)
+		marker := ""
+		if d.Name == busiest {
+			marker = "  🔥 busiest"
+		}
+		if d.breaks > 0 {
+			marker += "  ☕ " + formatDuration("summary", d.breaks)
+		}
+		if d.Interruptions > 0 {
+			marker += fmt.Sprintf("  ⚡ %d", d.Interruptions)
+		}
+		fmt.Fprintf(w, "    %s %s %10s%s%s%s\n", date.Weekday().String()[:3], d.Name, formatDuration("summary", d.total), earnedColumn(d.Earned), bar(d.total, busiestTotal), marker)
+	}
+	fmt.Fprintln(w, "\n  Tasks")
+	tasks = finishTotals(tasks)
+	for i, t := range tasks {
+		if i == periodTaskMax {
+			fmt.Fprintf(w, "    … and %d more\n", len(tasks)-periodTaskMax)
+			break
+		}
+		fmt.Fprintf(w, "    %-24s %10s  %s\n", t.Name, formatDuration("summary", t.total), projectLabel(t.Project, 0))
+	}
+	if tags := tagTotals(all); len(tags) > 0 {
+		fmt.Fprintln(w, "\n  Tags")
+		for _, t := range tags {
+			fmt.Fprintf(w, "    %-24s %10s  (%d entries)\n", "#"+t.Name, formatDuration("summary", t.total), t.Entries)
+		}
+	}
+	fmt.Fprintf(w, "\n  Total: %s · %s per day worked (%d days)\n", formatDuration("summary", total), formatDuration("summary", average), worked)
+	if breaks > 0 {
+		fmt.Fprintf(w, "  Breaks: %s · work/break %s\n", formatDuration("summary", breaks), breakRatio(total, breaks))
+		if reasons := breakReasons(all); reasons != "" {
+			fmt.Fprintf(w, "    %s\n", reasons)
+		}
+	}
+	if interruptions > 0 {
+		fmt.Fprintf(w, "  Interruptions: %s\n", formatInterruptions(all))
+	}
+	if earned != "" {
+		fmt.Fprintf(w, "  Earned: %s\n", earned)
+	}
+	return nil
+}
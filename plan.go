@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// pomodoroConfig describes the work/break cadence.
+type pomodoroConfig struct {
+	Work      time.Duration
+	Break     time.Duration
+	LongBreak time.Duration
+	LongEvery int // a long break follows every LongEvery-th pomodoro
+}
+
+// dayPlan is how much of the daily target is left and what it takes to
+// get there at the pomodoro cadence starting now.
+type dayPlan struct {
+	Remaining time.Duration
+	Pomodoros int
+	Finish    time.Time
+}
+
+// planRemaining converts what is left of target after done into whole
+// pomodoros and projects when the last one ends if started at now.
+// Breaks between pomodoros count toward the finish time but not toward
+// focused time. It reports false when there is no target.
+func planRemaining(target, done time.Duration, p pomodoroConfig, now time.Time) (dayPlan, bool) {
+	if target <= 0 || p.Work <= 0 {
+		return dayPlan{}, false
+	}
+	plan := dayPlan{Remaining: max(target-done, 0), Finish: now}
+	if plan.Remaining == 0 {
+		return plan, true
+	}
+	plan.Pomodoros = int((plan.Remaining + p.Work - 1) / p.Work)
+	end := now.Add(time.Duration(plan.Pomodoros) * p.Work)
+	for k := 1; k < plan.Pomodoros; k++ {
+		if p.LongEvery > 0 && k%p.LongEvery == 0 {
+			end = end.Add(p.LongBreak)
+		} else {
+			end = end.Add(p.Break)
+		}
+	}
+	plan.Finish = end
+	return plan, true
+}
+
+func (p dayPlan) String(target time.Duration) string {
+	if p.Remaining == 0 {
+		return fmt.Sprintf("🎯 Target of %s reached", formatDuration("footer", target))
+	}
+	noun := "pomodoros"
+	if p.Pomodoros == 1 {
+		noun = "pomodoro"
+	}
+	return fmt.Sprintf("🍅 %d more %s to hit %s (%s left) · done around %s",
+		p.Pomodoros, noun, formatDuration("footer", target), formatDuration("footer", p.Remaining), p.Finish.Format("15:04"))
+}
+
+// dayTarget is today's target for the summary at the end of the day.
+var dayTarget time.Duration
+
+// goalBarWidth is how many cells the progress bar has.
+const goalBarWidth = 20
+
+// goalBar shows how far done is along the daily target, or "" without
+// one.
+func goalBar(done, target time.Duration) string {
+	if target <= 0 {
+		return ""
+	}
+	filled := min(int(done*goalBarWidth/target), goalBarWidth)
+	full, empty := "█", "░"
+	if asciiMode {
+		full, empty = "#", "-"
+	}
+	return fmt.Sprintf("🎯 %s%s %d%% of %s", strings.Repeat(full, filled), strings.Repeat(empty, goalBarWidth-filled),
+		int(done*100/target), formatDuration("footer", target))
+}
+
+// goalSummary is the goal line at the end of the day.
+func goalSummary(done, target time.Duration) string {
+	if target <= 0 {
+		return ""
+	}
+	if done >= target {
+		return fmt.Sprintf("🎯 Goal of %s reached: %s (%d%%)", formatDuration("summary", target), formatDuration("summary", done), int(done*100/target))
+	}
+	return fmt.Sprintf("🎯 Goal of %s missed by %s: %s (%d%%)", formatDuration("summary", target),
+		formatDuration("summary", target-done), formatDuration("summary", done), int(done*100/target))
+}
+
+func todayCommand(args []string) error {
+	fs := newFlagSet("today")
+	asJSON := jsonFlag(fs, "Print the day as JSON: the status with the target and what is left of it")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return withCode(exitUsage, err)
+	}
+
+	now := time.Now()
+	info, err := currentStatus(now)
+	if err != nil {
+		return err
+	}
+	target := cfg.targetFor(cfg.localNow())
+	if *asJSON {
+		out := struct {
+			statusJSON
+			Target string `json:"target,omitempty"`
+			Left   string `json:"left,omitempty"`
+		}{statusJSON: newStatusJSON(info)}
+		if target > 0 {
+			out.Target, out.Left = formatDuration("json", target), formatDuration("json", max(target-info.Total, 0))
+		}
+		return printJSON(os.Stdout, out)
+	}
+	fmt.Println(info.headline())
+	for _, line := range formatSummary(info.Entries, 0, len(info.Entries)) {
+		fmt.Println(line)
+	}
+	if bar := goalBar(info.Total, target); bar != "" {
+		fmt.Println(bar)
+	}
+	if plan, ok := planRemaining(target, info.Total, cfg.Pomodoro, now); ok {
+		fmt.Println(plan.String(target))
+	}
+	return nil
+}
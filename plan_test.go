@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPlanRemaining(t *testing.T) {
+	classic := pomodoroConfig{Work: 25 * time.Minute, Break: 5 * time.Minute, LongBreak: 15 * time.Minute, LongEvery: 4}
+	tests := []struct {
+		name      string
+		target    time.Duration
+		done      time.Duration
+		p         pomodoroConfig
+		remaining time.Duration
+		pomodoros int
+		finish    string
+	}{
+		{"one pomodoro, no break", 6 * time.Hour, 5*time.Hour + 40*time.Minute, classic, 20 * time.Minute, 1, "13:25"},
+		{"exactly two", 6 * time.Hour, 5*time.Hour + 10*time.Minute, classic, 50 * time.Minute, 2, "13:55"},
+		{"partial rounds up", 6 * time.Hour, 5*time.Hour + 9*time.Minute, classic, 51 * time.Minute, 3, "14:25"},
+		// 5 pomodoros: breaks after the 1st, 2nd and 3rd are short, after
+		// the 4th long: 125m + 15m + 15m.
+		{"long break", 6 * time.Hour, 4 * time.Hour, classic, 2 * time.Hour, 5, "15:35"},
+		{"no long breaks", 6 * time.Hour, 4 * time.Hour, pomodoroConfig{Work: 25 * time.Minute, Break: 5 * time.Minute},
+			2 * time.Hour, 5, "15:25"},
+		{"target reached", 6 * time.Hour, 6 * time.Hour, classic, 0, 0, "13:00"},
+		{"target passed", 6 * time.Hour, 7 * time.Hour, classic, 0, 0, "13:00"},
+	}
+	for _, tt := range tests {
+		plan, ok := planRemaining(tt.target, tt.done, tt.p, at("13:00"))
+		if !ok {
+			t.Errorf("%s: no plan", tt.name)
+			continue
+		}
+		if plan.Remaining != tt.remaining || plan.Pomodoros != tt.pomodoros || !plan.Finish.Equal(at(tt.finish)) {
+			t.Errorf("%s: got %s left, %d pomodoros, done at %s; want %s, %d, %s", tt.name,
+				plan.Remaining, plan.Pomodoros, plan.Finish.Format("15:04"), tt.remaining, tt.pomodoros, tt.finish)
+		}
+	}
+}
+
+func TestPlanRemainingHidden(t *testing.T) {
+	if _, ok := planRemaining(0, time.Hour, pomodoroConfig{Work: 25 * time.Minute}, at("13:00")); ok {
+		t.Error("planned without a target")
+	}
+	if _, ok := planRemaining(6*time.Hour, time.Hour, pomodoroConfig{}, at("13:00")); ok {
+		t.Error("planned without a pomodoro length")
+	}
+}
+
+func TestDayPlanString(t *testing.T) {
+	plan, _ := planRemaining(6*time.Hour, 5*time.Hour+40*time.Minute, pomodoroConfig{Work: 25 * time.Minute}, at("13:00"))
+	got := plan.String(6 * time.Hour)
+	want := "🍅 1 more pomodoro to hit " + formatDuration("footer", 6*time.Hour) + " (" +
+		formatDuration("footer", 20*time.Minute) + " left) · done around 13:25"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
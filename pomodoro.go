@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// pomodoroNote marks an entry as the cycle-th completed pomodoro of the
+// run.
+func pomodoroNote(cycle int) string {
+	return fmt.Sprintf("🍅 Pomodoro #%d", cycle)
+}
+
+// breakFor is the break that follows the cycle-th pomodoro: a long one
+// every LongEvery cycles.
+func (p pomodoroConfig) breakFor(cycle int) (time.Duration, string) {
+	if p.LongEvery > 0 && p.LongBreak > 0 && cycle%p.LongEvery == 0 {
+		return p.LongBreak, "Long break"
+	}
+	return p.Break, "Break"
+}
+
+// takeBreak counts down the break after a completed pomodoro. Breaks are
+// not logged; 's' or 'q' ends one early.
+func (t *tracker) takeBreak() {
+	length, name := t.pomodoro.breakFor(t.cycle)
+	if length <= 0 {
+		return
+	}
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT)
+	defer signal.Stop(sigChan)
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+	defer leaveRaw()
+
+	end := time.Now().Add(length)
+	for {
+		left := time.Until(end).Round(time.Second)
+		if left <= 0 {
+			alert(soundPomodoroEnd)
+			fmt.Printf("\n🍅 %s over, time for pomodoro #%d\n", name, t.cycle+1)
+			t.notify.pomodoro("☕ "+name+" over", fmt.Sprintf("Time for pomodoro #%d", t.cycle+1))
+			return
+		}
+		if t.af.due() {
+			return
+		}
+		enterRaw()
+		renderClock(left)
+		tui.Printf("\n☕ %s after pomodoro #%d - Press 's' to skip\n", name, t.cycle)
+		tui.flush()
+		select {
+		case <-sigChan:
+			fmt.Println()
+			return
+		case line, ok := <-inputLines:
+			if !ok {
+				return
+			}
+			for _, b := range line {
+				if b == 's' || b == 'S' || b == 'q' || b == 'Q' {
+					fmt.Println()
+					return
+				}
+			}
+		case <-ticker.C:
+		}
+	}
+}
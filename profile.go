@@ -0,0 +1,148 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// profile is the active profile; "" is the default one, which keeps its
+// files directly in the config directory as before profiles existed.
+// Every other profile lives in its own subdirectory, so two profiles
+// never share config, history, state, locks or logs.
+var profile string
+
+const defaultProfile = "default"
+
+func checkProfile(name string) error {
+	if name == "" || name == "." || name == ".." || safeName(name) != name || strings.ContainsAny(name, " \t") {
+		return fmt.Errorf("invalid profile name %q", name)
+	}
+	return nil
+}
+
+// setProfile makes name the active profile.
+func setProfile(name string) error {
+	if name == defaultProfile {
+		profile = ""
+		return nil
+	}
+	if err := checkProfile(name); err != nil {
+		return err
+	}
+	profile = name
+	return nil
+}
+
+// profilePrefix keeps files outside the profile directories (temp files)
+// apart.
+func profilePrefix() string {
+	if profile == "" {
+		return ""
+	}
+	return profile + "-"
+}
+
+// takeProfileArg removes --profile NAME (or --profile=NAME, single dash
+// too) from args, wherever it appears, and returns the name.
+func takeProfileArg(args []string) (name string, rest []string, err error) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		flagName, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || flagName != "profile" {
+			rest = append(rest, arg)
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return "", nil, usageErrorf("--profile needs a name")
+			}
+			i++
+			value = args[i]
+		}
+		name = value
+	}
+	return name, rest, nil
+}
+
+// chooseProfile picks the active profile from --profile, WORKLOG_PROFILE
+// or the default profile's config, in that order, and strips the flag
+// from os.Args.
+func chooseProfile() error {
+	name, rest, err := takeProfileArg(os.Args[1:])
+	if err != nil {
+		return err
+	}
+	os.Args = append(os.Args[:1], rest...)
+	if name == "" {
+		name = os.Getenv("WORKLOG_PROFILE")
+	}
+	if name == "" {
+		cfg, err := loadConfig()
+		if err != nil {
+			return withCode(exitUsage, fmt.Errorf("could not load config: %v", err))
+		}
+		name = cfg.Profile
+	}
+	if name == "" {
+		return nil
+	}
+	if err := setProfile(name); err != nil {
+		return withCode(exitUsage, err)
+	}
+	return nil
+}
+
+// listProfiles returns the default profile, the active one and every
+// profile that has a directory.
+func listProfiles() ([]string, error) {
+	base, err := baseDir()
+	if err != nil {
+		return nil, err
+	}
+	names := []string{defaultProfile}
+	dirs, err := os.ReadDir(filepath.Join(base, "profiles"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	var named []string
+	if profile != "" {
+		named = append(named, profile)
+	}
+	for _, d := range dirs {
+		if d.IsDir() && d.Name() != profile {
+			named = append(named, d.Name())
+		}
+	}
+	sort.Strings(named)
+	return append(names, named...), nil
+}
+
+func profilesCommand(args []string) error {
+	if len(args) == 0 || args[0] != "list" {
+		return usageErrorf("usage: profiles list")
+	}
+	fs := newFlagSet("profiles list")
+	if err := parseFlags(fs, args[1:]); err != nil {
+		return err
+	}
+	names, err := listProfiles()
+	if err != nil {
+		return err
+	}
+	active := profile
+	if active == "" {
+		active = defaultProfile
+	}
+	for _, name := range names {
+		marker := "  "
+		if name == active {
+			marker = "▶ "
+		}
+		fmt.Println(marker + name)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTakeProfileArg(t *testing.T) {
+	tests := []struct {
+		args []string
+		name string
+		rest []string
+	}{
+		{[]string{"status"}, "", []string{"status"}},
+		{[]string{"--profile", "work", "status"}, "work", []string{"status"}},
+		{[]string{"status", "-profile=home", "--json"}, "home", []string{"status", "--json"}},
+		{[]string{"add", "--task", "profile"}, "", []string{"add", "--task", "profile"}},
+	}
+	for _, tt := range tests {
+		name, rest, err := takeProfileArg(tt.args)
+		if err != nil || name != tt.name || !reflect.DeepEqual(rest, tt.rest) {
+			t.Errorf("takeProfileArg(%q) = %q, %q, %v; want %q, %q", tt.args, name, rest, err, tt.name, tt.rest)
+		}
+	}
+	if _, _, err := takeProfileArg([]string{"status", "--profile"}); exitCode(err) != exitUsage {
+		t.Errorf("--profile without a name: %v", err)
+	}
+}
+
+func TestSetProfile(t *testing.T) {
+	defer func(name string) { profile = name }(profile)
+	for _, name := range []string{"..", "a/b", "with space", ""} {
+		if err := setProfile(name); err == nil {
+			t.Errorf("setProfile(%q) accepted it", name)
+		}
+	}
+	if err := setProfile(defaultProfile); err != nil || profile != "" {
+		t.Errorf("the default profile: %q, %v", profile, err)
+	}
+}
+
+// Everything a profile keeps, the daemon's socket included, lies in its
+// own directories.
+func TestProfilePaths(t *testing.T) {
+	useTempDirs(t)
+	defer func(name string) { profile = name }(profile)
+	paths := func() []string {
+		var out []string
+		for _, path := range []func() (string, error){appDir, socketPath, lockPath, statePath, legacyLogDir} {
+			p, err := path()
+			if err != nil {
+				t.Fatal(err)
+			}
+			out = append(out, p)
+		}
+		return out
+	}
+	defaults := paths()
+	if err := setProfile("work"); err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range paths() {
+		if p == defaults[i] || !strings.Contains(p, "work") {
+			t.Errorf("%s is not the work profile's own", p)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// otherProjectsToday lists the projects other than project that already
+// have entries logged for today.
+func otherProjectsToday(project string, now time.Time) ([]string, error) {
+	days, err := dayEntries(startOfDay(now), startOfDay(now))
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	var others []string
+	for _, e := range days[now.Format(dateLayout)] {
+		if e.Project != project && e.Project != "" && !seen[e.Project] {
+			seen[e.Project] = true
+			others = append(others, e.Project)
+		}
+	}
+	return others, nil
+}
+
+func bothAckPath() (string, error) {
+	dir, err := appDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "project-check"), nil
+}
+
+// checkProject catches starting the day's second half under the wrong
+// project. It returns the project to track.
+func checkProject(project string, now time.Time) string {
+	ack, err := bothAckPath()
+	if err == nil {
+		if data, err := os.ReadFile(ack); err == nil && strings.TrimSpace(string(data)) == now.Format(dateLayout) {
+			return project
+		}
+	}
+	others, err := otherProjectsToday(project, now)
+	if err != nil || len(others) == 0 {
+		return project
+	}
+
+	fmt.Printf("🤔 Today already has a log for %s, but you started %s.\n", strings.Join(others, ", "), project)
+	for i, other := range others {
+		fmt.Printf("  %d) continue %s instead\n", i+1, other)
+	}
+	fmt.Printf("  s) switch to %s\n", project)
+	fmt.Println("  b) track both today and don't ask again")
+	for {
+		answer := strings.ToLower(inputPrompt("Choice: "))
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(others) {
+			return others[n-1]
+		}
+		switch answer {
+		case "s", "":
+			return project
+		case "b":
+			if ack != "" {
+				os.WriteFile(ack, []byte(now.Format(dateLayout)+"\n"), 0o644)
+			}
+			return project
+		}
+	}
+}
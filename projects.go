@@ -0,0 +1,348 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// knownProject is one project of the registry: its name, the aliases
+// that stand for it and the color it is shown in.
+type knownProject struct {
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases,omitempty"`
+	Color   string   `json:"color,omitempty"`
+	Client  string   `json:"client,omitempty"` // who the project is billed to
+}
+
+// projectColors are the colors a project can have, as ANSI codes.
+var projectColors = map[string]string{
+	"red": "31", "green": "32", "yellow": "33", "blue": "34",
+	"magenta": "35", "cyan": "36", "white": "37", "gray": "90",
+}
+
+// registry is the project registry, loaded at startup. While it is
+// empty any project name is accepted.
+var registry []knownProject
+
+func projectsPath() (string, error) {
+	dir, err := appDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "projects.json"), nil
+}
+
+func loadProjects() ([]knownProject, error) {
+	path, err := projectsPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var known []knownProject
+	if err := json.Unmarshal(data, &known); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return known, nil
+}
+
+func saveProjects(known []knownProject) error {
+	path, err := projectsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	sort.Slice(known, func(i, j int) bool { return strings.ToLower(known[i].Name) < strings.ToLower(known[j].Name) })
+	data, err := json.MarshalIndent(known, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, append(data, '\n'))
+}
+
+// findProject returns the index of the project name or alias stands
+// for, ignoring case, or -1.
+func findProject(known []knownProject, name string) int {
+	for i, p := range known {
+		if strings.EqualFold(p.Name, name) {
+			return i
+		}
+	}
+	for i, p := range known {
+		for _, a := range p.Aliases {
+			if strings.EqualFold(a, name) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// resolveProject maps a name or alias to the registered project. With
+// a registry, a name it doesn't know is a usage error that suggests the
+// closest one.
+func resolveProject(name string) (string, error) {
+	if len(registry) == 0 || name == "" {
+		return name, nil
+	}
+	if i := findProject(registry, name); i >= 0 {
+		return registry[i].Name, nil
+	}
+	hint, best := "", 3
+	for _, p := range registry {
+		for _, candidate := range append([]string{p.Name}, p.Aliases...) {
+			if d := editDistance(strings.ToLower(name), strings.ToLower(candidate)); d < best {
+				hint, best = p.Name, d
+			}
+		}
+	}
+	if hint != "" {
+		return "", usageErrorf("unknown project %q; did you mean %s? (project list shows them all)", name, hint)
+	}
+	return "", usageErrorf("unknown project %q; add it with project add %q", name, name)
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
+
+// projectValue is a -project flag: Set resolves aliases and rejects
+// projects the registry doesn't know.
+type projectValue struct{ name *string }
+
+func (v projectValue) String() string {
+	if v.name == nil {
+		return ""
+	}
+	return *v.name
+}
+
+func (v projectValue) Set(s string) error {
+	p, err := resolveProject(strings.TrimSpace(s))
+	if err != nil {
+		return err
+	}
+	*v.name = p
+	return nil
+}
+
+// projectFlag defines a project flag on fs.
+func projectFlag(fs *flag.FlagSet, name, value, usage string) *string {
+	p := &value
+	fs.Var(projectValue{p}, name, usage)
+	return p
+}
+
+// projectLabel pads the project's name to width and colors it when the
+// project has a color and output is colored.
+func projectLabel(name string, width int) string {
+	text := fmt.Sprintf("%-*s", width, name)
+	if i := findProject(registry, name); i >= 0 && registry[i].Color != "" && colorOutput() {
+		return "\033[" + projectColors[registry[i].Color] + "m" + text + "\033[0m"
+	}
+	return text
+}
+
+// projectsInLogs lists the projects that have logs, sorted.
+func projectsInLogs() ([]string, error) {
+	logs, err := listLogs()
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	var names []string
+	for _, lf := range logs {
+		if !seen[lf.Project] {
+			seen[lf.Project] = true
+			names = append(names, lf.Project)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// projectCandidates are the names the project prompt completes from:
+// the registered projects, then any others that have logs.
+func projectCandidates() []string {
+	var names []string
+	for _, p := range registry {
+		names = append(names, p.Name)
+	}
+	inLogs, _ := projectsInLogs()
+	return dedupe(inLogs, names, len(names)+len(inLogs))
+}
+
+func checkProjectColor(color string) error {
+	if _, ok := projectColors[color]; color != "" && !ok {
+		var names []string
+		for name := range projectColors {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return usageErrorf("unknown color %q; want one of %s", color, strings.Join(names, ", "))
+	}
+	return nil
+}
+
+// projectCommand manages the registry: add (or update) a project with
+// aliases, a color and a client, list them, or rename one everywhere.
+func projectCommand(args []string) error {
+	fs := newFlagSet("project")
+	aliases := fs.String("alias", "", "Comma-separated aliases, e.g. lg,league")
+	color := fs.String("color", "", "Color to show the project in: red, green, yellow, blue, magenta, cyan, white or gray")
+	dryRun := fs.Bool("dry-run", false, "For rename: show the changes to the logs without writing them")
+	preview := fs.Bool("preview", false, "For rename: show the changes to the logs and ask before writing them")
+	client := fs.String("client", "", "Client the project is billed to")
+	asJSON := jsonFlag(fs, "For list: print the registry as JSON")
+	var words []string
+	for {
+		if err := parseFlags(fs, args); err != nil {
+			return err
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		words = append(words, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	if len(words) == 0 {
+		words = []string{"list"}
+	}
+	known, err := loadProjects()
+	if err != nil {
+		return err
+	}
+
+	switch action, names := words[0], words[1:]; action {
+	case "list":
+		inLogs, err := projectsInLogs()
+		if err != nil {
+			return err
+		}
+		var unknown []string
+		for _, name := range inLogs {
+			if findProject(known, name) < 0 {
+				unknown = append(unknown, name)
+			}
+		}
+		if len(known) == 0 && len(unknown) == 0 {
+			return withCode(exitEmpty, errors.New("no projects registered"))
+		}
+		if *asJSON {
+			return printJSON(os.Stdout, struct {
+				Projects     []knownProject `json:"projects"`
+				Unregistered []string       `json:"unregistered,omitempty"`
+			}{append([]knownProject{}, known...), unknown})
+		}
+		for _, p := range known {
+			line := "  " + projectLabel(p.Name, 20)
+			if len(p.Aliases) > 0 {
+				line += "  aka " + strings.Join(p.Aliases, ", ")
+			}
+			if p.Client != "" {
+				line += "  · client " + p.Client
+			}
+			fmt.Println(strings.TrimRight(line, " "))
+		}
+		if len(unknown) > 0 {
+			fmt.Printf("⚠️  In the logs but not registered: %s\n", strings.Join(unknown, ", "))
+		}
+		return nil
+	case "add":
+		if len(names) != 1 {
+			return usageErrorf(`project add takes one name; quote names with spaces`)
+		}
+		if err := checkProjectColor(*color); err != nil {
+			return err
+		}
+		name := strings.TrimSpace(names[0])
+		i := -1
+		for j, p := range known {
+			if strings.EqualFold(p.Name, name) {
+				i = j
+			}
+		}
+		if i < 0 {
+			known = append(known, knownProject{Name: name})
+			i = len(known) - 1
+		}
+		for _, a := range strings.Split(*aliases, ",") {
+			if a = strings.TrimSpace(a); a == "" {
+				continue
+			}
+			if j := findProject(known, a); j >= 0 && j != i {
+				return usageErrorf("%q already stands for %s", a, known[j].Name)
+			}
+			if findProject(known[i:i+1], a) < 0 {
+				known[i].Aliases = append(known[i].Aliases, a)
+			}
+		}
+		if *color != "" {
+			known[i].Color = *color
+		}
+		if *client != "" {
+			known[i].Client = clientName(strings.TrimSpace(*client))
+		}
+		name = known[i].Name
+		if err := saveProjects(known); err != nil {
+			return err
+		}
+		fmt.Println("📁 Registered", name)
+		return nil
+	case "rename":
+		if len(names) != 2 {
+			return usageErrorf("project rename takes the old and the new name")
+		}
+		i := findProject(known, names[0])
+		if i < 0 {
+			return usageErrorf("unknown project %q", names[0])
+		}
+		from, to := known[i].Name, strings.TrimSpace(names[1])
+		if j := findProject(known, to); j >= 0 && j != i {
+			return usageErrorf("%q already stands for %s", to, known[j].Name)
+		}
+		written, err := renameProject(from, to, *dryRun, *preview)
+		if err != nil || !written {
+			return err
+		}
+		known[i].Name = to
+		if err := saveProjects(known); err != nil {
+			return err
+		}
+		fmt.Printf("📁 Renamed %s to %s\n", from, to)
+		return nil
+	default:
+		return usageErrorf("unknown project action %q; want add, list or rename", action)
+	}
+}
@@ -0,0 +1,345 @@
+//go:build linux
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+// ptyTimeout bounds every wait for output from the tool on a terminal.
+const ptyTimeout = 10 * time.Second
+
+// ptySession is the tool running on a pseudo-terminal, as when someone
+// starts it in a shell.
+type ptySession struct {
+	t      *testing.T
+	master *os.File
+	cmd    *exec.Cmd
+	mu     sync.Mutex
+	out    bytes.Buffer
+	seen   int // how much of out expect has consumed
+	done   chan struct{}
+}
+
+func openPTY(t *testing.T) (master, slave *os.File) {
+	t.Helper()
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+	if err != nil {
+		t.Skip("no pseudo-terminals:", err)
+	}
+	var n uint32
+	var unlock int32
+	if _, _, e := syscall.Syscall(syscall.SYS_IOCTL, master.Fd(), syscall.TIOCGPTN, uintptr(unsafe.Pointer(&n))); e != 0 {
+		master.Close()
+		t.Skip("no pseudo-terminals:", e)
+	}
+	if _, _, e := syscall.Syscall(syscall.SYS_IOCTL, master.Fd(), syscall.TIOCSPTLCK, uintptr(unsafe.Pointer(&unlock))); e != 0 {
+		master.Close()
+		t.Skip("no pseudo-terminals:", e)
+	}
+	slave, err = os.OpenFile(fmt.Sprintf("/dev/pts/%d", n), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		master.Close()
+		t.Skip("no pseudo-terminals:", err)
+	}
+	return master, slave
+}
+
+// runPTY starts the tool with args in home, a directory from
+// useTempDirs, with a terminal for its stdin and stdout.
+func runPTY(t *testing.T, home string, args ...string) *ptySession {
+	t.Helper()
+	if _, err := exec.LookPath("stty"); err != nil {
+		t.Skip("stty is missing")
+	}
+	master, slave := openPTY(t)
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(),
+		"WORKLOG_TEST_MAIN=1",
+		"HOME="+home,
+		"XDG_CONFIG_HOME="+filepath.Join(home, ".config"),
+		"TMPDIR="+home,
+		"NO_COLOR=1",
+		"TERM=xterm",
+	)
+	cmd.Dir = home
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = slave, slave, slave
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	slave.Close()
+	p := &ptySession{t: t, master: master, cmd: cmd, done: make(chan struct{})}
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, err := master.Read(buf)
+			p.mu.Lock()
+			p.out.Write(buf[:n])
+			p.mu.Unlock()
+			if err != nil {
+				close(p.done)
+				return
+			}
+		}
+	}()
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+		master.Close()
+	})
+	return p
+}
+
+// send types keys on the terminal.
+func (p *ptySession) send(keys string) {
+	p.t.Helper()
+	if _, err := p.master.WriteString(keys); err != nil {
+		p.t.Fatal(err)
+	}
+}
+
+// expect waits for text in the output written since the last expect.
+func (p *ptySession) expect(text string) {
+	p.t.Helper()
+	deadline := time.Now().Add(ptyTimeout)
+	for {
+		exited := false
+		select {
+		case <-p.done:
+			exited = true
+		default:
+		}
+		p.mu.Lock()
+		out := p.out.String()
+		p.mu.Unlock()
+		if i := strings.Index(out[p.seen:], text); i >= 0 {
+			p.seen += i + len(text)
+			return
+		}
+		if exited {
+			p.t.Fatalf("exited without printing %q:\n%s", text, out)
+		}
+		if time.Now().After(deadline) {
+			p.t.Fatalf("no %q after %s:\n%s", text, ptyTimeout, out)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// wait waits for the tool to exit and returns its exit code.
+func (p *ptySession) wait() int {
+	p.t.Helper()
+	select {
+	case <-p.done:
+	case <-time.After(ptyTimeout):
+		p.t.Fatalf("still running after %s:\n%s", ptyTimeout, p.out.String())
+	}
+	p.cmd.Wait()
+	return p.cmd.ProcessState.ExitCode()
+}
+
+// menuHome is a home with a log directory and yesterday's work to
+// repeat.
+func menuHome(t *testing.T) string {
+	t.Helper()
+	config, logs := useTempDirs(t)
+	writeConfig(t, config, "log_dir: "+logs+"\n")
+	yesterday := startOfDay(time.Now()).AddDate(0, 0, -1)
+	writeDay(t, logs, "League", yesterday, []TaskEntry{
+		{Task: "triage", Project: "League", Start: yesterday.Add(9 * time.Hour), Duration: time.Hour},
+	})
+	return filepath.Dir(logs)
+}
+
+func TestMenuQuit(t *testing.T) {
+	for _, keys := range []string{"4", "q", "\x1b[A\r", "\x1b[B\x1b[B\x1b[B\x1b[B\r"} {
+		t.Run(fmt.Sprintf("%q", keys), func(t *testing.T) {
+			p := runPTY(t, menuHome(t), "-menu", "-no-banner", "-no-git-task")
+			p.expect("Start tracking")
+			p.expect("Quit")
+			p.send(keys)
+			p.expect("See you next time")
+			if code := p.wait(); code != exitOK {
+				t.Errorf("exit code %d", code)
+			}
+		})
+	}
+}
+
+func TestMenuReportsThenQuit(t *testing.T) {
+	p := runPTY(t, menuHome(t), "-menu", "-no-banner", "-no-git-task")
+	p.expect("Quit")
+	p.send("2")
+	p.expect("📊 Today")
+	p.expect("Quit")
+	p.send("3")
+	p.expect(isoWeek(time.Now()))
+	p.expect("Total: 1h0m0s")
+	p.expect("Quit")
+	p.send("\x1b[B\x1b[B")
+	p.expect("› 2) Today's summary")
+	p.send("\x1b[A")
+	p.expect("› s) Same as last time")
+	p.send("\x1b[A\x1b[A\x1b[B\x1b[B\x1b[B\x1b[B\x1b[B")
+	p.expect("› 4) Quit")
+	p.send("\r")
+	p.expect("See you next time")
+	p.wait()
+}
+
+// Starting to track is one key away, Enter or 1.
+func TestMenuStartsTracking(t *testing.T) {
+	for _, keys := range []string{"\r", "1"} {
+		t.Run(fmt.Sprintf("%q", keys), func(t *testing.T) {
+			p := runPTY(t, menuHome(t), "-menu", "-no-banner", "-no-git-task", "-task", "write docs")
+			p.expect("Quit")
+			p.send(keys)
+			p.expect("write docs")
+		})
+	}
+}
+
+func TestMenuSameAsLastTime(t *testing.T) {
+	p := runPTY(t, menuHome(t), "-menu", "-no-banner", "-no-git-task")
+	p.expect("Same as last time: triage (League)")
+	p.expect("Quit")
+	p.send("\x1b[B\r")
+	p.expect("🔁 Same as last time: triage (League)")
+}
+
+// Repeating the last entry also repeats its tags, estimate and billing.
+func TestSameKeepsDetails(t *testing.T) {
+	config, logs := useTempDirs(t)
+	writeConfig(t, config, "log_dir: "+logs+"\n")
+	yesterday := startOfDay(time.Now()).AddDate(0, 0, -1)
+	writeDay(t, logs, "League", yesterday, []TaskEntry{
+		{Task: "triage", Project: "League", Start: yesterday.Add(9 * time.Hour), Duration: time.Hour,
+			Tags: []string{"support"}, Estimate: 30 * time.Minute, Billable: true, Rate: rate{Cents: 9500, Currency: "EUR"}},
+	})
+	p := runPTY(t, filepath.Dir(logs), "-same", "-no-banner", "-no-git-task")
+	p.expect("Same as last time: triage (League) #support ~30m0s 95.00 EUR/h")
+	p.send("q")
+	p.expect("What task did you just finish?")
+	p.send("\r")
+	p.expect("Done for the day?")
+	p.send("yes\r")
+	p.wait()
+	entries, err := writtenEntries(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("logged %+v, want one entry", entries)
+	}
+	e := entries[0]
+	if e.Task != "triage" || !reflect.DeepEqual(e.Tags, []string{"support"}) || e.Estimate != 30*time.Minute ||
+		!e.Billable || e.Rate != (rate{Cents: 9500, Currency: "EUR"}) {
+		t.Errorf("logged %+v, want yesterday's tags, estimate and rate", e)
+	}
+}
+
+// Two profiles track at the same time without touching each other's
+// files.
+func TestProfilesRunSideBySide(t *testing.T) {
+	_, logs := useTempDirs(t)
+	home := filepath.Dir(logs)
+	names := []string{"work", "personal"}
+	// files maps each file under home, while tracking and after, to
+	// the profile whose path it lies in.
+	files := map[string]string{}
+	collect := func() {
+		filepath.WalkDir(home, func(path string, d os.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			rel, _ := filepath.Rel(home, path)
+			owners := ""
+			for _, part := range strings.Split(rel, string(filepath.Separator)) {
+				for _, name := range names {
+					if part == name || strings.HasPrefix(part, name+"-") {
+						owners += name + " "
+					}
+				}
+			}
+			files[rel] = strings.TrimSpace(owners)
+			return nil
+		})
+	}
+	var sessions []*ptySession
+	for _, name := range names {
+		p := runPTY(t, home, "--profile", name, "-no-banner", "-no-git-task", "-task", name+" task")
+		p.expect("Tracking")
+		sessions = append(sessions, p)
+	}
+	collect()
+	for _, p := range sessions {
+		p.send("q")
+		p.expect("What task did you just finish?")
+		p.send("\r")
+		p.expect("Done for the day?")
+		p.send("yes\r")
+		if code := p.wait(); code != exitOK {
+			t.Fatalf("exit code %d", code)
+		}
+	}
+	collect()
+	seen := map[string]int{}
+	for rel, owner := range files {
+		if owner == "" || strings.Contains(owner, " ") {
+			t.Errorf("%s belongs to %q, want exactly one profile", rel, owner)
+		}
+		seen[owner]++
+	}
+	for _, name := range names {
+		if seen[name] == 0 {
+			t.Errorf("no files for the %s profile", name)
+		}
+	}
+}
+
+// S saves the running session without ending it; the placeholder goes
+// when the session ends.
+func TestPanicSave(t *testing.T) {
+	for _, key := range []string{"S", "\x13"} {
+		t.Run(fmt.Sprintf("%q", key), func(t *testing.T) {
+			config, logs := useTempDirs(t)
+			writeConfig(t, config, "log_dir: "+logs+"\n")
+			p := runPTY(t, filepath.Dir(logs), "-no-banner", "-no-git-task", "-task", "write docs")
+			p.expect("Tracking")
+			p.send(key)
+			p.expect("💾 Saved at")
+			entries, err := writtenEntries(time.Now())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 || entries[0].Task != panicSaveTask || entries[0].Start.IsZero() {
+				t.Errorf("saved %+v, want the running session", entries)
+			}
+			if st, ok, _ := loadState(); !ok || st.Start.IsZero() || st.Paused {
+				t.Errorf("state %+v, want the session running", st)
+			}
+			p.send("q")
+			p.expect("What task did you just finish?")
+			p.send("\r")
+			p.expect("Done for the day?")
+			p.send("yes\r")
+			p.wait()
+			entries, _ = writtenEntries(time.Now())
+			if len(entries) != 1 || entries[0].Task != "write docs" {
+				t.Errorf("logged %+v, want only the finished session", entries)
+			}
+		})
+	}
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// plannedTask is one item of the plan queue. Items nobody finished stay
+// in the queue and so roll over to the next day; finished ones are kept
+// until the end of the day they were finished on.
+type plannedTask struct {
+	Task     string `json:"task"`
+	Project  string `json:"project,omitempty"`  // empty for any project
+	Planned  string `json:"planned"`            // the day it was first planned
+	Done     string `json:"done,omitempty"`     // the day it was finished
+	Estimate string `json:"estimate,omitempty"` // how long it should take
+}
+
+func (p plannedTask) open() bool { return p.Done == "" }
+
+// fits reports whether the item can be worked on in the project.
+func (p plannedTask) fits(project string) bool {
+	return p.Project == "" || p.Project == project
+}
+
+// label is the item as listed, with where it rolled over from.
+func (p plannedTask) label(today string) string {
+	text := p.Task
+	if d, err := time.ParseDuration(p.Estimate); err == nil {
+		text += " ~" + formatDuration("summary", d)
+	}
+	if p.Project != "" {
+		text += " (" + p.Project + ")"
+	}
+	if p.open() && p.Planned < today {
+		text += " ↪ since " + p.Planned
+	}
+	return text
+}
+
+func queuePath() (string, error) {
+	dir, err := appDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "plan.json"), nil
+}
+
+// loadQueue reads the plan queue, leaving out items finished before
+// today.
+func loadQueue(today time.Time) ([]plannedTask, error) {
+	path, err := queuePath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var all []plannedTask
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	date := today.Format(dateLayout)
+	queue := all[:0]
+	for _, p := range all {
+		if p.open() || p.Done >= date {
+			queue = append(queue, p)
+		}
+	}
+	return queue, nil
+}
+
+func saveQueue(queue []plannedTask) error {
+	path, err := queuePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(queue, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, append(data, '\n'))
+}
+
+// plannedTasks returns the open items that fit the project, in order.
+func plannedTasks(project string) []string {
+	queue, err := loadQueue(time.Now())
+	if err != nil {
+		return nil
+	}
+	var tasks []string
+	for _, p := range queue {
+		if p.open() && p.fits(project) {
+			tasks = append(tasks, p.Task)
+		}
+	}
+	return tasks
+}
+
+// finishPlanned ticks off the first open item with the task's name,
+// ignoring case, once an entry for it is logged.
+func finishPlanned(project, task string, at time.Time) {
+	if task == "" || task == autoClosedTask {
+		return
+	}
+	queue, err := loadQueue(at)
+	if err != nil || len(queue) == 0 {
+		return
+	}
+	for i, p := range queue {
+		if p.open() && p.fits(project) && strings.EqualFold(p.Task, task) {
+			queue[i].Done = at.Format(dateLayout)
+			if err := saveQueue(queue); err != nil {
+				fmt.Println("❌ Could not update the plan:", err)
+				return
+			}
+			fmt.Printf("📋 Ticked off the plan: %s\n", p.Task)
+			return
+		}
+	}
+}
+
+// nextPlanned is the open item after current in the queue, wrapping
+// around, or the first one when current is not planned.
+func nextPlanned(planned []string, current string) string {
+	if len(planned) == 0 {
+		return current
+	}
+	for i, task := range planned {
+		if strings.EqualFold(task, current) {
+			return planned[(i+1)%len(planned)]
+		}
+	}
+	return planned[0]
+}
+
+// planLine shows the queue under the clock: the open items still
+// waiting besides the current task.
+func planLine(planned []string, current string) string {
+	var waiting []string
+	for _, task := range planned {
+		if !strings.EqualFold(task, current) {
+			waiting = append(waiting, task)
+		}
+	}
+	if len(waiting) == 0 {
+		return ""
+	}
+	const shown = 3
+	line := "📋 Planned: " + strings.Join(waiting[:min(len(waiting), shown)], " · ")
+	if len(waiting) > shown {
+		line += fmt.Sprintf(" (+%d more)", len(waiting)-shown)
+	}
+	return line + " - Press 't' to work on the next one"
+}
+
+func printQueue(queue []plannedTask, today string) {
+	n := 0
+	for _, p := range queue {
+		if p.open() {
+			n++
+			fmt.Printf("  %d) %s\n", n, p.label(today))
+		}
+	}
+	for _, p := range queue {
+		if !p.open() {
+			fmt.Printf("  ✅ %s\n", p.label(today))
+		}
+	}
+}
+
+// planCommand adds tasks to the plan queue, or lists it without any.
+// "done N" and "drop N" finish or remove the Nth open item and "clear"
+// removes them all.
+func planCommand(args []string) error {
+	fs := newFlagSet("plan")
+	project := projectFlag(fs, "project", "", "Project the tasks are for (default any)")
+	estimate := durationFlag(fs, "estimate", 0, "Estimate for each task that names none with ~, e.g. 2h")
+	asJSON := jsonFlag(fs, "Print the plan as JSON")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	now := time.Now()
+	today := now.Format(dateLayout)
+	queue, err := loadQueue(now)
+	if err != nil {
+		return err
+	}
+	var open []int
+	for i, p := range queue {
+		if p.open() {
+			open = append(open, i)
+		}
+	}
+	pick := func(arg string) (int, error) {
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 1 || n > len(open) {
+			return 0, usageErrorf("no planned task %q; want 1 to %d", arg, len(open))
+		}
+		return open[n-1], nil
+	}
+
+	switch action := fs.Arg(0); {
+	case action == "":
+		if len(queue) == 0 {
+			return withCode(exitEmpty, errors.New("nothing planned"))
+		}
+		if *asJSON {
+			return printJSON(os.Stdout, struct {
+				Date  string        `json:"date"`
+				Tasks []plannedTask `json:"tasks"`
+			}{today, queue})
+		}
+		fmt.Printf("📋 Plan for %s\n", today)
+		printQueue(queue, today)
+		return nil
+	case (action == "done" || action == "drop") && fs.NArg() == 2:
+		i, err := pick(fs.Arg(1))
+		if err != nil {
+			return err
+		}
+		task := queue[i].Task
+		if action == "done" {
+			queue[i].Done = today
+			fmt.Println("✅ Done:", task)
+		} else {
+			queue = append(queue[:i], queue[i+1:]...)
+			fmt.Println("🗑️  Dropped:", task)
+		}
+		return saveQueue(queue)
+	case action == "clear" && fs.NArg() == 1:
+		kept := queue[:0]
+		for _, p := range queue {
+			if !p.open() {
+				kept = append(kept, p)
+			}
+		}
+		fmt.Printf("🗑️  Dropped %d planned tasks\n", len(queue)-len(kept))
+		return saveQueue(kept)
+	}
+
+	for _, task := range fs.Args() {
+		if task = strings.TrimSpace(task); task == "" {
+			continue
+		}
+		p := plannedTask{Project: *project, Planned: today}
+		var d time.Duration
+		if p.Task, d = parseEstimate(task); d == 0 {
+			d = *estimate
+		}
+		if d > 0 {
+			p.Estimate = d.String()
+		}
+		queue = append(queue, p)
+	}
+	if err := saveQueue(queue); err != nil {
+		return err
+	}
+	fmt.Printf("📋 Plan for %s\n", today)
+	printQueue(queue, today)
+	return nil
+}
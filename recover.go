@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// interruptedTask stands in for the task of a session cut short by a
+// crash when nobody names it.
+const interruptedTask = "(interrupted)"
+
+// unsavedEntries drops the entries of an earlier run that already made
+// it into today's logs, e.g. with write_mode incremental.
+func unsavedEntries(entries []TaskEntry, now time.Time) []TaskEntry {
+	written, err := writtenEntries(now)
+	if err != nil {
+		return entries
+	}
+	return notWritten(entries, written)
+}
+
+// notWritten drops the entries that appear in written, matching them by
+// project, task and logged duration.
+func notWritten(entries, written []TaskEntry) []TaskEntry {
+	onDisk := map[string]int{}
+	for _, e := range written {
+		onDisk[e.Project+"\x00"+e.Task+"\x00"+formatDuration("markdown", e.Duration)]++
+	}
+	var unsaved []TaskEntry
+	for _, e := range entries {
+		key := e.Project + "\x00" + e.Task + "\x00" + formatDuration("markdown", e.Duration)
+		if onDisk[key] > 0 {
+			onDisk[key]--
+			continue
+		}
+		unsaved = append(unsaved, e)
+	}
+	return unsaved
+}
+
+// recoverSession looks for a run that ended today without finishing the
+// day, e.g. killed or cut off by a reboot, and offers to resume it (the
+// interrupted session keeps counting from where the last autosave left
+// it), to write it to the log right away, or to drop it.
+func (t *tracker) recoverSession(now time.Time) {
+	st, ok, err := loadState()
+	if err != nil || !ok {
+		return
+	}
+	if !startOfDay(st.Updated).Equal(startOfDay(now)) {
+		clearState()
+		return
+	}
+	pending := unsavedEntries(st.Entries, now)
+	running := !st.Start.IsZero() && st.Elapsed > 0
+	if len(pending) == 0 && !running {
+		clearState()
+		return
+	}
+
+	what := []string{}
+	if len(pending) > 0 {
+		what = append(what, fmt.Sprintf("%d unsaved entries", len(pending)))
+	}
+	if running {
+		what = append(what, fmt.Sprintf("a %s session on %s", formatDuration("summary", st.Elapsed), st.Project))
+	}
+	fmt.Printf("♻️  A run was interrupted at %s with %s\n", st.Updated.Format("15:04"), strings.Join(what, " and "))
+	for {
+		switch strings.ToLower(inputPrompt("   (r)esume, (f)inalize to the log, or (d)iscard? [r] ")) {
+		case "", "r", "resume":
+			t.entries = append(t.entries, pending...)
+			if running {
+				t.resume = &st
+			}
+			fmt.Printf("♻️  Restored %s\n", strings.Join(what, " and "))
+			return
+		case "f", "finalize":
+			if running {
+				task := st.Task
+				if task == "" {
+					task = inputPrompt("📝 What was the interrupted session for? ")
+				}
+				if task == "" {
+					task = interruptedTask
+				}
+				pending = append(pending, TaskEntry{Task: task, Project: st.Project, Start: st.Start, Duration: st.Elapsed})
+			}
+			if _, err := writeDaily(st.Project, pending, true); err != nil {
+				fmt.Println("❌", err)
+				continue
+			}
+			// Later writes must pick these up as entries from an
+			// earlier run.
+			priorEntries = map[string][]TaskEntry{}
+			lastWrites = map[string]lastWrite{}
+			clearState()
+			return
+		case "d", "discard":
+			clearState()
+			fmt.Println("🗑️  Discarded")
+			return
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+const configPollInterval = time.Minute
+
+// configWatcher picks up edits to config.yaml while the tool runs. Only
+// settings that are safe to change mid-session are applied; anything
+// given explicitly on the command line keeps its flag value.
+type configWatcher struct {
+	explicit map[string]bool
+	af       *autoFinalizer
+	modTime  time.Time
+	checked  time.Time
+	notice   string
+}
+
+func newConfigWatcher(explicit map[string]bool, af *autoFinalizer) *configWatcher {
+	w := &configWatcher{explicit: explicit, af: af, checked: time.Now()}
+	w.modTime, _ = configModTime()
+	return w
+}
+
+func configModTime() (time.Time, error) {
+	path, err := configPath()
+	if err != nil {
+		return time.Time{}, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+// poll reloads the config when the file changed, at most once a minute.
+func (w *configWatcher) poll() {
+	if time.Since(w.checked) < configPollInterval {
+		return
+	}
+	w.checked = time.Now()
+	modTime, _ := configModTime()
+	if !modTime.Equal(w.modTime) {
+		w.reload()
+	}
+}
+
+// reload applies the config file now. A broken file leaves the current
+// settings in place.
+func (w *configWatcher) reload() {
+	w.checked = time.Now()
+	w.modTime, _ = configModTime()
+	cfg, err := loadConfig()
+	if err == nil {
+		err = w.apply(cfg)
+	}
+	if err != nil {
+		w.notice = fmt.Sprintf("⚠️  Config not reloaded, keeping the old settings: %v", err)
+		return
+	}
+	w.notice = "🔄 Config reloaded at " + w.checked.Format("15:04:05")
+	if cfg.Layout != logLayout || cfg.WriteMode != writeMode {
+		w.notice += " (layout and write_mode changes need a restart)"
+	}
+}
+
+func (w *configWatcher) apply(cfg Config) error {
+	clock, action := w.af.clock, w.af.action
+	if !w.explicit["auto-finalize"] {
+		clock = cfg.AutoFinalize
+	}
+	if !w.explicit["auto-finalize-action"] {
+		action = cfg.AutoFinalizeAction
+	}
+	if err := w.af.reconfigure(clock, action); err != nil {
+		return err
+	}
+	if !w.explicit["mute"] {
+		muted = cfg.Mute
+	}
+	if !w.explicit["ascii"] {
+		asciiMode = cfg.ASCII
+	}
+	soundConfig = cfg.Sounds
+	setDurationStyles(cfg.Durations)
+	return nil
+}
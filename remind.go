@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// remindConfig is the remind section of the config: a nudge to take a
+// break after tracking After without a pause, 0 to never.
+type remindConfig struct {
+	After  time.Duration
+	Snooze time.Duration // how long 'z' puts the reminder off
+	Bell   bool          // ring the terminal bell
+	Notify bool          // show a desktop notification
+}
+
+// remindCarryOver is how long the tracking may stop between sessions,
+// say at the task prompt, without it counting as a break.
+const remindCarryOver = 2 * time.Minute
+
+// breakReminder watches how long tracking has gone on since the last
+// pause, across back-to-back sessions, and shows a banner once it runs
+// past cfg.After. A nil reminder never reminds.
+type breakReminder struct {
+	cfg      remindConfig
+	since    time.Time // when the stretch of work began
+	last     time.Time // the last tick while tracking
+	due      time.Time
+	reminded bool // rang for the current due time
+}
+
+func newBreakReminder(cfg remindConfig) *breakReminder {
+	if cfg.After <= 0 {
+		return nil
+	}
+	return &breakReminder{cfg: cfg}
+}
+
+// tick looks at the session every second; a pause, or a gap of more
+// than remindCarryOver between sessions, ends the stretch. It returns
+// the banner to show, "" while no break is due.
+func (r *breakReminder) tick(now time.Time, paused bool) string {
+	if r == nil {
+		return ""
+	}
+	if paused {
+		r.last = time.Time{}
+		return ""
+	}
+	if now.Sub(r.last) > remindCarryOver {
+		r.since, r.due, r.reminded = now, now.Add(r.cfg.After), false
+	}
+	r.last = now
+	if now.Before(r.due) {
+		return ""
+	}
+	worked := formatDuration("footer", now.Sub(r.since).Round(time.Minute))
+	if !r.reminded {
+		r.reminded = true
+		if r.cfg.Bell && !muted {
+			fmt.Print("\a")
+		}
+		if r.cfg.Notify {
+			sendNotification("🧘 "+worked+" without a break", "Time to stand up and stretch")
+		}
+	}
+	return fmt.Sprintf("🧘 %s without a break - Press 'p' to take one or 'z' to snooze %s", worked, formatDuration("footer", r.cfg.Snooze))
+}
+
+// snooze puts the reminder off by cfg.Snooze from now.
+func (r *breakReminder) snooze(now time.Time) {
+	if r == nil || now.Before(r.due) {
+		return
+	}
+	r.due, r.reminded = now.Add(r.cfg.Snooze), false
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const glyphRows = 5
+
+var digits = map[rune][]string{
+	'0': {" ███ ", "█   █", "█   █", "█   █", " ███ "},
+	'1': {"  █  ", " ██  ", "  █  ", "  █  ", " ███ "},
+	'2': {" ███ ", "    █", " ███ ", "█    ", "█████"},
+	'3': {"████ ", "    █", " ███ ", "    █", "████ "},
+	'4': {"█  █ ", "█  █ ", "█████", "   █ ", "   █ "},
+	'5': {"█████", "█    ", "████ ", "    █", "████ "},
+	'6': {" ███ ", "█    ", "████ ", "█   █", " ███ "},
+	'7': {"█████", "   █ ", "  █  ", " █   ", " █   "},
+	'8': {" ███ ", "█   █", " ███ ", "█   █", " ███ "},
+	'9': {" ███ ", "█   █", " ████", "    █", " ███ "},
+	':': {"     ", "  █  ", "     ", "  █  ", "     "},
+	'-': {"     ", "     ", " ███ ", "     ", "     "},
+	'.': {"     ", "     ", "     ", "     ", "  █  "},
+	'?': {" ███ ", "█   █", "  ██ ", "     ", "  █  "},
+	' ': {"     ", "     ", "     ", "     ", "     "},
+}
+
+// asciiMode swaps the block glyphs for '#' on terminals that cannot
+// draw them.
+var asciiMode bool
+
+func init() {
+	for r, rows := range digits {
+		if len(rows) != glyphRows {
+			panic(fmt.Sprintf("glyph %q has %d rows, want %d", r, len(rows), glyphRows))
+		}
+		width := utf8.RuneCountInString(rows[0])
+		for i, row := range rows {
+			if n := utf8.RuneCountInString(row); n != width {
+				panic(fmt.Sprintf("glyph %q row %d is %d wide, want %d", r, i, n, width))
+			}
+		}
+	}
+}
+
+// RenderString returns the big-letter rows for s. Runes without a glyph
+// are drawn as '?'.
+func RenderString(s string) []string {
+	rows := make([]string, glyphRows)
+	for _, ch := range s {
+		glyph, ok := digits[ch]
+		if !ok {
+			glyph = digits['?']
+		}
+		for i := 0; i < glyphRows; i++ {
+			rows[i] += glyph[i] + "  "
+		}
+	}
+	if asciiMode {
+		for i := range rows {
+			rows[i] = strings.ReplaceAll(rows[i], "█", "#")
+		}
+	}
+	return rows
+}
+
+func clearScreen() {
+	fmt.Print("\033[2J\033[H")
+	tui.invalidate()
+}
+
+// renderClock starts a frame with d in big digits.
+func renderClock(d time.Duration) {
+	h := int(d.Hours())
+	m := int(d.Minutes()) % 60
+	s := int(d.Seconds()) % 60
+	timeStr := fmt.Sprintf("%02d:%02d:%02d", h, m, s)
+
+	for _, row := range RenderString(timeStr) {
+		tui.Println(row)
+	}
+}
+
+func renderTime(d time.Duration, paused bool) {
+	renderClock(d)
+	renderKeys(paused)
+}
+
+// renderKeys says what the keys do while tracking.
+func renderKeys(paused bool) {
+	if paused {
+		tui.Println("\n⏸️  Paused - Press 'p' to resume | 'q' to end task | 'n' for the next task | 'm' to switch project | 'i' for an interruption | 'r' to reload config | 'S' to save now")
+	} else {
+		tui.Println("\n▶️  Tracking - Press 'p' to pause | 'q' to end task | 'n' for the next task | 'm' to switch project | 'i' for an interruption | 'r' to reload config | 'S' to save now")
+	}
+}
+
+// renderTimebox counts down to the end of the timebox, then counts the
+// overtime up in red.
+func renderTimebox(box, elapsed time.Duration, paused bool) {
+	if elapsed <= box {
+		renderTime(box-elapsed, paused)
+		tui.Printf("⏲️  Timebox of %s\n", formatDuration("footer", box))
+		return
+	}
+	over := elapsed - box
+	h, m, s := int(over.Hours()), int(over.Minutes())%60, int(over.Seconds())%60
+	for _, row := range RenderString(fmt.Sprintf("-%02d:%02d:%02d", h, m, s)) {
+		tui.Println("\033[31m" + row + "\033[0m")
+	}
+	renderKeys(paused)
+	tui.Printf("⏰ %s over the timebox of %s\n", formatDuration("footer", over), formatDuration("footer", box))
+}
+
+// timeboxNote records the planned and the actual length of a session.
+func timeboxNote(box, took time.Duration) string {
+	note := fmt.Sprintf("⏲️ Timebox %s, took %s", formatDuration("markdown", box), formatDuration("markdown", took))
+	if took > box {
+		note += fmt.Sprintf(" (%s over)", formatDuration("markdown", took-box))
+	}
+	return note
+}
+
+// todayLines lists the last few of today's entries under the clock.
+func todayLines(entries []TaskEntry) []string {
+	if len(entries) == 0 {
+		return nil
+	}
+	return append([]string{"", "📋 Today"}, formatSummary(entries, max(len(entries)-todayShown, 0), len(entries))...)
+}
+
+const todayShown = 5
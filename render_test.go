@@ -0,0 +1,66 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestGlyphDimensions(t *testing.T) {
+	for _, r := range "0123456789:-.? " {
+		rows, ok := digits[r]
+		if !ok {
+			t.Errorf("no glyph for %q", r)
+			continue
+		}
+		if len(rows) != glyphRows {
+			t.Errorf("glyph %q has %d rows, want %d", r, len(rows), glyphRows)
+			continue
+		}
+		width := utf8.RuneCountInString(rows[0])
+		for i, row := range rows {
+			if n := utf8.RuneCountInString(row); n != width {
+				t.Errorf("glyph %q row %d is %d wide, want %d", r, i, n, width)
+			}
+		}
+	}
+}
+
+func TestRenderStringFallback(t *testing.T) {
+	want := RenderString("?")
+	for _, s := range []string{"x", "é", "🙂", "\t"} {
+		got := RenderString(s)
+		if strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Errorf("RenderString(%q) is not drawn as '?':\n%s", s, strings.Join(got, "\n"))
+		}
+	}
+
+	rows := RenderString("1x:2")
+	if len(rows) != glyphRows {
+		t.Fatalf("RenderString gave %d rows, want %d", len(rows), glyphRows)
+	}
+	for i, row := range rows {
+		want := digits['1'][i] + "  " + digits['?'][i] + "  " + digits[':'][i] + "  " + digits['2'][i] + "  "
+		if row != want {
+			t.Errorf("row %d = %q, want %q", i, row, want)
+		}
+	}
+}
+
+func TestRenderStringASCII(t *testing.T) {
+	asciiMode = true
+	defer func() { asciiMode = false }()
+	for _, row := range RenderString("12:34") {
+		if strings.Contains(row, "█") {
+			t.Errorf("ASCII row %q still has block characters", row)
+		}
+	}
+}
+
+func TestRenderStringEmpty(t *testing.T) {
+	for i, row := range RenderString("") {
+		if row != "" {
+			t.Errorf("row %d of the empty string = %q", i, row)
+		}
+	}
+}
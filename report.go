@@ -0,0 +1,335 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// dayEntries returns the logged entries of every day in [from, to],
+// keyed by YYYY-MM-DD, across daily and weekly logs.
+func dayEntries(from, to time.Time) (map[string][]TaskEntry, error) {
+	logs, err := listLogs()
+	if err != nil {
+		return nil, err
+	}
+	first, last := from.Format(dateLayout), to.Format(dateLayout)
+	days := map[string][]TaskEntry{}
+	for _, lf := range logs {
+		if !inRange(lf, from, to) {
+			continue
+		}
+		content, err := os.ReadFile(lf.Path)
+		if err != nil {
+			return nil, err
+		}
+		for date, entries := range parseLog(content) {
+			if date >= first && date <= last {
+				days[date] = append(days[date], entries...)
+			}
+		}
+	}
+	return days, nil
+}
+
+// dayTotals sums dayEntries per day.
+func dayTotals(from, to time.Time) (map[string]time.Duration, error) {
+	days, err := dayEntries(from, to)
+	if err != nil {
+		return nil, err
+	}
+	totals := make(map[string]time.Duration, len(days))
+	for date, entries := range days {
+		totals[date] = totalDuration(entries)
+	}
+	return totals, nil
+}
+
+// summaryReport prints the time per project between from and to.
+func summaryReport(w io.Writer, from, to time.Time, entries []TaskEntry, asJSON bool) error {
+	type projectTotal struct {
+		Project string `json:"project"`
+		Entries int    `json:"entries"`
+		Total   string `json:"total"`
+		Earned  string `json:"earned,omitempty"`
+		total   time.Duration
+		earned  map[string]int64
+	}
+	var totals []projectTotal
+	index := map[string]int{}
+	for _, e := range entries {
+		i, ok := index[e.Project]
+		if !ok {
+			i = len(totals)
+			index[e.Project] = i
+			totals = append(totals, projectTotal{Project: e.Project})
+		}
+		totals[i].Entries++
+		totals[i].total += e.Duration
+		totals[i].earned = addEarned(totals[i].earned, e)
+	}
+	sort.Slice(totals, func(i, j int) bool { return totals[i].total > totals[j].total })
+	for i := range totals {
+		totals[i].Total = formatDuration("json", totals[i].total)
+		totals[i].Earned = moneyText(totals[i].earned)
+	}
+	earned := moneyText(earnedTotal(entries))
+	work, breaks := totalDuration(entries), breakTotal(entries)
+	interruptions, interrupted := interruptionTotals(entries)
+
+	if asJSON {
+		out := struct {
+			From     string         `json:"from"`
+			To       string         `json:"to"`
+			Total    string         `json:"total"`
+			Breaks   string         `json:"breaks,omitempty"`
+			Ratio    string         `json:"work_break_ratio,omitempty"`
+			Count    int            `json:"interruptions,omitempty"`
+			Lost     string         `json:"interrupted,omitempty"`
+			Earned   string         `json:"earned,omitempty"`
+			Projects []projectTotal `json:"projects"`
+			Clients  []periodTotal  `json:"clients,omitempty"`
+			Tags     []periodTotal  `json:"tags,omitempty"`
+		}{from.Format(dateLayout), to.Format(dateLayout), formatDuration("json", work), durationJSON(breaks), breakRatio(work, breaks),
+			interruptions, durationJSON(interrupted), earned, totals, clientTotals(entries), tagTotals(entries)}
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+
+	span := from.Format(dateLayout)
+	if !to.Equal(from) {
+		span += " – " + to.Format(dateLayout)
+	}
+	fmt.Fprintf(w, "📊 %s\n", span)
+	for _, t := range totals {
+		fmt.Fprintf(w, "  %s %10s  (%d entries)%s%s\n", projectLabel(t.Project, 20), formatDuration("summary", t.total), t.Entries, earnedColumn(t.Earned), bar(t.total, totals[0].total))
+	}
+	fmt.Fprintf(w, "  %-20s %10s%s\n", "Total", formatDuration("summary", work), earnedColumn(earned))
+	if breaks > 0 {
+		fmt.Fprintf(w, "  %-20s %10s  work/break %s\n", "Breaks", formatDuration("summary", breaks), breakRatio(work, breaks))
+		if reasons := breakReasons(entries); reasons != "" {
+			fmt.Fprintf(w, "  %-20s %s\n", "", reasons)
+		}
+	}
+	if interruptions > 0 {
+		fmt.Fprintf(w, "  %-20s %s\n", "Interruptions", formatInterruptions(entries))
+	}
+	if clients := clientTotals(entries); len(clients) > 0 {
+		fmt.Fprintln(w, "\n🤝 Clients")
+		for _, c := range clients {
+			fmt.Fprintf(w, "  %-20s %10s  (%d entries)%s\n", c.Name, formatDuration("summary", c.total), c.Entries, earnedColumn(c.Earned))
+		}
+	}
+	if tags := tagTotals(entries); len(tags) > 0 {
+		fmt.Fprintln(w, "\n🏷️  Tags")
+		for _, t := range tags {
+			fmt.Fprintf(w, "  %-20s %10s  (%d entries)\n", "#"+t.Name, formatDuration("summary", t.total), t.Entries)
+		}
+	}
+	return nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// chartWidth is how many cells the bars in text reports may take; 0
+// leaves them out.
+var chartWidth int
+
+// defaultChartWidth applies when the terminal width is unknown, and
+// chartMargin is what the labels and totals beside a bar take.
+const (
+	defaultChartWidth = 30
+	chartMargin       = 60
+)
+
+// fitChart sizes the bars to the terminal.
+func fitChart() int {
+	if _, cols, ok := terminalSize(); ok {
+		return max(cols-chartMargin, 10)
+	}
+	if cols, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil {
+		return max(cols-chartMargin, 10)
+	}
+	return defaultChartWidth
+}
+
+var barEighths = []rune("▏▎▍▌▋▊▉")
+
+// bar draws v as a horizontal bar of up to chartWidth cells against the
+// largest value scaled, in eighths of a cell; "" when charts are off.
+func bar(v, largest time.Duration) string {
+	if chartWidth <= 0 || largest <= 0 || v <= 0 {
+		return ""
+	}
+	eighths := int(int64(v) * int64(chartWidth) * 8 / int64(largest))
+	if asciiMode {
+		return "  " + strings.Repeat("#", max(eighths/8, 1))
+	}
+	s := strings.Repeat("█", eighths/8)
+	if rest := eighths % 8; rest > 0 {
+		s += string(barEighths[rest-1])
+	} else if s == "" {
+		s = string(barEighths[0])
+	}
+	return "  " + s
+}
+
+// sparkline draws one character per value scaled to the largest. Days
+// without data are drawn as a dot; asciiMode uses the digits 0-9.
+func sparkline(values []time.Duration) string {
+	var max time.Duration
+	for _, v := range values {
+		if v > max {
+			max = v
+		}
+	}
+	var b strings.Builder
+	for _, v := range values {
+		switch {
+		case v <= 0 && asciiMode:
+			b.WriteRune('.')
+		case v <= 0:
+			b.WriteRune('·')
+		case asciiMode:
+			b.WriteByte(byte('0' + int(9*v/max)))
+		default:
+			b.WriteRune(sparkBlocks[int(time.Duration(len(sparkBlocks)-1)*v/max)])
+		}
+	}
+	return b.String()
+}
+
+// streak counts consecutive days with tracked time ending on day.
+func streak(totals func(time.Time) time.Duration, day time.Time) int {
+	n := 0
+	for totals(day) > 0 {
+		n++
+		day = day.AddDate(0, 0, -1)
+	}
+	return n
+}
+
+const streakLookback = 366
+
+// recentBanner summarises the week before now in one line.
+func recentBanner(now time.Time) (string, error) {
+	yesterday := startOfDay(now).AddDate(0, 0, -1)
+	totals, err := dayTotals(yesterday.AddDate(0, 0, -streakLookback), yesterday)
+	if err != nil {
+		return "", err
+	}
+	at := func(day time.Time) time.Duration { return totals[day.Format(dateLayout)] }
+
+	values := make([]time.Duration, 7)
+	var sum time.Duration
+	active := 0
+	for i := range values {
+		values[i] = at(yesterday.AddDate(0, 0, i-6))
+		if values[i] > 0 {
+			sum += values[i]
+			active++
+		}
+	}
+	if active == 0 {
+		return "", nil
+	}
+
+	line := fmt.Sprintf("Last 7 days: %s (avg %s) · yesterday %s", sparkline(values), formatDuration("footer", sum/time.Duration(active)), formatDuration("footer", at(yesterday)))
+	if n := streak(at, yesterday); n > 1 {
+		line += fmt.Sprintf(" · streak %d days", n)
+	}
+	return line, nil
+}
+
+func reportCommand(args []string) error {
+	fs := newFlagSet("report")
+	distribution := fs.Bool("distribution", false, "Show how long sessions last")
+	estimates := fs.Bool("estimates", false, "Compare the estimated tasks' estimates with the time tracked on them")
+	week := fs.Bool("week", false, "Report on the week of --from (default this week) per project, day and task")
+	month := fs.Bool("month", false, "Report on the month of --from (default this month) per project, day and task")
+	project := projectFlag(fs, "project", "", "Only include this project")
+	client := fs.String("client", "", "Only include the projects billed to this client")
+	match := fs.String("match", "", "Only include tasks containing this text")
+	tag := fs.String("tag", "", "Only include entries with this tag")
+	fromText := fs.String("from", "", "First day (YYYY-MM-DD; default today, or 30 days ago with --distribution or --estimates)")
+	toText := fs.String("to", "", "Last day (YYYY-MM-DD, default today)")
+	bucketsText := fs.String("buckets", "", "Bucket edges, e.g. 15m,30m,1h,2h")
+	asJSON := jsonFlag(fs, "Print JSON")
+	noChart := fs.Bool("no-chart", false, "Leave the bar charts out of text reports")
+	failOnEmpty := fs.Bool("fail-on-empty", false, "Exit with code 4 when no sessions match")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if !*noChart && !*asJSON {
+		chartWidth = fitChart()
+	}
+	from, to, err := parseDateRange(*fromText, *toText)
+	if err != nil {
+		return err
+	}
+	if *estimates && *distribution {
+		return usageErrorf("pick one of --estimates and --distribution")
+	}
+	title := ""
+	if *week || *month {
+		if *week && *month || *distribution || !to.IsZero() {
+			return usageErrorf("--week and --month take only --from, not each other, --to or --distribution")
+		}
+		if from.IsZero() {
+			from = time.Now()
+		}
+		period := "month"
+		if *week {
+			period = "week"
+		}
+		title, from, to = periodRange(period, from)
+	}
+	if to.IsZero() {
+		to = startOfDay(time.Now())
+	}
+	if from.IsZero() {
+		from = to
+		if *distribution || *estimates {
+			from = to.AddDate(0, 0, -29)
+		}
+	}
+	edges := defaultBucketEdges
+	if *bucketsText != "" {
+		if edges, err = parseBucketEdges(*bucketsText); err != nil {
+			return err
+		}
+	}
+
+	days, err := dayEntries(from, to)
+	if err != nil {
+		return err
+	}
+	entries, kept := reportFilter{Project: *project, Client: *client, Match: *match, Tag: *tag}.filterDays(days)
+	if len(entries) == 0 && *failOnEmpty {
+		return withCode(exitEmpty, fmt.Errorf("no sessions between %s and %s", from.Format(dateLayout), to.Format(dateLayout)))
+	}
+	if *estimates {
+		return estimateReport(os.Stdout, from, to, entries, *asJSON, *failOnEmpty)
+	}
+	if title != "" {
+		return periodReport(os.Stdout, title, from, to, kept, *asJSON)
+	}
+	if !*distribution {
+		return summaryReport(os.Stdout, from, to, entries, *asJSON)
+	}
+	return distributionReport(entries, edges, *asJSON)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// lastFinished is the logged entry that ended last, looking back as far
+// as the same-as-last-time lookup does; with project set, the last one
+// of that project.
+func lastFinished(project string, now time.Time) (TaskEntry, bool) {
+	today := startOfDay(now)
+	days, err := dayEntries(today.AddDate(0, 0, -sameAsLastTimeLookback), today)
+	if err != nil {
+		return TaskEntry{}, false
+	}
+	var last TaskEntry
+	found := false
+	for _, entries := range days {
+		for _, e := range entries {
+			if e.Task == "" || e.Task == autoClosedTask || (project != "" && e.Project != project) {
+				continue
+			}
+			if !found || entryEnd(e).After(entryEnd(last)) {
+				last, found = e, true
+			}
+		}
+	}
+	return last, found
+}
+
+// timerTask writes e's task back the way it is typed, with its tags and
+// rate, so the timer is logged like e when it stops.
+func timerTask(e TaskEntry) string {
+	words := []string{e.Task}
+	for _, tag := range e.Tags {
+		words = append(words, "#"+tag)
+	}
+	switch {
+	case !e.Rate.isZero():
+		words = append(words, e.Rate.String())
+	case e.Billable:
+		words = append(words, "$")
+	}
+	return strings.Join(words, " ")
+}
+
+// resumeCommand starts a timer on the project and task of the entry
+// that was finished last, for carrying on after an interruption.
+func resumeCommand(args []string) error {
+	fs := newFlagSet("resume")
+	project := projectFlag(fs, "project", "", "Resume the last task of this project instead")
+	name := fs.String("name", "", "Name for a timer running alongside others")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	e, ok := lastFinished(*project, time.Now())
+	if !ok {
+		return withCode(exitEmpty, fmt.Errorf("no finished entry in the last %d days to resume", sameAsLastTimeLookback))
+	}
+	t, err := timerRequest(daemonRequest{Cmd: "start", Name: *name, Project: e.Project, Task: timerTask(e)})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("🔁 Resumed %s · %s since %s (last tracked until %s)\n", t.label(), e.Task, t.Start.Format("15:04"), entryEnd(e).Format("Mon 15:04"))
+	return nil
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const browserPageSize = 10
+
+// reviewCommand is one line typed at the review/browse prompt, e.g.
+// "e 2 fix importer" or "d 3".
+type reviewCommand struct {
+	Action string
+	Index  int // zero-based; -1 when the action takes none
+	Arg    string
+}
+
+func parseReviewCommand(line string, count int) (reviewCommand, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return reviewCommand{Action: "quit", Index: -1}, nil
+	}
+
+	cmd := reviewCommand{Index: -1}
+	switch strings.ToLower(fields[0]) {
+	case "q", "quit", "done":
+		cmd.Action = "quit"
+		return cmd, nil
+	case "n", "next":
+		cmd.Action = "next"
+		return cmd, nil
+	case "b", "back":
+		cmd.Action = "back"
+		return cmd, nil
+	case "e", "edit":
+		cmd.Action = "edit"
+	case "d", "delete":
+		cmd.Action = "delete"
+	case "r", "rate":
+		cmd.Action = "rate"
+	case "a", "attach":
+		cmd.Action = "attach"
+	default:
+		return cmd, fmt.Errorf("unknown command %q", fields[0])
+	}
+
+	if len(fields) < 2 {
+		return cmd, fmt.Errorf("%s needs an entry number", cmd.Action)
+	}
+	n, err := strconv.Atoi(fields[1])
+	if err != nil || n < 1 || n > count {
+		return cmd, fmt.Errorf("no entry %q", fields[1])
+	}
+	cmd.Index = n - 1
+	cmd.Arg = strings.TrimSpace(strings.Join(fields[2:], " "))
+	if cmd.Action == "edit" && cmd.Arg == "" {
+		return cmd, fmt.Errorf("edit needs the new task name")
+	}
+	if cmd.Action == "attach" && cmd.Arg == "" {
+		return cmd, fmt.Errorf("attach needs a URL or path, or -N to remove the Nth attachment")
+	}
+	if cmd.Action == "rate" && cmd.Arg == "" {
+		return cmd, fmt.Errorf("rate needs a rate such as 120 EUR/h, $ for the project rate or - for not billable")
+	}
+	return cmd, nil
+}
+
+func totalDuration(entries []TaskEntry) time.Duration {
+	var total time.Duration
+	for _, e := range entries {
+		total += e.Duration
+	}
+	return total
+}
+
+// formatSummary renders entries[from:to] as numbered lines followed by
+// the running total of all entries.
+func formatSummary(entries []TaskEntry, from, to int) []string {
+	var lines []string
+	for i := from; i < to && i < len(entries); i++ {
+		e := entries[i]
+		start := "--:--"
+		if !e.Start.IsZero() {
+			start = e.Start.Format("15:04")
+		}
+		line := fmt.Sprintf("%3d) %s  %-40s ⏱️ %s", i+1, start, e.Task, formatDuration("summary", e.Duration))
+		if n := len(e.Attachments); n > 0 {
+			line += fmt.Sprintf("  🔗%d", n)
+		}
+		for _, t := range e.Tags {
+			line += " #" + t
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, fmt.Sprintf("     Total: %s across %d entries", formatDuration("summary", totalDuration(entries)), len(entries)))
+	return lines
+}
+
+// browseEntries shows today's entries a page at a time and applies edit
+// and delete commands to them. It returns the possibly modified slice.
+func browseEntries(entries []TaskEntry) []TaskEntry {
+	page := 0
+	for {
+		pages := (len(entries) + browserPageSize - 1) / browserPageSize
+		if pages == 0 {
+			pages = 1
+		}
+		if page >= pages {
+			page = pages - 1
+		}
+
+		fmt.Printf("\n📋 Today's entries (page %d/%d)\n", page+1, pages)
+		for _, line := range formatSummary(entries, page*browserPageSize, (page+1)*browserPageSize) {
+			fmt.Println(line)
+		}
+
+		cmd, err := parseReviewCommand(inputPrompt("[n]ext [b]ack | e <#> <task> | d <#> | r <#> <rate> | a <#> <url|path|-N> | Enter to return: "), len(entries))
+		if err != nil {
+			fmt.Println("❌", err)
+			continue
+		}
+		switch cmd.Action {
+		case "quit":
+			return entries
+		case "next":
+			if page < pages-1 {
+				page++
+			}
+		case "back":
+			if page > 0 {
+				page--
+			}
+		case "edit":
+			task, tags := parseTags(cmd.Arg)
+			entries[cmd.Index].Task = task
+			if len(tags) > 0 {
+				entries[cmd.Index].Tags = tags
+			}
+		case "rate":
+			e := &entries[cmd.Index]
+			switch cmd.Arg {
+			case "-":
+				e.Billable, e.Rate = false, rate{}
+			case "$":
+				e.Billable, e.Rate = true, rate{}
+			default:
+				r, err := parseRate(cmd.Arg, defaultCurrency)
+				if err != nil {
+					fmt.Println("❌", err)
+					continue
+				}
+				e.Billable, e.Rate = true, r
+			}
+		case "attach":
+			if err := editAttachments(&entries[cmd.Index], cmd.Arg); err != nil {
+				fmt.Println("❌", err)
+				continue
+			}
+			printAttachments(os.Stdout, entries[cmd.Index])
+		case "delete":
+			for _, rec := range syncedTargets(time.Now().Format(dateLayout), entries, cmd.Index) {
+				fmt.Printf("⚠️  Already synced to %s as %s; delete it there too\n", rec.Target, rec.RemoteID)
+			}
+			entries = append(entries[:cmd.Index], entries[cmd.Index+1:]...)
+		}
+	}
+}
+
+// breakTotal is the time the entries were paused for.
+func breakTotal(entries []TaskEntry) time.Duration {
+	var total time.Duration
+	for _, e := range entries {
+		for _, p := range e.Pauses {
+			total += p.Duration()
+		}
+	}
+	return total
+}
+
+// durationJSON is d for JSON reports, "" for none.
+func durationJSON(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+	return formatDuration("json", d)
+}
+
+// breakRatio is worked time per unit of break, e.g. "4.5:1", or "" with
+// no breaks.
+func breakRatio(work, breaks time.Duration) string {
+	if breaks <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%.1f:1", float64(work)/float64(breaks))
+}
+
+// breakReasons sums the pauses that were given a reason per reason,
+// e.g. "lunch 30m0s, phone call 15m0s".
+func breakReasons(entries []TaskEntry) string {
+	var reasons []string
+	byReason := map[string]time.Duration{}
+	for _, e := range entries {
+		for _, p := range e.Pauses {
+			if p.Reason == "" {
+				continue
+			}
+			if _, ok := byReason[p.Reason]; !ok {
+				reasons = append(reasons, p.Reason)
+			}
+			byReason[p.Reason] += p.Duration()
+		}
+	}
+	parts := make([]string, len(reasons))
+	for i, r := range reasons {
+		parts[i] = fmt.Sprintf("%s %s", r, formatDuration("summary", byReason[r]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatBreaks sums the day's pauses, broken down by reason when any
+// were given, e.g. "45m0s (lunch 30m0s, phone call 15m0s)".
+func formatBreaks(entries []TaskEntry) string {
+	total := breakTotal(entries)
+	if total == 0 {
+		return ""
+	}
+	out := formatDuration("summary", total)
+	if reasons := breakReasons(entries); reasons != "" {
+		out += " (" + reasons + ")"
+	}
+	return out
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sizeCheckInterval is how often the terminal size is looked up again,
+// to notice it being resized.
+const sizeCheckInterval = 5 * time.Second
+
+// screen draws the tracking view a frame at a time and rewrites only the
+// lines that changed since the last frame, so the clock does not
+// flicker. Anything printed outside a frame, like a prompt, must be
+// followed by invalidate so the next frame is drawn in full.
+type screen struct {
+	pending    strings.Builder
+	shown      []string
+	valid      bool
+	rows, cols int
+	checked    time.Time
+}
+
+var tui screen
+
+func (s *screen) Println(a ...any) { fmt.Fprintln(&s.pending, a...) }
+
+func (s *screen) Printf(format string, a ...any) { fmt.Fprintf(&s.pending, format, a...) }
+
+func (s *screen) invalidate() { s.valid = false }
+
+// resized looks the terminal size up now and then and reports whether it
+// changed. The size stays unknown when stdin is not a terminal.
+func (s *screen) resized(now time.Time) bool {
+	if now.Sub(s.checked) < sizeCheckInterval {
+		return false
+	}
+	s.checked = now
+	rows, cols, ok := terminalSize()
+	if !ok {
+		return false
+	}
+	changed := rows != s.rows || cols != s.cols
+	s.rows, s.cols = rows, cols
+	return changed
+}
+
+// terminalSize asks stty for the rows and columns of the terminal.
+func terminalSize() (int, int, bool) {
+	out, err := stty("size")
+	if err != nil {
+		return 0, 0, false
+	}
+	rowsText, colsText, _ := strings.Cut(out, " ")
+	rows, err1 := strconv.Atoi(rowsText)
+	cols, err2 := strconv.Atoi(colsText)
+	return rows, cols, err1 == nil && err2 == nil
+}
+
+// fit cuts the frame to the terminal, so no line wraps and nothing
+// scrolls the lines it is positioned by.
+func (s *screen) fit(lines []string) []string {
+	if s.rows > 1 && len(lines) > s.rows-1 {
+		lines = lines[:s.rows-1]
+	}
+	if s.cols > 0 {
+		for i, line := range lines {
+			if r := []rune(line); len(r) > s.cols-1 {
+				lines[i] = string(r[:s.cols-1])
+				if strings.Contains(lines[i], "\033[3") {
+					lines[i] += "\033[0m" // the color's reset was cut off
+				}
+			}
+		}
+	}
+	return lines
+}
+
+// flush draws the frame built since the last flush and leaves the
+// cursor below it.
+func (s *screen) flush() {
+	if s.resized(time.Now()) {
+		s.valid = false
+	}
+	lines := s.fit(strings.Split(strings.TrimSuffix(s.pending.String(), "\n"), "\n"))
+	s.pending.Reset()
+
+	var b strings.Builder
+	if !s.valid {
+		b.WriteString("\033[2J\033[H")
+		for _, line := range lines {
+			b.WriteString(line + "\n")
+		}
+	} else {
+		for i, line := range lines {
+			if i < len(s.shown) && s.shown[i] == line {
+				continue
+			}
+			fmt.Fprintf(&b, "\033[%d;1H%s\033[K", i+1, line)
+		}
+		fmt.Fprintf(&b, "\033[%d;1H", len(lines)+1)
+		if len(lines) < len(s.shown) {
+			b.WriteString("\033[J")
+		}
+	}
+	os.Stdout.WriteString(b.String())
+	s.shown, s.valid = lines, true
+}
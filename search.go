@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// searchText is what --grep looks through: the task, notes and
+// attachments.
+func searchText(e TaskEntry) string {
+	return strings.Join(append(append([]string{e.Task}, e.Notes...), e.Attachments...), "\n")
+}
+
+// logCommand lists the entries matching the filters, newest first.
+func logCommand(args []string) error {
+	fs := newFlagSet("log")
+	since := fs.String("since", "", "First day (YYYY-MM-DD, default the first log)")
+	until := fs.String("until", "", "Last day (YYYY-MM-DD, default today)")
+	project := projectFlag(fs, "project", "", "Only include this project")
+	grep := fs.String("grep", "", "Regular expression the task, a note or an attachment matches, ignoring case")
+	tag := fs.String("tag", "", "Only include entries with this tag")
+	limit := fs.Int("limit", 0, "Show at most this many entries (0 for all)")
+	asJSON := jsonFlag(fs, "Print export's JSON objects, one per line")
+	failOnEmpty := fs.Bool("fail-on-empty", false, "Exit with code 4 when no entry matches")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	var re *regexp.Regexp
+	if *grep != "" {
+		var err error
+		if re, err = regexp.Compile("(?i)" + *grep); err != nil {
+			return usageErrorf("invalid --grep: %v", err)
+		}
+	}
+	from, to, err := parseDateRange(*since, *until)
+	if err != nil {
+		return err
+	}
+	if to.IsZero() {
+		to = startOfDay(time.Now())
+	}
+	rows, err := exportRows(from, to, *project, *tag)
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].Date != rows[j].Date {
+			return rows[i].Date < rows[j].Date
+		}
+		return rows[i].Start.Before(rows[j].Start)
+	})
+	var found []datedEntry
+	for i := len(rows) - 1; i >= 0; i-- {
+		if re != nil && !re.MatchString(searchText(rows[i].TaskEntry)) {
+			continue
+		}
+		found = append(found, rows[i])
+		if *limit > 0 && len(found) == *limit {
+			break
+		}
+	}
+	if len(found) == 0 && *failOnEmpty {
+		return withCode(exitEmpty, errors.New("no matching entries"))
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		for _, r := range found {
+			if err := enc.Encode(r.exported()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if len(found) == 0 {
+		fmt.Println("📭 No matching entries")
+		return nil
+	}
+	var total time.Duration
+	for _, r := range found {
+		at := "     "
+		if !r.Start.IsZero() {
+			at = r.Start.Local().Format("15:04")
+		}
+		task := r.Task
+		if len(r.Tags) > 0 {
+			task += " #" + strings.Join(r.Tags, " #")
+		}
+		fmt.Printf("%s %s %10s  %s %s\n", r.Date, at, formatDuration("summary", r.Duration), projectLabel(r.Project, 16), task)
+		total += r.Duration
+	}
+	fmt.Printf("\n%d entries · %s\n", len(found), formatDuration("summary", total))
+	return nil
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+)
+
+// statusJSON is what /status returns and the page polls.
+type statusJSON struct {
+	Class   string        `json:"class"`
+	Project string        `json:"project"`
+	Task    string        `json:"task,omitempty"` // of the session in progress
+	Elapsed string        `json:"elapsed"`
+	Total   string        `json:"total"`
+	Seconds int64         `json:"total_seconds"`
+	Entries []entryJSON   `json:"entries"`
+	Split   []projectJSON `json:"projects"`
+}
+
+type entryJSON struct {
+	Task     string `json:"task"`
+	Project  string `json:"project"`
+	Duration string `json:"duration"`
+}
+
+type projectJSON struct {
+	Project string `json:"project"`
+	Total   string `json:"total"`
+	Percent int    `json:"percent"`
+}
+
+func newStatusJSON(info statusInfo) statusJSON {
+	out := statusJSON{
+		Class:   info.Class,
+		Project: info.Project,
+		Task:    info.Task,
+		Elapsed: formatDuration("summary", info.Elapsed),
+		Total:   formatDuration("summary", info.Total),
+		Seconds: int64(info.Total / time.Second),
+		Entries: []entryJSON{},
+		Split:   []projectJSON{},
+	}
+	byProject := map[string]time.Duration{}
+	for _, e := range info.Entries {
+		out.Entries = append(out.Entries, entryJSON{e.Task, e.Project, formatDuration("summary", e.Duration)})
+		byProject[e.Project] += e.Duration
+	}
+	if info.Elapsed > 0 {
+		byProject[info.Project] += info.Elapsed
+	}
+	for project, d := range byProject {
+		p := projectJSON{Project: project, Total: formatDuration("summary", d)}
+		if info.Total > 0 {
+			p.Percent = int(d * 100 / info.Total)
+		}
+		out.Split = append(out.Split, p)
+	}
+	sort.Slice(out.Split, func(i, j int) bool { return out.Split[i].Project < out.Split[j].Project })
+	return out
+}
+
+// servePage is self-contained so it works without internet access.
+var servePage = template.Must(template.New("page").Parse(`<!doctype html>
+<html><head><meta charset="utf-8"><meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Work log</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 40em; margin: 1em auto; padding: 0 1em; }
+h1 { font-size: 2.5em; margin: 0.2em 0; font-variant-numeric: tabular-nums; }
+td { padding: 0.2em 0.6em 0.2em 0; } .muted { color: #777; }
+</style></head>
+<body>
+<h1 id="total">{{.Total}}</h1>
+<p id="session" class="muted">{{if eq .Class "idle"}}No session running{{else}}{{.Project}}: {{.Elapsed}} ({{.Class}}){{end}}</p>
+<h2>Projects</h2>
+<table id="projects">{{range .Split}}<tr><td>{{.Project}}</td><td>{{.Total}}</td><td>{{.Percent}}%</td></tr>{{end}}</table>
+<h2>Entries</h2>
+<table id="entries">{{range .Entries}}<tr><td>{{.Duration}}</td><td>{{.Task}}</td><td class="muted">{{.Project}}</td></tr>{{end}}</table>
+<script>
+function cell(text, cls) { const td = document.createElement("td"); td.textContent = text; if (cls) td.className = cls; return td; }
+function rows(id, items, cols) {
+  const table = document.getElementById(id); table.replaceChildren();
+  for (const item of items) { const tr = document.createElement("tr"); for (const c of cols(item)) tr.appendChild(c); table.appendChild(tr); }
+}
+async function refresh() {
+  try {
+    const s = await (await fetch("status")).json();
+    document.getElementById("total").textContent = s.total;
+    document.getElementById("session").textContent = s.class === "idle" ? "No session running" : s.project + ": " + s.elapsed + " (" + s.class + ")";
+    rows("projects", s.projects || [], p => [cell(p.project), cell(p.total), cell(p.percent + "%")]);
+    rows("entries", s.entries, e => [cell(e.duration), cell(e.task), cell(e.project, "muted")]);
+  } catch (e) {}
+}
+setInterval(refresh, 10000);
+</script>
+</body></html>
+`))
+
+func serveHandler(token string) http.Handler {
+	mux := http.NewServeMux()
+	current := func(w http.ResponseWriter) (statusJSON, bool) {
+		info, err := currentStatus(time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return statusJSON{}, false
+		}
+		return newStatusJSON(info), true
+	}
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		if s, ok := current(w); ok {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			servePage.Execute(w, s)
+		}
+	})
+	status := func(w http.ResponseWriter, r *http.Request) {
+		if s, ok := current(w); ok {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(s)
+		}
+	}
+	mux.HandleFunc("/status", status)
+	mux.HandleFunc("GET /api/status", status)
+	mux.HandleFunc("GET /metrics", metricsHandler)
+	addAPIRoutes(mux)
+	return guardWrites(token, mux)
+}
+
+func serveCommand(args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return withCode(exitUsage, err)
+	}
+	fs := newFlagSet("serve")
+	addr := fs.String("addr", cfg.ServeAddr, "Address to listen on, e.g. :8787 for the LAN or 127.0.0.1:8787")
+	token := fs.String("token", cfg.ServeToken, "Bearer token that allows starting and stopping timers (default: read-only)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	srv := &http.Server{Addr: *addr, Handler: serveHandler(*token), ReadHeaderTimeout: 10 * time.Second}
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-stop
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+	}()
+
+	fmt.Printf("🌐 Serving today's log on http://%s (Ctrl+C to stop)\n", *addr)
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	fmt.Println("👋 Stopped serving")
+	return nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// get fetches path from srv and returns the status code and body.
+func get(t *testing.T, srv *httptest.Server, path string) (int, string) {
+	t.Helper()
+	resp, err := http.Get(srv.URL + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(body)
+}
+
+// The page and /status show today's entries and the timer running.
+func TestServeToday(t *testing.T) {
+	config, logs := useTempDirs(t)
+	writeConfig(t, config, "log_dir: "+logs+"\n")
+	today := startOfDay(time.Now())
+	writeDay(t, logs, "League", today, []TaskEntry{
+		{Task: "review", Project: "League", Start: today, Duration: time.Hour},
+	})
+	if _, err := beginTimer("", "Ops", "deploy", time.Now().Add(-30*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(serveHandler(""))
+	defer srv.Close()
+
+	code, page := get(t, srv, "/")
+	if code != http.StatusOK {
+		t.Fatalf("page: status %d", code)
+	}
+	for _, want := range []string{"<td>review</td>", "<td>League</td><td>1h0m0s</td><td>66%</td>", "Ops: 30m", "(running)"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("page lacks %q:\n%s", want, page)
+		}
+	}
+	if strings.Contains(page, "http://") || strings.Contains(page, "https://") {
+		t.Error("page loads something from elsewhere")
+	}
+
+	code, body := get(t, srv, "/status")
+	var s statusJSON
+	if err := json.Unmarshal([]byte(body), &s); err != nil || code != http.StatusOK {
+		t.Fatalf("status: %d %v:\n%s", code, err, body)
+	}
+	if s.Class != "running" || s.Task != "deploy" || len(s.Entries) != 1 || len(s.Split) != 2 {
+		t.Errorf("status %+v", s)
+	}
+	if s.Seconds < 90*60 || s.Seconds > 91*60 {
+		t.Errorf("total %ds, want 1h30m", s.Seconds)
+	}
+}
+
+// Without a token the server only answers reads.
+func TestServeReadOnly(t *testing.T) {
+	config, logs := useTempDirs(t)
+	writeConfig(t, config, "log_dir: "+logs+"\n")
+	for _, tt := range []struct {
+		token, auth string
+		want        int
+	}{
+		{"", "", http.StatusMethodNotAllowed},
+		{"secret", "", http.StatusUnauthorized},
+		{"secret", "Bearer wrong", http.StatusUnauthorized},
+	} {
+		srv := httptest.NewServer(serveHandler(tt.token))
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/api/timer/start", strings.NewReader(`{"project": "League"}`))
+		if tt.auth != "" {
+			req.Header.Set("Authorization", tt.auth)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		srv.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.want {
+			t.Errorf("token %q, auth %q: status %d, want %d", tt.token, tt.auth, resp.StatusCode, tt.want)
+		}
+	}
+	if _, _, ok, _ := listTimers(); ok {
+		t.Error("a refused request started a timer")
+	}
+}
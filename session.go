@@ -0,0 +1,613 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// tracker holds what the sessions of one interactive run share.
+type tracker struct {
+	project string
+	af      *autoFinalizer
+	config  *configWatcher
+	focus   *focusMode
+	entries []TaskEntry
+	banner  string // shown under the clock during the first session
+
+	// target and pomodoro drive the planning line; earlier is what was
+	// already logged today before this run started.
+	target   time.Duration
+	pomodoro pomodoroConfig
+	earlier  time.Duration
+
+	// pauseReasonAfter is how long a pause must last before resuming
+	// asks what it was for.
+	pauseReasonAfter time.Duration
+
+	// preset is the entry the next session repeats, if any; Enter at the
+	// task prompt accepts its task. A preset with a start continues
+	// timing from then.
+	preset *TaskEntry
+
+	// switching is set when a session ended with 'n': the next one has
+	// begun already and the day goes on without asking.
+	switching bool
+
+	// askTask asks what a session is for before it starts; current is
+	// the running session's task when known, shown under the clock.
+	askTask bool
+	current string
+
+	// gitTask suggests the git branch and repository as the task of
+	// sessions nobody named.
+	gitTask bool
+
+	// graceWindow is the longest gap after which a session with the same
+	// task may be merged into the previous one; gracePause records the
+	// gap as a pause when it is.
+	graceWindow time.Duration
+	gracePause  bool
+
+	// slept is how long the system was suspended before the running
+	// session was paused for it.
+	slept time.Duration
+
+	// saved confirms the last panic save; lastSave is what it wrote.
+	saved    string
+	lastSave string
+
+	// resume is an interrupted session the first session continues.
+	resume *sessionState
+
+	// pomodoroMode counts each session down from pomodoro.Work and ends
+	// it there; cycle is the number of pomodoros completed so far and
+	// breakDue is set when the last session completed one.
+	pomodoroMode bool
+	cycle        int
+	breakDue     bool
+
+	// timebox is how long each session is planned to take; the clock
+	// counts down to it and then on into overtime.
+	timebox time.Duration
+
+	// estimate is the estimate of sessions whose task names none.
+	estimate time.Duration
+
+	// idle pauses the running session when nobody is at the computer.
+	idle *idleMonitor
+
+	// notify shows desktop notifications when configured.
+	notify *notifier
+
+	// remind nudges towards a break after long stretches of work.
+	remind *breakReminder
+
+	// issue is the Jira issue every session is logged against; autoPush
+	// are the targets each session is pushed to when it ends, counting
+	// written, the entries from today already on disk.
+	issue    string
+	autoPush []syncTarget
+	written  []TaskEntry
+}
+
+// today is the day's entries so far: earlier runs' and this run's.
+func (t *tracker) today() []TaskEntry {
+	return append(append([]TaskEntry(nil), t.written...), t.entries...)
+}
+
+// panicSaveTask stands in for the task of the running session in a
+// panic save.
+const panicSaveTask = "(in progress, saved with S)"
+
+// panicSave writes today's entries plus the running session to the log
+// and the state file right away, without stopping the clock. Pressing
+// it again with nothing changed does nothing.
+func (t *tracker) panicSave(running TaskEntry, paused bool) {
+	running.Task = panicSaveTask
+	all := append(append([]TaskEntry(nil), t.entries...), running)
+	key := strings.Join(entryTexts(all), "")
+	if key == t.lastSave {
+		return
+	}
+	t.publish(running.Start, running.Duration, paused)
+	preview := previewWrites
+	previewWrites = false // S saves at once
+	written, err := writeDaily(t.project, all, false)
+	previewWrites = preview
+	if err != nil {
+		t.saved = "❌ Panic save failed: " + err.Error()
+		return
+	}
+	// Keep changes merged in from disk, minus the running session.
+	t.entries = t.entries[:0]
+	for _, e := range written {
+		if e.Task != panicSaveTask || !e.Start.Equal(running.Start) {
+			t.entries = append(t.entries, e)
+		}
+	}
+	t.lastSave = key
+	t.saved = "💾 Saved at " + time.Now().Format("15:04:05")
+}
+
+// absorb merges e into the previous entry when it continues the same
+// task within the grace window and the user agrees. It reports whether
+// e was merged.
+func (t *tracker) absorb(e TaskEntry) bool {
+	if t.graceWindow <= 0 || len(t.entries) == 0 {
+		return false
+	}
+	prev := &t.entries[len(t.entries)-1]
+	if prev.Task != e.Task || prev.Project != e.Project || prev.Start.IsZero() {
+		return false
+	}
+	end := entryEnd(*prev)
+	gap := e.Start.Sub(end)
+	if gap < 0 || gap > t.graceWindow {
+		return false
+	}
+	answer, timedOut := t.af.prompt(fmt.Sprintf("🔗 Merge with the previous %q session (%s gap)? (Y/n): ", e.Task, formatDuration("footer", gap)))
+	if timedOut || strings.HasPrefix(strings.ToLower(answer), "n") {
+		return false
+	}
+	if t.gracePause {
+		prev.Pauses = append(prev.Pauses, Pause{Start: end, End: e.Start, Reason: "gap"})
+	}
+	prev.Duration += e.Duration
+	prev.Pauses = append(prev.Pauses, e.Pauses...)
+	prev.Interruptions = append(prev.Interruptions, e.Interruptions...)
+	prev.Notes = append(prev.Notes, e.Notes...)
+	return true
+}
+
+const stateSaveInterval = 30 * time.Second
+
+// publish updates the state file other commands read. A zero started
+// means no session is running.
+func (t *tracker) publish(started time.Time, elapsed time.Duration, paused bool) {
+	err := saveState(sessionState{
+		Project: t.project,
+		Start:   started,
+		Elapsed: elapsed,
+		Paused:  paused,
+		Slept:   t.slept,
+		Task:    t.current,
+		Updated: time.Now(),
+		Entries: t.entries,
+	})
+	if err != nil {
+		fmt.Println("⚠️  Could not save session state:", err)
+	}
+}
+
+func (t *tracker) runSession() ([]TaskEntry, bool, bool) {
+	project, af, cw := t.project, t.af, t.config
+	preset := t.preset
+	t.preset = nil
+	if preset != nil {
+		project = preset.Project
+	}
+	t.current = ""
+	planned := plannedTasks(project)
+	switch {
+	case t.resume != nil:
+		t.current = t.resume.Task
+	case preset != nil:
+		t.current = preset.Task
+	case t.askTask:
+		printChoices("📋 Planned:", planned, 1)
+		if branch := t.branchTask(); branch != "" {
+			if t.current = taskPrompt(fmt.Sprintf("🎯 What are you working on? [Enter: %s] ", branch), project); t.current == "" {
+				t.current = branch
+			}
+		} else {
+			t.current = taskPrompt("🎯 What are you working on? (Enter to name it at the end) ", project)
+		}
+		t.current = pickTask(t.current, planned, project)
+	default:
+		if t.current = t.branchTask(); t.current == "" && len(planned) > 0 {
+			t.current = planned[0]
+		}
+	}
+	started := time.Now()
+	var clock sessionClock
+	clock.start(started)
+	if r := t.resume; r != nil {
+		t.resume = nil
+		project, started, clock.elapsed = r.Project, r.Start, r.Elapsed
+	}
+	if preset != nil && !preset.Start.IsZero() {
+		started, clock.elapsed = preset.Start, started.Sub(preset.Start)
+	}
+	auditAt(started, "session_start", project, 0)
+	hook := func(event, reason string, at time.Time) {
+		ev := newWebhookEvent(event, project, t.current, at, clock.elapsed)
+		ev.Reason = reason
+		announce(ev)
+	}
+	hook(webhookStart, "", started)
+	t.notify.start(project, clock.elapsed)
+	elapsed := time.Duration(0)
+	paused := false
+	var pausedAt, idleSince time.Time
+	lastActive := time.Now() // the last key pressed while tracking
+	var pauses []Pause
+	var interruptions []Interruption
+	var interrupted *Interruption // the one going on, if any
+	pauseReason := ""
+	quitApp := false
+	nextTask, nextProject := false, false
+	boxOver := t.timebox > 0 && clock.elapsed >= t.timebox
+	goalMet := t.target > 0 && t.earlier+totalDuration(t.entries)+clock.elapsed >= t.target
+	autoClosed := false
+	completed := false
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT)
+
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+	input := inputLines
+
+	t.focus.start(t.current)
+	defer t.focus.restore()
+	defer leaveRaw()
+	t.publish(started, 0, false)
+	lastPublish := time.Now()
+
+	var now time.Time
+loop:
+	for {
+		now = time.Now()
+		slept := clock.tick(now)
+		auditAt(now, "tick", "", 0)
+		if slept > 0 {
+			if !paused {
+				paused = true
+				clock.pause(now)
+				auditAt(now, "suspend", "", slept)
+				pausedAt = now.Add(-slept)
+				pauseReason = "suspend"
+				hook(webhookPause, pauseReason, pausedAt)
+				t.slept = slept
+			} else if pauseReason == "suspend" {
+				t.slept += slept
+			}
+			t.publish(started, clock.elapsed, paused)
+			lastPublish = now
+		}
+		if !paused {
+			if away, ok := t.idle.away(now); ok {
+				paused = true
+				clock.pause(now)
+				auditAt(now, "idle", "", away)
+				pausedAt = now
+				idleSince = now.Add(-away)
+				pauseReason = idleReason
+				hook(webhookPause, pauseReason, idleSince)
+				t.publish(started, clock.elapsed, paused)
+				lastPublish = now
+			}
+		} else if pauseReason == idleReason && t.idle.back(now) {
+			if pause, ok := endIdle(&clock, idleSince, pausedAt, time.Now()); ok {
+				pauses = append(pauses, pause)
+			}
+			paused = false
+			pauseReason = ""
+			resumed := time.Now()
+			clock.resume(resumed)
+			auditAt(resumed, "resume", "", 0)
+			hook(webhookResume, idleReason, resumed)
+			t.focus.start(t.current)
+			t.publish(started, clock.elapsed, paused)
+			lastPublish, lastActive = resumed, resumed
+		}
+		elapsed = clock.elapsed
+		if paused {
+			t.focus.paused(time.Since(pausedAt))
+		}
+		t.notify.tick(elapsed, paused, time.Since(pausedAt))
+		reminder := t.remind.tick(now, paused)
+		if t.pomodoroMode && elapsed >= t.pomodoro.Work {
+			completed = true
+			alert(soundPomodoroEnd)
+			t.notify.pomodoro(pomodoroNote(t.cycle+1)+" finished", "Time for a break")
+			break loop
+		}
+		if t.timebox > 0 && !boxOver && elapsed >= t.timebox {
+			boxOver = true
+			alert(soundPomodoroEnd)
+			t.notify.timebox("⏲️ Timebox of "+formatDuration("footer", t.timebox)+" is up", "Still tracking "+project+"; the overtime counts too")
+		}
+		if af.due() {
+			autoClosed = true
+			break loop
+		}
+		if time.Since(lastPublish) >= stateSaveInterval {
+			t.publish(started, elapsed, paused)
+			lastPublish = time.Now()
+		}
+		cw.poll()
+		enterRaw()
+		if t.pomodoroMode {
+			renderTime(t.pomodoro.Work-elapsed, paused)
+			tui.Printf("🍅 Pomodoro #%d of %s\n", t.cycle+1, formatDuration("footer", t.pomodoro.Work))
+		} else if t.timebox > 0 {
+			renderTimebox(t.timebox, elapsed, paused)
+		} else {
+			renderTime(elapsed, paused)
+		}
+		tui.Println("📁 " + projectLabel(project, 0))
+		if t.current != "" {
+			tui.Println("🎯 " + t.current)
+		}
+		if line := planLine(planned, t.current); line != "" {
+			tui.Println(line)
+		}
+		if t.banner != "" {
+			tui.Println(t.banner)
+		}
+		if reminder != "" {
+			tui.Println(reminder)
+		}
+		if i := interrupted; i != nil {
+			tui.Printf("⚡ Interrupted by %s for %s - Press 'i' when it's over\n", i.By, formatDuration("footer", time.Since(i.Start).Round(time.Second)))
+		}
+		if cw.notice != "" {
+			tui.Println(cw.notice)
+		}
+		if t.saved != "" {
+			tui.Println(t.saved)
+		}
+		if paused && pauseReason == "suspend" {
+			tui.Printf("💤 Paused: the system slept %s. Press 'p' when you're back at it\n", formatDuration("footer", t.slept))
+		}
+		if paused && pauseReason == idleReason {
+			tui.Printf("🙈 Paused: idle since %s. Press 'p' or just get back to work\n", idleSince.Format("15:04"))
+		}
+		done := t.earlier + totalDuration(t.entries) + elapsed
+		if bar := goalBar(done, t.target); bar != "" {
+			tui.Println(bar)
+		}
+		if !goalMet && t.target > 0 && done >= t.target {
+			goalMet = true
+			alert(soundTargetReached)
+		}
+		if plan, ok := planRemaining(t.target, done, t.pomodoro, time.Now()); ok {
+			tui.Println(plan.String(t.target))
+		}
+		for _, line := range todayLines(t.today()) {
+			tui.Println(line)
+		}
+		if left, ok := af.warning(); ok {
+			tui.Printf("⚠️  Auto-finalizing the day in %s - Press 'c' to cancel\n", durationFormat{Style: "go"}.format(left))
+		}
+		tui.flush()
+
+		select {
+		case <-sigChan:
+			audit("signal", "SIGINT", 0)
+			break loop
+		case line, ok := <-input:
+			if !ok {
+				input = nil
+				continue
+			}
+			for _, b := range line {
+				audit("key", string(b), 0)
+				switch b {
+				case 'p', 'P':
+					paused = !paused
+					if paused {
+						pausedAt = time.Now()
+						clock.pause(pausedAt)
+						auditAt(pausedAt, "pause", "", 0)
+						hook(webhookPause, "", pausedAt)
+						elapsed = clock.elapsed
+					} else {
+						pause, ok := Pause{Start: pausedAt, End: time.Now(), Reason: pauseReason}, true
+						if pause.Reason == idleReason {
+							pause, ok = endIdle(&clock, idleSince, pausedAt, pause.End)
+						} else if pause.Reason == "" && pause.Duration() > t.pauseReasonAfter {
+							pause.Reason = inputPrompt("\n💬 What was the pause for? (Enter to skip) ")
+						}
+						pauseReason = ""
+						t.slept = 0
+						if ok {
+							pauses = append(pauses, pause)
+						}
+						resumed := time.Now()
+						clock.resume(resumed)
+						auditAt(resumed, "resume", "", 0)
+						hook(webhookResume, pause.Reason, resumed)
+						t.focus.start(t.current)
+					}
+					t.publish(started, elapsed, paused)
+					lastPublish = time.Now()
+				case 'S', 0x13: // Ctrl+S
+					running := TaskEntry{Project: project, Start: started, Duration: clock.elapsed, Pauses: pauses}
+					if paused {
+						running.Pauses = append(running.Pauses, Pause{Start: pausedAt, End: time.Now(), Reason: pauseReason})
+					}
+					t.panicSave(running, paused)
+				case 'c', 'C':
+					af.cancel()
+				case 'z', 'Z':
+					t.remind.snooze(time.Now())
+				case 'i', 'I':
+					if interrupted != nil {
+						interrupted.End = time.Now()
+						interruptions = append(interruptions, *interrupted)
+						audit("interrupt_end", interrupted.By, interrupted.Duration())
+						interrupted = nil
+						break
+					}
+					at := time.Now()
+					answer := inputPrompt("\n⚡ Interrupted by? (who or what, then ': note' if you like; Enter to cancel) ")
+					if by, note := parseInterruptionAnswer(answer); by != "" {
+						interrupted = &Interruption{Start: at, By: by, Note: note}
+						audit("interrupt", by, 0)
+					}
+				case 't', 'T':
+					if len(planned) > 0 {
+						t.current = nextPlanned(planned, t.current)
+						if !paused {
+							t.focus.start(t.current)
+						}
+						t.publish(started, clock.elapsed, paused)
+						lastPublish = time.Now()
+					}
+				case 'r', 'R':
+					cw.reload()
+				case 'q', 'Q':
+					quitApp = true
+					break loop
+				case 'n', 'N':
+					nextTask = true
+					break loop
+				case 'm', 'M':
+					nextTask, nextProject = true, true
+					break loop
+				}
+				lastActive = time.Now()
+			}
+		case <-ticker.C:
+		}
+	}
+	leaveRaw()
+	if !autoClosed && !completed {
+		now = time.Now()
+		clock.tick(now)
+		elapsed = clock.elapsed
+	}
+	auditAt(now, "session_end", "", 0)
+	auditAt(now, "entry", "", elapsed)
+	if paused && pauseReason == idleReason && !autoClosed {
+		if pause, ok := endIdle(&clock, idleSince, pausedAt, now); ok {
+			pauses = append(pauses, pause)
+		}
+		elapsed = clock.elapsed
+	} else if paused {
+		pauses = append(pauses, Pause{Start: pausedAt, End: time.Now(), Reason: pauseReason})
+	}
+	if interrupted != nil {
+		interrupted.End = now
+		interruptions = append(interruptions, *interrupted)
+	}
+	t.slept = 0
+	t.saved = ""
+	t.focus.restore()
+	t.banner = ""
+
+	fmt.Print("\n")
+	entry := TaskEntry{Project: project, Start: started, Duration: elapsed, Pauses: pauses, Interruptions: interruptions}
+	if note := clock.note(); note != "" {
+		entry.Notes = append(entry.Notes, note)
+	}
+	if t.timebox > 0 {
+		entry.Notes = append(entry.Notes, timeboxNote(t.timebox, elapsed))
+	}
+	if completed {
+		t.cycle++
+		t.breakDue = true
+		entry.Notes = append(entry.Notes, pomodoroNote(t.cycle))
+		fmt.Printf("🍅 Pomodoro #%d done\n", t.cycle)
+	}
+	if autoClosed {
+		entry.Task = autoClosedTask
+		return []TaskEntry{entry}, false, true
+	}
+	if !paused && forgotten(entry, now) {
+		cut, timedOut := askTrim(entry, now, lastActive, af.prompt)
+		if timedOut {
+			entry.Task = autoClosedTask
+			return []TaskEntry{entry}, quitApp, true
+		}
+		if !cut.IsZero() {
+			trimEntry(&entry, cut, now)
+			fmt.Printf("✂️  Trimmed to %s\n", formatDuration("summary", entry.Duration))
+		}
+	}
+	planned = plannedTasks(project)
+	printChoices("📋 Planned:", planned, 1)
+	recent := recentTasks(project, recentTaskMax)
+	for _, task := range planned {
+		recent = removeTask(recent, task)
+	}
+	printChoices("🕘 Recent tasks:", recent, len(planned)+1)
+	choices := append(append([]string(nil), planned...), recent...)
+	candidates := taskCandidates(project)
+	suggested := t.current
+	t.current = ""
+	question := "📝 What task did you just finish? "
+	if suggested != "" {
+		question = fmt.Sprintf("📝 What task did you just finish? [Enter: %s] ", suggested)
+	}
+	for {
+		answer, timedOut := af.promptTask(question, candidates)
+		if timedOut {
+			entry.Task = autoClosedTask
+			return []TaskEntry{entry}, quitApp, true
+		}
+		if answer == "" {
+			answer = suggested
+		}
+		task, shares, err := parseSplit(answer)
+		if err != nil {
+			fmt.Println("❌", err)
+			continue
+		}
+		task, entry.Billable, entry.Rate, err = parseBilling(task)
+		if err != nil {
+			fmt.Println("❌", err)
+			continue
+		}
+		billByDefault(&entry)
+		task, entry.Tags = parseTags(task)
+		task, entry.Estimate = parseEstimate(task)
+		entry.Task = pickTask(task, choices, project)
+		reusePreset(&entry, preset)
+		if entry.Estimate == 0 {
+			entry.Estimate = t.estimate
+		}
+		estimateFromPlan(&entry, now)
+		if nextTask {
+			if nextProject {
+				t.project = t.askProject(project)
+			}
+			t.switchTo(t.project, now)
+		}
+		return applySplit(entry, shares), quitApp, true
+	}
+}
+
+// askProject asks which project the sessions after one ended with 'm'
+// are for; Enter keeps current.
+func (t *tracker) askProject(current string) string {
+	var none autoFinalizer
+	for {
+		answer, _ := none.promptTask(fmt.Sprintf("📁 Which project next? [Enter: %s] ", current), projectCandidates())
+		if answer == "" {
+			return current
+		}
+		next, err := resolveProject(answer)
+		if err == nil {
+			return next
+		}
+		fmt.Println("❌", err)
+	}
+}
+
+// switchTo asks for the task after one ended with 'n' or 'm' and has
+// the next session time it from the moment the key was pressed.
+func (t *tracker) switchTo(project string, at time.Time) {
+	next := taskPrompt("🎯 What's next? (Enter to name it at the end) ", project)
+	if next == "" {
+		next = t.branchTask()
+	}
+	t.preset = &TaskEntry{Project: project, Task: next, Start: at}
+	t.switching = true
+}
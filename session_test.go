@@ -0,0 +1,49 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// A second save with nothing changed writes nothing.
+func TestPanicSaveTwice(t *testing.T) {
+	config, logs := useTempDirs(t)
+	writeConfig(t, config, "log_dir: "+logs+"\n")
+	t.Cleanup(func() {
+		priorEntries = map[string][]TaskEntry{}
+		lastWrites = map[string]lastWrite{}
+	})
+	now := time.Now()
+	tr := &tracker{project: "League"}
+	running := TaskEntry{Project: "League", Start: now.Add(-time.Hour), Duration: 40 * time.Minute}
+	tr.panicSave(running, true)
+	if !strings.HasPrefix(tr.saved, "💾") {
+		t.Fatalf("first save: %q", tr.saved)
+	}
+	path := filepath.Join(logs, dailyFilename("League", now))
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Something rewriting the file would drop this line.
+	marked := append(content, "<!-- untouched -->\n"...)
+	if err := os.WriteFile(path, marked, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tr.panicSave(running, true)
+	if got, _ := os.ReadFile(path); string(got) != string(marked) {
+		t.Errorf("the second save rewrote the log:\n%s", got)
+	}
+	if len(tr.entries) != 0 {
+		t.Errorf("the running session became an entry: %+v", tr.entries)
+	}
+
+	running.Duration += time.Minute
+	tr.panicSave(running, true)
+	if got, _ := os.ReadFile(path); string(got) == string(marked) {
+		t.Error("a save after the session went on wrote nothing")
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// slackAPI is Slack's Web API.
+var slackAPI = "https://slack.com/api"
+
+// slackConfig is the slack section of the config: while a session
+// tracks, the status says what it is for and notifications are snoozed.
+type slackConfig struct {
+	Token  string        // a user token with users.profile:write and dnd:write
+	Status string        // the status text; {project} and {task} are filled in
+	Emoji  string        // the status emoji, e.g. :dart:
+	DND    time.Duration // how long to snooze notifications for, 0 to leave them be
+}
+
+// slack is the slack section of the config, set at startup; without a
+// token the status is left alone.
+var slack slackConfig
+
+var (
+	slackQueue chan webhookEvent
+	slackOnce  sync.Once
+)
+
+// updateSlack sets the Slack status when a session starts or resumes
+// and clears it when it pauses or stops. The calls are made one after
+// the other in the background, so a quick pause and stop land in order;
+// failures go to the debug log.
+func updateSlack(ev webhookEvent) {
+	if slack.Token == "" {
+		return
+	}
+	slackOnce.Do(func() {
+		slackQueue = make(chan webhookEvent, 16)
+		go func() {
+			for ev := range slackQueue {
+				if err := slack.apply(ev); err != nil {
+					debugf("slack: %s: %v", ev.Event, err)
+				}
+				webhooksSending.Done()
+			}
+		}()
+	})
+	webhooksSending.Add(1)
+	slackQueue <- ev
+}
+
+func (c slackConfig) apply(ev webhookEvent) error {
+	if ev.Event == webhookStart || ev.Event == webhookResume {
+		text := strings.NewReplacer("{project}", ev.Project, "{task}", ev.Task).Replace(c.Status)
+		if err := c.setStatus(strings.TrimSpace(text), c.Emoji); err != nil {
+			return err
+		}
+		if c.DND > 0 {
+			return c.call("dnd.setSnooze", url.Values{"num_minutes": {fmt.Sprint(int(c.DND.Minutes()))}})
+		}
+		return nil
+	}
+	if err := c.setStatus("", ""); err != nil {
+		return err
+	}
+	if c.DND > 0 {
+		return c.call("dnd.endSnooze", nil)
+	}
+	return nil
+}
+
+func (c slackConfig) setStatus(text, emoji string) error {
+	profile, err := json.Marshal(map[string]any{"status_text": text, "status_emoji": emoji, "status_expiration": 0})
+	if err != nil {
+		return err
+	}
+	return c.call("users.profile.set", url.Values{"profile": {string(profile)}})
+}
+
+// call posts form to a Web API method. Slack answers 200 even when the
+// call failed and says so in the body.
+func (c slackConfig) call(method string, form url.Values) error {
+	req, err := http.NewRequest(http.MethodPost, slackAPI+"/"+method, bytes.NewBufferString(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	client := http.Client{Timeout: webhookTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	var reply struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+		return fmt.Errorf("%s: %s", method, resp.Status)
+	}
+	if !reply.OK {
+		return fmt.Errorf("%s: %s", method, reply.Error)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+)
+
+const soundTimeout = 5 * time.Second
+
+// Events that can make a sound.
+const (
+	soundPomodoroEnd   = "pomodoro-end"
+	soundChime         = "chime"
+	soundPausedTooLong = "paused-too-long"
+	soundTargetReached = "target-reached"
+)
+
+var soundEvents = []string{soundPomodoroEnd, soundChime, soundPausedTooLong, soundTargetReached}
+
+//go:embed sounds/*.wav
+var defaultSounds embed.FS
+
+var (
+	muted       bool
+	soundConfig map[string]string
+)
+
+// soundFile returns the file to play for event: the configured path, or
+// the bundled default unpacked into the temp directory.
+func soundFile(event string) (string, error) {
+	if path := soundConfig[event]; path != "" {
+		return expandHome(path)
+	}
+	data, err := defaultSounds.ReadFile("sounds/" + event + ".wav")
+	if err != nil {
+		return "", fmt.Errorf("no sound for event %q", event)
+	}
+	path := filepath.Join(os.TempDir(), "worklog-"+profilePrefix()+event+".wav")
+	if info, err := os.Stat(path); err == nil && info.Size() == int64(len(data)) {
+		return path, nil
+	}
+	return path, os.WriteFile(path, data, 0o644)
+}
+
+func playerCommand(ctx context.Context, path string) (*exec.Cmd, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.CommandContext(ctx, "afplay", path), nil
+	case "windows":
+		script := fmt.Sprintf("(New-Object Media.SoundPlayer '%s').PlaySync()", strings.ReplaceAll(path, "'", "''"))
+		return exec.CommandContext(ctx, "powershell", "-NoProfile", "-Command", script), nil
+	}
+	for _, player := range []string{"paplay", "aplay"} {
+		if _, err := exec.LookPath(player); err == nil {
+			return exec.CommandContext(ctx, player, path), nil
+		}
+	}
+	return nil, fmt.Errorf("no audio player found (tried paplay, aplay)")
+}
+
+func playSound(event string) error {
+	path, err := soundFile(event)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), soundTimeout)
+	defer cancel()
+	cmd, err := playerCommand(ctx, path)
+	if err != nil {
+		return err
+	}
+	return cmd.Run()
+}
+
+// alert signals event in the background, falling back to the terminal
+// bell when the sound cannot be played.
+func alert(event string) {
+	if muted {
+		return
+	}
+	go func() {
+		if err := playSound(event); err != nil {
+			fmt.Print("\a")
+		}
+	}()
+}
+
+func soundCommand(args []string) error {
+	if len(args) != 2 || args[0] != "test" || !slices.Contains(soundEvents, args[1]) {
+		return usageErrorf("usage: sound test <%s>", strings.Join(soundEvents, "|"))
+	}
+	event := args[1]
+	fmt.Println("🔊 Playing", event)
+	if err := playSound(event); err != nil {
+		fmt.Print("\a")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// split is one "=NN% Project" share of a task.
+type split struct {
+	Project string
+	Basis   int // hundredths of a percent, 10000 == 100%
+}
+
+// shareRe finds the "=50% Project" shares in a task. Anything else with
+// an '=' in it, such as "set x=1 in config", is part of the task.
+var shareRe = regexp.MustCompile(`(?:^|\s)=(\d+(?:\.\d{1,2})?)%\s+\S`)
+
+// parseSplit parses task text of the form
+//
+//	pairing on importer =50% Consulting =50% League
+//
+// into the task name and its shares. Text without shares has none. The
+// projects go through the registry like --project does.
+func parseSplit(text string) (string, []split, error) {
+	found := shareRe.FindAllStringSubmatchIndex(text, -1)
+	if len(found) == 0 {
+		return strings.TrimSpace(text), nil, nil
+	}
+	task := strings.TrimSpace(text[:found[0][0]])
+
+	var shares []split
+	total := 0
+	for i, m := range found {
+		end := len(text)
+		if i+1 < len(found) {
+			end = found[i+1][0]
+		}
+		pct := text[m[2]:m[3]]
+		project := strings.TrimSpace(text[m[3]+1 : end])
+		basis, err := parseBasis(pct)
+		if err != nil {
+			return "", nil, fmt.Errorf("share %q: %v", "="+pct+"% "+project, err)
+		}
+		if project, err = resolveProject(project); err != nil {
+			return "", nil, err
+		}
+		shares = append(shares, split{Project: project, Basis: basis})
+		total += basis
+	}
+	if total != 10000 {
+		return "", nil, fmt.Errorf("shares add up to %s%%, want 100%%", formatBasis(total))
+	}
+	return task, shares, nil
+}
+
+// parseBasis parses a percentage with up to two decimals into hundredths.
+func parseBasis(pct string) (int, error) {
+	whole, frac, _ := strings.Cut(pct, ".")
+	if len(frac) > 2 {
+		return 0, fmt.Errorf("too many decimals in %q", pct)
+	}
+	frac += strings.Repeat("0", 2-len(frac))
+	w, err := strconv.Atoi(whole)
+	if err != nil {
+		return 0, fmt.Errorf("invalid percentage %q", pct)
+	}
+	f, err := strconv.Atoi(frac)
+	if err != nil {
+		return 0, fmt.Errorf("invalid percentage %q", pct)
+	}
+	basis := w*100 + f
+	if w < 0 || basis <= 0 || basis > 10000 {
+		return 0, fmt.Errorf("percentage %q out of range", pct)
+	}
+	return basis, nil
+}
+
+func formatBasis(b int) string {
+	if b%100 == 0 {
+		return strconv.Itoa(b / 100)
+	}
+	return fmt.Sprintf("%d.%02d", b/100, b%100)
+}
+
+// applySplit divides entry between the shares in whole seconds. Whatever
+// is lost to rounding goes to the first share so the parts always add
+// up to the original duration. The session's pauses, interruptions and
+// notes stay with the first share only, so reports count them once.
+func applySplit(entry TaskEntry, shares []split) []TaskEntry {
+	if len(shares) == 0 {
+		return []TaskEntry{entry}
+	}
+	out := make([]TaskEntry, len(shares))
+	var assigned time.Duration
+	for i, s := range shares {
+		d := (entry.Duration * time.Duration(s.Basis) / 10000).Truncate(time.Second)
+		out[i] = entry
+		out[i].Project = s.Project
+		out[i].Duration = d
+		if i > 0 {
+			out[i].Pauses, out[i].Interruptions, out[i].Notes = nil, nil, nil
+		}
+		assigned += d
+	}
+	out[0].Duration += entry.Duration - assigned
+	return out
+}
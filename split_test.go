@@ -0,0 +1,123 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseSplit(t *testing.T) {
+	tests := []struct {
+		text   string
+		task   string
+		shares []split
+	}{
+		{"write docs", "write docs", nil},
+		{"set x=1 in config", "set x=1 in config", nil},
+		{"a==b", "a==b", nil},
+		{"tune =50%", "tune =50%", nil},
+		{"pairing on importer =50% Consulting =50% League", "pairing on importer",
+			[]split{{"Consulting", 5000}, {"League", 5000}}},
+		{"set x=1 =50% Consulting =50% League", "set x=1",
+			[]split{{"Consulting", 5000}, {"League", 5000}}},
+		{"triage =33% A =33% B =34% C", "triage",
+			[]split{{"A", 3300}, {"B", 3300}, {"C", 3400}}},
+		{"triage =33.33% A =33.33% B =33.34% C", "triage",
+			[]split{{"A", 3333}, {"B", 3333}, {"C", 3334}}},
+		{"review =100% Big Client", "review", []split{{"Big Client", 10000}}},
+		{"  spaced   =25%   A  =75%  B  ", "spaced", []split{{"A", 2500}, {"B", 7500}}},
+		{"=60% A =40% B", "", []split{{"A", 6000}, {"B", 4000}}},
+	}
+	for _, tt := range tests {
+		task, shares, err := parseSplit(tt.text)
+		if err != nil {
+			t.Errorf("parseSplit(%q): %v", tt.text, err)
+			continue
+		}
+		if task != tt.task || !reflect.DeepEqual(shares, tt.shares) {
+			t.Errorf("parseSplit(%q) = %q, %v; want %q, %v", tt.text, task, shares, tt.task, tt.shares)
+		}
+	}
+}
+
+func TestParseSplitErrors(t *testing.T) {
+	for _, text := range []string{
+		"triage =33% A =33% B =33% C",
+		"triage =50% A =60% B",
+		"triage =0% A =100% B",
+		"triage =150% A",
+		"triage =33.33% A =33.33% B =33.33% C",
+	} {
+		if _, _, err := parseSplit(text); err == nil {
+			t.Errorf("parseSplit(%q) accepted it", text)
+		}
+	}
+}
+
+// Share projects go through the registry: aliases resolve, unknown
+// names are refused.
+func TestParseSplitRegistry(t *testing.T) {
+	defer func(known []knownProject) { registry = known }(registry)
+	registry = []knownProject{{Name: "Consulting", Aliases: []string{"cons"}}, {Name: "League"}}
+	_, shares, err := parseSplit("pairing =50% cons =50% league")
+	if want := []split{{"Consulting", 5000}, {"League", 5000}}; err != nil || !reflect.DeepEqual(shares, want) {
+		t.Errorf("shares %v, %v; want %v", shares, err, want)
+	}
+	if _, _, err := parseSplit("pairing =50% Consulting =50% Other"); exitCode(err) != exitUsage {
+		t.Errorf("an unknown project: %v", err)
+	}
+}
+
+func TestApplySplit(t *testing.T) {
+	start := at("09:00")
+	entry := TaskEntry{
+		Task:          "triage",
+		Project:       "League",
+		Start:         start,
+		Duration:      time.Hour + 7*time.Second,
+		Pauses:        []Pause{{Start: start.Add(10 * time.Minute), End: start.Add(20 * time.Minute), Reason: "lunch"}},
+		Interruptions: []Interruption{{Start: start.Add(30 * time.Minute), End: start.Add(35 * time.Minute), By: "Bob"}},
+		Notes:         []string{"🍅 Pomodoro #1"},
+	}
+	_, shares, err := parseSplit("triage =33% A =33% B =34% C")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := applySplit(entry, shares)
+	if len(out) != 3 {
+		t.Fatalf("got %d shares, want 3", len(out))
+	}
+	want := []time.Duration{19*time.Minute + 51*time.Second, 19*time.Minute + 50*time.Second, 20*time.Minute + 26*time.Second}
+	var sum time.Duration
+	for i, e := range out {
+		if e.Duration != want[i] {
+			t.Errorf("share %d lasts %s, want %s", i, e.Duration, want[i])
+		}
+		if e.Task != "triage" || !e.Start.Equal(start) {
+			t.Errorf("share %d is %q from %s", i, e.Task, e.Start)
+		}
+		sum += e.Duration
+	}
+	if sum != entry.Duration {
+		t.Errorf("shares add up to %s, want %s", sum, entry.Duration)
+	}
+	if got := breakTotal(out); got != 10*time.Minute {
+		t.Errorf("break time across the shares is %s, want 10m", got)
+	}
+	if count, total := interruptionTotals(out); count != 1 || total != 5*time.Minute {
+		t.Errorf("interruptions across the shares: %d for %s, want 1 for 5m", count, total)
+	}
+	if len(out[0].Notes) != 1 || len(out[1].Notes) != 0 {
+		t.Errorf("notes = %v and %v, want them on the first share only", out[0].Notes, out[1].Notes)
+	}
+	if got := totalDuration(out); got != entry.Duration {
+		t.Errorf("totalDuration of the shares = %s, want %s", got, entry.Duration)
+	}
+}
+
+func TestApplySplitNone(t *testing.T) {
+	e := TaskEntry{Task: "x", Project: "League", Duration: time.Minute}
+	if out := applySplit(e, nil); len(out) != 1 || !reflect.DeepEqual(out[0], e) {
+		t.Errorf("applySplit without shares = %v", out)
+	}
+}
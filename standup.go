@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// standupConfig is the standup section of the config: the incoming
+// webhooks standup --post sends to.
+type standupConfig struct {
+	Slack   string // a Slack incoming webhook URL
+	Discord string // a Discord channel webhook URL
+}
+
+// standupLookback is how far back standup looks for the last day with
+// entries, so a Monday reports on Friday.
+const standupLookback = 7
+
+// lastWorkday is the latest day before today with entries, or yesterday
+// when there is none in the past week.
+func lastWorkday(today time.Time) (time.Time, error) {
+	for i := 1; i <= standupLookback; i++ {
+		day := today.AddDate(0, 0, -i)
+		entries, err := writtenEntries(day)
+		if err != nil {
+			return day, err
+		}
+		if len(entries) > 0 {
+			return day, nil
+		}
+	}
+	return today.AddDate(0, 0, -1), nil
+}
+
+// standupMessage writes date's entries as standup notes, the tasks of a
+// project on one line with the time spent on each, followed by what is
+// open in the plan queue. bold marks the headings the way the chat
+// wants them.
+func standupMessage(date, today time.Time, bold func(string) string) (string, error) {
+	entries, err := writtenEntries(date)
+	if err != nil {
+		return "", err
+	}
+	queue, err := loadQueue(today)
+	if err != nil {
+		return "", err
+	}
+
+	heading := "Yesterday"
+	switch {
+	case date.Equal(today):
+		heading = "Today so far"
+	case !date.Equal(today.AddDate(0, 0, -1)):
+		heading = date.Format("Monday")
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s (%s)\n", bold(heading), date.Format("Mon 2 Jan"))
+	if len(entries) == 0 {
+		b.WriteString("• nothing tracked\n")
+	}
+	var projects []string
+	tasks := map[string][]periodTotal{}
+	index := map[string]int{}
+	for _, e := range entries {
+		if _, ok := tasks[e.Project]; !ok {
+			projects = append(projects, e.Project)
+		}
+		tasks[e.Project] = addTotal(tasks[e.Project], index, e.Task, e.Project, e)
+	}
+	for _, project := range projects {
+		var done []string
+		for _, t := range tasks[project] {
+			done = append(done, fmt.Sprintf("%s (%s)", t.Name, formatDuration("summary", t.total)))
+		}
+		fmt.Fprintf(&b, "• %s: %s\n", project, strings.Join(done, ", "))
+	}
+
+	if !date.Equal(today) {
+		var next []string
+		for _, p := range queue {
+			if p.open() {
+				next = append(next, "• "+p.label(today.Format(dateLayout)))
+			}
+		}
+		if len(next) > 0 {
+			fmt.Fprintf(&b, "%s\n%s\n", bold("Today"), strings.Join(next, "\n"))
+		}
+	}
+	return b.String(), nil
+}
+
+// postStandup sends text to a Slack or Discord incoming webhook.
+func postStandup(url, field, text string) error {
+	body, err := json.Marshal(map[string]string{field: text})
+	if err != nil {
+		return err
+	}
+	client := http.Client{Timeout: webhookTimeout}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s answered %s", url, resp.Status)
+	}
+	return nil
+}
+
+// standupCommand prints the last workday's entries as standup notes and
+// posts them to Slack or Discord with --post.
+func standupCommand(args []string) error {
+	fs := newFlagSet("standup")
+	day := fs.String("date", "", "Day to report: today, yesterday or YYYY-MM-DD (default: the last day before today with entries)")
+	post := fs.String("post", "", "Post the notes to slack or discord (standup.slack or standup.discord in the config)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return withCode(exitUsage, err)
+	}
+	url, field, bold := "", "", func(s string) string { return s }
+	switch *post {
+	case "":
+	case "slack":
+		url, field, bold = cfg.Standup.Slack, "text", func(s string) string { return "*" + s + "*" }
+	case "discord":
+		url, field, bold = cfg.Standup.Discord, "content", func(s string) string { return "**" + s + "**" }
+	default:
+		return usageErrorf("--post takes slack or discord, not %q", *post)
+	}
+	if *post != "" && url == "" {
+		return usageErrorf("standup --post %s needs standup.%s in the config", *post, *post)
+	}
+
+	today := startOfDay(time.Now())
+	date := today
+	if *day == "" {
+		date, err = lastWorkday(today)
+	} else {
+		date, err = parseDay(*day)
+	}
+	if err != nil {
+		return err
+	}
+	text, err := standupMessage(date, today, bold)
+	if err != nil {
+		return err
+	}
+	fmt.Print(text)
+	if *post == "" {
+		return nil
+	}
+	if err := postStandup(url, field, text); err != nil {
+		return err
+	}
+	fmt.Println("📣 Posted to", *post)
+	return nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// sessionState is what a running instance publishes about itself so
+// that other commands (status, ...) can read it without talking to it.
+type sessionState struct {
+	Project string        `json:"project"`
+	Start   time.Time     `json:"start"`
+	Elapsed time.Duration `json:"elapsed"`
+	Paused  bool          `json:"paused"`
+	Slept   time.Duration `json:"slept,omitempty"` // system suspend that paused the session
+	Task    string        `json:"task,omitempty"`  // of the running session, when given up front
+	Updated time.Time     `json:"updated"`
+	Entries []TaskEntry   `json:"entries"` // finished today, written or not
+}
+
+// elapsedAt is the session's elapsed time at now.
+func (s sessionState) elapsedAt(now time.Time) time.Duration {
+	if s.Paused || s.Start.IsZero() {
+		return s.Elapsed
+	}
+	return s.Elapsed + now.Sub(s.Updated)
+}
+
+func statePath() (string, error) {
+	dir, err := appDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "state.json"), nil
+}
+
+func saveState(s sessionState) error {
+	path, err := statePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// loadState returns the published state, or false when there is none.
+func loadState() (sessionState, bool, error) {
+	var s sessionState
+	path, err := statePath()
+	if err != nil {
+		return s, false, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, false, nil
+	}
+	if err != nil {
+		return s, false, err
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return s, false, err
+	}
+	return s, true, nil
+}
+
+func clearState() {
+	if path, err := statePath(); err == nil {
+		os.Remove(path)
+	}
+}
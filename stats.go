@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// trackingStats are the figures stats prints.
+type trackingStats struct {
+	From          string            `json:"from"`
+	To            string            `json:"to"`
+	DaysWorked    int               `json:"days_worked"`
+	CurrentStreak int               `json:"current_streak"`
+	LongestStreak int               `json:"longest_streak"`
+	LongestFrom   string            `json:"longest_streak_from,omitempty"`
+	Weekdays      map[string]string `json:"average_per_weekday"`
+	BestHour      int               `json:"best_hour"` // -1 when no entry has a time
+	BestHourTotal string            `json:"best_hour_total,omitempty"`
+	Longest       *exportedEntry    `json:"longest_session,omitempty"`
+	LongestRun    string            `json:"longest_session_duration,omitempty"`
+
+	weekdays [7]time.Duration
+	hours    [24]time.Duration
+	longest  time.Duration
+}
+
+// computeStats works the figures out of every row. Streaks count
+// consecutive days with time tracked; the current one may end yesterday
+// when nothing is logged today yet. The longest session is the longest
+// stretch worked without a pause.
+func computeStats(rows []datedEntry, today time.Time) trackingStats {
+	st := trackingStats{BestHour: -1, Weekdays: map[string]string{}}
+	perDay := map[string]time.Duration{}
+	var longest time.Duration
+	for _, r := range rows {
+		perDay[r.Date] += r.Duration
+		if r.Start.IsZero() {
+			if len(r.Pauses) == 0 && r.Duration > longest {
+				longest = r.Duration
+				e := r.exported()
+				st.Longest = &e
+			}
+			continue
+		}
+		for _, s := range workedStretches(r.TaskEntry) {
+			if d := s[1].Sub(s[0]); d > longest {
+				longest = d
+				e := r.exported()
+				st.Longest = &e
+			}
+			for at := s[0].Local(); at.Before(s[1]); {
+				next := time.Date(at.Year(), at.Month(), at.Day(), at.Hour()+1, 0, 0, 0, time.Local)
+				if next.After(s[1]) {
+					next = s[1]
+				}
+				st.hours[at.Hour()] += next.Sub(at)
+				at = next
+			}
+		}
+	}
+	st.longest = longest
+	if st.Longest != nil {
+		st.LongestRun = formatDuration("json", longest)
+	}
+
+	var dates []time.Time
+	worked := [7]int{}
+	for date, d := range perDay {
+		day, err := time.ParseInLocation(dateLayout, date, time.Local)
+		if err != nil || d <= 0 {
+			continue
+		}
+		dates = append(dates, day)
+		st.weekdays[day.Weekday()] += d
+		worked[day.Weekday()]++
+	}
+	st.DaysWorked = len(dates)
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		if worked[wd] > 0 {
+			st.weekdays[wd] /= time.Duration(worked[wd])
+			st.Weekdays[wd.String()] = formatDuration("json", st.weekdays[wd])
+		}
+	}
+	for h, d := range st.hours {
+		if d > 0 && (st.BestHour < 0 || d > st.hours[st.BestHour]) {
+			st.BestHour = h
+		}
+	}
+	if st.BestHour >= 0 {
+		st.BestHourTotal = formatDuration("json", st.hours[st.BestHour])
+	}
+
+	at := func(day time.Time) time.Duration { return perDay[day.Format(dateLayout)] }
+	if st.CurrentStreak = streak(at, today); st.CurrentStreak == 0 {
+		st.CurrentStreak = streak(at, today.AddDate(0, 0, -1))
+	}
+	for _, day := range dates {
+		if at(day.AddDate(0, 0, -1)) > 0 {
+			continue // not the first day of a run
+		}
+		n := 0
+		for d := day; at(d) > 0; d = d.AddDate(0, 0, 1) {
+			n++
+		}
+		if n > st.LongestStreak || n == st.LongestStreak && day.Format(dateLayout) > st.LongestFrom {
+			st.LongestStreak, st.LongestFrom = n, day.Format(dateLayout)
+		}
+	}
+	return st
+}
+
+func statsCommand(args []string) error {
+	fs := newFlagSet("stats")
+	project := projectFlag(fs, "project", "", "Only include this project")
+	fromText := fs.String("from", "", "First day (YYYY-MM-DD, default the first log)")
+	toText := fs.String("to", "", "Last day (YYYY-MM-DD, default today)")
+	asJSON := jsonFlag(fs, "Print JSON")
+	failOnEmpty := fs.Bool("fail-on-empty", false, "Exit with code 4 when there are no entries")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	from, to, err := parseDateRange(*fromText, *toText)
+	if err != nil {
+		return err
+	}
+	if to.IsZero() {
+		to = startOfDay(time.Now())
+	}
+	rows, err := exportRows(from, to, *project, "")
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 && *failOnEmpty {
+		return withCode(exitEmpty, errors.New("no entries to compute statistics from"))
+	}
+	st := computeStats(rows, to)
+	st.From, st.To = to.Format(dateLayout), to.Format(dateLayout)
+	if len(rows) > 0 {
+		st.From = rows[0].Date
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		return enc.Encode(st)
+	}
+	fmt.Printf("📈 %s – %s · %d days worked\n", st.From, st.To, st.DaysWorked)
+	fmt.Printf("\n  🔥 Current streak   %d days\n", st.CurrentStreak)
+	if st.LongestStreak > 0 {
+		fmt.Printf("  🏆 Longest streak   %d days from %s\n", st.LongestStreak, st.LongestFrom)
+	}
+	if st.DaysWorked > 0 {
+		fmt.Println("\n  Average per day worked")
+	}
+	for _, wd := range []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday} {
+		if _, ok := st.Weekdays[wd.String()]; ok {
+			fmt.Printf("    %-10s %10s\n", wd, formatDuration("summary", st.weekdays[wd]))
+		}
+	}
+	if st.BestHour >= 0 {
+		fmt.Printf("\n  ⏰ Most productive hour  %02d:00–%02d:00 (%s in all)\n", st.BestHour, (st.BestHour+1)%24, formatDuration("summary", st.hours[st.BestHour]))
+	}
+	if e := st.Longest; e != nil {
+		fmt.Printf("  🏃 Longest session       %s on %s: %s (%s)\n", formatDuration("summary", st.longest), e.Date, e.Task, e.Project)
+	}
+	return nil
+}
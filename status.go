@@ -0,0 +1,210 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// statusInfo is a cheap snapshot of the day built from the state file
+// and the logs on disk, without starting any interactive machinery.
+type statusInfo struct {
+	Class   string // running, paused or idle
+	Project string
+	Task    string        // of the session in progress, when known
+	Elapsed time.Duration // of the session in progress
+	Slept   time.Duration // suspend that paused the session
+	Total   time.Duration // today, including the session in progress
+	Entries []TaskEntry
+	// Others are the detached timers running besides the one shown.
+	Others []detachedTimer
+}
+
+func currentStatus(now time.Time) (statusInfo, error) {
+	info := statusInfo{Class: "idle"}
+	written, err := writtenEntries(now)
+	if err != nil {
+		return info, err
+	}
+	info.Entries = written
+
+	st, ok, err := loadState()
+	if err != nil {
+		return info, err
+	}
+	if ok && instanceRunning() {
+		info.Project = st.Project
+		// Incremental writes and S saves put the run's entries on disk
+		// already; a saved running session is counted by its elapsed
+		// time instead.
+		info.Entries = append(info.Entries, notWritten(st.Entries, written)...)
+		if !st.Start.IsZero() {
+			info.Entries = slices.DeleteFunc(info.Entries, func(e TaskEntry) bool {
+				return e.Task == panicSaveTask && e.Start.Equal(st.Start.Truncate(time.Minute))
+			})
+			info.Class = "running"
+			if st.Paused {
+				info.Class = "paused"
+				info.Slept = st.Slept
+			}
+			info.Elapsed = st.elapsedAt(now)
+			info.Task = st.Task
+		}
+	}
+	if t, others, ok, err := listTimers(); err == nil && ok && info.Class == "idle" {
+		info.Others = others
+		info.Class = "running"
+		if t.PausedAt != nil {
+			info.Class = "paused"
+		}
+		info.Project = t.Project
+		info.Task = t.Task
+		info.Elapsed = t.elapsed(now)
+	}
+	info.Total = totalDuration(info.Entries) + info.Elapsed
+	if info.Project == "" && len(info.Entries) > 0 {
+		info.Project = info.Entries[len(info.Entries)-1].Project
+	}
+	return info, nil
+}
+
+func (s statusInfo) headline() string {
+	line := "⏱ " + formatDuration("status", s.Total)
+	if s.Project != "" {
+		line += " · " + s.Project
+	}
+	if s.Class == "paused" {
+		line += " ⏸"
+		if s.Slept > 0 {
+			line += " (system slept " + formatDuration("footer", s.Slept) + ")"
+		}
+	}
+	return line
+}
+
+func (s statusInfo) details() []string {
+	var lines []string
+	for _, e := range s.Entries {
+		lines = append(lines, fmt.Sprintf("%s · %s", formatDuration("status", e.Duration), e.Task))
+	}
+	if s.Class != "idle" {
+		task := "current session"
+		if s.Task != "" {
+			task = s.Task
+		}
+		lines = append(lines, fmt.Sprintf("%s · %s (%s)", formatDuration("status", s.Elapsed), task, s.Class))
+	}
+	for _, t := range s.Others {
+		state := "running"
+		if t.PausedAt != nil {
+			state = "paused"
+		}
+		lines = append(lines, fmt.Sprintf("%s · %s (%s)", formatDuration("status", t.elapsed(time.Now())), t.label(), state))
+	}
+	return lines
+}
+
+// statusLine is what a status --format template sees.
+type statusLine struct {
+	State   string // running, paused or idle
+	Icon    string // ▶, ⏸ or empty when idle
+	Project string
+	Task    string
+	Elapsed string // of the session in progress
+	Total   string // today
+}
+
+func (s statusInfo) line() statusLine {
+	l := statusLine{State: s.Class, Project: s.Project, Task: s.Task, Total: formatDuration("status", s.Total)}
+	switch s.Class {
+	case "running":
+		l.Icon = "▶"
+	case "paused":
+		l.Icon = "⏸"
+	}
+	if s.Class != "idle" {
+		l.Elapsed = formatDuration("status", s.Elapsed)
+	}
+	return l
+}
+
+// compact is one short line for a tmux status bar or a shell prompt,
+// such as "▶ 0:42 review", and nothing while idle.
+func (s statusInfo) compact() string {
+	if s.Class == "idle" {
+		return ""
+	}
+	l := s.line()
+	what := l.Task
+	if what == "" {
+		what = l.Project
+	}
+	return strings.TrimSpace(l.Icon + " " + l.Elapsed + " " + what)
+}
+
+func statusCommand(args []string) error {
+	fs := newFlagSet("status")
+	format := fs.String("format", "text", "Output format: text, compact, xbar, waybar or a template such as '{{.Task}} {{.Elapsed}}'")
+	waybar := fs.Bool("json-waybar", false, "Same as --format waybar")
+	asJSON := jsonFlag(fs, "Print the status as JSON, as /api/status does")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *waybar {
+		*format = "waybar"
+	}
+	if *asJSON {
+		*format = "json"
+	}
+
+	info, err := currentStatus(time.Now())
+	if err != nil {
+		return err
+	}
+
+	switch *format {
+	case "text":
+		fmt.Println(info.headline())
+	case "json":
+		return printJSON(os.Stdout, newStatusJSON(info))
+	case "compact":
+		if line := info.compact(); line != "" {
+			fmt.Println(line)
+		}
+	case "xbar":
+		fmt.Println(info.headline())
+		fmt.Println("---")
+		for _, line := range info.details() {
+			fmt.Println(line)
+		}
+	case "waybar":
+		out, err := json.Marshal(map[string]string{
+			"text":    info.headline(),
+			"tooltip": strings.Join(info.details(), "\n"),
+			"class":   info.Class,
+			"alt":     info.Class, // picks the icon from format-icons
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+	default:
+		if !strings.Contains(*format, "{{") {
+			return usageErrorf("unknown status format %q", *format)
+		}
+		t, err := template.New("status").Parse(*format)
+		if err != nil {
+			return usageErrorf("status format: %v", err)
+		}
+		var b strings.Builder
+		if err := t.Execute(&b, info.line()); err != nil {
+			return usageErrorf("status format: %v", err)
+		}
+		fmt.Println(strings.TrimSpace(b.String()))
+	}
+	return nil
+}
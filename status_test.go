@@ -0,0 +1,191 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeDay writes entries as project's log for date.
+func writeDay(t testing.TB, logs, project string, date time.Time, entries []TaskEntry) {
+	t.Helper()
+	if err := os.MkdirAll(logs, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(logs, dailyFilename(project, date))
+	if err := os.WriteFile(path, renderMarkdown(project, date, entries), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// statusDay logs a day of short entries and starts a timer.
+func statusDay(t testing.TB, logs string, now time.Time) {
+	t.Helper()
+	var entries []TaskEntry
+	start := now.Add(-10 * time.Hour)
+	for i := 0; i < 24; i++ {
+		entries = append(entries, TaskEntry{Task: "chunk", Project: "League", Start: start, Duration: 3 * time.Minute})
+		start = start.Add(5 * time.Minute)
+	}
+	writeDay(t, logs, "League", now, entries)
+	if _, err := beginTimer("", "League", "review", now.Add(-12*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCurrentStatus(t *testing.T) {
+	_, logs := useTempDirs(t)
+	now := time.Now()
+	statusDay(t, logs, now)
+
+	info, err := currentStatus(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Class != "running" || info.Project != "League" || info.Task != "review" {
+		t.Errorf("status %s %s %s, want running League review", info.Class, info.Project, info.Task)
+	}
+	if len(info.Entries) != 24 {
+		t.Errorf("%d entries, want 24", len(info.Entries))
+	}
+	if want := 72*time.Minute + 12*time.Minute; info.Total.Round(time.Minute) != want {
+		t.Errorf("total %s, want %s", info.Total, want)
+	}
+	if h := info.headline(); !strings.HasPrefix(h, "⏱ ") || !strings.Contains(h, "League") {
+		t.Errorf("headline %q", h)
+	}
+	details := info.details()
+	if len(details) != 25 || !strings.Contains(details[24], "review (running)") {
+		t.Errorf("details end in %q, want the running timer after 24 entries", details[len(details)-1])
+	}
+}
+
+func TestStatusIdle(t *testing.T) {
+	useTempDirs(t)
+	info, err := currentStatus(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Class != "idle" || info.Total != 0 || info.compact() != "" {
+		t.Errorf("idle status %+v, compact %q", info, info.compact())
+	}
+}
+
+// TestStatusIsQuick holds currentStatus to the budget of a menu bar
+// refresh.
+func TestStatusIsQuick(t *testing.T) {
+	if testing.Short() {
+		t.Skip("benchmark")
+	}
+	_, logs := useTempDirs(t)
+	statusDay(t, logs, time.Now())
+	r := testing.Benchmark(func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := currentStatus(time.Now()); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	if per := time.Duration(r.NsPerOp()); per > 100*time.Millisecond {
+		t.Errorf("currentStatus takes %s, want well under 100ms", per)
+	}
+}
+
+func BenchmarkCurrentStatus(b *testing.B) {
+	_, logs := useTempDirs(b)
+	statusDay(b, logs, time.Now())
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := currentStatus(time.Now()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// holdLock makes this process the running instance, so that status
+// reads the state file.
+func holdLock(t *testing.T, config string) {
+	t.Helper()
+	if err := os.MkdirAll(config, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	lock, err := lockPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(lock, []byte(strconv.Itoa(os.Getpid())), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// A session paused by a suspend says so, and the sleep is not counted.
+func TestStatusSlept(t *testing.T) {
+	config, _ := useTempDirs(t)
+	holdLock(t, config)
+	now := time.Now()
+	slept := 7*time.Hour + 12*time.Minute
+	if err := saveState(sessionState{Project: "League", Start: now.Add(-8 * time.Hour), Elapsed: 40 * time.Minute,
+		Paused: true, Slept: slept, Updated: now.Add(-slept)}); err != nil {
+		t.Fatal(err)
+	}
+	info, err := currentStatus(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Class != "paused" || info.Elapsed != 40*time.Minute || info.Total != 40*time.Minute {
+		t.Errorf("status %s, elapsed %s, total %s; want paused at 40m0s", info.Class, info.Elapsed, info.Total)
+	}
+	if h, want := info.headline(), "(system slept 7h12m)"; !strings.Contains(h, want) {
+		t.Errorf("headline %q, want %q in it", h, want)
+	}
+}
+
+// With write_mode incremental the run's finished entries are on disk
+// and in the state file at once; status counts them once.
+func TestStatusIncremental(t *testing.T) {
+	config, logs := useTempDirs(t)
+	holdLock(t, config)
+	now := time.Now()
+	review := TaskEntry{Task: "review", Project: "League", Start: now.Add(-time.Hour), Duration: 20 * time.Minute}
+	triage := TaskEntry{Task: "triage", Project: "League", Start: now.Add(-40 * time.Minute), Duration: 25 * time.Minute}
+	writeDay(t, logs, "League", now, []TaskEntry{review})
+	if err := saveState(sessionState{Project: "League", Start: now.Add(-10 * time.Minute), Elapsed: 10 * time.Minute,
+		Updated: now, Entries: []TaskEntry{review, triage}}); err != nil {
+		t.Fatal(err)
+	}
+	info, err := currentStatus(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Entries) != 2 || info.Total != 55*time.Minute {
+		t.Errorf("entries %+v, total %s; want review and triage, 55m0s", info.Entries, info.Total)
+	}
+}
+
+// After S the running session is in the log as a placeholder; status
+// counts it by its elapsed time alone.
+func TestStatusPanicSaved(t *testing.T) {
+	config, logs := useTempDirs(t)
+	holdLock(t, config)
+	now := time.Now()
+	start := now.Add(-12*time.Minute - 30*time.Second)
+	review := TaskEntry{Task: "review", Project: "League", Start: now.Add(-time.Hour), Duration: 20 * time.Minute}
+	writeDay(t, logs, "League", now, []TaskEntry{
+		review,
+		{Task: panicSaveTask, Project: "League", Start: start, Duration: 12 * time.Minute},
+	})
+	if err := saveState(sessionState{Project: "League", Start: start, Elapsed: 12 * time.Minute,
+		Updated: now, Entries: []TaskEntry{review}}); err != nil {
+		t.Fatal(err)
+	}
+	info, err := currentStatus(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Class != "running" || len(info.Entries) != 1 || info.Total != 32*time.Minute {
+		t.Errorf("status %s, entries %+v, total %s; want running, review alone, 32m0s", info.Class, info.Entries, info.Total)
+	}
+}
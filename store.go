@@ -0,0 +1,576 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// storedEntry is one entry in the entry store, a copy of the Markdown
+// logs kept in step with every write; the logs stay the record. Unlike
+// them it keeps wall-clock times.
+type storedEntry struct {
+	ID          string        `json:"id"`
+	Date        string        `json:"date"`
+	Project     string        `json:"project"`
+	Task        string        `json:"task"`
+	Start       *time.Time    `json:"start,omitempty"` // unknown for entries imported from the logs
+	End         *time.Time    `json:"end,omitempty"`
+	Duration    string        `json:"duration"`
+	Pauses      []storedPause `json:"pauses,omitempty"`
+	Notes       []string      `json:"notes,omitempty"`
+	Billable    bool          `json:"billable,omitempty"`
+	Rate        string        `json:"rate,omitempty"`
+	Attachments []string      `json:"attachments,omitempty"`
+	Tags        []string      `json:"tags,omitempty"`
+	Estimate    string        `json:"estimate,omitempty"`
+
+	Interruptions []storedInterruption `json:"interruptions,omitempty"`
+}
+
+type storedInterruption struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	By    string    `json:"by"`
+	Note  string    `json:"note,omitempty"`
+}
+
+type storedPause struct {
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+func toStored(date string, e TaskEntry) storedEntry {
+	s := storedEntry{
+		ID: e.ID, Date: date, Project: e.Project, Task: e.Task, Duration: e.Duration.String(),
+		Notes: e.Notes, Billable: e.Billable, Attachments: e.Attachments, Tags: e.Tags,
+	}
+	if !e.Start.IsZero() {
+		start, end := e.Start, entryEnd(e)
+		s.Start, s.End = &start, &end
+	}
+	if !e.Rate.isZero() {
+		s.Rate = e.Rate.String()
+	}
+	if e.Estimate > 0 {
+		s.Estimate = e.Estimate.String()
+	}
+	for _, p := range e.Pauses {
+		s.Pauses = append(s.Pauses, storedPause{Start: p.Start, End: p.End, Reason: p.Reason})
+	}
+	for _, i := range e.Interruptions {
+		s.Interruptions = append(s.Interruptions, storedInterruption{Start: i.Start, End: i.End, By: i.By, Note: i.Note})
+	}
+	return s
+}
+
+func (s storedEntry) entry() TaskEntry {
+	e := TaskEntry{
+		ID: s.ID, Task: s.Task, Project: s.Project,
+		Notes: s.Notes, Billable: s.Billable, Attachments: s.Attachments, Tags: s.Tags,
+	}
+	e.Duration, _ = time.ParseDuration(s.Duration)
+	if s.Estimate != "" {
+		e.Estimate, _ = time.ParseDuration(s.Estimate)
+	}
+	if s.Start != nil {
+		e.Start = *s.Start
+	}
+	if s.Rate != "" {
+		e.Rate, _ = parseRate(s.Rate, defaultCurrency)
+	}
+	for _, p := range s.Pauses {
+		e.Pauses = append(e.Pauses, Pause{Start: p.Start, End: p.End, Reason: p.Reason})
+	}
+	for _, i := range s.Interruptions {
+		e.Interruptions = append(e.Interruptions, Interruption{Start: i.Start, End: i.End, By: i.By, Note: i.Note})
+	}
+	return e
+}
+
+func storePath() (string, error) {
+	dir, err := appDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "entries.jsonl"), nil
+}
+
+// loadStore returns every stored entry, sorted by date.
+func loadStore() ([]storedEntry, error) {
+	path, err := storePath()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var stored []storedEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for n := 1; scanner.Scan(); n++ {
+		var s storedEntry
+		if err := json.Unmarshal(scanner.Bytes(), &s); err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, n, err)
+		}
+		stored = append(stored, s)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	fillIDs(stored)
+	return stored, nil
+}
+
+// fillIDs names the entries stored before the store kept IDs as they
+// were named then, so the sync ledger still finds them.
+func fillIDs(stored []storedEntry) {
+	byDate := map[string][]int{}
+	for i, s := range stored {
+		byDate[s.Date] = append(byDate[s.Date], i)
+	}
+	for date, indexes := range byDate {
+		entries := make([]TaskEntry, len(indexes))
+		taken := map[string]bool{}
+		for k, i := range indexes {
+			entries[k] = stored[i].entry()
+			taken[stored[i].ID] = true
+		}
+		ids, _ := assignIDs(date, entries, nil, taken)
+		for k, i := range indexes {
+			stored[i].ID = ids[k]
+		}
+	}
+}
+
+// entryID names the n-th entry with this task on date for project, the
+// ID an entry gets when it is first stored.
+func entryID(date string, e TaskEntry, n int) string {
+	return fmt.Sprintf("%s/%s/%s#%d", date, e.Project, e.Task, n)
+}
+
+// matchStored pairs each of a day's entries with the stored entry it
+// is, giving its index in previous or -1. An entry carrying an ID
+// matches by it alone. The others match, in turn, by task and start, by
+// start, by task, first within their project and then across projects,
+// and last by position, within a project that has as many of them as
+// unmatched stored entries.
+func matchStored(entries []TaskEntry, previous []storedEntry) []int {
+	match := make([]int, len(entries))
+	used := make([]bool, len(previous))
+	for i := range match {
+		match[i] = -1
+	}
+	pair := func(same func(e TaskEntry, s storedEntry) bool) {
+		for i, e := range entries {
+			if match[i] >= 0 {
+				continue
+			}
+			for j, s := range previous {
+				if !used[j] && same(e, s) {
+					match[i], used[j] = j, true
+					break
+				}
+			}
+		}
+	}
+	sameStart := func(e TaskEntry, s storedEntry) bool {
+		if e.Start.IsZero() || s.Start == nil {
+			return e.Start.IsZero() && s.Start == nil
+		}
+		return e.Start.Truncate(time.Minute).Equal(s.Start.Truncate(time.Minute))
+	}
+	pair(func(e TaskEntry, s storedEntry) bool { return e.ID != "" && e.ID == s.ID })
+	for _, within := range []bool{true, false} {
+		sameProject := func(e TaskEntry, s storedEntry) bool { return e.ID == "" && (!within || e.Project == s.Project) }
+		pair(func(e TaskEntry, s storedEntry) bool { return sameProject(e, s) && e.Task == s.Task && sameStart(e, s) })
+		pair(func(e TaskEntry, s storedEntry) bool {
+			return sameProject(e, s) && !e.Start.IsZero() && sameStart(e, s)
+		})
+		pair(func(e TaskEntry, s storedEntry) bool { return sameProject(e, s) && e.Task == s.Task })
+	}
+
+	left, unused := map[string][]int{}, map[string][]int{}
+	for i, e := range entries {
+		if match[i] < 0 && e.ID == "" {
+			left[e.Project] = append(left[e.Project], i)
+		}
+	}
+	for j, s := range previous {
+		if !used[j] {
+			unused[s.Project] = append(unused[s.Project], j)
+		}
+	}
+	for project, indexes := range left {
+		if len(indexes) == len(unused[project]) {
+			for k, i := range indexes {
+				match[i] = unused[project][k]
+			}
+		}
+	}
+	return match
+}
+
+// assignIDs returns the IDs of a day's entries: the one an entry
+// carries, else that of the stored entry in previous it matches, else a
+// new one from entryID, numbered as entries were before the store kept
+// IDs but skipping those in taken. Every ID handed out joins taken.
+func assignIDs(date string, entries []TaskEntry, previous []storedEntry, taken map[string]bool) ([]string, []int) {
+	match := matchStored(entries, previous)
+	ids := make([]string, len(entries))
+	for i, e := range entries {
+		switch {
+		case e.ID != "":
+			ids[i] = e.ID
+		case match[i] >= 0:
+			ids[i] = previous[match[i]].ID
+		default:
+			continue
+		}
+		taken[ids[i]] = true
+	}
+	seen := map[string]int{}
+	for i, e := range entries {
+		key := e.Project + "\x00" + e.Task
+		seen[key]++
+		for n := seen[key]; ids[i] == ""; n++ {
+			if id := entryID(date, e, n); !taken[id] {
+				ids[i] = id
+				taken[id] = true
+			}
+		}
+	}
+	return ids, match
+}
+
+// matchDay returns the IDs of a day's entries, as stored or as storing
+// them would give them, and the stored entry each one is, if any.
+func matchDay(stored []storedEntry, date string, entries []TaskEntry) ([]string, []*storedEntry) {
+	var previous []storedEntry
+	for _, s := range stored {
+		if s.Date == date {
+			previous = append(previous, s)
+		}
+	}
+	ids, match := assignIDs(date, entries, previous, takenIDs(stored, date))
+	found := make([]*storedEntry, len(entries))
+	for i, j := range match {
+		if j >= 0 {
+			found[i] = &previous[j]
+		}
+	}
+	return ids, found
+}
+
+// takenIDs returns the IDs a new entry on date must not get: those of
+// the day's stored entries and, once the store knows the day, those the
+// sync ledger has records for, so an entry never inherits the records
+// of one deleted before it. On a day the store does not know yet, the
+// ledger's IDs are the ones its logged entries are about to get.
+func takenIDs(stored []storedEntry, date string) map[string]bool {
+	taken := map[string]bool{}
+	for _, s := range stored {
+		if s.Date == date {
+			taken[s.ID] = true
+		}
+	}
+	if len(taken) == 0 {
+		return taken
+	}
+	ledger, _ := loadLedger()
+	for id := range ledger {
+		if strings.HasPrefix(id, date+"/") {
+			taken[id] = true
+		}
+	}
+	return taken
+}
+
+// entryIDs returns the IDs of a day's entries.
+func entryIDs(date string, entries []TaskEntry) []string {
+	stored, _ := loadStore()
+	ids, _ := matchDay(stored, date, entries)
+	return ids
+}
+
+// storeDay replaces the stored entries of project on date.
+func storeDay(date, project string, entries []TaskEntry) error {
+	entries = append([]TaskEntry(nil), entries...)
+	for i := range entries {
+		entries[i].Project = project
+	}
+	return storeReplace(date, []string{project}, entries)
+}
+
+// storeReplace replaces the stored entries of projects on date with
+// entries, which belong to those projects. Each entry keeps the ID of
+// the stored entry it matches. Entries read back from a log have their
+// start time to the minute at best; they keep the exact times stored
+// when those agree to the minute.
+func storeReplace(date string, projects []string, entries []TaskEntry) error {
+	stored, err := loadStore()
+	if err != nil {
+		return err
+	}
+	replaced := map[string]bool{}
+	for _, p := range projects {
+		replaced[p] = true
+	}
+	var kept, previous []storedEntry
+	for _, s := range stored {
+		if s.Date == date && replaced[s.Project] {
+			previous = append(previous, s)
+		} else {
+			kept = append(kept, s)
+		}
+	}
+	ids, match := assignIDs(date, entries, previous, takenIDs(stored, date))
+	for i, e := range entries {
+		if j := match[i]; j >= 0 && previous[j].Start != nil &&
+			(e.Start.IsZero() || e.Start.Truncate(time.Minute).Equal(previous[j].Start.Truncate(time.Minute))) {
+			known := previous[j].entry()
+			e.Start = known.Start
+			if len(e.Pauses) == len(known.Pauses) {
+				e.Pauses = known.Pauses
+			}
+		}
+		e.ID = ids[i]
+		kept = append(kept, toStored(date, e))
+	}
+	sort.SliceStable(kept, func(i, j int) bool { return kept[i].Date < kept[j].Date })
+	return saveStore(kept)
+}
+
+// writeLog writes a log file and brings the entry store in line with
+// it. known are entries with the exact times the log keeps only to the
+// minute.
+func writeLog(path string, content []byte, known ...TaskEntry) error {
+	old, _ := os.ReadFile(path)
+	if err := writeFileAtomic(path, content); err != nil {
+		return err
+	}
+	storeLogs(map[string][]byte{path: old}, map[string][]byte{path: content}, known...)
+	return nil
+}
+
+// storeLogs brings the entry store in line with log files that changed
+// from before to after, their content by path; nil is no file. Every
+// day on either side is stored again, for the projects either side has
+// on it, with the entries after holds. Those that are among known take
+// its exact times. A failure only warns, as the logs are written.
+func storeLogs(before, after map[string][]byte, known ...TaskEntry) {
+	projects := map[string]map[string]bool{}
+	days := map[string][]TaskEntry{}
+	note := func(content []byte, keep bool) {
+		for date, entries := range parseLog(content) {
+			if date == "" {
+				continue
+			}
+			if projects[date] == nil {
+				projects[date] = map[string]bool{}
+			}
+			for _, e := range entries {
+				projects[date][e.Project] = true
+			}
+			if keep {
+				days[date] = append(days[date], entries...)
+			}
+		}
+	}
+	for _, content := range before {
+		note(content, false)
+	}
+	for _, content := range after {
+		note(content, true)
+	}
+	dates := make([]string, 0, len(projects))
+	for date := range projects {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+	for _, date := range dates {
+		names := make([]string, 0, len(projects[date]))
+		for project := range projects[date] {
+			names = append(names, project)
+		}
+		sort.Strings(names)
+		entries := days[date]
+		for i, e := range entries {
+			for _, k := range known {
+				if k.Project == e.Project && k.Task == e.Task && !k.Start.IsZero() &&
+					k.Start.Truncate(time.Minute).Equal(e.Start.Truncate(time.Minute)) {
+					entries[i].Start = k.Start
+					if len(e.Pauses) == len(k.Pauses) {
+						entries[i].Pauses = k.Pauses
+					}
+					break
+				}
+			}
+		}
+		if err := storeReplace(date, names, entries); err != nil {
+			fmt.Println("⚠️  Could not update the entry store:", err)
+			return
+		}
+	}
+}
+
+func saveStore(stored []storedEntry) error {
+	fillIDs(stored)
+	path, err := storePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	for _, s := range stored {
+		if err := enc.Encode(s); err != nil {
+			return err
+		}
+	}
+	return writeFileAtomic(path, b.Bytes())
+}
+
+// storedDays groups the stored entries by date and project.
+func storedDays(stored []storedEntry) map[string]map[string][]TaskEntry {
+	days := map[string]map[string][]TaskEntry{}
+	for _, s := range stored {
+		if days[s.Date] == nil {
+			days[s.Date] = map[string][]TaskEntry{}
+		}
+		days[s.Date][s.Project] = append(days[s.Date][s.Project], s.entry())
+	}
+	return days
+}
+
+func storeCommand(args []string) error {
+	if len(args) == 0 {
+		return usageErrorf("usage: store import | store render --date DATE [--dry-run | --preview]")
+	}
+	switch args[0] {
+	case "import":
+		return storeImport(args[1:])
+	case "render":
+		return storeRender(args[1:])
+	}
+	return usageErrorf("unknown store command %q", args[0])
+}
+
+// storeImport fills the store from the Markdown logs, for days it does
+// not know yet.
+func storeImport(args []string) error {
+	fs := newFlagSet("store import")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	stored, err := loadStore()
+	if err != nil {
+		return err
+	}
+	known := storedDays(stored)
+	logs, err := listLogs()
+	if err != nil {
+		return err
+	}
+	added := 0
+	for _, lf := range logs {
+		content, err := os.ReadFile(lf.Path)
+		if err != nil {
+			return err
+		}
+		for date, entries := range parseLog(content) {
+			for _, e := range entries {
+				if _, ok := known[date][e.Project]; ok {
+					continue
+				}
+				stored = append(stored, toStored(date, e))
+				added++
+			}
+		}
+	}
+	if added > 0 {
+		sort.SliceStable(stored, func(i, j int) bool { return stored[i].Date < stored[j].Date })
+		if err := saveStore(stored); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("🗄️  Imported %d entries from %d logs\n", added, len(logs))
+	return nil
+}
+
+// storeRender regenerates a day's Markdown logs from the store.
+func storeRender(args []string) error {
+	fs := newFlagSet("store render")
+	dateFlag := fs.String("date", "today", "Day to render: today, yesterday or YYYY-MM-DD")
+	dryRun := fs.Bool("dry-run", false, "Show the changes without writing them")
+	preview := fs.Bool("preview", false, "Show the changes and ask before writing them")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	date, err := parseDay(*dateFlag)
+	if err != nil {
+		return err
+	}
+	stored, err := loadStore()
+	if err != nil {
+		return err
+	}
+	projects := storedDays(stored)[date.Format(dateLayout)]
+	if len(projects) == 0 {
+		return fmt.Errorf("nothing stored for %s", date.Format(dateLayout))
+	}
+	changes, err := dayRewrites(date, projects)
+	if err != nil {
+		return err
+	}
+	_, err = applyRewrites(changes, *dryRun, *preview)
+	return err
+}
+
+// dayRewrites renders the logs of date for the given projects' entries
+// and returns the files that would change.
+func dayRewrites(date time.Time, projects map[string][]TaskEntry) ([]rewrite, error) {
+	dir, err := logDir()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for project := range projects {
+		names = append(names, project)
+	}
+	sort.Strings(names)
+	var changes []rewrite
+	for _, project := range names {
+		path := filepath.Join(dir, dailyFilename(project, date))
+		if logLayout == "weekly" {
+			path = filepath.Join(dir, weeklyFilename(project, date))
+		}
+		old, _ := os.ReadFile(path)
+		var updated []byte
+		if logLayout == "weekly" {
+			updated = renderWeek(project, date, projects[project], old, false)
+		} else {
+			updated = carryReviewFlags(renderMarkdown(project, date, projects[project]), old)
+		}
+		if !bytes.Equal(old, updated) {
+			changes = append(changes, rewrite{From: path, To: path, Old: old, New: updated})
+		}
+	}
+	return changes, nil
+}
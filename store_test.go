@@ -0,0 +1,235 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// storedIDs returns the IDs of the stored entries, in store order.
+func storedIDs(t *testing.T) []string {
+	t.Helper()
+	stored, err := loadStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, s := range stored {
+		ids = append(ids, s.ID)
+	}
+	return ids
+}
+
+// reviews are three entries with the same task, as read back from a log.
+func reviews() []TaskEntry {
+	var entries []TaskEntry
+	for _, clock := range []string{"09:00", "10:00", "11:00"} {
+		entries = append(entries, TaskEntry{Task: "review", Project: "League", Start: at(clock), Duration: 20 * time.Minute})
+	}
+	return entries
+}
+
+func TestStoreIDs(t *testing.T) {
+	const day = "2024-03-01"
+	id := func(task string, n int) string { return entryID(day, TaskEntry{Project: "League", Task: task}, n) }
+	tests := []struct {
+		name   string
+		change func([]TaskEntry) []TaskEntry
+		want   []string
+	}{
+		{"unchanged", func(e []TaskEntry) []TaskEntry { return e },
+			[]string{id("review", 1), id("review", 2), id("review", 3)}},
+		{"earlier one deleted", func(e []TaskEntry) []TaskEntry { return e[1:] },
+			[]string{id("review", 2), id("review", 3)}},
+		{"task renamed", func(e []TaskEntry) []TaskEntry { e[1].Task = "code review"; return e },
+			[]string{id("review", 1), id("review", 2), id("review", 3)}},
+		{"all retasked", func(e []TaskEntry) []TaskEntry {
+			for i := range e {
+				e[i].Task = "reviews"
+			}
+			return e
+		}, []string{id("review", 1), id("review", 2), id("review", 3)}},
+		{"moved and reordered", func(e []TaskEntry) []TaskEntry {
+			e[0].Start = at("12:00")
+			return append(e[1:], e[0])
+		}, []string{id("review", 2), id("review", 3), id("review", 1)}},
+		{"one added", func(e []TaskEntry) []TaskEntry {
+			return append([]TaskEntry{{Task: "review", Project: "League", Start: at("08:00"), Duration: time.Minute}}, e...)
+		}, []string{id("review", 4), id("review", 1), id("review", 2), id("review", 3)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTempDirs(t)
+			if err := storeDay(day, "League", reviews()); err != nil {
+				t.Fatal(err)
+			}
+			if err := storeDay(day, "League", tt.change(reviews())); err != nil {
+				t.Fatal(err)
+			}
+			if got := storedIDs(t); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("IDs %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// An entry moved to another project keeps its ID when both projects are
+// stored together, as edit does.
+func TestStoreIDsAcrossProjects(t *testing.T) {
+	useTempDirs(t)
+	const day = "2024-03-01"
+	if err := storeDay(day, "League", reviews()); err != nil {
+		t.Fatal(err)
+	}
+	before := storedIDs(t)
+	entries := reviews()
+	entries[2].Project = "Consulting"
+	if err := storeReplace(day, []string{"League", "Consulting"}, entries); err != nil {
+		t.Fatal(err)
+	}
+	if got := storedIDs(t); !reflect.DeepEqual(got, before) {
+		t.Errorf("IDs %q, want %q", got, before)
+	}
+}
+
+// A new entry does not take the ID of a deleted one the ledger still
+// has records for.
+func TestStoreIDsNotReused(t *testing.T) {
+	useTempDirs(t)
+	const day = "2024-03-01"
+	if err := storeDay(day, "League", reviews()); err != nil {
+		t.Fatal(err)
+	}
+	deleted := storedIDs(t)[0]
+	if err := recordSync(syncRecord{Entry: deleted, Target: "clockify", RemoteID: "r1", Synced: at("12:00")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := storeDay(day, "League", reviews()[1:]); err != nil {
+		t.Fatal(err)
+	}
+	added := TaskEntry{Task: "review", Project: "League", Start: at("08:00"), Duration: time.Minute}
+	if err := storeDay(day, "League", append([]TaskEntry{added}, reviews()[1:]...)); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range storedIDs(t) {
+		if id == deleted {
+			t.Errorf("a new entry took %s, the ID of a deleted one", deleted)
+		}
+	}
+}
+
+// Entries stored before the store kept IDs are named as they were then,
+// so their ledger records still apply.
+func TestStoreLegacyIDs(t *testing.T) {
+	config, _ := useTempDirs(t)
+	lines := `{"date":"2024-03-01","project":"League","task":"review","duration":"20m0s"}
+{"date":"2024-03-01","project":"League","task":"triage","duration":"5m0s"}
+{"date":"2024-03-01","project":"League","task":"review","duration":"10m0s"}
+{"date":"2024-03-02","project":"League","task":"review","duration":"10m0s"}
+`
+	if err := os.MkdirAll(config, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(config, "entries.jsonl"), []byte(lines), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"2024-03-01/League/review#1", "2024-03-01/League/triage#1", "2024-03-01/League/review#2", "2024-03-02/League/review#1"}
+	if got := storedIDs(t); !reflect.DeepEqual(got, want) {
+		t.Errorf("IDs %q, want %q", got, want)
+	}
+}
+
+// Entries read back from a log get the IDs they are stored under.
+func TestEntryIDsOfLog(t *testing.T) {
+	useTempDirs(t)
+	const day = "2024-03-01"
+	if err := storeDay(day, "League", reviews()); err != nil {
+		t.Fatal(err)
+	}
+	if err := storeDay(day, "League", reviews()[1:]); err != nil {
+		t.Fatal(err)
+	}
+	logged := reviews()[1:]
+	for i := range logged {
+		logged[i].Start = logged[i].Start.Add(20 * time.Second)
+	}
+	if got, want := entryIDs(day, logged), storedIDs(t); !reflect.DeepEqual(got, want) {
+		t.Errorf("IDs %q, want %q", got, want)
+	}
+}
+
+// Commands that rewrite the logs keep the store in step and the
+// entries' IDs with it.
+func TestStoreFollowsLogs(t *testing.T) {
+	today := startOfDay(time.Now())
+	date := today.Format(dateLayout)
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"retask", []string{"retask", "--match", "review", "--replace", "code review"}},
+		{"rename", []string{"rename", "--project", "League=LeagueApp"}},
+		{"attach", []string{"attach", "--last", "https://example.com/pr/7"}},
+		{"doctor --ack", []string{"doctor", "--ack", date}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, logs := useTempDirs(t)
+			writeConfig(t, config, "log_dir: "+logs+"\n")
+			writeDay(t, logs, "League", today, []TaskEntry{
+				{Task: "review", Project: "League", Start: today.Add(9 * time.Hour), Duration: 20 * time.Minute},
+				{Task: "triage", Project: "League", Start: today.Add(10 * time.Hour), Duration: 10 * time.Minute},
+			})
+			home := filepath.Dir(logs)
+			if code, out := runMain(t, home, "", "store", "import"); code != exitOK {
+				t.Fatalf("store import: exit %d:\n%s", code, out)
+			}
+			before := storedIDs(t)
+			if code, out := runMain(t, home, "", tt.args...); code != exitOK {
+				t.Fatalf("exit %d:\n%s", code, out)
+			}
+			if got := storedIDs(t); !reflect.DeepEqual(got, before) {
+				t.Errorf("IDs %q, want %q", got, before)
+			}
+			logged, err := writtenEntries(today)
+			if err != nil {
+				t.Fatal(err)
+			}
+			stored, err := loadStore()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(stored) != len(logged) {
+				t.Fatalf("%d entries stored, %d logged", len(stored), len(logged))
+			}
+			for i, s := range stored {
+				e := s.entry()
+				e.ID = ""
+				// Fields the log leaves out read back empty rather than nil.
+				if got, want := fmt.Sprintf("%+v", e), fmt.Sprintf("%+v", logged[i]); got != want {
+					t.Errorf("stored\n%s\nlogged\n%s", got, want)
+				}
+			}
+		})
+	}
+}
+
+// Finishing a timer whose entry the log already has stores it once.
+func TestStoreEndTimerOnce(t *testing.T) {
+	config, logs := useTempDirs(t)
+	writeConfig(t, config, "log_dir: "+logs+"\n")
+	home := filepath.Dir(logs)
+	for i := 0; i < 2; i++ {
+		runMain(t, home, "", "start", "--project", "League", "--task", "triage")
+		if code, out := runMain(t, home, "", "stop"); code != exitOK {
+			t.Fatalf("stop: exit %d:\n%s", code, out)
+		}
+	}
+	logged, _ := writtenEntries(time.Now())
+	if stored := storedIDs(t); len(stored) != len(logged) {
+		t.Errorf("%d entries stored, %d logged", len(stored), len(logged))
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+const tagPrefix = "- 🏷️ **Tags**: "
+
+// parseTags takes "#tag" words out of a task: "fix login #bugfix
+// #LEAGUE-123" is the task "fix login" tagged bugfix and LEAGUE-123.
+// A # followed by a digit, as in "PR #42", stays part of the task.
+func parseTags(task string) (string, []string) {
+	var kept, tags []string
+	for _, f := range strings.Fields(task) {
+		name, ok := strings.CutPrefix(f, "#")
+		if !ok || name == "" || unicode.IsDigit([]rune(name)[0]) {
+			kept = append(kept, f)
+			continue
+		}
+		if !hasTag(TaskEntry{Tags: tags}, name) {
+			tags = append(tags, name)
+		}
+	}
+	return strings.Join(kept, " "), tags
+}
+
+// hasTag reports whether e carries tag, ignoring case.
+func hasTag(e TaskEntry, tag string) bool {
+	for _, t := range e.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTagList reads the value of a Tags line.
+func parseTagList(text string) []string {
+	var tags []string
+	for _, t := range strings.Split(text, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+// frontmatterTags lists the log's tags: work-log, the project and every
+// tag on its entries, lowercased the way the project always was.
+func frontmatterTags(project string, entries []TaskEntry) string {
+	tags := []string{"work-log", strings.ToLower(project)}
+	seen := map[string]bool{tags[0]: true, tags[1]: true}
+	for _, e := range entries {
+		for _, t := range e.Tags {
+			t = strings.ToLower(t)
+			if !seen[t] {
+				seen[t] = true
+				tags = append(tags, t)
+			}
+		}
+	}
+	return strings.Join(tags, ", ")
+}
+
+// tagTotals sums the entries per tag; an entry with several tags counts
+// toward each. It is empty when no entry has a tag.
+func tagTotals(entries []TaskEntry) []periodTotal {
+	var totals []periodTotal
+	index := map[string]int{}
+	for _, e := range entries {
+		for _, t := range e.Tags {
+			totals = addTotal(totals, index, strings.ToLower(t), "", e)
+		}
+	}
+	return finishTotals(totals)
+}
+
+// retag brings the frontmatter tags of a daily log up to date with its
+// entries after they were added to as text.
+func retag(content []byte) []byte {
+	project := frontmatterValue(content, "project")
+	var entries []TaskEntry
+	for _, day := range parseLog(content) {
+		entries = append(entries, day...)
+	}
+	lines := strings.SplitAfter(string(content), "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "# ") {
+			break
+		}
+		if strings.HasPrefix(line, "tags: [") {
+			lines[i] = "tags: [" + frontmatterTags(project, entries) + "]\n"
+			break
+		}
+	}
+	return []byte(strings.Join(lines, ""))
+}
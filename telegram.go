@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// telegramAPI is the Telegram Bot API.
+var telegramAPI = "https://api.telegram.org"
+
+const (
+	telegramPoll  = 50 * time.Second // how long a getUpdates call waits for messages
+	telegramRetry = 10 * time.Second // pause after a failed poll
+)
+
+// telegramConfig is the telegram section of the config.
+type telegramConfig struct {
+	Token string // from @BotFather
+	Chat  int64  // the only chat the bot takes commands from
+}
+
+const telegramHelp = `/track [project] [task] starts a timer; the first word is a project when project list has it
+/pause [reason] pauses it, /resume carries on
+/stop [task] stops it and logs the entry
+/status shows what is being tracked`
+
+// telegramCommands are offered in the chat's command menu.
+var telegramCommands = []map[string]string{
+	{"command": "track", "description": "Start a timer: [project] [task]"},
+	{"command": "pause", "description": "Pause it: [reason]"},
+	{"command": "resume", "description": "Carry on after a pause"},
+	{"command": "stop", "description": "Stop and log it: [task]"},
+	{"command": "status", "description": "What is being tracked"},
+}
+
+type telegramBot struct {
+	cfg    telegramConfig
+	client *http.Client
+}
+
+// call invokes a Bot API method. Errors leave out the URL, which holds
+// the token.
+func (b telegramBot) call(method string, body, result any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	resp, err := b.client.Post(telegramAPI+"/bot"+b.cfg.Token+"/"+method, "application/json", bytes.NewReader(data))
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("%s: %v", method, err)
+	}
+	defer resp.Body.Close()
+	var reply struct {
+		OK          bool            `json:"ok"`
+		Description string          `json:"description"`
+		Result      json.RawMessage `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+		return fmt.Errorf("%s: %s", method, resp.Status)
+	}
+	if !reply.OK {
+		return fmt.Errorf("%s: %s", method, reply.Description)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(reply.Result, result)
+}
+
+type telegramUpdate struct {
+	ID      int64 `json:"update_id"`
+	Message *struct {
+		Text string `json:"text"`
+		Chat struct {
+			ID int64 `json:"id"`
+		} `json:"chat"`
+	} `json:"message"`
+}
+
+// handle carries out a message's command and returns the answer.
+func (b telegramBot) handle(chat int64, text string) string {
+	if chat != b.cfg.Chat {
+		return fmt.Sprintf("⛔ This chat is not the one in the config; set telegram.chat to %d to use it", chat)
+	}
+	cmd, rest, _ := strings.Cut(strings.TrimSpace(text), " ")
+	cmd, _, _ = strings.Cut(cmd, "@") // /status@worklog_bot in a group
+	rest = strings.TrimSpace(rest)
+	req := daemonRequest{Cmd: strings.TrimPrefix(cmd, "/")}
+	switch cmd {
+	case "/track":
+		req.Cmd, req.Project, req.Task = "start", defaultProject, rest
+		if first, task, _ := strings.Cut(rest, " "); len(registry) > 0 && findProject(registry, first) >= 0 {
+			req.Project, req.Task = registry[findProject(registry, first)].Name, strings.TrimSpace(task)
+		}
+	case "/pause", "/resume":
+		t, err := timerRequest(daemonRequest{Cmd: "status"})
+		if err != nil {
+			return "❌ " + err.Error()
+		}
+		if paused := t.PausedAt != nil; paused != (cmd == "/resume") {
+			return timerLine(t)
+		}
+		req.Cmd, req.Reason = "pause", rest
+	case "/stop":
+		req.Task = rest
+	case "/status":
+		info, err := currentStatus(time.Now())
+		if err != nil {
+			return "❌ " + err.Error()
+		}
+		return strings.Join(append([]string{info.headline()}, info.details()...), "\n")
+	default: // also /start, which Telegram sends when the chat is opened
+		return telegramHelp
+	}
+	reply, err := runTimer(req)
+	if err != nil {
+		return "❌ " + err.Error()
+	}
+	if e := reply.Entry; e != nil {
+		return fmt.Sprintf("⏹️ %s · %s: %s", e.Project, e.Task, formatDuration("summary", e.Duration))
+	}
+	if reply.Timer == nil {
+		return "📭 Nothing is being tracked"
+	}
+	return timerLine(*reply.Timer)
+}
+
+// telegramCommand runs a bot that drives the timers from a Telegram
+// chat, through the daemon when one is running, until interrupted.
+func telegramCommand(args []string) error {
+	fs := newFlagSet("telegram")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return withCode(exitUsage, err)
+	}
+	if cfg.Telegram.Token == "" || cfg.Telegram.Chat == 0 {
+		return usageErrorf("telegram needs telegram.token and telegram.chat in the config")
+	}
+	bot := telegramBot{cfg: cfg.Telegram, client: &http.Client{Timeout: telegramPoll + syncHTTPTimeout}}
+	if err := bot.call("setMyCommands", map[string]any{"commands": telegramCommands}, nil); err != nil {
+		return err
+	}
+	fmt.Println("🤖 Taking commands from Telegram; Ctrl+C stops")
+	var offset int64
+	for {
+		var updates []telegramUpdate
+		poll := map[string]any{"offset": offset, "timeout": int(telegramPoll.Seconds()), "allowed_updates": []string{"message"}}
+		if err := bot.call("getUpdates", poll, &updates); err != nil {
+			fmt.Println("⚠️ ", err)
+			time.Sleep(telegramRetry)
+			continue
+		}
+		for _, u := range updates {
+			offset = u.ID + 1
+			if u.Message == nil || u.Message.Text == "" {
+				continue
+			}
+			answer := bot.handle(u.Message.Chat.ID, u.Message.Text)
+			if err := bot.call("sendMessage", map[string]any{"chat_id": u.Message.Chat.ID, "text": answer}, nil); err != nil {
+				fmt.Println("⚠️ ", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"sync/atomic"
+)
+
+// rawInput is set while the terminal delivers keys one at a time, so
+// readInput passes them on without waiting for Enter.
+var rawInput atomic.Bool
+
+// savedTTY is the terminal state from before enterRaw, as printed by
+// stty -g.
+var savedTTY string
+
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// enterRaw switches the terminal to single-key input without echo for
+// the session loop. It does nothing when stdin is not a terminal, stty
+// is missing or the terminal is already raw. -ixon lets Ctrl+S through.
+func enterRaw() {
+	if rawInput.Load() || !stdinIsTerminal() {
+		return
+	}
+	saved, err := stty("-g")
+	if err != nil {
+		return
+	}
+	if _, err := stty("-icanon", "-echo", "-ixon", "min", "1", "time", "0"); err != nil {
+		return
+	}
+	savedTTY = saved
+	rawInput.Store(true)
+}
+
+// leaveRaw restores the terminal for prompts and on the way out. What
+// gets printed next is not part of the tracking view, so that is drawn
+// afresh afterwards.
+func leaveRaw() {
+	tui.invalidate()
+	if !rawInput.Swap(false) {
+		return
+	}
+	stty(savedTTY)
+}
@@ -0,0 +1,509 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// detachedTimer is a session started with the start subcommand and
+// ended with stop, for scripts and key bindings. Without a daemon no
+// process runs in between. Several can run at once under their own
+// names; the unnamed one is the default.
+type detachedTimer struct {
+	Name     string        `json:"name,omitempty"`
+	Project  string        `json:"project"`
+	Task     string        `json:"task,omitempty"`
+	Start    time.Time     `json:"start"`
+	PausedAt *time.Time    `json:"paused_at,omitempty"`
+	Reason   string        `json:"pause_reason,omitempty"` // why it is paused
+	Pauses   []storedPause `json:"pauses,omitempty"`
+}
+
+// elapsed is the tracked time at now, pauses excluded.
+func (t detachedTimer) elapsed(now time.Time) time.Duration {
+	if t.PausedAt != nil {
+		now = *t.PausedAt
+	}
+	d := now.Sub(t.Start)
+	for _, p := range t.Pauses {
+		d -= p.End.Sub(p.Start)
+	}
+	return max(d, 0).Round(time.Second)
+}
+
+// lastActive is when the timer was last resumed, the only sign of life
+// a running detached timer has; zero for never.
+func (t detachedTimer) lastActive() time.Time {
+	var last time.Time
+	for _, p := range t.Pauses {
+		if p.End.After(last) {
+			last = p.End
+		}
+	}
+	return last
+}
+
+// label names the timer for messages: its project, and its name when
+// it has one.
+func (t detachedTimer) label() string {
+	if t.Name == "" {
+		return t.Project
+	}
+	return t.Name + " · " + t.Project
+}
+
+// checkTimerName keeps names usable in file names.
+func checkTimerName(name string) error {
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' {
+			return usageErrorf("timer names are letters, digits, - and _; got %q", name)
+		}
+	}
+	return nil
+}
+
+func timerPath(name string) (string, error) {
+	dir, err := appDir()
+	if err != nil {
+		return "", err
+	}
+	if name == "" {
+		return filepath.Join(dir, "timer.json"), nil
+	}
+	return filepath.Join(dir, "timer-"+name+".json"), nil
+}
+
+// loadTimer returns the running detached timer of that name, or false
+// when there is none.
+func loadTimer(name string) (detachedTimer, bool, error) {
+	var t detachedTimer
+	if err := checkTimerName(name); err != nil {
+		return t, false, err
+	}
+	path, err := timerPath(name)
+	if err != nil {
+		return t, false, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return t, false, nil
+	}
+	if err != nil {
+		return t, false, err
+	}
+	if err := json.Unmarshal(data, &t); err != nil {
+		return t, false, fmt.Errorf("%s: %v", path, err)
+	}
+	t.Name = name
+	return t, true, nil
+}
+
+// loadTimers returns every running timer, the first started first.
+func loadTimers() ([]detachedTimer, error) {
+	path, err := timerPath("")
+	if err != nil {
+		return nil, err
+	}
+	paths, err := filepath.Glob(filepath.Join(filepath.Dir(path), "timer-*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var timers []detachedTimer
+	for _, name := range append([]string{""}, paths...) {
+		if name != "" {
+			name = strings.TrimSuffix(strings.TrimPrefix(filepath.Base(name), "timer-"), ".json")
+		}
+		t, ok, err := loadTimer(name)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			timers = append(timers, t)
+		}
+	}
+	sort.SliceStable(timers, func(i, j int) bool { return timers[i].Start.Before(timers[j].Start) })
+	return timers, nil
+}
+
+func focusPath() (string, error) {
+	dir, err := appDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "timer.focus"), nil
+}
+
+// focusTimer makes name the timer shown by status and acted on when no
+// name is given.
+func focusTimer(name string) error {
+	path, err := focusPath()
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, []byte(name+"\n"))
+}
+
+// shownTimer is the focused timer, or the last one started when the
+// focused one is gone; the others come second.
+func shownTimer() (detachedTimer, []detachedTimer, bool, error) {
+	timers, err := loadTimers()
+	if err != nil || len(timers) == 0 {
+		return detachedTimer{}, nil, false, err
+	}
+	shown := len(timers) - 1
+	if path, err := focusPath(); err == nil {
+		if data, err := os.ReadFile(path); err == nil {
+			for i, t := range timers {
+				if t.Name == strings.TrimSpace(string(data)) {
+					shown = i
+				}
+			}
+		}
+	}
+	others := append(append([]detachedTimer(nil), timers[:shown]...), timers[shown+1:]...)
+	return timers[shown], others, true, nil
+}
+
+// resolveTimer picks the timer a command without --name means: the only
+// one running, or else the focused one.
+func resolveTimer(name string) (string, error) {
+	if name != "" {
+		return name, checkTimerName(name)
+	}
+	t, others, ok, err := shownTimer()
+	if err != nil || !ok || len(others) == 0 {
+		return t.Name, err
+	}
+	path, err := focusPath()
+	if err != nil {
+		return "", err
+	}
+	if data, err := os.ReadFile(path); err == nil && strings.TrimSpace(string(data)) == t.Name {
+		return t.Name, nil
+	}
+	names := []string{t.label()}
+	for _, o := range others {
+		names = append(names, o.label())
+	}
+	return "", usageErrorf("several timers are running (%s); pass --name or pick one with timers switch", strings.Join(names, ", "))
+}
+
+func saveTimer(t detachedTimer) error {
+	path, err := timerPath(t.Name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// beginTimer starts a detached timer and shows it, unless the
+// interactive tracker or a timer of that name is tracking already.
+func beginTimer(name, project, task string, now time.Time) (detachedTimer, error) {
+	if instanceRunning() {
+		return detachedTimer{}, withCode(exitLocked, errors.New("an interactive session is already tracking"))
+	}
+	if t, ok, err := loadTimer(name); err != nil {
+		return t, err
+	} else if ok {
+		return t, withCode(exitLocked, fmt.Errorf("already tracking %s since %s; stop it first or give the new one a --name", t.label(), t.Start.Format("15:04")))
+	}
+	t := detachedTimer{Name: name, Project: project, Task: task, Start: now}
+	if err := saveTimer(t); err != nil {
+		return t, err
+	}
+	t.announce(webhookStart, "", now)
+	return t, focusTimer(name)
+}
+
+// pauseTimer pauses the named timer for reason, or resumes it when
+// paused.
+func pauseTimer(name, reason string, now time.Time) (detachedTimer, error) {
+	t, ok, err := loadTimer(name)
+	if err != nil {
+		return t, err
+	}
+	if !ok {
+		return t, withCode(exitEmpty, errors.New("nothing is being tracked; use start first"))
+	}
+	event := webhookPause
+	if t.PausedAt == nil {
+		t.PausedAt, t.Reason = &now, reason
+	} else {
+		event, reason = webhookResume, t.Reason
+		t.Pauses = append(t.Pauses, storedPause{Start: *t.PausedAt, End: now, Reason: t.Reason})
+		t.PausedAt, t.Reason = nil, ""
+	}
+	if err := saveTimer(t); err != nil {
+		return t, err
+	}
+	t.announce(event, reason, now)
+	return t, nil
+}
+
+// announce tells the webhooks and Slack about event of the timer.
+func (t detachedTimer) announce(event, reason string, now time.Time) {
+	ev := newWebhookEvent(event, t.Project, t.Task, now, t.elapsed(now))
+	ev.Timer, ev.Reason = t.Name, reason
+	announce(ev)
+}
+
+// endTimer logs the named timer as an entry for task, or for the task
+// it was started with when task is empty, and removes it. A Jira issue
+// is added as a tag unless the task names one. With until the entry is
+// trimmed to end there. A day that ends up over the ceiling is flagged
+// for review.
+func endTimer(name, task, issue string, now time.Time, until *time.Time) (TaskEntry, error) {
+	t, ok, err := loadTimer(name)
+	if err != nil {
+		return TaskEntry{}, err
+	}
+	if !ok {
+		return TaskEntry{}, withCode(exitEmpty, errors.New("nothing is being tracked; use start first"))
+	}
+	if task != "" {
+		t.Task = task
+	}
+	if t.Task == "" {
+		return TaskEntry{}, usageErrorf("stop needs --task")
+	}
+	if t.PausedAt != nil {
+		t.Pauses = append(t.Pauses, storedPause{Start: *t.PausedAt, End: now, Reason: t.Reason})
+		t.PausedAt = nil
+	}
+
+	e := TaskEntry{Task: t.Task, Project: t.Project, Start: t.Start, Duration: t.elapsed(now)}
+	for _, p := range t.Pauses {
+		e.Pauses = append(e.Pauses, Pause{Start: p.Start, End: p.End, Reason: p.Reason})
+	}
+	if until != nil {
+		if !until.After(t.Start) || !until.Before(now) {
+			return e, usageErrorf("can only trim to a time between %s and now", t.Start.Format("15:04"))
+		}
+		trimEntry(&e, *until, now)
+	}
+	if e.Task, e.Billable, e.Rate, err = parseBilling(e.Task); err != nil {
+		return e, withCode(exitUsage, err)
+	}
+	billByDefault(&e)
+	e.Task, e.Tags = parseTags(e.Task)
+	e.Task, e.Estimate = parseEstimate(e.Task)
+	estimateFromPlan(&e, now)
+	if issue != "" && entryIssue(e) == "" {
+		e.Tags = append(e.Tags, issue)
+	}
+	linkGitHub(&e)
+	if err := importEntries(e.Project, startOfDay(t.Start), []TaskEntry{e}, false); err != nil {
+		return e, err
+	}
+	recordHistory(e.Project, e.Task)
+	finishPlanned(e.Project, e.Task, now)
+	path, err := timerPath(name)
+	if err != nil {
+		return e, err
+	}
+	if err := os.Remove(path); err != nil {
+		return e, err
+	}
+	ev := newWebhookEvent(webhookStop, e.Project, e.Task, entryEnd(e), e.Duration)
+	ev.Timer = name
+	announce(ev)
+	if day, err := writtenEntries(t.Start); err == nil && overCeiling(day) {
+		if err := flagDay(t.Start, needsReviewKey); err != nil {
+			fmt.Println("❌ Could not flag the day:", err)
+		}
+	}
+	return e, nil
+}
+
+func startCommand(args []string) error {
+	fs := newFlagSet("start")
+	project := projectFlag(fs, "project", defaultProject, "Project to track")
+	task := fs.String("task", "", "Task, if already known; stop can give it too")
+	name := fs.String("name", "", "Name for a timer running alongside others")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	t, err := timerRequest(daemonRequest{Cmd: "start", Name: *name, Project: *project, Task: *task})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("▶️  Tracking %s since %s\n", t.label(), t.Start.Format("15:04"))
+	return nil
+}
+
+func stopCommand(args []string) error {
+	fs := newFlagSet("stop")
+	task := fs.String("task", "", "What the session was for (default: the task given to start)")
+	issue := fs.String("issue", "", "Jira issue to log the session against, when the task names none")
+	name := fs.String("name", "", "Timer to stop when several are running")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	resolved, err := resolveTimer(*name)
+	if err != nil {
+		return err
+	}
+	if *task == "" {
+		if t, ok, err := loadTimer(resolved); err != nil {
+			return err
+		} else if ok && t.Task == "" {
+			*task = taskPrompt(fmt.Sprintf("📝 What task did you just finish on %s? ", t.label()), t.Project)
+		}
+	}
+	var until *time.Time
+	if t, ok, err := loadTimer(resolved); err == nil && ok && t.PausedAt == nil && stdinIsTerminal() {
+		now := time.Now()
+		e := TaskEntry{Start: t.Start, Duration: t.elapsed(now)}
+		if forgotten(e, now) {
+			var none autoFinalizer
+			if cut, _ := askTrim(e, now, t.lastActive(), none.prompt); !cut.IsZero() {
+				until = &cut
+			}
+		}
+	}
+	var e TaskEntry
+	reply, err := callDaemon(daemonRequest{Cmd: "stop", Name: resolved, Task: *task, Issue: *issue, Until: until})
+	switch {
+	case errors.Is(err, errNoDaemon):
+		if e, err = endTimer(resolved, *task, *issue, time.Now(), until); err != nil {
+			return err
+		}
+	case err != nil:
+		return err
+	default:
+		e = *reply.Entry
+	}
+	fmt.Printf("⏹️  %s · %s: %s\n", e.Project, e.Task, formatDuration("summary", e.Duration))
+	if day, err := writtenEntries(e.Start); err == nil && overCeiling(day) {
+		for _, line := range longDayWarning(day) {
+			fmt.Println(line)
+		}
+	}
+	autoPush(startOfDay(e.Start))
+	return nil
+}
+
+func pauseCommand(args []string) error {
+	fs := newFlagSet("pause")
+	name := fs.String("name", "", "Timer to pause when several are running")
+	reason := fs.String("reason", "", "Why you are pausing, e.g. lunch; kept with the pause in the log")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	t, err := timerRequest(daemonRequest{Cmd: "pause", Name: *name, Reason: strings.TrimSpace(*reason)})
+	if err != nil {
+		return err
+	}
+	printTimer(t)
+	return nil
+}
+
+// toggleCommand is meant for a single hotkey: it starts a timer when
+// there is none and pauses or resumes the running one otherwise.
+func toggleCommand(args []string) error {
+	fs := newFlagSet("toggle")
+	project := projectFlag(fs, "project", defaultProject, "Project to track when starting")
+	name := fs.String("name", "", "Timer to toggle when several are running")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	t, err := timerRequest(daemonRequest{Cmd: "toggle", Name: *name, Project: *project})
+	if err != nil {
+		return err
+	}
+	printTimer(t)
+	return nil
+}
+
+func printTimer(t detachedTimer) {
+	fmt.Println(timerLine(t))
+}
+
+// timerLine says whether t is paused or tracking and for how long.
+func timerLine(t detachedTimer) string {
+	if t.PausedAt != nil {
+		reason := ""
+		if t.Reason != "" {
+			reason = " (" + t.Reason + ")"
+		}
+		return fmt.Sprintf("⏸️  %s paused at %s%s", t.label(), formatDuration("status", t.elapsed(time.Now())), reason)
+	}
+	return fmt.Sprintf("▶️  Tracking %s (%s so far)", t.label(), formatDuration("status", t.elapsed(time.Now())))
+}
+
+// timersCommand lists the running timers, or with switch NAME shows
+// that one in status and makes it the one commands act on.
+func timersCommand(args []string) error {
+	if len(args) > 0 && args[0] == "switch" {
+		if len(args) != 2 {
+			return usageErrorf("usage: timers switch NAME (default for the unnamed timer)")
+		}
+		name := args[1]
+		if name == "default" {
+			name = ""
+		}
+		t, err := timerRequest(daemonRequest{Cmd: "focus", Name: name})
+		if err != nil {
+			return err
+		}
+		printTimer(t)
+		return nil
+	}
+	fs := newFlagSet("timers")
+	asJSON := jsonFlag(fs, "Print the timers as JSON, the shown one first")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	shown, others, ok, err := listTimers()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return withCode(exitEmpty, errors.New("no timers are running"))
+	}
+	if *asJSON {
+		type timerJSON struct {
+			detachedTimer
+			State   string `json:"state"`
+			Elapsed string `json:"elapsed"`
+		}
+		var out []timerJSON
+		for _, t := range append([]detachedTimer{shown}, others...) {
+			state := "running"
+			if t.PausedAt != nil {
+				state = "paused"
+			}
+			out = append(out, timerJSON{t, state, formatDuration("json", t.elapsed(time.Now()))})
+		}
+		return printJSON(os.Stdout, out)
+	}
+	for i, t := range append([]detachedTimer{shown}, others...) {
+		mark, state := "  ", "running"
+		if i == 0 {
+			mark = "👉"
+		}
+		if t.PausedAt != nil {
+			state = "paused"
+		}
+		name := t.Name
+		if name == "" {
+			name = "default"
+		}
+		fmt.Printf("%s %-12s %-20s %s since %s (%s)\n", mark, name, t.Project, formatDuration("status", t.elapsed(time.Now())), t.Start.Format("15:04"), state)
+	}
+	return nil
+}
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// togglAPI is Toggl Track's v9 API.
+var togglAPI = "https://api.track.toggl.com/api/v9"
+
+// togglNote marks entries that came from Toggl, so they are not pushed
+// back to it.
+const togglNote = "imported from Toggl"
+
+// togglConfig is the toggl section of the config.
+type togglConfig struct {
+	Token     string // API token from the Toggl profile page
+	Workspace int64  // default: the account's default workspace
+	// Projects maps local project names to Toggl's where they differ.
+	Projects map[string]string
+}
+
+func (c togglConfig) remoteProject(local string) string {
+	if name, ok := c.Projects[local]; ok {
+		return name
+	}
+	return local
+}
+
+func (c togglConfig) localProject(remote string) string {
+	for local, name := range c.Projects {
+		if name == remote {
+			return local
+		}
+	}
+	return remote
+}
+
+// togglEntry is a time entry as the API has it. Duration is in seconds
+// and negative while the entry is running.
+type togglEntry struct {
+	ID          int64     `json:"id,omitempty"`
+	WorkspaceID int64     `json:"workspace_id"`
+	ProjectID   *int64    `json:"project_id,omitempty"`
+	Description string    `json:"description"`
+	Start       time.Time `json:"start"`
+	Duration    int64     `json:"duration"`
+	Tags        []string  `json:"tags"`
+	Billable    bool      `json:"billable"`
+	CreatedWith string    `json:"created_with,omitempty"`
+}
+
+// togglTarget pushes entries to a Toggl workspace; the workspace and
+// its projects are looked up on first use.
+type togglTarget struct {
+	cfg       togglConfig
+	client    *http.Client
+	workspace int64
+	projects  map[string]int64 // by Toggl name
+	names     map[int64]string
+}
+
+func newTogglTarget(cfg Config) (syncTarget, error) {
+	return newTogglClient(cfg)
+}
+
+func newTogglClient(cfg Config) (*togglTarget, error) {
+	if cfg.Toggl.Token == "" {
+		return nil, errors.New("toggl needs toggl.token in the config")
+	}
+	return &togglTarget{cfg: cfg.Toggl, client: &http.Client{Timeout: syncHTTPTimeout}}, nil
+}
+
+func (t *togglTarget) call(method, path string, body, reply any) error {
+	return sendJSON(t.client, method, togglAPI+path, t.cfg.Token, "api_token", body, reply)
+}
+
+// loadProjects finds the workspace and learns its project names.
+func (t *togglTarget) loadProjects() error {
+	if t.projects != nil {
+		return nil
+	}
+	t.workspace = t.cfg.Workspace
+	if t.workspace == 0 {
+		var me struct {
+			DefaultWorkspaceID int64 `json:"default_workspace_id"`
+		}
+		if err := t.call(http.MethodGet, "/me", nil, &me); err != nil {
+			return err
+		}
+		t.workspace = me.DefaultWorkspaceID
+	}
+	var projects []struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	}
+	if err := t.call(http.MethodGet, fmt.Sprintf("/workspaces/%d/projects", t.workspace), nil, &projects); err != nil {
+		return err
+	}
+	t.projects, t.names = map[string]int64{}, map[int64]string{}
+	for _, p := range projects {
+		t.projects[p.Name], t.names[p.ID] = p.ID, p.Name
+	}
+	return nil
+}
+
+func (*togglTarget) name() string { return "toggl" }
+
+func (*togglTarget) accepts(e TaskEntry) bool { return !slices.Contains(e.Notes, togglNote) }
+
+// push creates a time entry, or updates the one made before. Entries
+// logged without a start time start at the beginning of their day. A
+// project Toggl does not know is left off.
+func (t *togglTarget) push(date string, e TaskEntry, remoteID string) (string, error) {
+	if err := t.loadProjects(); err != nil {
+		return "", err
+	}
+	start := e.Start
+	if start.IsZero() {
+		start, _ = time.ParseInLocation(dateLayout, date, time.Local)
+	}
+	te := togglEntry{
+		WorkspaceID: t.workspace,
+		Description: e.Task,
+		Start:       start.UTC(),
+		Duration:    int64(e.Duration.Seconds()),
+		Tags:        append([]string{}, e.Tags...),
+		Billable:    e.Billable,
+		CreatedWith: "worklog",
+	}
+	if id, ok := t.projects[t.cfg.remoteProject(e.Project)]; ok {
+		te.ProjectID = &id
+	}
+	method, path := http.MethodPost, fmt.Sprintf("/workspaces/%d/time_entries", t.workspace)
+	if remoteID != "" {
+		method, path = http.MethodPut, path+"/"+remoteID
+	}
+	var reply togglEntry
+	if err := t.call(method, path, te, &reply); err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(reply.ID, 10), nil
+}
+
+// remove deletes a time entry.
+func (t *togglTarget) remove(remoteID string) error {
+	if err := t.loadProjects(); err != nil {
+		return err
+	}
+	return t.call(http.MethodDelete, fmt.Sprintf("/workspaces/%d/time_entries/%s", t.workspace, remoteID), nil, nil)
+}
+
+// fetch returns the finished time entries between from and to, both
+// days included.
+func (t *togglTarget) fetch(from, to time.Time) ([]togglEntry, error) {
+	if err := t.loadProjects(); err != nil {
+		return nil, err
+	}
+	path := fmt.Sprintf("/me/time_entries?start_date=%s&end_date=%s",
+		from.Format(dateLayout), to.AddDate(0, 0, 1).Format(dateLayout))
+	var entries []togglEntry
+	if err := t.call(http.MethodGet, path, nil, &entries); err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(entries, func(te togglEntry) bool { return te.Duration < 0 }), nil
+}
+
+// togglImported turns a Toggl entry into a local one, mapping its
+// project back and filing entries without one under fallback.
+func togglImported(cfg togglConfig, project, task string, start time.Time, d time.Duration, tags []string, billable bool, fallback string) TaskEntry {
+	task, more := parseTags(task)
+	if task == "" {
+		task = "(no description)"
+	}
+	e := TaskEntry{Task: task, Project: fallback, Start: start.Local(), Duration: d, Billable: billable, Notes: []string{togglNote}}
+	if project != "" {
+		e.Project = cfg.localProject(project)
+	}
+	for _, tag := range append(tags, more...) {
+		if !hasTag(e, tag) {
+			e.Tags = append(e.Tags, tag)
+		}
+	}
+	return e
+}
+
+// readTogglCSV reads a detailed report exported from Toggl as CSV.
+func readTogglCSV(path string, cfg togglConfig, fallback string) ([]TaskEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%s: empty file", path)
+	}
+	col := map[string]int{}
+	for i, name := range rows[0] {
+		col[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, name := range []string{"description", "start date", "start time", "duration"} {
+		if _, ok := col[name]; !ok {
+			return nil, fmt.Errorf("%s: no %q column; export a detailed report as CSV", path, name)
+		}
+	}
+	get := func(row []string, name string) string {
+		if i, ok := col[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	var entries []TaskEntry
+	for n, row := range rows[1:] {
+		start, err := time.ParseInLocation("2006-01-02 15:04:05", get(row, "start date")+" "+get(row, "start time"), time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: bad start %q %q", path, n+2, get(row, "start date"), get(row, "start time"))
+		}
+		d, err := parseDuration(get(row, "duration"))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, n+2, err)
+		}
+		entries = append(entries, togglImported(cfg, get(row, "project"), get(row, "description"), start, d,
+			parseTagList(get(row, "tags")), strings.EqualFold(get(row, "billable"), "yes"), fallback))
+	}
+	return entries, nil
+}
+
+func togglCommand(args []string) error {
+	if len(args) > 0 && args[0] == "import" {
+		return togglImport(args[1:])
+	}
+	return usageErrorf("usage: toggl import [FILE.csv] [--from DAY] [--to DAY] [--project P] [--preview]")
+}
+
+// togglImport adds Toggl entries, from a CSV export or the API, to the
+// logs and the store, a day and project at a time. Entries imported
+// before are skipped.
+func togglImport(args []string) error {
+	fs := newFlagSet("toggl import")
+	fromFlag := fs.String("from", "", "First day to fetch from the API (default: 30 days ago)")
+	toFlag := fs.String("to", "", "Last day to fetch from the API (default: today)")
+	project := projectFlag(fs, "project", defaultProject, "Project for entries that have none in Toggl")
+	preview := fs.Bool("preview", false, "Show the changes and ask before writing them")
+	var path string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		path, args = args[0], args[1:]
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if path == "" && fs.NArg() == 1 {
+		path = fs.Arg(0)
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return withCode(exitUsage, err)
+	}
+
+	var entries []TaskEntry
+	if path != "" {
+		if entries, err = readTogglCSV(path, cfg.Toggl, *project); err != nil {
+			return err
+		}
+	} else {
+		from, to, err := parseDateRange(*fromFlag, *toFlag)
+		if err != nil {
+			return err
+		}
+		if to.IsZero() {
+			to = startOfDay(time.Now())
+		}
+		if from.IsZero() {
+			from = to.AddDate(0, 0, -30)
+		}
+		t, err := newTogglClient(cfg)
+		if err != nil {
+			return withCode(exitUsage, err)
+		}
+		fetched, err := t.fetch(from, to)
+		if err != nil {
+			return err
+		}
+		for _, te := range fetched {
+			remote := ""
+			if te.ProjectID != nil {
+				remote = t.names[*te.ProjectID]
+			}
+			entries = append(entries, togglImported(cfg.Toggl, remote, te.Description, te.Start,
+				time.Duration(te.Duration)*time.Second, te.Tags, te.Billable, *project))
+		}
+	}
+	if len(entries) == 0 {
+		return withCode(exitEmpty, errors.New("no Toggl entries to import"))
+	}
+
+	type group struct {
+		date    time.Time
+		project string
+	}
+	var order []group
+	byGroup := map[group][]TaskEntry{}
+	for _, e := range entries {
+		g := group{startOfDay(e.Start), e.Project}
+		if _, ok := byGroup[g]; !ok {
+			order = append(order, g)
+		}
+		byGroup[g] = append(byGroup[g], e)
+	}
+	sort.SliceStable(order, func(i, j int) bool { return order[i].date.Before(order[j].date) })
+	today := startOfDay(time.Now())
+	for _, g := range order {
+		if g.date.Equal(today) && instanceRunning() {
+			fmt.Println("⚠️  Skipped today, it is being tracked; import again after finishing the day")
+			continue
+		}
+		day := byGroup[g]
+		sort.SliceStable(day, func(i, j int) bool { return day[i].Start.Before(day[j].Start) })
+		if err := importEntries(g.project, g.date, day, *preview); err != nil {
+			return err
+		}
+	}
+	return nil
+}
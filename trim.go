@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// sessionCeiling is how long a session may track before ending it asks
+// whether it was left running; 0 turns the check off.
+var sessionCeiling = 6 * time.Hour
+
+// forgotten reports whether e, ended at end, looks like a session nobody
+// stopped: it tracked more than sessionCeiling or ran past midnight.
+func forgotten(e TaskEntry, end time.Time) bool {
+	if sessionCeiling <= 0 {
+		return false
+	}
+	return e.Duration > sessionCeiling || !startOfDay(e.Start).Equal(startOfDay(end))
+}
+
+// askTrim offers to end a forgotten session earlier: at lastActive, the
+// last sign of life when there is one, or at a time typed in. It
+// returns the time to cut the entry at, zero to keep it as it is.
+func askTrim(e TaskEntry, end, lastActive time.Time, prompt func(string) (string, bool)) (cut time.Time, timedOut bool) {
+	fmt.Printf("⚠️  This session tracked %s, from %s to %s. Forgot to stop it?\n",
+		formatDuration("summary", e.Duration), e.Start.Format("Mon 15:04"), end.Format("Mon 15:04"))
+	question := "✂️  End it earlier at? (a time like 17:30, Enter to keep it) "
+	if lastActive.After(e.Start) && lastActive.Before(end) {
+		question = fmt.Sprintf("✂️  End it at the last activity, %s? (yes, a time like 17:30, or Enter to keep it) ", lastActive.Format("Mon 15:04"))
+	}
+	for {
+		answer, timedOut := prompt(question)
+		if timedOut {
+			return time.Time{}, true
+		}
+		switch answer = strings.ToLower(strings.TrimSpace(answer)); answer {
+		case "", "n", "no":
+			return time.Time{}, false
+		case "y", "yes":
+			if lastActive.After(e.Start) && lastActive.Before(end) {
+				return lastActive, false
+			}
+		}
+		at, err := time.ParseInLocation("15:04", answer, time.Local)
+		if err != nil {
+			fmt.Println("❌ Want a time like 17:30")
+			continue
+		}
+		cut = time.Date(e.Start.Year(), e.Start.Month(), e.Start.Day(), at.Hour(), at.Minute(), 0, 0, time.Local)
+		for !cut.After(e.Start) && cut.Before(end) {
+			cut = cut.AddDate(0, 0, 1)
+		}
+		if !cut.Before(end) {
+			fmt.Printf("❌ Want a time between %s and %s\n", e.Start.Format("15:04"), end.Format("15:04"))
+			continue
+		}
+		return cut, false
+	}
+}
+
+// trimEntry cuts e, which ran until end, off at cut: the time tracked
+// after cut is dropped, and so are the pauses and interruptions in it.
+func trimEntry(e *TaskEntry, cut, end time.Time) {
+	dropped := end.Sub(cut)
+	var kept []Pause
+	for _, p := range e.Pauses {
+		switch {
+		case !p.End.After(cut):
+			kept = append(kept, p)
+		case p.Start.Before(cut):
+			dropped -= p.End.Sub(cut)
+			p.End = cut
+			kept = append(kept, p)
+		default:
+			dropped -= p.Duration()
+		}
+	}
+	e.Pauses = kept
+	var interruptions []Interruption
+	for _, i := range e.Interruptions {
+		if i.Start.Before(cut) {
+			if i.End.After(cut) {
+				i.End = cut
+			}
+			interruptions = append(interruptions, i)
+		}
+	}
+	e.Interruptions = interruptions
+	dropped = min(max(dropped, 0), e.Duration)
+	e.Duration -= dropped
+	e.Notes = append(e.Notes, fmt.Sprintf("✂️ Trimmed %s after %s, left running", formatDuration("markdown", dropped), cut.Format("15:04")))
+}
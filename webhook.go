@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// Session events a webhook can fire on.
+const (
+	webhookStart  = "start"
+	webhookPause  = "pause"
+	webhookResume = "resume"
+	webhookStop   = "stop"
+)
+
+var webhookEvents = []string{webhookStart, webhookPause, webhookResume, webhookStop}
+
+const webhookTimeout = 10 * time.Second
+
+// webhook is one webhook of the config: the URL it posts to, the events
+// it fires on (all of them when empty) and the template of the body
+// (the event as JSON when nil).
+type webhook struct {
+	URL      string
+	Events   []string
+	Template *template.Template
+}
+
+// webhooks are the configured webhooks by name, set at startup.
+var webhooks map[string]webhook
+
+// webhookEvent is what a webhook is told, and what its template sees.
+type webhookEvent struct {
+	Event   string    `json:"event"`
+	Project string    `json:"project"`
+	Task    string    `json:"task,omitempty"`
+	Timer   string    `json:"timer,omitempty"`  // the detached timer's name
+	Reason  string    `json:"reason,omitempty"` // why the session paused
+	Time    time.Time `json:"time"`
+	Elapsed string    `json:"elapsed"` // tracked so far; at stop, the entry's length
+	Seconds int64     `json:"seconds"`
+}
+
+func newWebhookEvent(event, project, task string, at time.Time, elapsed time.Duration) webhookEvent {
+	elapsed = elapsed.Round(time.Second)
+	return webhookEvent{Event: event, Project: project, Task: task, Time: at,
+		Elapsed: formatDuration("json", elapsed), Seconds: int64(elapsed / time.Second)}
+}
+
+// parseWebhookTemplate reads a webhook_templates value: the template
+// itself, or @path for a file holding it. {{json .Task}} quotes a value
+// for a JSON body.
+func parseWebhookTemplate(name, value string) (*template.Template, error) {
+	if path, ok := strings.CutPrefix(value, "@"); ok {
+		path, err := expandHome(path)
+		if err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		value = string(data)
+	}
+	return template.New(name).Funcs(template.FuncMap{"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	}}).Parse(value)
+}
+
+// parseWebhookEvents reads a webhook_events value, e.g. "start, stop".
+func parseWebhookEvents(value string) ([]string, error) {
+	var events []string
+	for _, event := range strings.Split(value, ",") {
+		event = strings.ToLower(strings.TrimSpace(event))
+		if !slices.Contains(webhookEvents, event) {
+			return nil, fmt.Errorf("unknown event %q; want %s", event, strings.Join(webhookEvents, ", "))
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// body renders ev for h, and the content type to send it with: JSON
+// unless the template makes something else.
+func (h webhook) body(ev webhookEvent) ([]byte, string, error) {
+	if h.Template == nil {
+		data, err := json.Marshal(ev)
+		return data, "application/json", err
+	}
+	var buf bytes.Buffer
+	if err := h.Template.Execute(&buf, ev); err != nil {
+		return nil, "", err
+	}
+	if json.Valid(buf.Bytes()) {
+		return buf.Bytes(), "application/json", nil
+	}
+	return buf.Bytes(), "text/plain; charset=utf-8", nil
+}
+
+func (h webhook) send(ev webhookEvent) error {
+	body, contentType, err := h.body(ev)
+	if err != nil {
+		return err
+	}
+	client := http.Client{Timeout: webhookTimeout}
+	resp, err := client.Post(h.URL, contentType, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s answered %s", h.URL, resp.Status)
+	}
+	return nil
+}
+
+// hooksFor lists the names of the webhooks that fire on event, sorted.
+func hooksFor(event string) []string {
+	var names []string
+	for name, h := range webhooks {
+		if len(h.Events) == 0 || slices.Contains(h.Events, event) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// webhooksSending counts the webhook and Slack calls still going on.
+var webhooksSending sync.WaitGroup
+
+// announce tells the webhooks and Slack about ev.
+func announce(ev webhookEvent) {
+	fireWebhooks(ev)
+	updateSlack(ev)
+}
+
+// fireWebhooks sends ev to its webhooks in the background. Like a
+// notification it is only a nicety: failures go to the debug log.
+func fireWebhooks(ev webhookEvent) {
+	for _, name := range hooksFor(ev.Event) {
+		h := webhooks[name]
+		webhooksSending.Add(1)
+		go func() {
+			defer webhooksSending.Done()
+			if err := h.send(ev); err != nil {
+				debugf("webhook %s: %s: %v", name, ev.Event, err)
+			}
+		}()
+	}
+}
+
+// waitWebhooks gives the webhooks still being sent, and the Slack
+// status being set, the time to finish before the process exits.
+func waitWebhooks() {
+	done := make(chan struct{})
+	go func() {
+		webhooksSending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(webhookTimeout):
+	}
+}
+
+// webhookCommand sends a sample event to the webhooks that fire on it
+// and reports how each went.
+func webhookCommand(args []string) error {
+	if len(args) != 2 || args[0] != "test" || !slices.Contains(webhookEvents, args[1]) {
+		return usageErrorf("usage: webhook test <%s>", strings.Join(webhookEvents, "|"))
+	}
+	event := args[1]
+	names := hooksFor(event)
+	if len(names) == 0 {
+		return withCode(exitEmpty, fmt.Errorf("no webhook fires on %s; add one under webhooks in the config", event))
+	}
+	ev := newWebhookEvent(event, defaultProject, "webhook test", time.Now(), 25*time.Minute)
+	if event == webhookPause || event == webhookResume {
+		ev.Reason = "lunch"
+	}
+	failed := 0
+	for _, name := range names {
+		if err := webhooks[name].send(ev); err != nil {
+			failed++
+			fmt.Printf("❌ %s: %v\n", name, err)
+			continue
+		}
+		fmt.Printf("🪝 %s: sent %s\n", name, event)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d webhooks failed", failed, len(names))
+	}
+	return nil
+}
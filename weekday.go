@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// weekdayRule is what the config says about one day of the week.
+type weekdayRule struct {
+	Day     time.Weekday
+	Project string
+	Target  time.Duration
+}
+
+func parseWeekday(name string) (time.Weekday, error) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(name, d.String()) || strings.EqualFold(name, d.String()[:3]) {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown weekday %q", name)
+}
+
+// localNow is the current time in the configured timezone.
+func (c Config) localNow() time.Time {
+	if c.Location == nil {
+		return time.Now()
+	}
+	return time.Now().In(c.Location)
+}
+
+// ruleFor returns the weekday rule matching date, if the config has one.
+func (c Config) ruleFor(date time.Time) (weekdayRule, bool) {
+	rule := weekdayRule{Day: date.Weekday()}
+	project, hasProject := c.WeekdayProjects[rule.Day]
+	target, hasTarget := c.WeekdayTargets[rule.Day]
+	rule.Project, rule.Target = project, target
+	return rule, hasProject || hasTarget
+}
+
+// targetFor is the daily target that applies on date.
+func (c Config) targetFor(date time.Time) time.Duration {
+	if rule, ok := c.ruleFor(date); ok && rule.Target > 0 {
+		return rule.Target
+	}
+	return c.Target
+}
+
+func (r weekdayRule) String() string {
+	var parts []string
+	if r.Project != "" {
+		parts = append(parts, "project "+r.Project)
+	}
+	if r.Target > 0 {
+		parts = append(parts, "target "+formatDuration("footer", r.Target))
+	}
+	return fmt.Sprintf("%s rule: %s", r.Day, strings.Join(parts, ", "))
+}
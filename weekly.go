@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const subtotalPrefix = "_Subtotal: "
+
+func isoWeek(date time.Time) string {
+	year, week := date.ISOWeek()
+	return fmt.Sprintf("%04d-W%02d", year, week)
+}
+
+func weeklyFilename(project string, date time.Time) string {
+	return weekFilename(isoWeek(date), project)
+}
+
+// weekFilename names the weekly log of project for week, e.g. 2024-W23.
+func weekFilename(week, project string) string {
+	return week + "_" + safeName(project) + ".md"
+}
+
+// weekSection is one "## Monday 2024-06-03" block of a weekly file, kept
+// as raw text so days other than today are written back untouched.
+type weekSection struct {
+	date     string
+	raw      string
+	subtotal time.Duration
+}
+
+// parseDayHeading reads a "## Monday 2024-06-03" day heading. Other
+// second-level headings, such as a template's "## Notes", are not days.
+func parseDayHeading(line string) (time.Time, bool) {
+	fields := strings.Fields(line)
+	if len(fields) != 3 || fields[0] != "##" {
+		return time.Time{}, false
+	}
+	date, err := time.ParseInLocation("2006-01-02", fields[2], time.Local)
+	if err != nil || !strings.EqualFold(date.Weekday().String(), fields[1]) {
+		return time.Time{}, false
+	}
+	return date, true
+}
+
+// parseWeek splits a weekly file into its day sections. Everything
+// before the first day heading is the header and is regenerated.
+func parseWeek(content []byte) []weekSection {
+	var sections []weekSection
+	var current *weekSection
+	for _, line := range strings.SplitAfter(string(content), "\n") {
+		if date, ok := parseDayHeading(line); ok {
+			sections = append(sections, weekSection{date: date.Format(dateLayout)})
+			current = &sections[len(sections)-1]
+		}
+		if current == nil {
+			continue
+		}
+		current.raw += line
+		if rest, ok := strings.CutPrefix(strings.TrimSpace(line), subtotalPrefix); ok {
+			current.subtotal, _ = parseDuration(strings.TrimSuffix(rest, "_"))
+		}
+	}
+	return sections
+}
+
+func renderDaySection(date time.Time, entries []TaskEntry) weekSection {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "## %s %s\n\n", date.Weekday(), date.Format("2006-01-02"))
+	writeEntries(&b, entries)
+	writeEarnings(&b, entries)
+	subtotal := totalDuration(entries).Round(time.Second)
+	fmt.Fprintf(&b, "\n%s%s_\n\n", subtotalPrefix, formatDuration("markdown", subtotal))
+	return weekSection{date: date.Format("2006-01-02"), raw: b.String(), subtotal: subtotal}
+}
+
+// renderWeek returns the weekly file for project with today's section
+// replaced by entries, keeping the other days of existing as they are.
+func renderWeek(project string, date time.Time, entries []TaskEntry, existing []byte, inProgress bool) []byte {
+	today := renderDaySection(date, entries)
+	if inProgress {
+		today.raw += inProgressMarker + "\n"
+	}
+	var sections []weekSection
+	placed := false
+	for _, s := range parseWeek(existing) {
+		switch {
+		case s.date == today.date:
+			continue
+		case !placed && s.date > today.date:
+			sections = append(sections, today)
+			placed = true
+		}
+		sections = append(sections, s)
+	}
+	if !placed {
+		sections = append(sections, today)
+	}
+
+	return carryReviewFlags(assembleWeek(project, isoWeek(date), sections), existing)
+}
+
+// assembleWeek writes the header with the weekly total followed by the
+// day sections.
+func assembleWeek(project, week string, sections []weekSection) []byte {
+	var total time.Duration
+	var entries []TaskEntry
+	for _, s := range sections {
+		total += s.subtotal
+		entries = append(entries, parseLog([]byte(s.raw))[s.date]...)
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "---\ntags: [%s]\nweek: %s\nproject: %s\n---\n\n",
+		frontmatterTags(project, entries), week, project)
+	fmt.Fprintf(&b, "# 📝 Work Log for %s (%s)\n\n", project, week)
+	fmt.Fprintf(&b, "**Weekly total**: %s\n\n", formatDuration("markdown", total))
+	for _, s := range sections {
+		b.WriteString(s.raw)
+	}
+	return b.Bytes()
+}
+
+// mergeWeeks adds the day sections of src to dst. A day present in both
+// is re-rendered with the entries of both; other days keep their text.
+func mergeWeeks(dst, src []byte) []byte {
+	sections := parseWeek(dst)
+	for _, add := range parseWeek(src) {
+		i := sort.Search(len(sections), func(i int) bool { return sections[i].date >= add.date })
+		switch {
+		case i < len(sections) && sections[i].date == add.date:
+			date, err := time.ParseInLocation("2006-01-02", add.date, time.Local)
+			if err != nil {
+				continue
+			}
+			entries := append(parseLog([]byte(sections[i].raw))[add.date], parseLog([]byte(add.raw))[add.date]...)
+			sections[i] = renderDaySection(date, entries)
+		default:
+			sections = append(sections[:i], append([]weekSection{add}, sections[i:]...)...)
+		}
+	}
+	return carryReviewFlags(assembleWeek(frontmatterValue(dst, "project"), frontmatterValue(dst, "week"), sections), dst)
+}